@@ -892,7 +892,7 @@ func (f *Fs) list(ctx context.Context, container, directory, prefix string, addC
 	}
 	for marker := (azblob.Marker{}); marker.NotDone(); {
 		var response *azblob.ListBlobsHierarchySegmentResponse
-		err := f.pacer.Call(func() (bool, error) {
+		err := f.pacer.CallContext(ctx, func() (bool, error) {
 			var err error
 			response, err = f.cntURL(container).ListBlobsHierarchySegment(ctx, marker, delimiter, options)
 			return f.shouldRetry(ctx, err)
@@ -1118,7 +1118,7 @@ func (f *Fs) listContainersToFn(fn listContainerFn) error {
 	ctx := context.Background()
 	for marker := (azblob.Marker{}); marker.NotDone(); {
 		var response *azblob.ListContainersSegmentResponse
-		err := f.pacer.Call(func() (bool, error) {
+		err := f.pacer.CallContext(ctx, func() (bool, error) {
 			var err error
 			response, err = f.svcURL.ListContainersSegment(ctx, marker, params)
 			return f.shouldRetry(ctx, err)
@@ -1172,7 +1172,7 @@ func (f *Fs) makeContainer(ctx context.Context, container string) error {
 			return nil
 		}
 		// now try to create the container
-		return f.pacer.Call(func() (bool, error) {
+		return f.pacer.CallContext(ctx, func() (bool, error) {
 			_, err := f.cntURL(container).Create(ctx, azblob.Metadata{}, f.publicAccess)
 			if err != nil {
 				if storageErr, ok := err.(azblob.StorageError); ok {
@@ -1216,7 +1216,7 @@ func (f *Fs) isEmpty(ctx context.Context, container, directory string) (err erro
 func (f *Fs) deleteContainer(ctx context.Context, container string) error {
 	return f.cache.Remove(container, func() error {
 		options := azblob.ContainerAccessConditions{}
-		return f.pacer.Call(func() (bool, error) {
+		return f.pacer.CallContext(ctx, func() (bool, error) {
 			_, err := f.cntURL(container).GetProperties(ctx, azblob.LeaseAccessConditions{})
 			if err == nil {
 				_, err = f.cntURL(container).Delete(ctx, options)
@@ -1302,7 +1302,7 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	options := azblob.BlobAccessConditions{}
 	var startCopy *azblob.BlobStartCopyFromURLResponse
 
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		startCopy, err = dstBlobURL.StartCopyFromURL(ctx, *source, nil, azblob.ModifiedAccessConditions{}, options, azblob.AccessTierType(f.opt.AccessTier), nil)
 		return f.shouldRetry(ctx, err)
 	})
@@ -1489,7 +1489,7 @@ func (o *Object) readMetaData() (err error) {
 	options := azblob.BlobAccessConditions{}
 	ctx := context.Background()
 	var blobProperties *azblob.BlobGetPropertiesResponse
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		blobProperties, err = blob.GetProperties(ctx, options, azblob.ClientProvidedKeyOptions{})
 		return o.fs.shouldRetry(ctx, err)
 	})
@@ -1524,7 +1524,7 @@ func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
 	o.meta[modTimeKey] = modTime.Format(timeFormatOut)
 
 	blob := o.getBlobReference()
-	err := o.fs.pacer.Call(func() (bool, error) {
+	err := o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		_, err := blob.SetMetadata(ctx, o.meta, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
 		return o.fs.shouldRetry(ctx, err)
 	})
@@ -1567,7 +1567,7 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 	blob := o.getBlobReference()
 	ac := azblob.BlobAccessConditions{}
 	var downloadResponse *azblob.DownloadResponse
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		downloadResponse, err = blob.Download(ctx, offset, count, ac, false, azblob.ClientProvidedKeyOptions{})
 		return o.fs.shouldRetry(ctx, err)
 	})
@@ -1742,7 +1742,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	}
 
 	// Don't retry, return a retry error instead
-	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+	err = o.fs.pacer.CallNoRetryContext(ctx, func() (bool, error) {
 		// Stream contents of the reader object to the given blob URL
 		blockBlobURL := blob.ToBlockBlobURL()
 		_, err = azblob.UploadStreamToBlockBlob(ctx, in, blockBlobURL, putBlobOptions)
@@ -1772,7 +1772,7 @@ func (o *Object) Remove(ctx context.Context) error {
 	blob := o.getBlobReference()
 	snapShotOptions := azblob.DeleteSnapshotsOptionNone
 	ac := azblob.BlobAccessConditions{}
-	return o.fs.pacer.Call(func() (bool, error) {
+	return o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		_, err := blob.Delete(ctx, snapShotOptions, ac)
 		return o.fs.shouldRetry(ctx, err)
 	})
@@ -1801,7 +1801,7 @@ func (o *Object) SetTier(tier string) error {
 	desiredAccessTier := azblob.AccessTierType(tier)
 	blob := o.getBlobReference()
 	ctx := context.Background()
-	err := o.fs.pacer.Call(func() (bool, error) {
+	err := o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		_, err := blob.SetTier(ctx, desiredAccessTier, azblob.LeaseAccessConditions{}, azblob.RehydratePriorityStandard)
 		return o.fs.shouldRetry(ctx, err)
 	})
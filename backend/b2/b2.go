@@ -522,7 +522,7 @@ func (f *Fs) authorizeAccount(ctx context.Context) error {
 		Password:     f.opt.Key,
 		ExtraHeaders: map[string]string{"Authorization": ""}, // unset the Authorization for this request
 	}
-	err := f.pacer.Call(func() (bool, error) {
+	err := f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := f.srv.CallJSON(ctx, &opts, nil, &f.info)
 		return f.shouldRetryNoReauth(ctx, resp, err)
 	})
@@ -568,7 +568,7 @@ func (f *Fs) getUploadURL(ctx context.Context, bucket string) (upload *api.GetUp
 	var request = api.GetUploadURLRequest{
 		BucketID: bucketID,
 	}
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := f.srv.CallJSON(ctx, &opts, &request, &upload)
 		return f.shouldRetry(ctx, resp, err)
 	})
@@ -711,7 +711,7 @@ func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBuck
 
 	for {
 		var response api.ListFileNamesResponse
-		err := f.pacer.Call(func() (bool, error) {
+		err := f.pacer.CallContext(ctx, func() (bool, error) {
 			resp, err := f.srv.CallJSON(ctx, &opts, &request, &response)
 			return f.shouldRetry(ctx, resp, err)
 		})
@@ -922,7 +922,7 @@ func (f *Fs) listBucketsToFn(ctx context.Context, fn listBucketFn) error {
 		Method: "POST",
 		Path:   "/b2_list_buckets",
 	}
-	err := f.pacer.Call(func() (bool, error) {
+	err := f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := f.srv.CallJSON(ctx, &opts, &account, &response)
 		return f.shouldRetry(ctx, resp, err)
 	})
@@ -1061,7 +1061,7 @@ func (f *Fs) makeBucket(ctx context.Context, bucket string) error {
 			Type:      "allPrivate",
 		}
 		var response api.Bucket
-		err := f.pacer.Call(func() (bool, error) {
+		err := f.pacer.CallContext(ctx, func() (bool, error) {
 			resp, err := f.srv.CallJSON(ctx, &opts, &request, &response)
 			return f.shouldRetry(ctx, resp, err)
 		})
@@ -1110,7 +1110,7 @@ func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 			AccountID: f.info.AccountID,
 		}
 		var response api.Bucket
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			resp, err := f.srv.CallJSON(ctx, &opts, &request, &response)
 			return f.shouldRetry(ctx, resp, err)
 		})
@@ -1144,7 +1144,7 @@ func (f *Fs) hide(ctx context.Context, bucket, bucketPath string) error {
 		Name:     f.opt.Enc.FromStandardPath(bucketPath),
 	}
 	var response api.File
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := f.srv.CallJSON(ctx, &opts, &request, &response)
 		return f.shouldRetry(ctx, resp, err)
 	})
@@ -1172,7 +1172,7 @@ func (f *Fs) deleteByID(ctx context.Context, ID, Name string) error {
 		Name: f.opt.Enc.FromStandardPath(Name),
 	}
 	var response api.File
-	err := f.pacer.Call(func() (bool, error) {
+	err := f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := f.srv.CallJSON(ctx, &opts, &request, &response)
 		return f.shouldRetry(ctx, resp, err)
 	})
@@ -1322,7 +1322,7 @@ func (f *Fs) copy(ctx context.Context, dstObj *Object, srcObj *Object, newInfo *
 		request.Info = newInfo.Info
 	}
 	var response api.FileInfo
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := f.srv.CallJSON(ctx, &opts, &request, &response)
 		return f.shouldRetry(ctx, resp, err)
 	})
@@ -1388,7 +1388,7 @@ func (f *Fs) getDownloadAuthorization(ctx context.Context, bucket, remote string
 		ValidDurationInSeconds: validDurationInSeconds,
 	}
 	var response api.GetDownloadAuthorizationResponse
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := f.srv.CallJSON(ctx, &opts, &request, &response)
 		return f.shouldRetry(ctx, resp, err)
 	})
@@ -1736,7 +1736,7 @@ func (o *Object) getOrHead(ctx context.Context, method string, options []fs.Open
 		bucket, bucketPath := o.split()
 		opts.Path += "/file/" + urlEncode(o.fs.opt.Enc.FromStandardName(bucket)) + "/" + urlEncode(o.fs.opt.Enc.FromStandardPath(bucketPath))
 	}
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = o.fs.srv.Call(ctx, &opts)
 		return o.fs.shouldRetry(ctx, resp, err)
 	})
@@ -1985,7 +1985,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	}
 	var response api.FileInfo
 	// Don't retry, return a retry error instead
-	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+	err = o.fs.pacer.CallNoRetryContext(ctx, func() (bool, error) {
 		resp, err := o.fs.srv.CallJSON(ctx, &opts, nil, &response)
 		retry, err := o.fs.shouldRetry(ctx, resp, err)
 		// On retryable error clear UploadURL
@@ -135,7 +135,7 @@ func (f *Fs) newLargeUpload(ctx context.Context, o *Object, in io.Reader, src fs
 		request.Info = newInfo.Info
 	}
 	var response api.StartLargeFileResponse
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := f.srv.CallJSON(ctx, &opts, &request, &response)
 		return f.shouldRetry(ctx, resp, err)
 	})
@@ -178,7 +178,7 @@ func (up *largeUpload) getUploadURL(ctx context.Context) (upload *api.GetUploadP
 		var request = api.GetUploadPartURLRequest{
 			ID: up.id,
 		}
-		err := up.f.pacer.Call(func() (bool, error) {
+		err := up.f.pacer.CallContext(ctx, func() (bool, error) {
 			resp, err := up.f.srv.CallJSON(ctx, &opts, &request, &upload)
 			return up.f.shouldRetry(ctx, resp, err)
 		})
@@ -203,7 +203,7 @@ func (up *largeUpload) returnUploadURL(upload *api.GetUploadPartURLResponse) {
 
 // Transfer a chunk
 func (up *largeUpload) transferChunk(ctx context.Context, part int64, body []byte) error {
-	err := up.f.pacer.Call(func() (bool, error) {
+	err := up.f.pacer.CallContext(ctx, func() (bool, error) {
 		fs.Debugf(up.o, "Sending chunk %d length %d", part, len(body))
 
 		// Get upload URL
@@ -275,7 +275,7 @@ func (up *largeUpload) transferChunk(ctx context.Context, part int64, body []byt
 
 // Copy a chunk
 func (up *largeUpload) copyChunk(ctx context.Context, part int64, partSize int64) error {
-	err := up.f.pacer.Call(func() (bool, error) {
+	err := up.f.pacer.CallContext(ctx, func() (bool, error) {
 		fs.Debugf(up.o, "Copying chunk %d length %d", part, partSize)
 		opts := rest.Opts{
 			Method: "POST",
@@ -317,7 +317,7 @@ func (up *largeUpload) finish(ctx context.Context) error {
 		SHA1s: up.sha1s,
 	}
 	var response api.FileInfo
-	err := up.f.pacer.Call(func() (bool, error) {
+	err := up.f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := up.f.srv.CallJSON(ctx, &opts, &request, &response)
 		return up.f.shouldRetry(ctx, resp, err)
 	})
@@ -338,7 +338,7 @@ func (up *largeUpload) cancel(ctx context.Context) error {
 		ID: up.id,
 	}
 	var response api.CancelLargeFileResponse
-	err := up.f.pacer.Call(func() (bool, error) {
+	err := up.f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := up.f.srv.CallJSON(ctx, &opts, &request, &response)
 		return up.f.shouldRetry(ctx, resp, err)
 	})
@@ -792,7 +792,7 @@ func containsString(slice []string, s string) bool {
 
 // getFile returns drive.File for the ID passed and fields passed in
 func (f *Fs) getFile(ctx context.Context, ID string, fields googleapi.Field) (info *drive.File, err error) {
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		info, err = f.svc.Files.Get(ID).
 			Fields(fields).
 			SupportsAllDrives(true).
@@ -938,7 +938,7 @@ func (f *Fs) list(ctx context.Context, dirIDs []string, title string, directorie
 OUTER:
 	for {
 		var files *drive.FileList
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			files, err = list.Fields(googleapi.Field(fields)).Context(ctx).Do()
 			return f.shouldRetry(ctx, err)
 		})
@@ -1544,7 +1544,7 @@ func (f *Fs) CreateDir(ctx context.Context, pathID, leaf string) (newID string,
 		Parents:     []string{pathID},
 	}
 	var info *drive.File
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		info, err = f.svc.Files.Create(createInfo).
 			Fields("id").
 			SupportsAllDrives(true).
@@ -1588,7 +1588,7 @@ func (f *Fs) fetchFormats(ctx context.Context) {
 	fetchFormatsOnce.Do(func() {
 		var about *drive.About
 		var err error
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			about, err = f.svc.About.Get().
 				Fields("exportFormats,importFormats").
 				Context(ctx).Do()
@@ -2245,7 +2245,7 @@ func (f *Fs) PutUnchecked(ctx context.Context, in io.Reader, src fs.ObjectInfo,
 	if size >= 0 && size < int64(f.opt.UploadCutoff) {
 		// Make the API request to upload metadata and file data.
 		// Don't retry, return a retry error instead
-		err = f.pacer.CallNoRetry(func() (bool, error) {
+		err = f.pacer.CallNoRetryContext(ctx, func() (bool, error) {
 			info, err = f.svc.Files.Create(createInfo).
 				Media(in, googleapi.ContentType(srcMimeType), googleapi.ChunkSize(0)).
 				Fields(partialFields).
@@ -2300,7 +2300,7 @@ func (f *Fs) MergeDirs(ctx context.Context, dirs []fs.Directory) error {
 		for _, info := range infos {
 			fs.Infof(srcDir, "merging %q", info.Name)
 			// Move the file into the destination
-			err = f.pacer.Call(func() (bool, error) {
+			err = f.pacer.CallContext(ctx, func() (bool, error) {
 				_, err = f.svc.Files.Update(info.Id, nil).
 					RemoveParents(srcDir.ID()).
 					AddParents(dstDir.ID()).
@@ -2331,7 +2331,7 @@ func (f *Fs) Mkdir(ctx context.Context, dir string) error {
 
 // delete a file or directory unconditionally by ID
 func (f *Fs) delete(ctx context.Context, id string, useTrash bool) error {
-	return f.pacer.Call(func() (bool, error) {
+	return f.pacer.CallContext(ctx, func() (bool, error) {
 		var err error
 		if useTrash {
 			info := drive.File{
@@ -2483,7 +2483,7 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	}
 
 	var info *drive.File
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		copy := f.svc.Files.Copy(id, createInfo).
 			Fields(partialFields).
 			SupportsAllDrives(true).
@@ -2587,7 +2587,7 @@ func (f *Fs) CleanUp(ctx context.Context) error {
 		_, err = f.cleanupTeamDrive(ctx, "", directoryID)
 		return err
 	}
-	err := f.pacer.Call(func() (bool, error) {
+	err := f.pacer.CallContext(ctx, func() (bool, error) {
 		err := f.svc.Files.EmptyTrash().Context(ctx).Do()
 		return f.shouldRetry(ctx, err)
 	})
@@ -2605,7 +2605,7 @@ func (f *Fs) teamDriveOK(ctx context.Context) (err error) {
 		return nil
 	}
 	var td *drive.Drive
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		td, err = f.svc.Drives.Get(f.opt.TeamDriveID).Fields("name,id,capabilities,createdTime,restrictions").Context(ctx).Do()
 		return f.shouldRetry(ctx, err)
 	})
@@ -2628,7 +2628,7 @@ func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
 	}
 	var about *drive.About
 	var err error
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		about, err = f.svc.About.Get().Fields("storageQuota").Context(ctx).Do()
 		return f.shouldRetry(ctx, err)
 	})
@@ -2696,7 +2696,7 @@ func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object,
 
 	// Do the move
 	var info *drive.File
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		info, err = f.svc.Files.Update(shortcutID(srcObj.id), dstInfo).
 			RemoveParents(srcParentID).
 			AddParents(dstParents).
@@ -2733,7 +2733,7 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 		Type:               "anyone",
 	}
 
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		// TODO: On TeamDrives this might fail if lacking permissions to change ACLs.
 		// Need to either check `canShare` attribute on the object or see if a sufficient permission is already present.
 		_, err = f.svc.Permissions.Create(id, permission).
@@ -2776,7 +2776,7 @@ func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string
 	patch := drive.File{
 		Name: dstLeaf,
 	}
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		_, err = f.svc.Files.Update(shortcutID(srcID), &patch).
 			RemoveParents(srcDirectoryID).
 			AddParents(dstDirectoryID).
@@ -2843,7 +2843,7 @@ func (f *Fs) ChangeNotify(ctx context.Context, notifyFunc func(string, fs.EntryT
 }
 func (f *Fs) changeNotifyStartPageToken(ctx context.Context) (pageToken string, err error) {
 	var startPageToken *drive.StartPageToken
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		changes := f.svc.Changes.GetStartPageToken().SupportsAllDrives(true)
 		if f.isTeamDrive {
 			changes.DriveId(f.opt.TeamDriveID)
@@ -2862,7 +2862,7 @@ func (f *Fs) changeNotifyRunner(ctx context.Context, notifyFunc func(string, fs.
 	for {
 		var changeList *drive.ChangeList
 
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			changesCall := f.svc.Changes.List(pageToken).
 				Fields("nextPageToken,newStartPageToken,changes(fileId,file(name,parents,mimeType))")
 			if f.opt.ListChunk > 0 {
@@ -3069,7 +3069,7 @@ func (f *Fs) makeShortcut(ctx context.Context, srcPath string, dstFs *Fs, dstPat
 	}
 
 	var info *drive.File
-	err = dstFs.pacer.Call(func() (bool, error) {
+	err = dstFs.pacer.CallContext(ctx, func() (bool, error) {
 		info, err = dstFs.svc.Files.Create(createInfo).
 			Fields(partialFields).
 			SupportsAllDrives(true).
@@ -3093,7 +3093,7 @@ func (f *Fs) listTeamDrives(ctx context.Context) (drives []*drive.Drive, err err
 	var defaultFs Fs // default Fs with default Options
 	for {
 		var teamDrives *drive.DriveList
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			teamDrives, err = listTeamDrives.Context(ctx).Do()
 			return defaultFs.shouldRetry(ctx, err)
 		})
@@ -3133,7 +3133,7 @@ func (f *Fs) unTrash(ctx context.Context, dir string, directoryID string, recurs
 				ForceSendFields: []string{"Trashed"}, // necessary to set false value
 				Trashed:         false,
 			}
-			err := f.pacer.Call(func() (bool, error) {
+			err := f.pacer.CallContext(ctx, func() (bool, error) {
 				_, err := f.svc.Files.Update(item.Id, &update).
 					SupportsAllDrives(true).
 					Fields("trashed").
@@ -3589,7 +3589,7 @@ func (o *baseObject) SetModTime(ctx context.Context, modTime time.Time) error {
 	}
 	// Set modified date
 	var info *drive.File
-	err := o.fs.pacer.Call(func() (bool, error) {
+	err := o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		var err error
 		info, err = o.fs.svc.Files.Update(actualID(o.id), updateInfo).
 			Fields(partialFields).
@@ -3634,7 +3634,7 @@ func (o *baseObject) httpResponse(ctx context.Context, url, method string, optio
 		delete(req.Header, "Range")
 	}
 	o.addResourceKey(req.Header)
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		res, err = o.fs.client.Do(req)
 		if err == nil {
 			err = googleapi.CheckResponse(res)
@@ -3730,7 +3730,7 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 	}
 	if o.v2Download {
 		var v2File *drive_v2.File
-		err = o.fs.pacer.Call(func() (bool, error) {
+		err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 			v2File, err = o.fs.v2Svc.Files.Get(actualID(o.id)).
 				Fields("downloadUrl").
 				SupportsAllDrives(true).
@@ -3809,7 +3809,7 @@ func (o *baseObject) update(ctx context.Context, updateInfo *drive.File, uploadM
 	size := src.Size()
 	if size >= 0 && size < int64(o.fs.opt.UploadCutoff) {
 		// Don't retry, return a retry error instead
-		err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+		err = o.fs.pacer.CallNoRetryContext(ctx, func() (bool, error) {
 			info, err = o.fs.svc.Files.Update(actualID(o.id), updateInfo).
 				Media(in, googleapi.ContentType(uploadMimeType), googleapi.ChunkSize(0)).
 				Fields(partialFields).
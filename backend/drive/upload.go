@@ -70,7 +70,7 @@ func (f *Fs) Upload(ctx context.Context, in io.Reader, size int64, contentType,
 	urls += "?" + params.Encode()
 	var res *http.Response
 	var err error
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		var body io.Reader
 		body, err = googleapi.WithoutDataWrapper.JSONReader(info)
 		if err != nil {
@@ -199,7 +199,7 @@ func (rx *resumableUpload) Upload(ctx context.Context) (*drive.File, error) {
 		}
 
 		// Transfer the chunk
-		err = rx.f.pacer.Call(func() (bool, error) {
+		err = rx.f.pacer.CallContext(ctx, func() (bool, error) {
 			fs.Debugf(rx.remote, "Sending chunk %d length %d", start, reqSize)
 			StatusCode, err = rx.transferChunk(ctx, start, chunk, reqSize)
 			again, err := rx.f.shouldRetry(ctx, err)
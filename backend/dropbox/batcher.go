@@ -122,7 +122,7 @@ func (b *batcher) finishBatch(ctx context.Context, items []*files.UploadSessionF
 	var arg = &files.UploadSessionFinishBatchArg{
 		Entries: items,
 	}
-	err = b.f.pacer.Call(func() (bool, error) {
+	err = b.f.pacer.CallContext(ctx, func() (bool, error) {
 		complete, err = b.f.srv.UploadSessionFinishBatchV2(arg)
 		// If error is insufficient space then don't retry
 		if e, ok := err.(files.UploadSessionFinishAPIError); ok {
@@ -155,7 +155,7 @@ func (b *batcher) finishBatchJobStatus(ctx context.Context, launchBatchStatus *f
 		if remaining < 0 {
 			break
 		}
-		err = b.f.pacer.Call(func() (bool, error) {
+		err = b.f.pacer.CallContext(ctx, func() (bool, error) {
 			batchStatus, err = b.f.srv.UploadSessionFinishBatchCheck(&async.PollArg{
 				AsyncJobId: launchBatchStatus.AsyncJobId,
 			})
@@ -548,7 +548,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	// If root starts with / then use the actual root
 	if strings.HasPrefix(root, "/") {
 		var acc *users.FullAccount
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			acc, err = f.users.GetCurrentAccount()
 			return shouldRetry(ctx, err)
 		})
@@ -605,7 +605,7 @@ func (f *Fs) setRoot(root string) {
 
 // getMetadata gets the metadata for a file or directory
 func (f *Fs) getMetadata(ctx context.Context, objPath string) (entry files.IsMetadata, notFound bool, err error) {
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		entry, err = f.srv.GetMetadata(&files.GetMetadataArg{
 			Path: f.opt.Enc.FromStandardPath(objPath),
 		})
@@ -697,7 +697,7 @@ func (f *Fs) listSharedFolders(ctx context.Context) (entries fs.DirEntries, err
 			arg := sharing.ListFoldersArgs{
 				Limit: 100,
 			}
-			err := f.pacer.Call(func() (bool, error) {
+			err := f.pacer.CallContext(ctx, func() (bool, error) {
 				res, err = f.sharing.ListFolders(&arg)
 				return shouldRetry(ctx, err)
 			})
@@ -709,7 +709,7 @@ func (f *Fs) listSharedFolders(ctx context.Context) (entries fs.DirEntries, err
 			arg := sharing.ListFoldersContinueArg{
 				Cursor: res.Cursor,
 			}
-			err := f.pacer.Call(func() (bool, error) {
+			err := f.pacer.CallContext(ctx, func() (bool, error) {
 				res, err = f.sharing.ListFoldersContinue(&arg)
 				return shouldRetry(ctx, err)
 			})
@@ -754,7 +754,7 @@ func (f *Fs) mountSharedFolder(ctx context.Context, id string) error {
 	arg := sharing.MountFolderArg{
 		SharedFolderId: id,
 	}
-	err := f.pacer.Call(func() (bool, error) {
+	err := f.pacer.CallContext(ctx, func() (bool, error) {
 		_, err := f.sharing.MountFolder(&arg)
 		return shouldRetry(ctx, err)
 	})
@@ -771,7 +771,7 @@ func (f *Fs) listReceivedFiles(ctx context.Context) (entries fs.DirEntries, err
 			arg := sharing.ListFilesArg{
 				Limit: 100,
 			}
-			err := f.pacer.Call(func() (bool, error) {
+			err := f.pacer.CallContext(ctx, func() (bool, error) {
 				res, err = f.sharing.ListReceivedFiles(&arg)
 				return shouldRetry(ctx, err)
 			})
@@ -783,7 +783,7 @@ func (f *Fs) listReceivedFiles(ctx context.Context) (entries fs.DirEntries, err
 			arg := sharing.ListFilesContinueArg{
 				Cursor: res.Cursor,
 			}
-			err := f.pacer.Call(func() (bool, error) {
+			err := f.pacer.CallContext(ctx, func() (bool, error) {
 				res, err = f.sharing.ListReceivedFilesContinue(&arg)
 				return shouldRetry(ctx, err)
 			})
@@ -859,7 +859,7 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 			if root == "/" {
 				arg.Path = "" // Specify root folder as empty string
 			}
-			err = f.pacer.Call(func() (bool, error) {
+			err = f.pacer.CallContext(ctx, func() (bool, error) {
 				res, err = f.srv.ListFolder(&arg)
 				return shouldRetry(ctx, err)
 			})
@@ -877,7 +877,7 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 			arg := files.ListFolderContinueArg{
 				Cursor: res.Cursor,
 			}
-			err = f.pacer.Call(func() (bool, error) {
+			err = f.pacer.CallContext(ctx, func() (bool, error) {
 				res, err = f.srv.ListFolderContinue(&arg)
 				return shouldRetry(ctx, err)
 			})
@@ -973,7 +973,7 @@ func (f *Fs) Mkdir(ctx context.Context, dir string) error {
 	if cErr := checkPathLength(arg2.Path); cErr != nil {
 		return cErr
 	}
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		_, err = f.srv.CreateFolderV2(&arg2)
 		return shouldRetry(ctx, err)
 	})
@@ -1007,7 +1007,7 @@ func (f *Fs) purgeCheck(ctx context.Context, dir string, check bool) (err error)
 			arg.Path = "" // Specify root folder as empty string
 		}
 		var res *files.ListFolderResult
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			res, err = f.srv.ListFolder(&arg)
 			return shouldRetry(ctx, err)
 		})
@@ -1020,7 +1020,7 @@ func (f *Fs) purgeCheck(ctx context.Context, dir string, check bool) (err error)
 	}
 
 	// remove it
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		_, err = f.srv.DeleteV2(&files.DeleteArg{Path: root})
 		return shouldRetry(ctx, err)
 	})
@@ -1073,7 +1073,7 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	}
 	var err error
 	var result *files.RelocationResult
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		result, err = f.srv.CopyV2(&arg)
 		return shouldRetry(ctx, err)
 	})
@@ -1134,7 +1134,7 @@ func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	}
 	var err error
 	var result *files.RelocationResult
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		result, err = f.srv.MoveV2(&arg)
 		return shouldRetry(ctx, err)
 	})
@@ -1178,7 +1178,7 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 	}
 
 	var linkRes sharing.IsSharedLinkMetadata
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		linkRes, err = f.sharing.CreateSharedLinkWithSettings(&createArg)
 		return shouldRetry(ctx, err)
 	})
@@ -1191,7 +1191,7 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 			DirectOnly: true,
 		}
 		var listRes *sharing.ListSharedLinksResult
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			listRes, err = f.sharing.ListSharedLinks(&listArg)
 			return shouldRetry(ctx, err)
 		})
@@ -1252,7 +1252,7 @@ func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string
 			ToPath:   f.opt.Enc.FromStandardPath(dstPath),
 		},
 	}
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		_, err = f.srv.MoveV2(&arg)
 		return shouldRetry(ctx, err)
 	})
@@ -1266,7 +1266,7 @@ func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string
 // About gets quota information
 func (f *Fs) About(ctx context.Context) (usage *fs.Usage, err error) {
 	var q *users.SpaceUsage
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		q, err = f.users.GetSpaceUsage()
 		return shouldRetry(ctx, err)
 	})
@@ -1343,7 +1343,7 @@ func (f *Fs) ChangeNotify(ctx context.Context, notifyFunc func(string, fs.EntryT
 func (f *Fs) changeNotifyCursor(ctx context.Context) (cursor string, err error) {
 	var startCursor *files.ListFolderGetLatestCursorResult
 
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		arg := files.ListFolderArg{
 			Path:      f.opt.Enc.FromStandardPath(f.slashRoot),
 			Recursive: true,
@@ -1380,7 +1380,7 @@ func (f *Fs) changeNotifyRunner(ctx context.Context, notifyFunc func(string, fs.
 		fs.Debugf(f, "Decreasing poll interval to maximum 480s")
 	}
 
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		args := files.ListFolderLongpollArg{
 			Cursor:  cursor,
 			Timeout: timeout,
@@ -1408,7 +1408,7 @@ func (f *Fs) changeNotifyRunner(ctx context.Context, notifyFunc func(string, fs.
 		arg := files.ListFolderContinueArg{
 			Cursor: cursor,
 		}
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			changeList, err = f.srv.ListFolderContinue(&arg)
 			return shouldRetry(ctx, err)
 		})
@@ -1582,7 +1582,7 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 		arg := sharing.GetSharedLinkMetadataArg{
 			Url: o.url,
 		}
-		err = o.fs.pacer.Call(func() (bool, error) {
+		err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 			_, in, err = o.fs.sharing.GetSharedLinkFile(&arg)
 			return shouldRetry(ctx, err)
 		})
@@ -1598,7 +1598,7 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 		Path:         o.id,
 		ExtraHeaders: headers,
 	}
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		_, in, err = o.fs.srv.Download(&arg)
 		return shouldRetry(ctx, err)
 	})
@@ -1622,7 +1622,7 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 func (o *Object) uploadChunked(ctx context.Context, in0 io.Reader, commitInfo *files.CommitInfo, size int64) (entry *files.FileMetadata, err error) {
 	// start upload
 	var res *files.UploadSessionStartResult
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		res, err = o.fs.srv.UploadSessionStart(&files.UploadSessionStartArg{}, nil)
 		return shouldRetry(ctx, err)
 	})
@@ -1655,7 +1655,7 @@ func (o *Object) uploadChunked(ctx context.Context, in0 io.Reader, commitInfo *f
 
 		chunk := readers.NewRepeatableLimitReaderBuffer(in, buf, chunkSize)
 		skip := int64(0)
-		err = o.fs.pacer.Call(func() (bool, error) {
+		err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 			// seek to the start in case this is a retry
 			if _, err = chunk.Seek(skip, io.SeekStart); err != nil {
 				return false, err
@@ -1718,7 +1718,7 @@ func (o *Object) uploadChunked(ctx context.Context, in0 io.Reader, commitInfo *f
 		return o.fs.batcher.Commit(ctx, args)
 	}
 
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		entry, err = o.fs.srv.UploadSessionFinish(args, nil)
 		// If error is insufficient space then don't retry
 		if e, ok := err.(files.UploadSessionFinishAPIError); ok {
@@ -1790,7 +1790,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	if size > int64(o.fs.opt.ChunkSize) || size < 0 || o.fs.batcher.Batching() {
 		entry, err = o.uploadChunked(ctx, in, commitInfo, size)
 	} else {
-		err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+		err = o.fs.pacer.CallNoRetryContext(ctx, func() (bool, error) {
 			entry, err = o.fs.srv.Upload(&files.UploadArg{CommitInfo: *commitInfo}, in)
 			return shouldRetry(ctx, err)
 		})
@@ -1815,7 +1815,7 @@ func (o *Object) Remove(ctx context.Context) (err error) {
 	if o.fs.opt.SharedFiles || o.fs.opt.SharedFolders {
 		return errNotSupportedInSharedMode
 	}
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		_, err = o.fs.srv.DeleteV2(&files.DeleteArg{
 			Path: o.fs.opt.Enc.FromStandardPath(o.remotePath()),
 		})
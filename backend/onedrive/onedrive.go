@@ -731,7 +731,7 @@ func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, err
 func (f *Fs) readMetaDataForPathRelativeToID(ctx context.Context, normalizedID string, relPath string) (info *api.Item, resp *http.Response, err error) {
 	opts, _ := f.newOptsCallWithIDPath(normalizedID, relPath, true, "GET", "")
 
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = f.srv.CallJSON(ctx, &opts, nil, &info)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -746,7 +746,7 @@ func (f *Fs) readMetaDataForPath(ctx context.Context, path string) (info *api.It
 	if f.driveType != driveTypePersonal || firstSlashIndex == -1 {
 		opts := f.newOptsCallWithPath(ctx, path, "GET", "")
 		opts.Path = strings.TrimSuffix(opts.Path, ":")
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			resp, err = f.srv.CallJSON(ctx, &opts, nil, &info)
 			return shouldRetry(ctx, resp, err)
 		})
@@ -1019,7 +1019,7 @@ func (f *Fs) CreateDir(ctx context.Context, dirID, leaf string) (newID string, e
 		Name:             f.opt.Enc.FromStandardName(leaf),
 		ConflictBehavior: "fail",
 	}
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = f.srv.CallJSON(ctx, &opts, &mkdir, &info)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1051,7 +1051,7 @@ OUTER:
 	for {
 		var result api.ListChildrenResponse
 		var resp *http.Response
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			resp, err = f.srv.CallJSON(ctx, &opts, nil, &result)
 			return shouldRetry(ctx, resp, err)
 		})
@@ -1188,7 +1188,7 @@ func (f *Fs) deleteObject(ctx context.Context, id string) error {
 	opts := f.newOptsCall(id, "DELETE", "")
 	opts.NoResponse = true
 
-	return f.pacer.Call(func() (bool, error) {
+	return f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := f.srv.Call(ctx, &opts)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1245,7 +1245,7 @@ func (f *Fs) waitForJob(ctx context.Context, location string, o *Object) error {
 		var resp *http.Response
 		var err error
 		var body []byte
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			resp, err = http.Get(location)
 			if err != nil {
 				return fserrors.ShouldRetry(err), err
@@ -1347,7 +1347,7 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 		},
 	}
 	var resp *http.Response
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = f.srv.CallJSON(ctx, &opts, &copyReq, nil)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1440,7 +1440,7 @@ func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	}
 	var resp *http.Response
 	var info api.Item
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = f.srv.CallJSON(ctx, &opts, &move, &info)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1507,7 +1507,7 @@ func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string
 	}
 	var resp *http.Response
 	var info api.Item
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = f.srv.CallJSON(ctx, &opts, &move, &info)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1533,7 +1533,7 @@ func (f *Fs) About(ctx context.Context) (usage *fs.Usage, err error) {
 		Path:   "",
 	}
 	var resp *http.Response
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = f.srv.CallJSON(ctx, &opts, nil, &drive)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1583,7 +1583,7 @@ func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration,
 
 	var resp *http.Response
 	var result api.CreateShareLinkResponse
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = f.srv.CallJSON(ctx, &opts, &share, &result)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1703,7 +1703,7 @@ func (f *Fs) CleanUp(ctx context.Context) error {
 func (o *Object) deleteVersions(ctx context.Context) error {
 	opts := o.fs.newOptsCall(o.id, "GET", "/versions")
 	var versions api.VersionsResponse
-	err := o.fs.pacer.Call(func() (bool, error) {
+	err := o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := o.fs.srv.CallJSON(ctx, &opts, nil, &versions)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1730,7 +1730,7 @@ func (o *Object) deleteVersion(ctx context.Context, ID string) error {
 	fs.Infof(o, "removing version %q", ID)
 	opts := o.fs.newOptsCall(o.id, "DELETE", "/versions/"+ID)
 	opts.NoResponse = true
-	return o.fs.pacer.Call(func() (bool, error) {
+	return o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := o.fs.srv.Call(ctx, &opts)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1870,7 +1870,7 @@ func (o *Object) setModTime(ctx context.Context, modTime time.Time) (*api.Item,
 		},
 	}
 	var info *api.Item
-	err := o.fs.pacer.Call(func() (bool, error) {
+	err := o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err := o.fs.srv.CallJSON(ctx, &opts, &update, &info)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1912,7 +1912,7 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 	opts := o.fs.newOptsCall(o.id, "GET", "/content")
 	opts.Options = options
 
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = o.fs.srv.Call(ctx, &opts)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1934,7 +1934,7 @@ func (o *Object) createUploadSession(ctx context.Context, modTime time.Time) (re
 	createRequest.Item.FileSystemInfo.CreatedDateTime = api.Timestamp(modTime)
 	createRequest.Item.FileSystemInfo.LastModifiedDateTime = api.Timestamp(modTime)
 	var resp *http.Response
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = o.fs.srv.CallJSON(ctx, &opts, &createRequest, &response)
 		if apiErr, ok := err.(*api.Error); ok {
 			if apiErr.ErrorInfo.Code == "nameAlreadyExists" {
@@ -1955,7 +1955,7 @@ func (o *Object) getPosition(ctx context.Context, url string) (pos int64, err er
 	}
 	var info api.UploadFragmentResponse
 	var resp *http.Response
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = o.fs.srv.CallJSON(ctx, &opts, nil, &info)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -1984,7 +1984,7 @@ func (o *Object) uploadFragment(ctx context.Context, url string, start int64, to
 	var resp *http.Response
 	var body []byte
 	var skip = int64(0)
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		toSend := chunkSize - skip
 		opts := rest.Opts{
 			Method:        "PUT",
@@ -2042,7 +2042,7 @@ func (o *Object) cancelUploadSession(ctx context.Context, url string) (err error
 		NoResponse: true,
 	}
 	var resp *http.Response
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = o.fs.srv.Call(ctx, &opts)
 		return shouldRetry(ctx, resp, err)
 	})
@@ -2107,7 +2107,7 @@ func (o *Object) uploadSinglepart(ctx context.Context, in io.Reader, size int64,
 	opts.Body = in
 	opts.Options = options
 
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = o.fs.srv.CallJSON(ctx, &opts, nil, &info)
 		if apiErr, ok := err.(*api.Error); ok {
 			if apiErr.ErrorInfo.Code == "nameAlreadyExists" {
@@ -3440,7 +3440,7 @@ func (f *Fs) list(ctx context.Context, opt listOpt, fn listFn) error {
 		var resp *s3.ListObjectsV2Output
 		var err error
 		var versionIDs []*string
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			listBucket.URLEncodeListings(urlEncodeListings)
 			resp, versionIDs, err = listBucket.List(ctx)
 			if err != nil && !urlEncodeListings {
@@ -3600,7 +3600,7 @@ func (f *Fs) listDir(ctx context.Context, bucket, directory, prefix string, addB
 func (f *Fs) listBuckets(ctx context.Context) (entries fs.DirEntries, err error) {
 	req := s3.ListBucketsInput{}
 	var resp *s3.ListBucketsOutput
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = f.c.ListBucketsWithContext(ctx, &req)
 		return f.shouldRetry(ctx, err)
 	})
@@ -3723,7 +3723,7 @@ func (f *Fs) bucketExists(ctx context.Context, bucket string) (bool, error) {
 	req := s3.HeadBucketInput{
 		Bucket: &bucket,
 	}
-	err := f.pacer.Call(func() (bool, error) {
+	err := f.pacer.CallContext(ctx, func() (bool, error) {
 		_, err := f.c.HeadBucketWithContext(ctx, &req)
 		return f.shouldRetry(ctx, err)
 	})
@@ -3759,7 +3759,7 @@ func (f *Fs) makeBucket(ctx context.Context, bucket string) error {
 				LocationConstraint: &f.opt.LocationConstraint,
 			}
 		}
-		err := f.pacer.Call(func() (bool, error) {
+		err := f.pacer.CallContext(ctx, func() (bool, error) {
 			_, err := f.c.CreateBucketWithContext(ctx, &req)
 			return f.shouldRetry(ctx, err)
 		})
@@ -3789,7 +3789,7 @@ func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 		req := s3.DeleteBucketInput{
 			Bucket: &bucket,
 		}
-		err := f.pacer.Call(func() (bool, error) {
+		err := f.pacer.CallContext(ctx, func() (bool, error) {
 			_, err := f.c.DeleteBucketWithContext(ctx, &req)
 			return f.shouldRetry(ctx, err)
 		})
@@ -3852,7 +3852,7 @@ func (f *Fs) copy(ctx context.Context, req *s3.CopyObjectInput, dstBucket, dstPa
 	if src.bytes >= int64(f.opt.CopyCutoff) {
 		return f.copyMultipart(ctx, req, dstBucket, dstPath, srcBucket, srcPath, src)
 	}
-	return f.pacer.Call(func() (bool, error) {
+	return f.pacer.CallContext(ctx, func() (bool, error) {
 		_, err := f.c.CopyObjectWithContext(ctx, req)
 		return f.shouldRetry(ctx, err)
 	})
@@ -3897,7 +3897,7 @@ func (f *Fs) copyMultipart(ctx context.Context, copyReq *s3.CopyObjectInput, dst
 	req.Key = &dstPath
 
 	var cout *s3.CreateMultipartUploadOutput
-	if err := f.pacer.Call(func() (bool, error) {
+	if err := f.pacer.CallContext(ctx, func() (bool, error) {
 		var err error
 		cout, err = f.c.CreateMultipartUploadWithContext(ctx, req)
 		return f.shouldRetry(ctx, err)
@@ -3909,7 +3909,7 @@ func (f *Fs) copyMultipart(ctx context.Context, copyReq *s3.CopyObjectInput, dst
 	defer atexit.OnError(&err, func() {
 		// Try to abort the upload, but ignore the error.
 		fs.Debugf(src, "Cancelling multipart copy")
-		_ = f.pacer.Call(func() (bool, error) {
+		_ = f.pacer.CallContext(ctx, func() (bool, error) {
 			_, err := f.c.AbortMultipartUploadWithContext(context.Background(), &s3.AbortMultipartUploadInput{
 				Bucket:       &dstBucket,
 				Key:          &dstPath,
@@ -3928,7 +3928,7 @@ func (f *Fs) copyMultipart(ctx context.Context, copyReq *s3.CopyObjectInput, dst
 
 	var parts []*s3.CompletedPart
 	for partNum := int64(1); partNum <= numParts; partNum++ {
-		if err := f.pacer.Call(func() (bool, error) {
+		if err := f.pacer.CallContext(ctx, func() (bool, error) {
 			partNum := partNum
 			uploadPartReq := &s3.UploadPartCopyInput{}
 			//structs.SetFrom(uploadPartReq, copyReq)
@@ -3952,7 +3952,7 @@ func (f *Fs) copyMultipart(ctx context.Context, copyReq *s3.CopyObjectInput, dst
 		}
 	}
 
-	return f.pacer.Call(func() (bool, error) {
+	return f.pacer.CallContext(ctx, func() (bool, error) {
 		_, err := f.c.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
 			Bucket: &dstBucket,
 			Key:    &dstPath,
@@ -4227,7 +4227,7 @@ func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[str
 			reqCopy.Bucket = &bucket
 			reqCopy.Key = &bucketPath
 			reqCopy.VersionId = o.versionID
-			err = f.pacer.Call(func() (bool, error) {
+			err = f.pacer.CallContext(ctx, func() (bool, error) {
 				_, err = f.c.RestoreObject(&reqCopy)
 				return f.shouldRetry(ctx, err)
 			})
@@ -4279,7 +4279,7 @@ func (f *Fs) listMultipartUploads(ctx context.Context, bucket, key string) (uplo
 			Prefix:         &key,
 		}
 		var resp *s3.ListMultipartUploadsOutput
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			resp, err = f.c.ListMultipartUploads(&req)
 			return f.shouldRetry(ctx, err)
 		})
@@ -4404,7 +4404,7 @@ func (f *Fs) setGetVersioning(ctx context.Context, arg ...string) (status string
 			Bucket:                  &f.rootBucket,
 			VersioningConfiguration: &versioning,
 		}
-		err := f.pacer.Call(func() (bool, error) {
+		err := f.pacer.CallContext(ctx, func() (bool, error) {
 			_, err = f.c.PutBucketVersioningWithContext(ctx, &req)
 			return f.shouldRetry(ctx, err)
 		})
@@ -4416,7 +4416,7 @@ func (f *Fs) setGetVersioning(ctx context.Context, arg ...string) (status string
 		Bucket: &f.rootBucket,
 	}
 	var resp *s3.GetBucketVersioningOutput
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = f.c.GetBucketVersioningWithContext(ctx, &req)
 		return f.shouldRetry(ctx, err)
 	})
@@ -4618,7 +4618,7 @@ func (o *Object) headObject(ctx context.Context) (resp *s3.HeadObjectOutput, err
 	if o.fs.opt.SSECustomerKeyMD5 != "" {
 		req.SSECustomerKeyMD5 = &o.fs.opt.SSECustomerKeyMD5
 	}
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		var err error
 		resp, err = o.fs.c.HeadObjectWithContext(ctx, &req)
 		return o.fs.shouldRetry(ctx, err)
@@ -4787,7 +4787,7 @@ func (o *Object) downloadFromURL(ctx context.Context, bucketPath string, options
 		RootURL: url,
 		Options: options,
 	}
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = o.fs.srvRest.Call(ctx, &opts)
 		return o.fs.shouldRetry(ctx, err)
 	})
@@ -4884,7 +4884,7 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 			}
 		}
 	}
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		var err error
 		httpReq.HTTPRequest = httpReq.HTTPRequest.WithContext(ctx)
 		err = httpReq.Send()
@@ -4974,7 +4974,7 @@ func (o *Object) uploadMultipart(ctx context.Context, req *s3.PutObjectInput, si
 	//structs.SetFrom(&mReq, req)
 	setFrom_s3CreateMultipartUploadInput_s3PutObjectInput(&mReq, req)
 	var cout *s3.CreateMultipartUploadOutput
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		var err error
 		cout, err = f.c.CreateMultipartUploadWithContext(ctx, &mReq)
 		return f.shouldRetry(ctx, err)
@@ -4989,7 +4989,7 @@ func (o *Object) uploadMultipart(ctx context.Context, req *s3.PutObjectInput, si
 			return
 		}
 		fs.Debugf(o, "Cancelling multipart upload")
-		errCancel := f.pacer.Call(func() (bool, error) {
+		errCancel := f.pacer.CallContext(ctx, func() (bool, error) {
 			_, err := f.c.AbortMultipartUploadWithContext(context.Background(), &s3.AbortMultipartUploadInput{
 				Bucket:       req.Bucket,
 				Key:          req.Key,
@@ -5069,7 +5069,7 @@ func (o *Object) uploadMultipart(ctx context.Context, req *s3.PutObjectInput, si
 			addMd5(&md5sumBinary, partNum-1)
 			md5sum := base64.StdEncoding.EncodeToString(md5sumBinary[:])
 
-			err = f.pacer.Call(func() (bool, error) {
+			err = f.pacer.CallContext(ctx, func() (bool, error) {
 				uploadPartReq := &s3.UploadPartInput{
 					Body:                 bytes.NewReader(buf),
 					Bucket:               req.Bucket,
@@ -5117,7 +5117,7 @@ func (o *Object) uploadMultipart(ctx context.Context, req *s3.PutObjectInput, si
 	})
 
 	var resp *s3.CompleteMultipartUploadOutput
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		resp, err = f.c.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
 			Bucket: req.Bucket,
 			Key:    req.Key,
@@ -5175,7 +5175,7 @@ func (o *Object) uploadSinglepartPutObject(ctx context.Context, req *s3.PutObjec
 	r.SetContext(ctx)
 	r.HTTPRequest.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
 
-	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+	err = o.fs.pacer.CallNoRetryContext(ctx, func() (bool, error) {
 		err := r.Send()
 		return o.fs.shouldRetry(ctx, err)
 	})
@@ -5235,7 +5235,7 @@ func (o *Object) uploadSinglepartPresignedRequest(ctx context.Context, req *s3.P
 	httpReq.ContentLength = size
 
 	var resp *http.Response
-	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+	err = o.fs.pacer.CallNoRetryContext(ctx, func() (bool, error) {
 		var err error
 		resp, err = o.fs.srv.Do(httpReq)
 		if err != nil {
@@ -5507,7 +5507,7 @@ func (o *Object) Remove(ctx context.Context) error {
 	if o.fs.opt.RequesterPays {
 		req.RequestPayer = aws.String(s3.RequestPayerRequester)
 	}
-	err := o.fs.pacer.Call(func() (bool, error) {
+	err := o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		_, err := o.fs.c.DeleteObjectWithContext(ctx, &req)
 		return o.fs.shouldRetry(ctx, err)
 	})
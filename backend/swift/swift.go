@@ -498,7 +498,7 @@ func NewFsWithConnection(ctx context.Context, opt *Options, name, root string, c
 		var info swift.Object
 		var err error
 		encodedDirectory := f.opt.Enc.FromStandardPath(f.rootDirectory)
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			var rxHeaders swift.Headers
 			info, rxHeaders, err = f.c.Object(ctx, f.rootContainer, encodedDirectory)
 			return shouldRetryHeaders(ctx, rxHeaders, err)
@@ -607,7 +607,7 @@ func (f *Fs) listContainerRoot(ctx context.Context, container, directory, prefix
 	return f.c.ObjectsWalk(ctx, container, &opts, func(ctx context.Context, opts *swift.ObjectsOpts) (interface{}, error) {
 		var objects []swift.Object
 		var err error
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			objects, err = f.c.Objects(ctx, container, opts)
 			return shouldRetry(ctx, err)
 		})
@@ -692,7 +692,7 @@ func (f *Fs) listDir(ctx context.Context, container, directory, prefix string, a
 // listContainers lists the containers
 func (f *Fs) listContainers(ctx context.Context) (entries fs.DirEntries, err error) {
 	var containers []swift.Container
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		containers, err = f.c.ContainersAll(ctx, nil)
 		return shouldRetry(ctx, err)
 	})
@@ -785,7 +785,7 @@ func (f *Fs) About(ctx context.Context) (usage *fs.Usage, err error) {
 	var total, objects int64
 	if f.rootContainer != "" {
 		var container swift.Container
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			container, _, err = f.c.Container(ctx, f.rootContainer)
 			return shouldRetry(ctx, err)
 		})
@@ -796,7 +796,7 @@ func (f *Fs) About(ctx context.Context) (usage *fs.Usage, err error) {
 		objects = container.Count
 	} else {
 		var containers []swift.Container
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			containers, err = f.c.ContainersAll(ctx, nil)
 			return shouldRetry(ctx, err)
 		})
@@ -847,7 +847,7 @@ func (f *Fs) makeContainer(ctx context.Context, container string) error {
 		// Check to see if container exists first
 		var err error = swift.ContainerNotFound
 		if !f.noCheckContainer {
-			err = f.pacer.Call(func() (bool, error) {
+			err = f.pacer.CallContext(ctx, func() (bool, error) {
 				var rxHeaders swift.Headers
 				_, rxHeaders, err = f.c.Container(ctx, container)
 				return shouldRetryHeaders(ctx, rxHeaders, err)
@@ -858,7 +858,7 @@ func (f *Fs) makeContainer(ctx context.Context, container string) error {
 			if f.opt.StoragePolicy != "" {
 				headers["X-Storage-Policy"] = f.opt.StoragePolicy
 			}
-			err = f.pacer.Call(func() (bool, error) {
+			err = f.pacer.CallContext(ctx, func() (bool, error) {
 				err = f.c.ContainerCreate(ctx, container, headers)
 				return shouldRetry(ctx, err)
 			})
@@ -879,7 +879,7 @@ func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 		return nil
 	}
 	err := f.cache.Remove(container, func() error {
-		err := f.pacer.Call(func() (bool, error) {
+		err := f.pacer.CallContext(ctx, func() (bool, error) {
 			err := f.c.ContainerDelete(ctx, container)
 			return shouldRetry(ctx, err)
 		})
@@ -956,7 +956,7 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 		err = copyLargeObject(ctx, f, srcObj, dstContainer, dstPath)
 	} else {
 		srcContainer, srcPath := srcObj.split()
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.CallContext(ctx, func() (bool, error) {
 			var rxHeaders swift.Headers
 			rxHeaders, err = f.c.ObjectCopy(ctx, srcContainer, srcPath, dstContainer, dstPath, nil)
 			return shouldRetryHeaders(ctx, rxHeaders, err)
@@ -1022,7 +1022,7 @@ func copyLargeObject(ctx context.Context, f *Fs, src *Object, dstContainer strin
 				lastIndex = lastIndex + 1
 			}
 			segmentName := dstPath + "/" + prefixSegment + "/" + s[lastIndex:]
-			err = f.pacer.Call(func() (bool, error) {
+			err = f.pacer.CallContext(ctx, func() (bool, error) {
 				var rxHeaders swift.Headers
 				rxHeaders, err = f.c.ObjectCopy(ctx, c, s, segmentsContainer, segmentName, nil)
 				copiedSegments = append(copiedSegments, segmentName)
@@ -1038,7 +1038,7 @@ func copyLargeObject(ctx context.Context, f *Fs, src *Object, dstContainer strin
 	headers["X-Object-Manifest"] = urlEncode(fmt.Sprintf("%s/%s/%s", segmentsContainer, dstPath, prefixSegment))
 	headers["Content-Length"] = "0"
 	emptyReader := bytes.NewReader(nil)
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.CallContext(ctx, func() (bool, error) {
 		var rxHeaders swift.Headers
 		rxHeaders, err = f.c.ObjectPut(ctx, dstContainer, dstPath, emptyReader, true, "", src.contentType, headers)
 		return shouldRetryHeaders(ctx, rxHeaders, err)
@@ -1201,7 +1201,7 @@ func (o *Object) readMetaData(ctx context.Context) (err error) {
 	var info swift.Object
 	var h swift.Headers
 	container, containerPath := o.split()
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		info, h, err = o.fs.c.Object(ctx, container, containerPath)
 		return shouldRetryHeaders(ctx, h, err)
 	})
@@ -1259,7 +1259,7 @@ func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
 		}
 	}
 	container, containerPath := o.split()
-	return o.fs.pacer.Call(func() (bool, error) {
+	return o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		err = o.fs.c.ObjectUpdate(ctx, container, containerPath, newHeaders)
 		return shouldRetry(ctx, err)
 	})
@@ -1279,7 +1279,7 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 	headers := fs.OpenOptionHeaders(options)
 	_, isRanging := headers["Range"]
 	container, containerPath := o.split()
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		var rxHeaders swift.Headers
 		in, rxHeaders, err = o.fs.c.ObjectOpen(ctx, container, containerPath, !isRanging, headers)
 		return shouldRetryHeaders(ctx, rxHeaders, err)
@@ -1369,7 +1369,7 @@ func (o *Object) updateChunks(ctx context.Context, in0 io.Reader, headers swift.
 	segmentsContainer := container + "_segments"
 	// Create the segmentsContainer if it doesn't exist
 	var err error
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		var rxHeaders swift.Headers
 		_, rxHeaders, err = o.fs.c.Container(ctx, segmentsContainer)
 		return shouldRetryHeaders(ctx, rxHeaders, err)
@@ -1379,7 +1379,7 @@ func (o *Object) updateChunks(ctx context.Context, in0 io.Reader, headers swift.
 		if o.fs.opt.StoragePolicy != "" {
 			headers["X-Storage-Policy"] = o.fs.opt.StoragePolicy
 		}
-		err = o.fs.pacer.Call(func() (bool, error) {
+		err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 			err = o.fs.c.ContainerCreate(ctx, segmentsContainer, headers)
 			return shouldRetry(ctx, err)
 		})
@@ -1423,7 +1423,7 @@ func (o *Object) updateChunks(ctx context.Context, in0 io.Reader, headers swift.
 		segmentReader := io.LimitReader(in, n)
 		segmentPath := fmt.Sprintf("%s/%08d", segmentsPath, i)
 		fs.Debugf(o, "Uploading segment file %q into %q", segmentPath, segmentsContainer)
-		err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+		err = o.fs.pacer.CallNoRetryContext(ctx, func() (bool, error) {
 			var rxHeaders swift.Headers
 			rxHeaders, err = o.fs.c.ObjectPut(ctx, segmentsContainer, segmentPath, segmentReader, true, "", "", headers)
 			if err == nil {
@@ -1440,7 +1440,7 @@ func (o *Object) updateChunks(ctx context.Context, in0 io.Reader, headers swift.
 	headers["X-Object-Manifest"] = urlEncode(fmt.Sprintf("%s/%s", segmentsContainer, segmentsPath))
 	headers["Content-Length"] = "0" // set Content-Length as we know it
 	emptyReader := bytes.NewReader(nil)
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		var rxHeaders swift.Headers
 		rxHeaders, err = o.fs.c.ObjectPut(ctx, container, containerPath, emptyReader, true, "", contentType, headers)
 		return shouldRetryHeaders(ctx, rxHeaders, err)
@@ -1516,7 +1516,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 			in = inCount
 		}
 		var rxHeaders swift.Headers
-		err = o.fs.pacer.CallNoRetry(func() (bool, error) {
+		err = o.fs.pacer.CallNoRetryContext(ctx, func() (bool, error) {
 			rxHeaders, err = o.fs.c.ObjectPut(ctx, container, containerPath, in, true, "", contentType, headers)
 			return shouldRetryHeaders(ctx, rxHeaders, err)
 		})
@@ -1574,7 +1574,7 @@ func (o *Object) Remove(ctx context.Context) (err error) {
 		}
 	}
 	// Remove file/manifest first
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.CallContext(ctx, func() (bool, error) {
 		err = o.fs.c.ObjectDelete(ctx, container, containerPath)
 		return shouldRetry(ctx, err)
 	})
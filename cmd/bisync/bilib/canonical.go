@@ -2,10 +2,13 @@
 package bilib
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"os"
 	"regexp"
 	"runtime"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/rclone/rclone/fs"
 )
@@ -36,7 +39,40 @@ func CanonicalPath(remote string) string {
 
 var nonCanonicalChars = regexp.MustCompile(`[\s\\/:?*]`)
 
+// MaxSessionName is the longest session name used as is. It leaves
+// room in the 255 byte file name limit of most file systems for the
+// longest suffix of the working files, ".path16.lst-dry-new.tmp".
+const MaxSessionName = 230
+
 // SessionName makes a unique base name for the sync operation
-func SessionName(fs1, fs2 fs.Fs) string {
-	return CanonicalPath(FsPath(fs1)) + ".." + CanonicalPath(FsPath(fs2))
+//
+// Names longer than MaxSessionName, which happens easily with many
+// paths, are cut short and end in the MD5 hash of the full name
+// instead.
+func SessionName(fss ...fs.Fs) string {
+	name := fullSessionName(fss)
+	if len(name) <= MaxSessionName {
+		return name
+	}
+	sum := md5.Sum([]byte(name))
+	cut := MaxSessionName - 2 - hex.EncodedLen(md5.Size)
+	for cut > 0 && !utf8.RuneStart(name[cut]) {
+		cut--
+	}
+	return name[:cut] + ".." + hex.EncodeToString(sum[:])
+}
+
+// LegacySessionName is the name SessionName made for two paths before
+// long names were shortened, which is the full name however long.
+func LegacySessionName(fs1, fs2 fs.Fs) string {
+	return fullSessionName([]fs.Fs{fs1, fs2})
+}
+
+// fullSessionName joins the canonical names of the paths
+func fullSessionName(fss []fs.Fs) string {
+	names := make([]string, len(fss))
+	for i, f := range fss {
+		names[i] = CanonicalPath(FsPath(f))
+	}
+	return strings.Join(names, "..")
 }
@@ -134,3 +134,28 @@ func CopyDir(src string, dst string) (err error) {
 
 	return
 }
+
+// WriteFileAtomic writes data to a temporary file and renames it into
+// place, so readers will see either the old or the new contents
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, perm); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// CopyFileIfExistsAtomic is like CopyFileIfExists but replaces
+// the destination atomically
+func CopyFileIfExistsAtomic(srcFile, dstFile string) error {
+	if !FileExists(srcFile) {
+		return nil
+	}
+	tmpFile := dstFile + ".tmp"
+	if err := CopyFile(srcFile, tmpFile); err != nil {
+		_ = os.Remove(tmpFile)
+		return err
+	}
+	return os.Rename(tmpFile, dstFile)
+}
@@ -21,6 +21,7 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/rclone/rclone/cmd/bisync"
 	"github.com/rclone/rclone/cmd/bisync/bilib"
@@ -147,6 +148,8 @@ type bisyncTest struct {
 	fs2        fs.Fs
 	path2      string
 	canonPath2 string
+	fs3        fs.Fs // only set up for test cases using {path3/}
+	path3      string
 	// test log
 	logDir  string
 	logPath string
@@ -249,6 +252,74 @@ func TestBisync(t *testing.T) {
 	}
 }
 
+// TestSessionNameLong checks that long session names are shortened
+func TestSessionNameLong(t *testing.T) {
+	ctx := context.Background()
+	var fss []fs.Fs
+	for i := 1; i <= 3; i++ {
+		f, err := fs.NewFs(ctx, filepath.Join(os.TempDir(), strings.Repeat("ü", 50), fmt.Sprintf("path%d", i)))
+		require.NoError(t, err)
+		fss = append(fss, f)
+	}
+	name := bilib.SessionName(fss...)
+	assert.LessOrEqual(t, len(name), bilib.MaxSessionName)
+	assert.True(t, utf8.ValidString(name))
+	assert.Regexp(t, `\.\.[0-9a-f]{32}$`, name)
+	assert.NotEqual(t, name, bilib.SessionName(fss[0], fss[2], fss[1]))
+
+	f, err := fs.NewFs(ctx, filepath.Join(os.TempDir(), "short"))
+	require.NoError(t, err)
+	canon := bilib.CanonicalPath(bilib.FsPath(f))
+	assert.Equal(t, canon+".."+canon, bilib.SessionName(f, f))
+}
+
+// TestRenameLegacySession checks listings made under the old long
+// session names are picked up without a resync
+func TestRenameLegacySession(t *testing.T) {
+	ctx := context.Background()
+	accounting.Stats(ctx).ResetErrors()
+	dir := t.TempDir()
+	// Make the legacy name longer than MaxSessionName but short
+	// enough for the listings to have been made
+	pad := (bilib.MaxSessionName + 8 - 2*len(bilib.CanonicalPath(dir+"/_path1/")) - 2) / 2
+	if pad < 1 {
+		t.Skip("temporary directory name too long")
+	}
+	var fss []fs.Fs
+	for i := 1; i <= 2; i++ {
+		path := filepath.Join(dir, strings.Repeat("x", pad), fmt.Sprintf("path%d", i))
+		require.NoError(t, os.MkdirAll(path, 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(path, "file.txt"), []byte("potato"), 0600))
+		f, err := fs.NewFs(ctx, path)
+		require.NoError(t, err)
+		fss = append(fss, f)
+	}
+	legacyName := bilib.LegacySessionName(fss[0], fss[1])
+	require.Greater(t, len(legacyName), bilib.MaxSessionName)
+	opt := &bisync.Options{
+		Workdir:       filepath.Join(dir, "workdir"),
+		Resync:        true,
+		MaxDelete:     bisync.DefaultMaxDelete,
+		CheckFilename: bisync.DefaultCheckFilename,
+		CheckSync:     bisync.CheckSyncTrue,
+	}
+	require.NoError(t, bisync.Bisync(ctx, fss[0], fss[1], opt))
+
+	// Move the listings to the name older versions used
+	basePath := filepath.Join(opt.Workdir, bilib.SessionName(fss...))
+	legacyBase := filepath.Join(opt.Workdir, legacyName)
+	for _, suffix := range []string{".path1.lst", ".path2.lst"} {
+		require.NoError(t, os.Rename(basePath+suffix, legacyBase+suffix))
+	}
+
+	opt.Resync = false
+	require.NoError(t, bisync.Bisync(ctx, fss[0], fss[1], opt))
+	for _, suffix := range []string{".path1.lst", ".path2.lst"} {
+		assert.False(t, bilib.FileExists(legacyBase+suffix))
+		assert.True(t, bilib.FileExists(basePath+suffix))
+	}
+}
+
 func (b *bisyncTest) cleanupAll() {
 	if b.noCleanup {
 		return
@@ -273,6 +344,17 @@ func (b *bisyncTest) runTestCase(ctx context.Context, t *testing.T, testCase str
 
 	b.sessionName = bilib.SessionName(b.fs1, b.fs2)
 	b.testDir = b.ensureDir(b.dataRoot, "test_"+b.testCase, false)
+
+	// Test cases of bisync with more than two paths use a third path
+	// on the first remote
+	scenFile := filepath.Join(b.testDir, "scenario.txt")
+	scenBuf, err := os.ReadFile(scenFile)
+	require.NoError(b.t, err)
+	b.fs3, b.path3 = nil, ""
+	if strings.Contains(string(scenBuf), "{path3/}") {
+		b.fs3, _, b.path3, _ = b.makeTempRemote(ctx, b.argRemote1, "path3")
+	}
+
 	b.initDir = b.ensureDir(b.testDir, "initial", false)
 	b.goldenDir = b.ensureDir(b.testDir, "golden", false)
 	b.dataDir = b.ensureDir(b.testDir, "modfiles", true) // optional
@@ -294,6 +376,9 @@ func (b *bisyncTest) runTestCase(ctx context.Context, t *testing.T, testCase str
 	require.NoError(b.t, err)
 	require.NoError(b.t, sync.CopyDir(ctx, b.fs1, initFs, true), "setting up path1")
 	require.NoError(b.t, sync.CopyDir(ctx, b.fs2, initFs, true), "setting up path2")
+	if b.fs3 != nil {
+		require.NoError(b.t, sync.CopyDir(ctx, b.fs3, initFs, true), "setting up path3")
+	}
 
 	// Create log file
 	b.mkdir(b.workDir)
@@ -301,10 +386,7 @@ func (b *bisyncTest) runTestCase(ctx context.Context, t *testing.T, testCase str
 	require.NoError(b.t, err, "creating log file")
 
 	// Execute test scenario
-	scenFile := filepath.Join(b.testDir, "scenario.txt")
-	scenBuf, err := os.ReadFile(scenFile)
 	scenReplacer := b.newReplacer(false)
-	require.NoError(b.t, err)
 	b.step = 0
 	b.stopped = false
 	for _, line := range strings.Split(string(scenBuf), "\n") {
@@ -442,6 +524,11 @@ func (b *bisyncTest) cleanupCase(ctx context.Context) {
 	_ = bilib.CaptureOutput(func() {
 		_ = operations.Purge(ctx, b.fs2, "")
 	})
+	if b.fs3 != nil {
+		_ = bilib.CaptureOutput(func() {
+			_ = operations.Purge(ctx, b.fs3, "")
+		})
+	}
 	_ = os.RemoveAll(b.workDir)
 	accounting.Stats(ctx).ResetCounters()
 }
@@ -591,7 +678,7 @@ func (b *bisyncTest) runBisync(ctx context.Context, args []string) (err error) {
 		CheckSync:     bisync.CheckSyncTrue,
 	}
 	octx, ci := fs.AddConfig(ctx)
-	fs1, fs2 := b.fs1, b.fs2
+	fs1, fs2, fs3 := b.fs1, b.fs2, fs.Fs(nil)
 
 	addSubdir := func(path, subdir string) fs.Fs {
 		remote := path + subdir
@@ -631,16 +718,25 @@ func (b *bisyncTest) runBisync(ctx context.Context, args []string) (err error) {
 			require.NoError(b.t, err, "parsing max-delete=%q", val)
 		case "size-only":
 			ci.SizeOnly = true
+		case "detect-renames":
+			opt.DetectRenames = true
 		case "subdir":
 			fs1 = addSubdir(b.path1, val)
 			fs2 = addSubdir(b.path2, val)
+		case "path3":
+			require.NotNil(b.t, b.fs3, "path3 needs {path3/} in the scenario")
+			fs3 = b.fs3
 		default:
 			return fmt.Errorf("invalid bisync option %q", arg)
 		}
 	}
 
 	output := bilib.CaptureOutput(func() {
-		err = bisync.Bisync(octx, fs1, fs2, opt)
+		if fs3 != nil {
+			err = bisync.BisyncMulti(octx, []fs.Fs{fs1, fs2, fs3}, opt)
+		} else {
+			err = bisync.Bisync(octx, fs1, fs2, opt)
+		}
 	})
 
 	_, _ = os.Stdout.Write(output)
@@ -1150,6 +1246,7 @@ func (b *bisyncTest) newReplacer(mangle bool) *strings.Replacer {
 			"{workdir/}", b.workDir + slash,
 			"{path1/}", b.path1,
 			"{path2/}", b.path2,
+			"{path3/}", b.path3,
 			"{session}", b.sessionName,
 			"{/}", slash,
 		}
@@ -1164,6 +1261,9 @@ func (b *bisyncTest) newReplacer(mangle bool) *strings.Replacer {
 		b.path2, "{path2/}",
 		b.sessionName, "{session}",
 	}
+	if b.path3 != "" {
+		rep = append(rep, b.path3, "{path3/}")
+	}
 	if fixSlash {
 		prep := []string{}
 		for i := 0; i < len(rep); i += 2 {
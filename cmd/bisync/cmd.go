@@ -31,6 +31,8 @@ type Options struct {
 	CheckAccess     bool
 	CheckFilename   string
 	CheckSync       CheckSyncMode
+	Compare         CompareMode
+	DetectRenames   bool
 	RemoveEmptyDirs bool
 	MaxDelete       int // percentage from 0 to 100
 	Force           bool
@@ -38,7 +40,8 @@ type Options struct {
 	Workdir         string
 	DryRun          bool
 	NoCleanup       bool
-	SaveQueues      bool // save extra debugging files (test only flag)
+	MaxLock         time.Duration // lock files older than this are considered stale, 0 for never
+	SaveQueues      bool          // save extra debugging files (test only flag)
 }
 
 // Default values
@@ -47,6 +50,9 @@ const (
 	DefaultCheckFilename string = "RCLONE_TEST"
 )
 
+// MaxPaths is the maximum number of paths bisync can keep in sync
+const MaxPaths = 16
+
 // DefaultWorkdir is default working directory
 var DefaultWorkdir = filepath.Join(config.GetCacheDir(), "bisync")
 
@@ -92,6 +98,73 @@ func (x *CheckSyncMode) Type() string {
 	return "string"
 }
 
+// CompareMode is a set of file attributes used to detect changes
+type CompareMode uint8
+
+// Compare modes
+const (
+	CompareSize     CompareMode = 1 << iota // Compare file sizes
+	CompareModtime                          // Compare modification times
+	CompareChecksum                         // Compare hashes, ignoring modtime changes if content is the same
+
+	CompareDefault = CompareModtime // Used if no mode is set
+)
+
+var compareNames = []struct {
+	mode CompareMode
+	name string
+}{
+	{CompareSize, "size"},
+	{CompareModtime, "modtime"},
+	{CompareChecksum, "checksum"},
+}
+
+// Has returns true if the given mode is set, taking the default into account
+func (x CompareMode) Has(mode CompareMode) bool {
+	if x == 0 {
+		x = CompareDefault
+	}
+	return x&mode != 0
+}
+
+func (x CompareMode) String() string {
+	if x == 0 {
+		x = CompareDefault
+	}
+	var names []string
+	for _, c := range compareNames {
+		if x&c.mode != 0 {
+			names = append(names, c.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Set a Compare mode from a comma separated string
+func (x *CompareMode) Set(s string) error {
+	var mode CompareMode
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, c := range compareNames {
+			if name == c.name {
+				mode |= c.mode
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown compare mode for bisync: %q", name)
+		}
+	}
+	*x = mode
+	return nil
+}
+
+// Type of the Compare value
+func (x *CompareMode) Type() string {
+	return "string"
+}
+
 // Opt keeps command line options
 var Opt Options
 
@@ -103,23 +176,38 @@ func init() {
 	flags.StringVarP(cmdFlags, &Opt.CheckFilename, "check-filename", "", Opt.CheckFilename, makeHelp("Filename for --check-access (default: {CHECKFILE})"))
 	flags.BoolVarP(cmdFlags, &Opt.Force, "force", "", Opt.Force, "Bypass --max-delete safety check and run the sync. Consider using with --verbose")
 	flags.FVarP(cmdFlags, &Opt.CheckSync, "check-sync", "", "Controls comparison of final listings: true|false|only (default: true)")
+	flags.FVarP(cmdFlags, &Opt.Compare, "compare", "", "Comma separated list of attributes used to detect changes: size,modtime,checksum (default: modtime)")
+	flags.BoolVarP(cmdFlags, &Opt.DetectRenames, "detect-renames", "", Opt.DetectRenames, "Detect renamed files by size and hash and propagate them as server-side moves.")
 	flags.BoolVarP(cmdFlags, &Opt.RemoveEmptyDirs, "remove-empty-dirs", "", Opt.RemoveEmptyDirs, "Remove empty directories at the final cleanup step.")
 	flags.StringVarP(cmdFlags, &Opt.FiltersFile, "filters-file", "", Opt.FiltersFile, "Read filtering patterns from a file")
 	flags.StringVarP(cmdFlags, &Opt.Workdir, "workdir", "", Opt.Workdir, makeHelp("Use custom working dir - useful for testing. (default: {WORKDIR})"))
 	flags.BoolVarP(cmdFlags, &tzLocal, "localtime", "", tzLocal, "Use local time in listings (default: UTC)")
 	flags.BoolVarP(cmdFlags, &Opt.NoCleanup, "no-cleanup", "", Opt.NoCleanup, "Retain working files (useful for troubleshooting and testing).")
+	flags.DurationVarP(cmdFlags, &Opt.MaxLock, "max-lock", "", Opt.MaxLock, "Consider lock files older than this stale and remove them (0 to never expire).")
 }
 
 // bisync command definition
 var commandDefinition = &cobra.Command{
-	Use:   "bisync remote1:path1 remote2:path2",
+	Use:   "bisync remote1:path1 remote2:path2 [remote3:path3 ...]",
 	Short: shortHelp,
 	Long:  longHelp,
 	RunE: func(command *cobra.Command, args []string) error {
-		cmd.CheckArgs(2, 2, command, args)
-		fs1, file1, fs2, file2 := cmd.NewFsSrcDstFiles(args)
-		if file1 != "" || file2 != "" {
-			return errors.New("paths must be existing directories")
+		cmd.CheckArgs(2, MaxPaths, command, args)
+		var fss []fs.Fs
+		if len(args) == 2 {
+			fs1, file1, fs2, file2 := cmd.NewFsSrcDstFiles(args)
+			if file1 != "" || file2 != "" {
+				return errors.New("paths must be existing directories")
+			}
+			fss = []fs.Fs{fs1, fs2}
+		} else {
+			for _, arg := range args {
+				f, file := cmd.NewFsFile(arg)
+				if file != "" {
+					return errors.New("paths must be existing directories")
+				}
+				fss = append(fss, f)
+			}
 		}
 
 		ctx := context.Background()
@@ -130,10 +218,13 @@ var commandDefinition = &cobra.Command{
 			TZ = time.Local
 		}
 
-		commonHashes := fs1.Hashes().Overlap(fs2.Hashes())
-		isDropbox1 := strings.HasPrefix(fs1.String(), "Dropbox")
-		isDropbox2 := strings.HasPrefix(fs2.String(), "Dropbox")
-		if commonHashes == hash.Set(0) && (isDropbox1 || isDropbox2) {
+		commonHashes := fss[0].Hashes()
+		isDropbox := false
+		for _, f := range fss {
+			commonHashes = commonHashes.Overlap(f.Hashes())
+			isDropbox = isDropbox || strings.HasPrefix(f.String(), "Dropbox")
+		}
+		if commonHashes == hash.Set(0) && isDropbox {
 			ci := fs.GetConfig(ctx)
 			if !ci.DryRun && !ci.RefreshTimes {
 				fs.Debugf(nil, "Using flag --refresh-times is recommended")
@@ -142,7 +233,7 @@ var commandDefinition = &cobra.Command{
 
 		fs.Logf(nil, "bisync is EXPERIMENTAL. Don't use in production!")
 		cmd.Run(false, true, command, func() error {
-			err := Bisync(ctx, fs1, fs2, &opt)
+			err := BisyncMulti(ctx, fss, &opt)
 			if err == ErrBisyncAborted {
 				os.Exit(2)
 			}
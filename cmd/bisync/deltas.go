@@ -7,10 +7,13 @@ import (
 	"fmt"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/rclone/rclone/cmd/bisync/bilib"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/transferlog"
 )
 
 // delta
@@ -28,7 +31,7 @@ const (
 
 const (
 	deltaModified delta = deltaNewer | deltaOlder | deltaSize | deltaHash | deltaDeleted
-	deltaOther    delta = deltaNew | deltaNewer | deltaOlder
+	deltaOther    delta = deltaNew | deltaNewer | deltaOlder | deltaSize | deltaHash
 )
 
 func (d delta) is(cond delta) bool {
@@ -45,6 +48,10 @@ type deltaSet struct {
 	deleted    int    // number of deleted files (for "excess deletes" check)
 	foundSame  bool   // true if found at least one unchanged file
 	checkFiles bilib.Names
+	hash       hash.Type            // hash type of the current listing
+	current    map[string]*fileInfo // current info of changed files kept for reconciliation
+	renames    map[string]string    // new file name to old file name of detected renames
+	queued     map[string]string    // detected renames queued as moves on the other paths
 }
 
 func (ds *deltaSet) empty() bool {
@@ -72,6 +79,7 @@ func (ds *deltaSet) printStats() {
 	nNewer := 0
 	nOlder := 0
 	nDeleted := 0
+	nChanged := 0
 	for _, d := range ds.deltas {
 		if d.is(deltaNew) {
 			nNew++
@@ -85,9 +93,19 @@ func (ds *deltaSet) printStats() {
 		if d.is(deltaDeleted) {
 			nDeleted++
 		}
+		if d.is(deltaSize|deltaHash) && !d.is(deltaNewer|deltaOlder) {
+			nChanged++
+		}
 	}
-	fs.Infof(nil, "%s: %4d changes: %4d new, %4d newer, %4d older, %4d deleted",
+	stats := fmt.Sprintf("%s: %4d changes: %4d new, %4d newer, %4d older, %4d deleted",
 		ds.msg, nAll, nNew, nNewer, nOlder, nDeleted)
+	if nChanged > 0 {
+		stats += fmt.Sprintf(", %4d changed", nChanged)
+	}
+	if len(ds.renames) > 0 {
+		stats += fmt.Sprintf(", %4d renamed", len(ds.renames))
+	}
+	fs.Infof(nil, "%s", stats)
 }
 
 // findDeltas
@@ -119,6 +137,10 @@ func (b *bisyncRun) findDeltas(fctx context.Context, f fs.Fs, oldListing, newLis
 		oldCount:   len(old.list),
 		opt:        b.opt,
 		checkFiles: bilib.Names{},
+		hash:       now.hash,
+		current:    map[string]*fileInfo{},
+		renames:    map[string]string{},
+		queued:     map[string]string{},
 	}
 
 	for _, file := range old.list {
@@ -128,16 +150,7 @@ func (b *bisyncRun) findDeltas(fctx context.Context, f fs.Fs, oldListing, newLis
 			ds.deleted++
 			d |= deltaDeleted
 		} else {
-			if old.getTime(file) != now.getTime(file) {
-				if old.beforeOther(now, file) {
-					b.indent(msg, file, "File is newer")
-					d |= deltaNewer
-				} else { // Current version is older than prior sync.
-					b.indent(msg, file, "File is OLDER")
-					d |= deltaOlder
-				}
-			}
-			// TODO Compare sizes and hashes
+			d = b.compareFile(old, now, file, msg)
 		}
 
 		if d.is(deltaModified) {
@@ -157,6 +170,22 @@ func (b *bisyncRun) findDeltas(fctx context.Context, f fs.Fs, oldListing, newLis
 		}
 	}
 
+	if b.opt.DetectRenames {
+		ds.findRenames(b, old, now)
+	}
+
+	// Keep current state of files which an interrupted run might have
+	// already transferred, or of all changed files when syncing more
+	// than two paths, so that identical changes can be reconciled.
+	multi := len(b.fss) > 2
+	if multi || !b.journal.empty() {
+		for file := range ds.deltas {
+			if (multi || b.journal.has(file)) && now.has(file) {
+				ds.current[file] = now.get(file)
+			}
+		}
+	}
+
 	if b.opt.CheckAccess {
 		// checkFiles is a small structure compared with the `now`, so we
 		// return it alone and let the full delta map be garbage collected.
@@ -170,6 +199,158 @@ func (b *bisyncRun) findDeltas(fctx context.Context, f fs.Fs, oldListing, newLis
 	return
 }
 
+// compareFile returns the delta between prior and current versions of
+// a file which exists in both listings, according to --compare.
+//
+// If both versions have a hash of the same type and checksum comparison
+// is on, the hashes decide alone, so a touched file with unchanged
+// content is not a change. Otherwise size and modtime are compared as
+// requested, or both of them if checksum was requested but hashes are
+// not available.
+func (b *bisyncRun) compareFile(old, now *fileList, file, msg string) (d delta) {
+	compare := b.opt.Compare
+	oldInfo, nowInfo := old.get(file), now.get(file)
+	if compare.Has(CompareChecksum) {
+		if old.hash != hash.None && old.hash == now.hash && oldInfo.hash != "" && nowInfo.hash != "" {
+			if oldInfo.hash != nowInfo.hash {
+				b.indent(msg, file, "File content changed")
+				d |= deltaHash
+			}
+			return d
+		}
+		compare |= CompareSize | CompareModtime
+	}
+	if compare.Has(CompareSize) && oldInfo.size != nowInfo.size {
+		b.indent(msg, file, "File size changed")
+		d |= deltaSize
+	}
+	if compare.Has(CompareModtime) && old.getTime(file) != now.getTime(file) {
+		if old.beforeOther(now, file) {
+			b.indent(msg, file, "File is newer")
+			d |= deltaNewer
+		} else { // Current version is older than prior sync.
+			b.indent(msg, file, "File is OLDER")
+			d |= deltaOlder
+		}
+	}
+	return d
+}
+
+// findRenames matches deleted and new files by size and hash.
+//
+// Only unambiguous matches are taken as renames, i.e. when exactly one
+// deleted and one new file share the same size and hash.
+func (ds *deltaSet) findRenames(b *bisyncRun, old, now *fileList) {
+	if old.hash == hash.None || old.hash != now.hash {
+		return
+	}
+	type key struct {
+		size int64
+		hash string
+	}
+	deleted := map[key][]string{}
+	created := map[key][]string{}
+	for file, d := range ds.deltas {
+		switch {
+		case d.is(deltaDeleted):
+			if fi := old.get(file); fi.hash != "" {
+				k := key{fi.size, fi.hash}
+				deleted[k] = append(deleted[k], file)
+			}
+		case d.is(deltaNew):
+			if fi := now.get(file); fi.hash != "" {
+				k := key{fi.size, fi.hash}
+				created[k] = append(created[k], file)
+			}
+		}
+	}
+	for _, file := range ds.sort() {
+		if !ds.deltas[file].is(deltaNew) {
+			continue
+		}
+		fi := now.get(file)
+		k := key{fi.size, fi.hash}
+		newFiles, oldFiles := created[k], deleted[k]
+		if len(newFiles) != 1 || len(oldFiles) != 1 {
+			continue
+		}
+		b.indentf(ds.msg, file, "File was renamed from %s", escapePath(oldFiles[0], false))
+		ds.renames[file] = oldFiles[0]
+	}
+}
+
+// sortedRenames returns new names of detected renames in sorted order
+func (ds *deltaSet) sortedRenames() (sorted []string) {
+	for newFile := range ds.renames {
+		sorted = append(sorted, newFile)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// queueRenames plans server-side moves on all the other paths for the
+// renames detected on each path. A rename is only propagated if
+// neither of its names has changed on another path, otherwise the
+// files are left to the regular copy and delete handling.
+//
+// This must run before the --max-delete check as the queued renames
+// are no longer counted as deletes.
+func (b *bisyncRun) queueRenames(dss []*deltaSet) {
+	handled := bilib.Names{}
+	for i, ds := range dss {
+		for _, newFile := range ds.sortedRenames() {
+			oldFile := ds.renames[newFile]
+			if handled.Has(oldFile) || handled.Has(newFile) {
+				continue
+			}
+			clean := true
+			for j, other := range dss {
+				_, oldChanged := other.deltas[oldFile]
+				_, newChanged := other.deltas[newFile]
+				if j != i && (oldChanged || newChanged) {
+					clean = false
+				}
+			}
+			if !clean {
+				continue
+			}
+			for j := range dss {
+				if j != i {
+					b.indent(ds.msg, newFile, "Queue rename on "+dss[j].msg)
+				}
+			}
+			ds.queued[newFile] = oldFile
+			ds.deleted--
+			handled.Add(oldFile)
+			handled.Add(newFile)
+		}
+	}
+}
+
+// addRenames marks both names of the queued renames as handled
+func addRenames(handled bilib.Names, renames map[string]string) {
+	for newFile, oldFile := range renames {
+		handled.Add(newFile)
+		handled.Add(oldFile)
+	}
+}
+
+// fastRename does the queued server-side moves on f
+func (b *bisyncRun) fastRename(ctx context.Context, f fs.Fs, renames map[string]string, queueName string) error {
+	files := renameNames(renames)
+	if err := b.saveQueue(files, queueName); err != nil {
+		return err
+	}
+	ctxMove := b.opt.setDryRun(transferlog.WithReason(ctx, "bisync "+queueName))
+	for _, newFile := range files.ToList() {
+		oldFile := renames[newFile]
+		if err := operations.MoveFile(ctxMove, f, f, newFile, oldFile); err != nil {
+			return fmt.Errorf("rename of %s to %s failed: %w", oldFile, newFile, err)
+		}
+	}
+	return nil
+}
+
 // applyDeltas
 func (b *bisyncRun) applyDeltas(ctx context.Context, ds1, ds2 *deltaSet) (changes1, changes2 bool, err error) {
 	path1 := bilib.FsPath(b.fs1)
@@ -180,14 +361,22 @@ func (b *bisyncRun) applyDeltas(ctx context.Context, ds1, ds2 *deltaSet) (change
 	delete1 := bilib.Names{}
 	delete2 := bilib.Names{}
 	handled := bilib.Names{}
+	rename1 := ds2.queued // renames found on Path2 are done on Path1
+	rename2 := ds1.queued
+	addRenames(handled, rename1)
+	addRenames(handled, rename2)
 
-	ctxMove := b.opt.setDryRun(ctx)
+	ctxMove := b.opt.setDryRun(transferlog.WithReason(ctx, "bisync conflict: new or changed in both paths"))
 
 	for _, file := range ds1.sort() {
 		p1 := path1 + file
 		p2 := path2 + file
 		d1 := ds1.deltas[file]
 
+		if handled.Has(file) {
+			continue
+		}
+
 		if d1.is(deltaOther) {
 			d2, in2 := ds2.deltas[file]
 			if !in2 {
@@ -197,6 +386,9 @@ func (b *bisyncRun) applyDeltas(ctx context.Context, ds1, ds2 *deltaSet) (change
 				b.indent("Path1", p2, "Queue copy to Path2")
 				copy1to2.Add(file)
 				handled.Add(file)
+			} else if d2.is(deltaOther) && b.reconciled(ctx, ds1, ds2, file) {
+				b.indent("Path1", file, "Recovered from interrupted run")
+				handled.Add(file)
 			} else if d2.is(deltaOther) {
 				b.indent("!WARNING", file, "New or changed in both paths")
 				b.indent("!Path1", p1+"..path1", "Renaming Path1 copy")
@@ -250,7 +442,37 @@ func (b *bisyncRun) applyDeltas(ctx context.Context, ds1, ds2 *deltaSet) (change
 		}
 	}
 
+	// Record the queues so an interrupted run can be recovered
+	if !b.opt.DryRun {
+		b.journal.add("copy2to1", copy2to1)
+		b.journal.add("copy1to2", copy1to2)
+		b.journal.add("delete1", delete1)
+		b.journal.add("delete2", delete2)
+		b.journal.add("rename1", renameNames(rename1))
+		b.journal.add("rename2", renameNames(rename2))
+		if err = b.journal.save(); err != nil {
+			err = fmt.Errorf("cannot save recovery journal: %w", err)
+			return
+		}
+	}
+
 	// Do the batch operation
+	if len(rename1) > 0 {
+		changes1 = true
+		b.indent("Path2", "Path1", "Do queued renames on")
+		if err = b.fastRename(ctx, b.fs1, rename1, "rename1"); err != nil {
+			return
+		}
+	}
+
+	if len(rename2) > 0 {
+		changes2 = true
+		b.indent("Path1", "Path2", "Do queued renames on")
+		if err = b.fastRename(ctx, b.fs2, rename2, "rename2"); err != nil {
+			return
+		}
+	}
+
 	if copy2to1.NotEmpty() {
 		changes1 = true
 		b.indent("Path2", "Path1", "Do queued copies to")
@@ -290,6 +512,42 @@ func (b *bisyncRun) applyDeltas(ctx context.Context, ds1, ds2 *deltaSet) (change
 	return
 }
 
+// renameNames returns the new names of queued renames
+func renameNames(renames map[string]string) bilib.Names {
+	names := bilib.Names{}
+	for newFile := range renames {
+		names.Add(newFile)
+	}
+	return names
+}
+
+// reconciled returns true if the file was queued by an interrupted
+// run and both paths now hold the same version of it, so there is
+// nothing left to do but record it in the listings.
+func (b *bisyncRun) reconciled(ctx context.Context, ds1, ds2 *deltaSet, file string) bool {
+	window := fs.GetModifyWindow(ctx, b.fs1, b.fs2)
+	return sameVersion(ds1, ds2, file, window)
+}
+
+// sameVersion returns true if the current versions of a changed file
+// kept in both delta sets match by size and hash, or by size and
+// modification time within window if there is no common hash.
+func sameVersion(ds1, ds2 *deltaSet, file string, window time.Duration) bool {
+	fi1 := ds1.current[file]
+	fi2 := ds2.current[file]
+	if fi1 == nil || fi2 == nil || fi1.size != fi2.size {
+		return false
+	}
+	if ds1.hash != hash.None && ds1.hash == ds2.hash && fi1.hash != "" && fi2.hash != "" {
+		return fi1.hash == fi2.hash
+	}
+	dt := fi1.time.Sub(fi2.time)
+	if dt < 0 {
+		dt = -dt
+	}
+	return dt <= window
+}
+
 // excessDeletes checks whether number of deletes is within allowed range
 func (ds *deltaSet) excessDeletes() bool {
 	maxDelete := ds.opt.MaxDelete
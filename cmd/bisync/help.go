@@ -21,6 +21,7 @@ var rcHelp = makeHelp(`This takes the following parameters
 
 - path1 - a remote directory string e.g. |drive:path1|
 - path2 - a remote directory string e.g. |drive:path2|
+- path3, path4, ... - optional further remote directories to keep in sync
 - dryRun - dry-run mode
 - resync - performs the resync run
 - checkAccess - abort if {CHECKFILE} files are not found on both filesystems
@@ -30,10 +31,15 @@ var rcHelp = makeHelp(`This takes the following parameters
 - force - maxDelete safety check and run the sync
 - checkSync - |true| by default, |false| disables comparison of final listings,
               |only| will skip sync, only compare listings from the last run
+- compare - comma separated list of attributes used to detect changes:
+            |size|, |modtime|, |checksum| (default: |modtime|)
+- detectRenames - detect renamed files by size and hash and propagate
+                  them as server-side moves
 - removeEmptyDirs - remove empty directories at the final cleanup step
 - filtersFile - read filtering patterns from a file
 - workdir - server directory for history files (default: {WORKDIR})
 - noCleanup - retain working files
+- maxLock - consider lock files older than this duration stale (default: never)
 
 See [bisync command help](https://rclone.org/commands/rclone_bisync/)
 and [full bisync description](https://rclone.org/bisync/)
@@ -46,8 +52,12 @@ bidirectional cloud sync solution in rclone.
 It retains the Path1 and Path2 filesystem listings from the prior run.
 On each successive run it will:
 - list files on Path1 and Path2, and check for changes on each side.
-  Changes include |New|, |Newer|, |Older|, and |Deleted| files.
+  Changes include |New|, |Newer|, |Older|, |Changed|, |Renamed|
+  and |Deleted| files.
 - Propagate changes on Path1 to Path2, and vice-versa.
 
+More than two paths may be given, in which case changes found on
+any path are propagated to all the other paths in a single run.
+
 See [full bisync description](https://rclone.org/bisync/) for details.
 `)
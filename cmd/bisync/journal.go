@@ -0,0 +1,129 @@
+package bisync
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/cmd/bisync/bilib"
+	"github.com/rclone/rclone/fs"
+)
+
+// JournalHeader defines first line of a recovery journal
+const JournalHeader = "# bisync journal v1 from"
+
+// journal records the operations queued by a bisync run before they
+// are applied, so that a run interrupted in the middle can be
+// reconciled on the next run instead of demanding a --resync.
+//
+// Each line holds the queue name and the quoted file name:
+//
+//	copy1to2 "dir/file.txt"
+type journal struct {
+	path   string
+	queues map[string]bilib.Names
+}
+
+func newJournal(path string) *journal {
+	return &journal{
+		path:   path,
+		queues: map[string]bilib.Names{},
+	}
+}
+
+// add queues file names under the given queue name
+func (j *journal) add(queueName string, files bilib.Names) {
+	if !files.NotEmpty() {
+		return
+	}
+	queue := j.queues[queueName]
+	if queue == nil {
+		queue = bilib.Names{}
+		j.queues[queueName] = queue
+	}
+	for file := range files {
+		queue.Add(file)
+	}
+}
+
+// has returns true if the file is part of any queue
+func (j *journal) has(file string) bool {
+	for _, queue := range j.queues {
+		if queue.Has(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// empty returns true if the journal holds no operations
+func (j *journal) empty() bool {
+	return len(j.queues) == 0
+}
+
+// save writes the journal atomically
+func (j *journal) save() error {
+	buf := &bytes.Buffer{}
+	_, _ = fmt.Fprintf(buf, "%s %s\n", JournalHeader, time.Now().In(TZ).Format(timeFormat))
+	for _, queueName := range j.queueNames() {
+		for _, file := range j.queues[queueName].ToList() {
+			_, _ = fmt.Fprintf(buf, "%s %q\n", queueName, file)
+		}
+	}
+	return bilib.WriteFileAtomic(j.path, buf.Bytes(), bilib.PermSecure)
+}
+
+// queueNames returns sorted names of the queues
+func (j *journal) queueNames() (names []string) {
+	for queueName := range j.queues {
+		names = append(names, queueName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// remove deletes the journal file
+func (j *journal) remove() error {
+	err := os.Remove(j.path)
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	return err
+}
+
+// loadJournal reads the journal left behind by an interrupted run.
+// It returns an empty journal if there is none.
+func loadJournal(path string) (*journal, error) {
+	j := newJournal(path)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		pos := strings.IndexByte(line, ' ')
+		if pos <= 0 {
+			fs.Logf(path, "Ignoring incorrect line: %q", line)
+			continue
+		}
+		file, err := strconv.Unquote(line[pos+1:])
+		if err != nil {
+			fs.Logf(path, "Ignoring incorrect line: %q", line)
+			continue
+		}
+		j.add(line[:pos], bilib.Names{file: nil})
+	}
+	return j, scanner.Err()
+}
@@ -81,6 +81,7 @@ func (ls *fileList) put(file string, size int64, time time.Time, hash, id string
 	if fi != nil {
 		fi.size = size
 		fi.time = time
+		fi.hash = hash
 	} else {
 		fi = &fileInfo{
 			size: size,
@@ -119,8 +120,11 @@ func (ls *fileList) afterTime(file string, time time.Time) bool {
 }
 
 // save will save listing to a file.
+// The listing is written to a temporary file first and renamed into
+// place, so an interrupted run never leaves a truncated listing behind.
 func (ls *fileList) save(ctx context.Context, listing string) error {
-	file, err := os.Create(listing)
+	tmpListing := listing + ".tmp"
+	file, err := os.Create(tmpListing)
 	if err != nil {
 		return err
 	}
@@ -133,7 +137,7 @@ func (ls *fileList) save(ctx context.Context, listing string) error {
 	_, err = fmt.Fprintf(file, "%s %s\n", ListingHeader, time.Now().In(TZ).Format(timeFormat))
 	if err != nil {
 		_ = file.Close()
-		_ = os.Remove(listing)
+		_ = os.Remove(tmpListing)
 		return err
 	}
 
@@ -156,12 +160,16 @@ func (ls *fileList) save(ctx context.Context, listing string) error {
 		_, err = fmt.Fprintf(file, lineFormat, flags, fi.size, hash, id, time, remote)
 		if err != nil {
 			_ = file.Close()
-			_ = os.Remove(listing)
+			_ = os.Remove(tmpListing)
 			return err
 		}
 	}
 
-	return file.Close()
+	if err = file.Close(); err != nil {
+		_ = os.Remove(tmpListing)
+		return err
+	}
+	return os.Rename(tmpListing, listing)
 }
 
 // loadListing will load listing from a file.
@@ -254,9 +262,7 @@ func (b *bisyncRun) makeListing(ctx context.Context, f fs.Fs, listing string) (l
 	depth := ci.MaxDepth
 	hashType := hash.None
 	if !ci.IgnoreChecksum {
-		// Currently bisync just honors --ignore-checksum
-		// TODO add full support for checksums and related flags
-		hashType = f.Hashes().GetOne()
+		hashType = b.listingHash(f)
 	}
 	ls = newFileList()
 	ls.hash = hashType
@@ -293,6 +299,22 @@ func (b *bisyncRun) makeListing(ctx context.Context, f fs.Fs, listing string) (l
 	return
 }
 
+// listingHash returns the hash type to record in listings of f.
+// When comparing by checksum a hash supported by all paths is
+// preferred, so that listings of any side can be matched.
+func (b *bisyncRun) listingHash(f fs.Fs) hash.Type {
+	if b.opt.Compare.Has(CompareChecksum) {
+		common := f.Hashes()
+		for _, other := range b.fss {
+			common = common.Overlap(other.Hashes())
+		}
+		if hashType := common.GetOne(); hashType != hash.None {
+			return hashType
+		}
+	}
+	return f.Hashes().GetOne()
+}
+
 // checkListing verifies that listing is not empty (unless resynching)
 func (b *bisyncRun) checkListing(ls *fileList, listing, msg string) error {
 	if b.opt.Resync || !ls.empty() {
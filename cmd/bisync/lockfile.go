@@ -0,0 +1,179 @@
+package bisync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/cmd/bisync/bilib"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/random"
+)
+
+// lockFile guards a bisync session against concurrent runs.
+//
+// The file holds the PID of the owner followed by a random token
+// identifying the run on the first line and, if --max-lock is set,
+// the time the lock expires on the second line.
+// A running bisync keeps pushing the expiry forward so only locks
+// left behind by crashed or killed runs will ever expire.
+type lockFile struct {
+	path    string
+	maxLock time.Duration
+	token   string
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	err     error
+}
+
+// lockTimeFormat defines time format used in lock files
+const lockTimeFormat = time.RFC3339
+
+// newLockFile creates the lock file at path or returns an error
+// if another unexpired lock is in place.
+func newLockFile(path string, maxLock time.Duration) (*lockFile, error) {
+	l := &lockFile{
+		path:    path,
+		maxLock: maxLock,
+		token:   random.String(16),
+	}
+	if err := l.create(); err != nil {
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("cannot create lock file: %s: %w", path, err)
+		}
+		pid, expires, readErr := readLockFile(path)
+		if readErr != nil || expires.IsZero() || time.Now().Before(expires) {
+			return nil, fmt.Errorf("prior lock file found: %s (pid %s)", path, pid)
+		}
+		fs.Logf(nil, "Replacing stale lock file %s of pid %s expired at %s", path, pid, expires.Format(lockTimeFormat))
+		if err = l.takeover(); err != nil {
+			return nil, err
+		}
+	}
+	fs.Debugf(nil, "Lock file created: %s", path)
+	if maxLock > 0 {
+		l.stop = make(chan struct{})
+		l.done = make(chan struct{})
+		go l.renew()
+	}
+	return l, nil
+}
+
+// create writes a new lock file failing if one exists already
+func (l *lockFile) create() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, bilib.PermSecure)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(l.contents())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(l.path)
+	}
+	return err
+}
+
+// takeover replaces a stale lock file with ours in a single rename
+// so no other run can create its lock between the removal of the old
+// one and the creation of ours. Another run may be replacing the same
+// stale lock at the same time so the lock is read back afterwards and
+// only the run whose token is found there goes ahead.
+func (l *lockFile) takeover() error {
+	tmpPath := l.path + "." + l.token + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(l.contents()), bilib.PermSecure); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("cannot create lock file: %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("cannot replace stale lock file: %s: %w", l.path, err)
+	}
+	owned, err := l.owned()
+	if err != nil {
+		return fmt.Errorf("cannot read lock file: %s: %w", l.path, err)
+	}
+	if !owned {
+		pid, _, _ := readLockFile(l.path)
+		return fmt.Errorf("prior lock file found: %s (pid %s)", l.path, pid)
+	}
+	return nil
+}
+
+// owned reports whether the lock file carries our token
+func (l *lockFile) owned() (bool, error) {
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return false, err
+	}
+	fields := strings.Fields(strings.SplitN(string(data), "\n", 2)[0])
+	return len(fields) > 1 && fields[1] == l.token, nil
+}
+
+// contents returns the text to store in the lock file
+func (l *lockFile) contents() string {
+	s := strconv.Itoa(os.Getpid()) + " " + l.token
+	if l.maxLock > 0 {
+		s += "\n" + time.Now().Add(l.maxLock).Format(lockTimeFormat)
+	}
+	return s
+}
+
+// renew keeps extending the lock expiry until the lock is released
+func (l *lockFile) renew() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.maxLock / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := bilib.WriteFileAtomic(l.path, []byte(l.contents()), bilib.PermSecure); err != nil {
+				fs.Errorf(nil, "cannot renew lock file %s: %v", l.path, err)
+			}
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// release stops renewing the lock and removes the lock file.
+// It is safe to call more than once.
+func (l *lockFile) release() error {
+	l.once.Do(func() {
+		if l.stop != nil {
+			close(l.stop)
+			<-l.done
+		}
+		l.err = os.Remove(l.path)
+		if l.err == nil {
+			fs.Debugf(nil, "Lock file removed: %s", l.path)
+		}
+	})
+	return l.err
+}
+
+// readLockFile returns PID and expiry time recorded in the lock file.
+// Expiry is zero for locks which never expire.
+func readLockFile(path string) (pid string, expires time.Time, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if fields := strings.Fields(lines[0]); len(fields) > 0 {
+		pid = fields[0]
+	}
+	if len(lines) > 1 {
+		expires, err = time.Parse(lockTimeFormat, strings.TrimSpace(lines[1]))
+		if err != nil {
+			return pid, time.Time{}, errors.New("invalid lock expiry time")
+		}
+	}
+	return pid, expires, nil
+}
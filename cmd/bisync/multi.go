@@ -0,0 +1,511 @@
+package bisync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rclone/rclone/cmd/bisync/bilib"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/sync"
+	"github.com/rclone/rclone/fs/transferlog"
+)
+
+// pathName returns the name of the i-th path for logging
+func pathName(i int) string {
+	return fmt.Sprintf("Path%d", i+1)
+}
+
+// runMulti performs a full bisync run across three or more paths.
+//
+// Each path keeps its own prior listing and deltas are found for every
+// path separately. The deltas are then merged into a desired state and
+// every change is propagated from the path it originates on to all the
+// other paths.
+func (b *bisyncRun) runMulti(octx context.Context, listings []string) (err error) {
+	opt := b.opt
+
+	paths := make([]string, len(b.fss))
+	for i, f := range b.fss {
+		paths[i] = quotePath(bilib.FsPath(f))
+	}
+
+	if opt.CheckSync == CheckSyncOnly {
+		fs.Infof(nil, "Validating listings for %s", strings.Join(paths, ", "))
+		if err = b.checkSyncMulti(listings); err != nil {
+			b.critical = true
+		}
+		return err
+	}
+
+	fs.Infof(nil, "Synching %s", strings.Join(paths, ", "))
+
+	if opt.DryRun {
+		// In --dry-run mode, preserve original listings and save updates to the .lst-dry files
+		listings = append([]string(nil), listings...)
+		for i, listing := range listings {
+			listings[i] = listing + "-dry"
+			if err := bilib.CopyFileIfExists(listing, listings[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Create second context with filters
+	var fctx context.Context
+	if fctx, err = b.opt.applyFilters(octx); err != nil {
+		b.critical = true
+		return
+	}
+
+	if opt.Resync {
+		return b.resyncMulti(octx, fctx, listings)
+	}
+
+	// Check for existence of prior listings
+	for _, listing := range listings {
+		if !bilib.FileExists(listing) {
+			// On prior critical error abort, the prior listings are renamed to .lst-err to lock out further runs
+			b.critical = true
+			return errors.New("cannot find prior listings, likely due to critical error on prior run")
+		}
+	}
+
+	// Check for deltas relative to the prior sync on every path
+	dss := make([]*deltaSet, len(b.fss))
+	for i, f := range b.fss {
+		fs.Infof(nil, "%s checking for diffs", pathName(i))
+		if dss[i], err = b.findDeltas(fctx, f, listings[i], listings[i]+"-new", pathName(i)); err != nil {
+			return err
+		}
+		dss[i].printStats()
+	}
+
+	// Check access health on all filesystems
+	if opt.CheckAccess {
+		fs.Infof(nil, "Checking access health")
+		if err = b.checkAccessMulti(dss); err != nil {
+			b.critical = true
+			return
+		}
+	}
+
+	// Queue renames so they don't count as deletes below
+	b.queueRenames(dss)
+
+	// Check for too many deleted files and for all files changed
+	if !opt.Force {
+		excess := false
+		for _, ds := range dss {
+			excess = ds.excessDeletes() || excess
+		}
+		if excess {
+			b.abort = true
+			return errors.New("too many deletes")
+		}
+		msg := "Safety abort: all files were changed on %s %s. Run with --force if desired."
+		allChanged := false
+		for i, ds := range dss {
+			if !ds.foundSame {
+				fs.Errorf(nil, msg, ds.msg, paths[i])
+				allChanged = true
+			}
+		}
+		if allChanged {
+			b.abort = true
+			return errors.New("all files were changed")
+		}
+	}
+
+	// Determine and apply changes to all paths
+	noChanges := true
+	for _, ds := range dss {
+		noChanges = noChanges && ds.empty()
+	}
+	changes := make([]bool, len(b.fss))
+	if noChanges {
+		fs.Infof(nil, "No changes found")
+	} else {
+		fs.Infof(nil, "Applying changes")
+		if changes, err = b.applyDeltasMulti(octx, dss); err != nil {
+			b.critical = true
+			return err
+		}
+	}
+
+	// Clean up and check listings integrity
+	fs.Infof(nil, "Updating listings")
+	for i, f := range b.fss {
+		if changes[i] {
+			_, err = b.makeListing(fctx, f, listings[i])
+		} else {
+			err = bilib.CopyFileIfExistsAtomic(listings[i]+"-new", listings[i])
+		}
+		if err != nil {
+			b.critical = true
+			return err
+		}
+	}
+
+	// Listings are consistent again so the journal is not needed
+	if !opt.DryRun {
+		if err = b.journal.remove(); err != nil {
+			return fmt.Errorf("cannot remove recovery journal: %w", err)
+		}
+	}
+
+	if !opt.NoCleanup {
+		for _, listing := range listings {
+			_ = os.Remove(listing + "-new")
+		}
+	}
+
+	if opt.CheckSync == CheckSyncTrue && !opt.DryRun {
+		fs.Infof(nil, "Validating listings for %s", strings.Join(paths, ", "))
+		if err := b.checkSyncMulti(listings); err != nil {
+			b.critical = true
+			return err
+		}
+	}
+
+	// Optional rmdirs for empty directories
+	if opt.RemoveEmptyDirs {
+		fs.Infof(nil, "Removing empty directories")
+		for _, f := range b.fss {
+			if err := operations.Rmdirs(fctx, f, "", true); err != nil {
+				b.critical = true
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyDeltasMulti merges the deltas of all paths and propagates every
+// change from the path it was made on to all the other paths.
+//
+// A file changed on a single path is copied from there to all the
+// others. If it is changed on several paths to the same version, that
+// version is copied to the remaining paths; otherwise each changed
+// version is renamed with a "..pathN" suffix and copied everywhere.
+// A file deleted on some paths and unchanged on the others is deleted.
+func (b *bisyncRun) applyDeltasMulti(ctx context.Context, dss []*deltaSet) (changes []bool, err error) {
+	n := len(b.fss)
+	changes = make([]bool, n)
+	copies := map[[2]int]bilib.Names{} // keyed by source and destination index
+	deletes := make([]bilib.Names, n)
+	renames := make([]map[string]string, n)
+	for i := range b.fss {
+		deletes[i] = bilib.Names{}
+		renames[i] = map[string]string{}
+	}
+	queueCopy := func(src, dst int, file string) {
+		b.indent(pathName(src), bilib.FsPath(b.fss[dst])+file, "Queue copy to "+pathName(dst))
+		key := [2]int{src, dst}
+		if copies[key] == nil {
+			copies[key] = bilib.Names{}
+		}
+		copies[key].Add(file)
+	}
+	handled := bilib.Names{}
+	window := fs.GetModifyWindow(ctx, fsInfos(b.fss)...)
+	ctxMove := b.opt.setDryRun(transferlog.WithReason(ctx, "bisync conflict: new or changed on several paths"))
+
+	// Renames queued by queueRenames are done on all the other paths
+	for i, ds := range dss {
+		for newFile, oldFile := range ds.queued {
+			for j := range dss {
+				if j != i {
+					renames[j][newFile] = oldFile
+				}
+			}
+		}
+		addRenames(handled, ds.queued)
+	}
+
+	// Merge the remaining deltas of all paths
+	all := bilib.Names{}
+	for _, ds := range dss {
+		for file := range ds.deltas {
+			all.Add(file)
+		}
+	}
+	for _, file := range all.ToList() {
+		if handled.Has(file) {
+			continue
+		}
+		var changed []int
+		for i, ds := range dss {
+			if d, found := ds.deltas[file]; found && d.is(deltaOther) {
+				changed = append(changed, i)
+			}
+		}
+		isChanged := func(j int) bool {
+			for _, i := range changed {
+				if i == j {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(changed) == 0 {
+			// Deleted on some paths and unchanged on the others
+			for j, ds := range dss {
+				if _, found := ds.deltas[file]; !found {
+					b.indent(pathName(j), bilib.FsPath(b.fss[j])+file, "Queue delete")
+					deletes[j].Add(file)
+				}
+			}
+			continue
+		}
+
+		same := true
+		for _, i := range changed[1:] {
+			same = same && sameVersion(dss[changed[0]], dss[i], file, window)
+		}
+		if same {
+			origin := changed[0]
+			for j := range dss {
+				if !isChanged(j) {
+					queueCopy(origin, j, file)
+				}
+			}
+			continue
+		}
+
+		b.indent("!WARNING", file, "New or changed on several paths")
+		for _, i := range changed {
+			suffix := fmt.Sprintf("..path%d", i+1)
+			b.indent("!"+pathName(i), bilib.FsPath(b.fss[i])+file+suffix, "Renaming "+pathName(i)+" copy")
+			if err = operations.MoveFile(ctxMove, b.fss[i], b.fss[i], file+suffix, file); err != nil {
+				err = fmt.Errorf("%s rename failed for %s: %w", strings.ToLower(pathName(i)), file, err)
+				return
+			}
+			changes[i] = true
+			for j := range dss {
+				if j != i {
+					queueCopy(i, j, file+suffix)
+				}
+			}
+		}
+		// The old version is superseded by the renamed copies
+		for j, ds := range dss {
+			if _, found := ds.deltas[file]; !found {
+				b.indent(pathName(j), bilib.FsPath(b.fss[j])+file, "Queue delete")
+				deletes[j].Add(file)
+			}
+		}
+	}
+
+	copyKeys := make([][2]int, 0, len(copies))
+	for key := range copies {
+		copyKeys = append(copyKeys, key)
+	}
+	sort.Slice(copyKeys, func(i, j int) bool {
+		if copyKeys[i][0] != copyKeys[j][0] {
+			return copyKeys[i][0] < copyKeys[j][0]
+		}
+		return copyKeys[i][1] < copyKeys[j][1]
+	})
+
+	// Record the queues so an interrupted run can be recovered
+	if !b.opt.DryRun {
+		for _, key := range copyKeys {
+			b.journal.add(fmt.Sprintf("copy%dto%d", key[0]+1, key[1]+1), copies[key])
+		}
+		for j := range b.fss {
+			b.journal.add(fmt.Sprintf("delete%d", j+1), deletes[j])
+			b.journal.add(fmt.Sprintf("rename%d", j+1), renameNames(renames[j]))
+		}
+		if err = b.journal.save(); err != nil {
+			err = fmt.Errorf("cannot save recovery journal: %w", err)
+			return
+		}
+	}
+
+	// Do the batch operations
+	for j, f := range b.fss {
+		if len(renames[j]) > 0 {
+			changes[j] = true
+			b.indent("", pathName(j), "Do queued renames on")
+			if err = b.fastRename(ctx, f, renames[j], fmt.Sprintf("rename%d", j+1)); err != nil {
+				return
+			}
+		}
+	}
+
+	for _, key := range copyKeys {
+		src, dst := key[0], key[1]
+		changes[dst] = true
+		b.indent(pathName(src), pathName(dst), "Do queued copies to")
+		if err = b.fastCopy(ctx, b.fss[src], b.fss[dst], copies[key], fmt.Sprintf("copy%dto%d", src+1, dst+1)); err != nil {
+			return
+		}
+	}
+
+	for j, f := range b.fss {
+		if deletes[j].NotEmpty() {
+			changes[j] = true
+			b.indent("", pathName(j), "Do queued deletes on")
+			if err = b.fastDelete(ctx, f, deletes[j], fmt.Sprintf("delete%d", j+1)); err != nil {
+				return
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// fsInfos converts a slice of Fs to a slice of Info
+func fsInfos(fss []fs.Fs) []fs.Info {
+	infos := make([]fs.Info, len(fss))
+	for i, f := range fss {
+		infos[i] = f
+	}
+	return infos
+}
+
+// resyncMulti implements the --resync mode for three or more paths.
+// Files missing on Path1 are copied there from the first path having
+// them, then Path1 is synced to all the other paths.
+func (b *bisyncRun) resyncMulti(octx, fctx context.Context, listings []string) error {
+	lists := make([]*fileList, len(b.fss))
+	for i, f := range b.fss {
+		newListing := listings[i] + "-new"
+		ls, err := b.makeListing(fctx, f, newListing)
+		if err == nil {
+			err = b.checkListing(ls, newListing, "current "+pathName(i))
+		}
+		if err != nil {
+			return err
+		}
+		lists[i] = ls
+	}
+
+	fs.Infof(nil, "Copying unique files to Path1")
+	seen := bilib.ToNames(lists[0].list)
+	for i := 1; i < len(b.fss); i++ {
+		copyTo1 := bilib.Names{}
+		for _, file := range lists[i].list {
+			if !seen.Has(file) {
+				b.indent(pathName(i), file, "Resync will copy to Path1")
+				copyTo1.Add(file)
+				seen.Add(file)
+			}
+		}
+		if copyTo1.NotEmpty() {
+			b.indent(pathName(i), "Path1", "Resync is doing queued copies to")
+			// octx does not have extra filters!
+			if err := b.fastCopy(octx, b.fss[i], b.fss[0], copyTo1, fmt.Sprintf("resync-copy%dto1", i+1)); err != nil {
+				b.critical = true
+				return err
+			}
+		}
+	}
+
+	ctxRun := b.opt.setDryRun(fctx)
+	// fctx has our extra filters added!
+	ctxSync, filterSync := filter.AddConfig(ctxRun)
+	if filterSync.Opt.MinSize == -1 {
+		// prevent overwriting Google Doc files (their size is -1)
+		filterSync.Opt.MinSize = 0
+	}
+	for i := 1; i < len(b.fss); i++ {
+		fs.Infof(nil, "Resynching Path1 to %s", pathName(i))
+		if err := sync.Sync(ctxSync, b.fss[i], b.fss[0], false); err != nil {
+			b.critical = true
+			return err
+		}
+	}
+
+	fs.Infof(nil, "Resync updating listings")
+	for i, f := range b.fss {
+		if _, err := b.makeListing(fctx, f, listings[i]); err != nil {
+			b.critical = true
+			return err
+		}
+	}
+
+	if !b.opt.DryRun {
+		if err := b.journal.remove(); err != nil {
+			return fmt.Errorf("cannot remove recovery journal: %w", err)
+		}
+	}
+
+	if !b.opt.NoCleanup {
+		for _, listing := range listings {
+			_ = os.Remove(listing + "-new")
+		}
+	}
+	return nil
+}
+
+// checkSyncMulti validates that all listings hold the same files
+func (b *bisyncRun) checkSyncMulti(listings []string) error {
+	lists := make([]*fileList, len(listings))
+	for i, listing := range listings {
+		ls, err := b.loadListing(listing)
+		if err != nil {
+			return fmt.Errorf("cannot read prior listing of %s: %w", pathName(i), err)
+		}
+		lists[i] = ls
+	}
+
+	ok := true
+	for i, ls := range lists {
+		for _, file := range ls.list {
+			for j, other := range lists {
+				if j != i && !other.has(file) {
+					b.indentf("ERROR", file, "%s file not found in %s", pathName(i), pathName(j))
+					ok = false
+				}
+			}
+		}
+	}
+	if !ok {
+		return errors.New("paths are out of sync, run --resync to recover")
+	}
+	return nil
+}
+
+// checkAccessMulti validates access health comparing every path with Path1
+func (b *bisyncRun) checkAccessMulti(dss []*deltaSet) error {
+	ok := true
+	prefix := "Access test failed:"
+	checkFiles1 := dss[0].checkFiles
+	numChecks1 := len(checkFiles1)
+
+	for i, ds := range dss[1:] {
+		name := pathName(i + 1)
+		numChecks := len(ds.checkFiles)
+		if numChecks1 == 0 || numChecks1 != numChecks {
+			fs.Errorf(nil, "%s Path1 count %d, %s count %d - %s", prefix, numChecks1, name, numChecks, b.opt.CheckFilename)
+			ok = false
+		}
+		for file := range checkFiles1 {
+			if !ds.checkFiles.Has(file) {
+				b.indentf("ERROR", file, "%s Path1 file not found in %s", prefix, name)
+				ok = false
+			}
+		}
+		for file := range ds.checkFiles {
+			if !checkFiles1.Has(file) {
+				b.indentf("ERROR", file, "%s %s file not found in Path1", prefix, name)
+				ok = false
+			}
+		}
+	}
+
+	if !ok {
+		return errors.New("check file check failed")
+	}
+	fs.Infof(nil, "Found %d matching %q files on all paths", numChecks1, b.opt.CheckFilename)
+	return nil
+}
@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	gosync "sync"
 
 	"github.com/rclone/rclone/cmd/bisync/bilib"
@@ -27,19 +26,31 @@ var ErrBisyncAborted = errors.New("bisync aborted")
 type bisyncRun struct {
 	fs1      fs.Fs
 	fs2      fs.Fs
+	fss      []fs.Fs // all paths, fs1 and fs2 being the first two
 	abort    bool
 	critical bool
 	basePath string
 	workDir  string
 	opt      *Options
+	journal  *journal
 }
 
 // Bisync handles lock file, performs bisync run and checks exit status
 func Bisync(ctx context.Context, fs1, fs2 fs.Fs, optArg *Options) (err error) {
+	return BisyncMulti(ctx, []fs.Fs{fs1, fs2}, optArg)
+}
+
+// BisyncMulti is like Bisync but takes two or more paths.
+// Changes found on any path are propagated to all the other paths.
+func BisyncMulti(ctx context.Context, fss []fs.Fs, optArg *Options) (err error) {
+	if len(fss) < 2 || len(fss) > MaxPaths {
+		return fmt.Errorf("bisync needs from 2 to %d paths", MaxPaths)
+	}
 	opt := *optArg // ensure that input is never changed
 	b := &bisyncRun{
-		fs1: fs1,
-		fs2: fs2,
+		fs1: fss[0],
+		fs2: fss[1],
+		fss: fss,
 		opt: &opt,
 	}
 
@@ -51,11 +62,18 @@ func Bisync(ctx context.Context, fs1, fs2 fs.Fs, optArg *Options) (err error) {
 	}
 
 	if !opt.DryRun && !opt.Force {
-		if fs1.Precision() == fs.ModTimeNotSupported {
-			return errors.New("modification time support is missing on path1")
+		for i, f := range fss {
+			if f.Precision() == fs.ModTimeNotSupported {
+				return fmt.Errorf("modification time support is missing on path%d", i+1)
+			}
 		}
-		if fs2.Precision() == fs.ModTimeNotSupported {
-			return errors.New("modification time support is missing on path2")
+	}
+
+	if opt.Compare.Has(CompareChecksum) {
+		for _, f := range fss {
+			if f.Hashes().Count() == 0 {
+				fs.Logf(f, "No hashes available, comparing by size and modtime. Consider wrapping the remote in a hasher remote.")
+			}
 		}
 	}
 
@@ -67,23 +85,37 @@ func Bisync(ctx context.Context, fs1, fs2 fs.Fs, optArg *Options) (err error) {
 	}
 
 	// Produce a unique name for the sync operation
-	b.basePath = filepath.Join(b.workDir, bilib.SessionName(b.fs1, b.fs2))
-	listing1 := b.basePath + ".path1.lst"
-	listing2 := b.basePath + ".path2.lst"
+	b.basePath = filepath.Join(b.workDir, bilib.SessionName(fss...))
+	listings := make([]string, len(fss))
+	for i := range fss {
+		listings[i] = fmt.Sprintf("%s.path%d.lst", b.basePath, i+1)
+	}
 
 	// Handle lock file
-	lockFile := ""
+	var lock *lockFile
 	if !opt.DryRun {
-		lockFile = b.basePath + ".lck"
-		if bilib.FileExists(lockFile) {
-			return fmt.Errorf("prior lock file found: %s", lockFile)
+		if lock, err = newLockFile(b.basePath+".lck", opt.MaxLock); err != nil {
+			return err
 		}
+	}
 
-		pidStr := []byte(strconv.Itoa(os.Getpid()))
-		if err = os.WriteFile(lockFile, pidStr, bilib.PermSecure); err != nil {
-			return fmt.Errorf("cannot create lock file: %s: %w", lockFile, err)
+	// Pick up listings made before long session names were shortened
+	if lock != nil && len(fss) == 2 {
+		if err = b.renameLegacySession(bilib.LegacySessionName(fss[0], fss[1])); err != nil {
+			_ = lock.release()
+			return fmt.Errorf("cannot rename listings to the new session name: %w", err)
 		}
-		fs.Debugf(nil, "Lock file created: %s", lockFile)
+	}
+
+	// Load recovery journal left behind by an interrupted run
+	if b.journal, err = loadJournal(b.basePath + ".jnl"); err != nil {
+		if lock != nil {
+			_ = lock.release()
+		}
+		return fmt.Errorf("cannot read recovery journal: %w", err)
+	}
+	if !b.journal.empty() && !opt.Resync {
+		fs.Logf(nil, "Recovering from interrupted run using journal %s", b.journal.path)
 	}
 
 	// Handle SIGINT
@@ -98,10 +130,20 @@ func Bisync(ctx context.Context, fs1, fs2 fs.Fs, optArg *Options) (err error) {
 	finalise := func() {
 		finaliseOnce.Do(func() {
 			if atexit.Signalled() {
-				fs.Logf(nil, "Bisync interrupted. Must run --resync to recover.")
-				markFailed(listing1)
-				markFailed(listing2)
-				_ = os.Remove(lockFile)
+				if opt.Resync {
+					fs.Logf(nil, "Bisync interrupted. Must run --resync to recover.")
+					for _, listing := range listings {
+						markFailed(listing)
+					}
+				} else {
+					// Listings are written atomically and the journal
+					// records the queued operations, so the next run
+					// can pick up from the last consistent state.
+					fs.Logf(nil, "Bisync interrupted. Next run will recover using journal %s", b.journal.path)
+				}
+				if lock != nil {
+					_ = lock.release()
+				}
 			}
 		})
 	}
@@ -109,25 +151,30 @@ func Bisync(ctx context.Context, fs1, fs2 fs.Fs, optArg *Options) (err error) {
 	defer atexit.Unregister(fnHandle)
 
 	// run bisync
-	err = b.runLocked(ctx, listing1, listing2)
-
-	if lockFile != "" {
-		errUnlock := os.Remove(lockFile)
-		if errUnlock == nil {
-			fs.Debugf(nil, "Lock file removed: %s", lockFile)
-		} else if err == nil {
-			err = errUnlock
-		} else {
-			fs.Errorf(nil, "cannot remove lockfile %s: %v", lockFile, errUnlock)
+	if len(fss) == 2 {
+		err = b.runLocked(ctx, listings[0], listings[1])
+	} else {
+		err = b.runMulti(ctx, listings)
+	}
+
+	if lock != nil {
+		if errUnlock := lock.release(); errUnlock != nil {
+			if err == nil {
+				err = errUnlock
+			} else {
+				fs.Errorf(nil, "cannot remove lockfile %s: %v", lock.path, errUnlock)
+			}
 		}
 	}
 
 	if b.critical {
-		if bilib.FileExists(listing1) {
-			_ = os.Rename(listing1, listing1+"-err")
+		if !opt.DryRun {
+			_ = b.journal.remove()
 		}
-		if bilib.FileExists(listing2) {
-			_ = os.Rename(listing2, listing2+"-err")
+		for _, listing := range listings {
+			if bilib.FileExists(listing) {
+				_ = os.Rename(listing, listing+"-err")
+			}
 		}
 		fs.Errorf(nil, "Bisync critical error: %v", err)
 		fs.Errorf(nil, "Bisync aborted. Must run --resync to recover.")
@@ -142,6 +189,35 @@ func Bisync(ctx context.Context, fs1, fs2 fs.Fs, optArg *Options) (err error) {
 	return err
 }
 
+// legacySessionSuffixes are the working files renamed by
+// renameLegacySession
+var legacySessionSuffixes = []string{".path1.lst", ".path2.lst", ".path1.lst-err", ".path2.lst-err", ".jnl"}
+
+// renameLegacySession renames the listings and journal of a session
+// made before long session names were shortened so that the session
+// carries on under the new name without a resync.
+func (b *bisyncRun) renameLegacySession(legacyName string) error {
+	legacyBase := filepath.Join(b.workDir, legacyName)
+	if legacyBase == b.basePath {
+		return nil
+	}
+	for _, suffix := range legacySessionSuffixes {
+		oldPath, newPath := legacyBase+suffix, b.basePath+suffix
+		if !bilib.FileExists(oldPath) {
+			continue
+		}
+		if bilib.FileExists(newPath) {
+			fs.Logf(nil, "Not renaming %s as %s exists", oldPath, newPath)
+			continue
+		}
+		if err := os.Rename(oldPath, newPath); err != nil {
+			return err
+		}
+		fs.Infof(nil, "Renamed %s to %s as long session names are now shortened", oldPath, newPath)
+	}
+	return nil
+}
+
 // runLocked performs a full bisync run
 func (b *bisyncRun) runLocked(octx context.Context, listing1, listing2 string) (err error) {
 	opt := b.opt
@@ -219,6 +295,9 @@ func (b *bisyncRun) runLocked(octx context.Context, listing1, listing2 string) (
 		}
 	}
 
+	// Queue renames so they don't count as deletes below
+	b.queueRenames([]*deltaSet{ds1, ds2})
+
 	// Check for too many deleted files - possible error condition.
 	// Don't want to start deleting on the other side!
 	if !opt.Force {
@@ -263,18 +342,18 @@ func (b *bisyncRun) runLocked(octx context.Context, listing1, listing2 string) (
 	fs.Infof(nil, "Updating listings")
 	var err1, err2 error
 	if noChanges {
-		err1 = bilib.CopyFileIfExists(newListing1, listing1)
-		err2 = bilib.CopyFileIfExists(newListing2, listing2)
+		err1 = bilib.CopyFileIfExistsAtomic(newListing1, listing1)
+		err2 = bilib.CopyFileIfExistsAtomic(newListing2, listing2)
 	} else {
 		if changes1 {
 			_, err1 = b.makeListing(fctx, b.fs1, listing1)
 		} else {
-			err1 = bilib.CopyFileIfExists(newListing1, listing1)
+			err1 = bilib.CopyFileIfExistsAtomic(newListing1, listing1)
 		}
 		if changes2 {
 			_, err2 = b.makeListing(fctx, b.fs2, listing2)
 		} else {
-			err2 = bilib.CopyFileIfExists(newListing2, listing2)
+			err2 = bilib.CopyFileIfExistsAtomic(newListing2, listing2)
 		}
 	}
 	err = err1
@@ -286,6 +365,13 @@ func (b *bisyncRun) runLocked(octx context.Context, listing1, listing2 string) (
 		return err
 	}
 
+	// Listings are consistent again so the journal is not needed
+	if !opt.DryRun {
+		if err = b.journal.remove(); err != nil {
+			return fmt.Errorf("cannot remove recovery journal: %w", err)
+		}
+	}
+
 	if !opt.NoCleanup {
 		_ = os.Remove(newListing1)
 		_ = os.Remove(newListing2)
@@ -383,6 +469,12 @@ func (b *bisyncRun) resync(octx, fctx context.Context, listing1, listing2 string
 		return err
 	}
 
+	if !b.opt.DryRun {
+		if err = b.journal.remove(); err != nil {
+			return fmt.Errorf("cannot remove recovery journal: %w", err)
+		}
+	}
+
 	if !b.opt.NoCleanup {
 		_ = os.Remove(newListing1)
 		_ = os.Remove(newListing2)
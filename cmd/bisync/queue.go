@@ -9,6 +9,7 @@ import (
 	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/sync"
+	"github.com/rclone/rclone/fs/transferlog"
 )
 
 func (b *bisyncRun) fastCopy(ctx context.Context, fsrc, fdst fs.Fs, files bilib.Names, queueName string) error {
@@ -16,7 +17,7 @@ func (b *bisyncRun) fastCopy(ctx context.Context, fsrc, fdst fs.Fs, files bilib.
 		return err
 	}
 
-	ctxCopy, filterCopy := filter.AddConfig(b.opt.setDryRun(ctx))
+	ctxCopy, filterCopy := filter.AddConfig(b.opt.setDryRun(transferlog.WithReason(ctx, "bisync "+queueName)))
 	for _, file := range files.ToList() {
 		if err := filterCopy.AddFile(file); err != nil {
 			return err
@@ -32,7 +33,7 @@ func (b *bisyncRun) fastDelete(ctx context.Context, f fs.Fs, files bilib.Names,
 	}
 
 	transfers := fs.GetConfig(ctx).Transfers
-	ctxRun := b.opt.setDryRun(ctx)
+	ctxRun := b.opt.setDryRun(transferlog.WithReason(ctx, "bisync "+queueName))
 
 	objChan := make(fs.ObjectsChan, transfers)
 	errChan := make(chan error, 1)
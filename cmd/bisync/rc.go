@@ -3,6 +3,7 @@ package bisync
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 
 	"github.com/rclone/rclone/cmd/bisync/bilib"
@@ -17,6 +18,28 @@ func init() {
 		Fn:           rcBisync,
 		Title:        shortHelp,
 		Help:         rcHelp,
+		Params: []rc.Param{
+			{Name: "path1", Type: rc.TypeString, Required: true, Help: `a remote directory string e.g. "drive:path1"`},
+			{Name: "path2", Type: rc.TypeString, Required: true, Help: `a remote directory string e.g. "drive:path2"`},
+			{Name: "path3", Type: rc.TypeString, Help: "optional further remote directories to keep in sync as path3, path4 etc"},
+			{Name: "dryRun", Type: rc.TypeBoolean, Help: "dry-run mode"},
+			{Name: "resync", Type: rc.TypeBoolean, Help: "performs the resync run"},
+			{Name: "checkAccess", Type: rc.TypeBoolean, Help: "abort if check files are not found on both filesystems"},
+			{Name: "checkFilename", Type: rc.TypeString, Help: "file name for checkAccess"},
+			{Name: "maxDelete", Type: rc.TypeInteger, Help: "abort sync if percentage of deleted files is above this threshold"},
+			{Name: "force", Type: rc.TypeBoolean, Help: "bypass maxDelete safety check and run the sync"},
+			{Name: "checkSync", Type: rc.TypeString, Help: `"true", "false" or "only" to control comparison of final listings`},
+			{Name: "compare", Type: rc.TypeString, Help: "comma separated list of size, modtime and checksum used to detect changes"},
+			{Name: "detectRenames", Type: rc.TypeBoolean, Help: "detect renamed files by size and hash and propagate them as server-side moves"},
+			{Name: "removeEmptyDirs", Type: rc.TypeBoolean, Help: "remove empty directories at the final cleanup step"},
+			{Name: "filtersFile", Type: rc.TypeString, Help: "read filtering patterns from a file"},
+			{Name: "workdir", Type: rc.TypeString, Help: "server directory for history files"},
+			{Name: "noCleanup", Type: rc.TypeBoolean, Help: "retain working files"},
+			{Name: "maxLock", Type: rc.TypeString, Help: "consider lock files older than this duration stale"},
+		},
+		Returns: []rc.Param{
+			{Name: "output", Type: rc.TypeString, Required: true, Help: "the log output of the run"},
+		},
 	})
 }
 
@@ -48,6 +71,9 @@ func rcBisync(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	if opt.Force, err = in.GetBool("force"); rc.NotErrParamNotFound(err) {
 		return
 	}
+	if opt.DetectRenames, err = in.GetBool("detectRenames"); rc.NotErrParamNotFound(err) {
+		return
+	}
 	if opt.RemoveEmptyDirs, err = in.GetBool("removeEmptyDirs"); rc.NotErrParamNotFound(err) {
 		return
 	}
@@ -65,6 +91,18 @@ func rcBisync(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 		return
 	}
 
+	if opt.MaxLock, err = in.GetDuration("maxLock"); rc.NotErrParamNotFound(err) {
+		return
+	}
+
+	if compare, err := in.GetString("compare"); err == nil {
+		if err := opt.Compare.Set(compare); err != nil {
+			return nil, rc.NewErrParamInvalid(err)
+		}
+	} else if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+
 	checkSync, err := in.GetString("checkSync")
 	if rc.NotErrParamNotFound(err) {
 		return nil, err
@@ -83,8 +121,20 @@ func rcBisync(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 		return nil, err
 	}
 
+	fss := []fs.Fs{fs1, fs2}
+	for i := 3; i <= MaxPaths; i++ {
+		f, err := rc.GetFsNamed(octx, in, fmt.Sprintf("path%d", i))
+		if rc.IsErrParamNotFound(err) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		fss = append(fss, f)
+	}
+
 	output := bilib.CaptureOutput(func() {
-		err = Bisync(octx, fs1, fs2, opt)
+		err = BisyncMulti(octx, fss, opt)
 	})
 	_, _ = log.Writer().Write(output)
 	return rc.Params{"output": string(output)}, err
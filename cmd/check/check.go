@@ -3,6 +3,7 @@ package check
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -28,6 +29,9 @@ var (
 	differ            = ""
 	errFile           = ""
 	checkFileHashType = ""
+	report            = ""
+	reportFormat      = ""
+	sampleOpt         = operations.CheckSample{}
 )
 
 func init() {
@@ -35,6 +39,10 @@ func init() {
 	cmdFlags := commandDefinition.Flags()
 	flags.BoolVarP(cmdFlags, &download, "download", "", download, "Check by downloading rather than with hash")
 	flags.StringVarP(cmdFlags, &checkFileHashType, "checkfile", "C", checkFileHashType, "Treat source:path as a SUM file with hashes of given type")
+	flags.Float64VarP(cmdFlags, &sampleOpt.Percent, "sample-percent", "", sampleOpt.Percent, "Only check this percentage of the files in both source and destination")
+	flags.FVarP(cmdFlags, &sampleOpt.Bytes, "sample-bytes", "", "Only check this many bytes of the files in both source and destination")
+	flags.Int64VarP(cmdFlags, &sampleOpt.Seed, "sample-seed", "", sampleOpt.Seed, "Seed for choosing the sample, 0 for random")
+	flags.BoolVarP(cmdFlags, &sampleOpt.Stratified, "sample-stratified", "", sampleOpt.Stratified, "Sample each directory in proportion to its size")
 	AddFlags(cmdFlags)
 }
 
@@ -47,6 +55,8 @@ func AddFlags(cmdFlags *pflag.FlagSet) {
 	flags.StringVarP(cmdFlags, &match, "match", "", match, "Report all matching files to this file")
 	flags.StringVarP(cmdFlags, &differ, "differ", "", differ, "Report all non-matching files to this file")
 	flags.StringVarP(cmdFlags, &errFile, "error", "", errFile, "Report all files with errors (hashing or reading) to this file")
+	flags.StringVarP(cmdFlags, &report, "report", "", report, "Write a machine readable report of all files to this file")
+	flags.StringVarP(cmdFlags, &reportFormat, "report-format", "", reportFormat, "Format for --report: json|html (default json)")
 }
 
 // FlagsHelp describes the flags for the help
@@ -72,6 +82,23 @@ you what happened to it. These are reminiscent of diff files.
 - |+ path| means path was missing on the destination, so only in the source
 - |* path| means path was present in source and destination but different.
 - |! path| means there was an error reading or hashing the source or dest.
+
+The |--report| flag writes a machine readable report to the file (or
+stdout if it is |-|) supplied, in the format given by |--report-format|
+which may be |json| (the default) or |html|. Setting |--report-format|
+on its own writes the report to stdout.
+
+The JSON report has a |summary| with the number of files for each
+status and a |files| list with an entry for every file checked giving
+
+- |path| - the path of the file
+- |status| - one of |match|, |differ|, |missing_on_src|, |missing_on_dst| or |error|
+- |src| and |dst| - the |size|, |modTime| and the |hashes| compared, if present
+- |compared| - what was compared, e.g. |size|, a hash name or |content|
+- |error| - why the file differs or the error checking it
+
+The HTML report shows the summary and a table of the files which
+didn't match.
 `, "|", "`")
 
 // GetCheckOpt gets the options corresponding to the check flags
@@ -119,8 +146,30 @@ func GetCheckOpt(fsrc, fdst fs.Fs) (opt *operations.CheckOpt, close func(), err
 	if err = open(errFile, &opt.Error); err != nil {
 		return nil, nil, err
 	}
+	var reportOut io.Writer
+	reportName, reportFormat := report, reportFormat
+	if reportName != "" || reportFormat != "" {
+		if reportFormat == "" {
+			reportFormat = "json"
+		}
+		if err = operations.CheckReportFormatValid(reportFormat); err != nil {
+			return nil, nil, err
+		}
+		if reportName == "" {
+			reportName = "-"
+		}
+		if err = open(reportName, &reportOut); err != nil {
+			return nil, nil, err
+		}
+		opt.Report = operations.NewCheckReport()
+	}
 
 	close = func() {
+		if opt.Report != nil {
+			if err := opt.Report.Write(reportOut, reportFormat); err != nil {
+				fs.Errorf(nil, "Failed to write report: %v", err)
+			}
+		}
 		for _, closer := range closers {
 			err := closer.Close()
 			if err != nil {
@@ -154,6 +203,33 @@ to check all the data.
 
 If you supply the |--checkfile HASH| flag with a valid hash name,
 the |source:path| must point to a text file in the SUM format.
+
+### Sampling
+
+Checking everything with |--download| can take a very long time on
+large remotes. Use |--sample-percent N| to check only N% of the files
+found in both the source and destination, or |--sample-bytes SIZE| to
+check files until that many bytes have been checked. If both are
+given the check stops at whichever limit is reached first. Files
+missing on either side are always reported.
+
+rclone remembers which files were checked OK and when, in a database
+in the cache directory, and checks the files which haven't been
+checked for the longest time first. Successive runs will therefore
+cover the whole tree. Files which fail, or whose size, modification
+time or hash have changed since they were checked, are forgotten so
+they are checked again on the next run.
+
+The files to check are otherwise chosen at random. The seed used is
+logged and can be supplied with |--sample-seed| to repeat the choice.
+Use |--sample-stratified| to take the sample from each directory in
+proportion to its size, rather than from the whole tree at once.
+
+At the end rclone logs how much of the tree has been checked OK in
+this or previous runs, the proportion of the sample which failed and
+an upper bound, at 95% confidence, on the proportion of all the files
+which would fail. These figures are also in the |sample| section of
+the |--report|.
 `, "|", "`") + FlagsHelp,
 	RunE: func(command *cobra.Command, args []string) error {
 		cmd.CheckArgs(2, 2, command, args)
@@ -180,6 +256,17 @@ the |source:path| must point to a text file in the SUM format.
 			}
 			defer close()
 
+			if sampleOpt.Percent != 0 || sampleOpt.Bytes != 0 || sampleOpt.Stratified {
+				if checkFileHashType != "" {
+					return errors.New("can't use --sample-percent or --sample-bytes with --checkfile")
+				}
+				sample := sampleOpt
+				if err := sample.Validate(); err != nil {
+					return err
+				}
+				opt.Sample = &sample
+			}
+
 			if checkFileHashType != "" {
 				return operations.CheckSum(context.Background(), fsrc, fsum, sumFile, hashType, opt, download)
 			}
@@ -35,6 +35,9 @@ import (
 	fslog "github.com/rclone/rclone/fs/log"
 	"github.com/rclone/rclone/fs/rc/rcflags"
 	"github.com/rclone/rclone/fs/rc/rcserver"
+	"github.com/rclone/rclone/fs/tracing"
+	"github.com/rclone/rclone/fs/transferlog"
+	"github.com/rclone/rclone/fs/webhook"
 	"github.com/rclone/rclone/lib/atexit"
 	"github.com/rclone/rclone/lib/buildinfo"
 	"github.com/rclone/rclone/lib/exitcode"
@@ -239,8 +242,11 @@ func ShowStats() bool {
 
 // Run the function with stats and retries if required
 func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
-	ci := fs.GetConfig(context.Background())
+	ctx := context.Background()
+	ci := fs.GetConfig(ctx)
 	var cmdErr error
+	startTime := time.Now()
+	webhook.CommandStarted(ctx, cmd.CommandPath())
 	stopStats := func() {}
 	if !showStats && ShowStats() {
 		showStats = true
@@ -327,6 +333,9 @@ func Run(Retry bool, showStats bool, cmd *cobra.Command, f func() error) {
 		cmdErr = lastErr
 	}
 
+	// Notify any webhooks before exiting
+	webhook.CommandFinished(ctx, cmd.CommandPath(), startTime, cmdErr)
+
 	// Log the final error message and exit
 	if cmdErr != nil {
 		nerrs := accounting.GlobalStats().GetErrors()
@@ -418,6 +427,36 @@ func initConfig() {
 		fs.Debugf("rclone", "systemd logging support activated")
 	}
 
+	// Start tracing if configured
+	stopTracing, err := tracing.Start(ctx)
+	if err != nil {
+		log.Fatalf("Failed to start tracing: %v", err)
+	}
+	atexit.Register(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := stopTracing(ctx); err != nil {
+			fs.Errorf(nil, "Failed to flush traces: %v", err)
+		}
+	})
+
+	// Start the transfer log if configured
+	stopTransferLog, err := transferlog.Start(ctx)
+	if err != nil {
+		log.Fatalf("Failed to start transfer log: %v", err)
+	}
+	atexit.Register(func() {
+		if err := stopTransferLog(); err != nil {
+			fs.Errorf(nil, "Failed to close transfer log: %v", err)
+		}
+	})
+
+	// Start sending webhooks for rc jobs if configured
+	_, err = webhook.Start(ctx)
+	if err != nil {
+		log.Fatalf("Failed to start webhooks: %v", err)
+	}
+
 	// Start the remote control server if configured
 	_, err = rcserver.Start(context.Background(), &rcflags.Opt)
 	if err != nil {
@@ -156,6 +156,8 @@ func (fsys *FS) stat(node vfs.Node, stat *fuse.Stat_t) (errc int) {
 	Mode := node.Mode().Perm()
 	if node.IsDir() {
 		Mode |= fuse.S_IFDIR
+	} else if node.Mode()&os.ModeSymlink != 0 {
+		Mode |= fuse.S_IFLNK
 	} else {
 		Mode |= fuse.S_IFREG
 	}
@@ -506,13 +508,14 @@ func (fsys *FS) Link(oldpath string, newpath string) (errc int) {
 // Symlink creates a symbolic link.
 func (fsys *FS) Symlink(target string, newpath string) (errc int) {
 	defer log.Trace(target, "newpath=%q", newpath)("errc=%d", &errc)
-	return -fuse.ENOSYS
+	return translateError(fsys.VFS.Symlink(target, newpath))
 }
 
 // Readlink reads the target of a symbolic link.
 func (fsys *FS) Readlink(path string) (errc int, linkPath string) {
 	defer log.Trace(path, "")("linkPath=%q, errc=%d", &linkPath, &errc)
-	return -fuse.ENOSYS, ""
+	linkPath, err := fsys.VFS.Readlink(path)
+	return translateError(err), linkPath
 }
 
 // Chmod changes the permission bits of a file.
@@ -596,6 +599,12 @@ func translateError(err error) (errc int) {
 		return -fuse.ENOSYS
 	case vfs.EINVAL:
 		return -fuse.EINVAL
+	case vfs.ENOATTR:
+		return -fuse.ENOATTR
+	case vfs.ENOTSUP:
+		return -fuse.ENOTSUP
+	case vfs.EAGAIN:
+		return -fuse.EAGAIN
 	}
 	fs.Errorf(nil, "IO error: %v", err)
 	return -fuse.EIO
@@ -98,6 +98,7 @@ func cryptCheck(ctx context.Context, fdst, fsrc fs.Fs) error {
 		if cryptHash == "" {
 			return false, true, nil
 		}
+		operations.CheckRecordHashes(ctx, hashType, cryptHash, underlyingHash)
 		if cryptHash != underlyingHash {
 			err = fmt.Errorf("hashes differ (%s:%s) %q vs (%s:%s) %q", fdst.Name(), fdst.Root(), cryptHash, fsrc.Name(), fsrc.Root(), underlyingHash)
 			fs.Errorf(src, err.Error())
@@ -14,6 +14,9 @@ import (
 	"github.com/rclone/rclone/fs/filter/filterflags"
 	"github.com/rclone/rclone/fs/log/logflags"
 	"github.com/rclone/rclone/fs/rc/rcflags"
+	"github.com/rclone/rclone/fs/tracing/tracingflags"
+	"github.com/rclone/rclone/fs/transferlog/transferlogflags"
+	"github.com/rclone/rclone/fs/webhook/webhookflags"
 	"github.com/rclone/rclone/lib/atexit"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -176,6 +179,9 @@ func setupRootCommand(rootCmd *cobra.Command) {
 	filterflags.AddFlags(pflag.CommandLine)
 	rcflags.AddFlags(pflag.CommandLine)
 	logflags.AddFlags(pflag.CommandLine)
+	webhookflags.AddFlags(pflag.CommandLine)
+	tracingflags.AddFlags(pflag.CommandLine)
+	transferlogflags.AddFlags(pflag.CommandLine)
 
 	Root.Run = runRoot
 	Root.Flags().BoolVarP(&version, "version", "V", false, "Print the version number")
@@ -5,7 +5,6 @@ package mount
 
 import (
 	"context"
-	"syscall"
 	"time"
 
 	"bazil.org/fuse"
@@ -33,6 +32,9 @@ func (f *File) Attr(ctx context.Context, a *fuse.Attr) (err error) {
 	a.Gid = f.VFS().Opt.GID
 	a.Uid = f.VFS().Opt.UID
 	a.Mode = f.VFS().Opt.FilePerms
+	if f.File.IsSymlink() {
+		a.Mode = f.File.Mode()
+	}
 	a.Size = Size
 	a.Atime = modTime
 	a.Mtime = modTime
@@ -97,23 +99,27 @@ func (f *File) Fsync(ctx context.Context, req *fuse.FsyncRequest) (err error) {
 // node.
 //
 // If there is no xattr by that name, returns fuse.ErrNoXattr.
-func (f *File) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
-	return syscall.ENOSYS // we never implement this
+func (f *File) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) (err error) {
+	defer log.Trace(f, "name=%q", req.Name)("err=%v", &err)
+	resp.Xattr, err = getxattr(f.File, req.Name)
+	return err
 }
 
 var _ fusefs.NodeGetxattrer = (*File)(nil)
 
 // Listxattr lists the extended attributes recorded for the node.
-func (f *File) Listxattr(ctx context.Context, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) error {
-	return syscall.ENOSYS // we never implement this
+func (f *File) Listxattr(ctx context.Context, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) (err error) {
+	defer log.Trace(f, "")("err=%v", &err)
+	return listxattr(f.File, resp)
 }
 
 var _ fusefs.NodeListxattrer = (*File)(nil)
 
 // Setxattr sets an extended attribute with the given name and
 // value for the node.
-func (f *File) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) error {
-	return syscall.ENOSYS // we never implement this
+func (f *File) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) (err error) {
+	defer log.Trace(f, "name=%q", req.Name)("err=%v", &err)
+	return setxattr(f.File, req.Name, req.Xattr)
 }
 
 var _ fusefs.NodeSetxattrer = (*File)(nil)
@@ -121,8 +127,18 @@ var _ fusefs.NodeSetxattrer = (*File)(nil)
 // Removexattr removes an extended attribute for the name.
 //
 // If there is no xattr by that name, returns fuse.ErrNoXattr.
-func (f *File) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) error {
-	return syscall.ENOSYS // we never implement this
+func (f *File) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) (err error) {
+	defer log.Trace(f, "name=%q", req.Name)("err=%v", &err)
+	return removexattr(f.File, req.Name)
 }
 
 var _ fusefs.NodeRemovexattrer = (*File)(nil)
+
+// Readlink reads the target of a symbolic link.
+func (f *File) Readlink(ctx context.Context, req *fuse.ReadlinkRequest) (target string, err error) {
+	defer log.Trace(f, "")("target=%q, err=%v", &target, &err)
+	target, err = f.File.Readlink()
+	return target, translateError(err)
+}
+
+var _ fusefs.NodeReadlinker = (*File)(nil)
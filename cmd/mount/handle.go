@@ -70,6 +70,8 @@ var _ fusefs.HandleFlusher = (*FileHandle)(nil)
 // some writes, or that if will be called at all.
 func (fh *FileHandle) Flush(ctx context.Context, req *fuse.FlushRequest) (err error) {
 	defer log.Trace(fh, "")("err=%v", &err)
+	// POSIX locks are released on any close
+	fh.unlockAll(lockOwner(req.LockOwner, 0))
 	return translateError(fh.Handle.Flush())
 }
 
@@ -81,5 +83,8 @@ var _ fusefs.HandleReleaser = (*FileHandle)(nil)
 // the kernel
 func (fh *FileHandle) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error) {
 	defer log.Trace(fh, "")("err=%v", &err)
+	if req.ReleaseFlags&fuse.ReleaseFlockUnlock != 0 {
+		fh.unlockAll(lockOwner(req.LockOwner, fuse.LockFlock))
+	}
 	return translateError(fh.Handle.Release())
 }
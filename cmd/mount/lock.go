@@ -0,0 +1,130 @@
+//go:build linux || freebsd
+// +build linux freebsd
+
+package mount
+
+import (
+	"context"
+	"fmt"
+
+	"bazil.org/fuse"
+	fusefs "bazil.org/fuse/fs"
+	"github.com/rclone/rclone/fs/log"
+	"github.com/rclone/rclone/vfs"
+)
+
+// The flock and POSIX locks taken on the mount are kept in the VFS
+// advisory lock table so they are shared with anything else using
+// the VFS.
+
+// lockOwner returns the VFS lock owner for the kernel lock owner.
+//
+// flock and POSIX locks are kept apart as they are released at
+// different times.
+func lockOwner(owner fuse.LockOwner, flags fuse.LockFlags) string {
+	if flags&fuse.LockFlock != 0 {
+		return fmt.Sprintf("mount-flock-%d", owner)
+	}
+	return fmt.Sprintf("mount-posix-%d", owner)
+}
+
+// toVFSLock converts a FUSE lock request into a vfs.Lock
+func toVFSLock(req *fuse.LockRequest) (lock vfs.Lock, err error) {
+	lock = vfs.Lock{
+		Owner: lockOwner(req.LockOwner, req.LockFlags),
+		Start: int64(req.Lock.Start),
+		End:   int64(req.Lock.End),
+	}
+	if req.Lock.End > vfs.LockEOF {
+		lock.End = vfs.LockEOF
+	}
+	switch req.Lock.Type {
+	case fuse.LockRead:
+		lock.Type = vfs.LockShared
+	case fuse.LockWrite:
+		lock.Type = vfs.LockExclusive
+	default:
+		return lock, vfs.EINVAL
+	}
+	return lock, nil
+}
+
+// Check interfaces satisfied
+var (
+	_ fusefs.HandleFlockLocker = (*FileHandle)(nil)
+	_ fusefs.HandlePOSIXLocker = (*FileHandle)(nil)
+)
+
+// Lock tries to acquire a lock on a byte range of the node. If a
+// conflicting lock is already held, returns syscall.EAGAIN.
+func (fh *FileHandle) Lock(ctx context.Context, req *fuse.LockRequest) (err error) {
+	defer log.Trace(fh, "req=%v", req)("err=%v", &err)
+	lock, err := toVFSLock(req)
+	if err != nil {
+		return translateError(err)
+	}
+	node := fh.Node()
+	return translateError(node.VFS().TryLockFile(node.Path(), lock))
+}
+
+// LockWait acquires a lock on a byte range of the node, waiting
+// until the lock can be obtained (or context is canceled).
+func (fh *FileHandle) LockWait(ctx context.Context, req *fuse.LockWaitRequest) (err error) {
+	defer log.Trace(fh, "req=%v", req)("err=%v", &err)
+	lock, err := toVFSLock((*fuse.LockRequest)(req))
+	if err != nil {
+		return translateError(err)
+	}
+	node := fh.Node()
+	err = node.VFS().LockFile(ctx, node.Path(), lock)
+	if err == context.Canceled {
+		return fuse.EINTR
+	}
+	return translateError(err)
+}
+
+// Unlock releases the lock on a byte range of the node.
+func (fh *FileHandle) Unlock(ctx context.Context, req *fuse.UnlockRequest) (err error) {
+	defer log.Trace(fh, "req=%v", req)("err=%v", &err)
+	end := int64(req.Lock.End)
+	if req.Lock.End > vfs.LockEOF {
+		end = vfs.LockEOF
+	}
+	node := fh.Node()
+	node.VFS().UnlockFile(node.Path(), lockOwner(req.LockOwner, req.LockFlags), int64(req.Lock.Start), end)
+	return nil
+}
+
+// QueryLock returns the current state of locks held for the byte
+// range of the node.
+func (fh *FileHandle) QueryLock(ctx context.Context, req *fuse.QueryLockRequest, resp *fuse.QueryLockResponse) (err error) {
+	defer log.Trace(fh, "req=%v", req)("err=%v", &err)
+	lock, err := toVFSLock(&fuse.LockRequest{
+		LockOwner: req.LockOwner,
+		Lock:      req.Lock,
+		LockFlags: req.LockFlags,
+	})
+	if err != nil {
+		return translateError(err)
+	}
+	node := fh.Node()
+	conflict := node.VFS().TestLock(node.Path(), lock)
+	if conflict == nil {
+		return nil
+	}
+	resp.Lock = fuse.FileLock{
+		Start: uint64(conflict.Start),
+		End:   uint64(conflict.End),
+		Type:  fuse.LockRead,
+	}
+	if conflict.Type == vfs.LockExclusive {
+		resp.Lock.Type = fuse.LockWrite
+	}
+	return nil
+}
+
+// unlockAll releases all the locks owner holds on the file
+func (fh *FileHandle) unlockAll(owner string) {
+	node := fh.Node()
+	node.VFS().UnlockFile(node.Path(), owner, 0, vfs.LockEOF)
+}
@@ -0,0 +1,40 @@
+//go:build linux || freebsd
+// +build linux freebsd
+
+package mount
+
+import (
+	"bazil.org/fuse"
+	"github.com/rclone/rclone/vfs"
+)
+
+// The extended attributes are implemented by the VFS - see
+// vfs.Xattrer for details.
+
+// getxattr reads the extended attribute name for node
+func getxattr(node vfs.Xattrer, name string) ([]byte, error) {
+	value, err := node.Getxattr(name)
+	return value, translateError(err)
+}
+
+// listxattr lists the extended attributes set on node
+func listxattr(node vfs.Xattrer, resp *fuse.ListxattrResponse) error {
+	names, err := node.Listxattr()
+	if err != nil {
+		return translateError(err)
+	}
+	for _, name := range names {
+		resp.Append(name)
+	}
+	return nil
+}
+
+// setxattr sets the extended attribute name to value on node
+func setxattr(node vfs.Xattrer, name string, value []byte) error {
+	return translateError(node.Setxattr(name, value))
+}
+
+// removexattr removes the extended attribute name from node
+func removexattr(node vfs.Xattrer, name string) error {
+	return translateError(node.Removexattr(name))
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
 	"syscall"
 
 	fusefs "github.com/hanwen/go-fuse/v2/fs"
@@ -36,6 +37,9 @@ import (
 type FileHandle struct {
 	h    vfs.Handle
 	fsys *FS
+
+	mu     sync.Mutex      // protects the following
+	owners map[string]bool // VFS lock owners used by this handle - true for flock
 }
 
 // Create a new FileHandle
@@ -86,6 +90,8 @@ var _ fusefs.FileWriter = (*FileHandle)(nil)
 // of a descriptor that was duplicated using dup(2), it may be called
 // more than once for the same FileHandle.
 func (f *FileHandle) Flush(ctx context.Context) syscall.Errno {
+	// POSIX locks are released on any close
+	f.unlockAll(false)
 	return translateError(f.h.Flush())
 }
 
@@ -96,6 +102,7 @@ var _ fusefs.FileFlusher = (*FileHandle)(nil)
 // so any cleanup that requires specific synchronization or
 // could fail with I/O errors should happen in Flush instead.
 func (f *FileHandle) Release(ctx context.Context) syscall.Errno {
+	f.unlockAll(true)
 	return translateError(f.h.Release())
 }
 
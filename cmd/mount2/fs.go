@@ -54,6 +54,8 @@ func getMode(node os.FileInfo) uint32 {
 	Mode := node.Mode().Perm()
 	if node.IsDir() {
 		Mode |= fuse.S_IFDIR
+	} else if node.Mode()&os.ModeSymlink != 0 {
+		Mode |= fuse.S_IFLNK
 	} else {
 		Mode |= fuse.S_IFREG
 	}
@@ -128,6 +130,12 @@ func translateError(err error) syscall.Errno {
 		return syscall.ENOSYS
 	case vfs.EINVAL:
 		return syscall.EINVAL
+	case vfs.ENOATTR:
+		return syscall.Errno(fuse.ENOATTR)
+	case vfs.ENOTSUP:
+		return syscall.ENOTSUP
+	case vfs.EAGAIN:
+		return syscall.EAGAIN
 	}
 	fs.Errorf(nil, "IO error: %v", err)
 	return syscall.EIO
@@ -0,0 +1,141 @@
+//go:build linux || (darwin && amd64)
+// +build linux darwin,amd64
+
+package mount2
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	fusefs "github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/rclone/rclone/fs/log"
+	"github.com/rclone/rclone/vfs"
+)
+
+// The flock and POSIX locks taken on the mount are kept in the VFS
+// advisory lock table so they are shared with anything else using
+// the VFS.
+//
+// The kernel doesn't tell us the lock owner when a file is closed so
+// the locks taken through each file handle are remembered and
+// released when it is flushed (POSIX locks) or released (flock
+// locks).
+
+// toVFSLock converts a FUSE lock into a vfs.Lock, recording the
+// owner in the file handle so it can be released later.
+func (f *FileHandle) toVFSLock(owner uint64, lk *fuse.FileLock, flags uint32) (lock vfs.Lock, errno syscall.Errno) {
+	flock := flags&fuse.FUSE_LK_FLOCK != 0
+	if flock {
+		lock.Owner = fmt.Sprintf("mount2-flock-%d", owner)
+	} else {
+		lock.Owner = fmt.Sprintf("mount2-posix-%d", owner)
+	}
+	lock.Start = int64(lk.Start)
+	lock.End = int64(lk.End)
+	if lk.End > vfs.LockEOF {
+		lock.End = vfs.LockEOF
+	}
+	switch lk.Typ {
+	case syscall.F_RDLCK:
+		lock.Type = vfs.LockShared
+	case syscall.F_WRLCK:
+		lock.Type = vfs.LockExclusive
+	case syscall.F_UNLCK:
+	default:
+		return lock, syscall.EINVAL
+	}
+	f.mu.Lock()
+	if f.owners == nil {
+		f.owners = make(map[string]bool)
+	}
+	f.owners[lock.Owner] = flock
+	f.mu.Unlock()
+	return lock, 0
+}
+
+// setlk takes or releases the lock, waiting for it if wait is set
+func (f *FileHandle) setlk(ctx context.Context, owner uint64, lk *fuse.FileLock, flags uint32, wait bool) syscall.Errno {
+	lock, errno := f.toVFSLock(owner, lk, flags)
+	if errno != 0 {
+		return errno
+	}
+	node := f.h.Node()
+	VFS := node.VFS()
+	if lk.Typ == syscall.F_UNLCK {
+		VFS.UnlockFile(node.Path(), lock.Owner, lock.Start, lock.End)
+		return 0
+	}
+	if !wait {
+		return translateError(VFS.TryLockFile(node.Path(), lock))
+	}
+	err := VFS.LockFile(ctx, node.Path(), lock)
+	if err == context.Canceled {
+		return syscall.EINTR
+	}
+	return translateError(err)
+}
+
+// Getlk returns locks that would conflict with the given input
+// lock. If no locks conflict, the output has type L_UNLCK.
+func (f *FileHandle) Getlk(ctx context.Context, owner uint64, lk *fuse.FileLock, flags uint32, out *fuse.FileLock) (errno syscall.Errno) {
+	defer log.Trace(f, "owner=%d, lk=%+v, flags=%d", owner, lk, flags)("out=%+v, errno=%v", out, &errno)
+	lock, errno := f.toVFSLock(owner, lk, flags)
+	if errno != 0 {
+		return errno
+	}
+	node := f.h.Node()
+	conflict := node.VFS().TestLock(node.Path(), lock)
+	if conflict == nil {
+		out.Typ = syscall.F_UNLCK
+		return 0
+	}
+	out.Start = uint64(conflict.Start)
+	out.End = uint64(conflict.End)
+	out.Typ = syscall.F_RDLCK
+	if conflict.Type == vfs.LockExclusive {
+		out.Typ = syscall.F_WRLCK
+	}
+	return 0
+}
+
+var _ fusefs.FileGetlker = (*FileHandle)(nil)
+
+// Setlk obtains a lock on a file, or fail if the lock could not
+// obtained.
+func (f *FileHandle) Setlk(ctx context.Context, owner uint64, lk *fuse.FileLock, flags uint32) (errno syscall.Errno) {
+	defer log.Trace(f, "owner=%d, lk=%+v, flags=%d", owner, lk, flags)("errno=%v", &errno)
+	return f.setlk(ctx, owner, lk, flags, false)
+}
+
+var _ fusefs.FileSetlker = (*FileHandle)(nil)
+
+// Setlkw obtains a lock on a file, waiting if necessary.
+func (f *FileHandle) Setlkw(ctx context.Context, owner uint64, lk *fuse.FileLock, flags uint32) (errno syscall.Errno) {
+	defer log.Trace(f, "owner=%d, lk=%+v, flags=%d", owner, lk, flags)("errno=%v", &errno)
+	return f.setlk(ctx, owner, lk, flags, true)
+}
+
+var _ fusefs.FileSetlkwer = (*FileHandle)(nil)
+
+// unlockAll releases the flock or POSIX locks taken through the file
+// handle
+func (f *FileHandle) unlockAll(flock bool) {
+	f.mu.Lock()
+	var owners []string
+	for owner, isFlock := range f.owners {
+		if isFlock == flock {
+			owners = append(owners, owner)
+			delete(f.owners, owner)
+		}
+	}
+	f.mu.Unlock()
+	if len(owners) == 0 {
+		return
+	}
+	node := f.h.Node()
+	for _, owner := range owners {
+		node.VFS().UnlockFile(node.Path(), owner, 0, vfs.LockEOF)
+	}
+}
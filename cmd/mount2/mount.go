@@ -26,12 +26,12 @@ func init() {
 // man mount.fuse for more info and note the -o flag for other options
 func mountOptions(fsys *FS, f fs.Fs, opt *mountlib.Options) (mountOpts *fuse.MountOptions) {
 	mountOpts = &fuse.MountOptions{
-		AllowOther:    fsys.opt.AllowOther,
-		FsName:        opt.DeviceName,
-		Name:          "rclone",
-		DisableXAttrs: true,
-		Debug:         fsys.opt.DebugFUSE,
-		MaxReadAhead:  int(fsys.opt.MaxReadAhead),
+		AllowOther:   fsys.opt.AllowOther,
+		FsName:       opt.DeviceName,
+		Name:         "rclone",
+		Debug:        fsys.opt.DebugFUSE,
+		MaxReadAhead: int(fsys.opt.MaxReadAhead),
+		EnableLocks:  true,
 
 		// RememberInodes: true,
 		// SingleThreaded: true,
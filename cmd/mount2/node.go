@@ -314,6 +314,42 @@ func (n *Node) Mkdir(ctx context.Context, name string, mode uint32, out *fuse.En
 
 var _ = (fusefs.NodeMkdirer)((*Node)(nil))
 
+// Symlink is similar to Lookup, but must create a symlink called
+// name pointing to target.
+func (n *Node) Symlink(ctx context.Context, target, name string, out *fuse.EntryOut) (inode *fusefs.Inode, errno syscall.Errno) {
+	defer log.Trace(name, "target=%q", target)("inode=%v, errno=%v", &inode, &errno)
+	dir, ok := n.node.(*vfs.Dir)
+	if !ok {
+		return nil, syscall.ENOTDIR
+	}
+	file, err := dir.Symlink(target, name)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	newNode := newNode(n.fsys, file)
+	n.fsys.setEntryOut(newNode.node, out)
+	newInode := n.NewInode(ctx, newNode, fusefs.StableAttr{Mode: out.Attr.Mode})
+	return newInode, 0
+}
+
+var _ = (fusefs.NodeSymlinker)((*Node)(nil))
+
+// Readlink reads the content of a symlink.
+func (n *Node) Readlink(ctx context.Context) (target []byte, errno syscall.Errno) {
+	defer log.Trace(n, "")("target=%q, errno=%v", &target, &errno)
+	file, ok := n.node.(*vfs.File)
+	if !ok {
+		return nil, syscall.EINVAL
+	}
+	link, err := file.Readlink()
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return []byte(link), 0
+}
+
+var _ = (fusefs.NodeReadlinker)((*Node)(nil))
+
 // Create is similar to Lookup, but should create a new
 // child. It typically also returns a FileHandle as a
 // reference for future reads/writes.
@@ -405,3 +441,75 @@ func (n *Node) Rename(ctx context.Context, oldName string, newParent fusefs.Inod
 }
 
 var _ = (fusefs.NodeRenamer)((*Node)(nil))
+
+// Getxattr should read data for the given attribute into
+// `dest` and return the number of bytes. If `dest` is too
+// small, it should return ERANGE and the size of the attribute.
+func (n *Node) Getxattr(ctx context.Context, attr string, dest []byte) (size uint32, errno syscall.Errno) {
+	defer log.Trace(n, "attr=%q", attr)("size=%d, errno=%v", &size, &errno)
+	xattrer, ok := n.node.(vfs.Xattrer)
+	if !ok {
+		return 0, syscall.ENOTSUP
+	}
+	value, err := xattrer.Getxattr(attr)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	if len(dest) < len(value) {
+		return uint32(len(value)), syscall.ERANGE
+	}
+	return uint32(copy(dest, value)), 0
+}
+
+var _ = (fusefs.NodeGetxattrer)((*Node)(nil))
+
+// Setxattr should store data for the given attribute.  See
+// setxattr(2) for information about flags.
+func (n *Node) Setxattr(ctx context.Context, attr string, data []byte, flags uint32) (errno syscall.Errno) {
+	defer log.Trace(n, "attr=%q, flags=%d", attr, flags)("errno=%v", &errno)
+	xattrer, ok := n.node.(vfs.Xattrer)
+	if !ok {
+		return syscall.ENOTSUP
+	}
+	return translateError(xattrer.Setxattr(attr, data))
+}
+
+var _ = (fusefs.NodeSetxattrer)((*Node)(nil))
+
+// Removexattr should delete the given attribute.
+func (n *Node) Removexattr(ctx context.Context, attr string) (errno syscall.Errno) {
+	defer log.Trace(n, "attr=%q", attr)("errno=%v", &errno)
+	xattrer, ok := n.node.(vfs.Xattrer)
+	if !ok {
+		return syscall.ENOTSUP
+	}
+	return translateError(xattrer.Removexattr(attr))
+}
+
+var _ = (fusefs.NodeRemovexattrer)((*Node)(nil))
+
+// Listxattr should read all attributes (null terminated) into
+// `dest`. If the `dest` buffer is too small, it should return ERANGE
+// and the correct size.
+func (n *Node) Listxattr(ctx context.Context, dest []byte) (size uint32, errno syscall.Errno) {
+	defer log.Trace(n, "")("size=%d, errno=%v", &size, &errno)
+	xattrer, ok := n.node.(vfs.Xattrer)
+	if !ok {
+		return 0, syscall.ENOTSUP
+	}
+	names, err := xattrer.Listxattr()
+	if err != nil {
+		return 0, translateError(err)
+	}
+	var buf []byte
+	for _, name := range names {
+		buf = append(buf, name...)
+		buf = append(buf, 0)
+	}
+	if len(dest) < len(buf) {
+		return uint32(len(buf)), syscall.ERANGE
+	}
+	return uint32(copy(dest, buf)), 0
+}
+
+var _ = (fusefs.NodeListxattrer)((*Node)(nil))
@@ -51,6 +51,13 @@ func init() {
 		AuthRequired: true,
 		Fn:           mountRc,
 		Title:        "Create a new mount point",
+		Params: []rc.Param{
+			{Name: "fs", Type: rc.TypeString, Required: true, Help: "a remote path to be mounted"},
+			{Name: "mountPoint", Type: rc.TypeString, Required: true, Help: "path on the local machine of the mount"},
+			{Name: "mountType", Type: rc.TypeString, Help: "the mount implementation to use as returned by mount/types"},
+			{Name: "mountOpt", Type: rc.TypeObject, Help: "mount options"},
+			{Name: "vfsOpt", Type: rc.TypeObject, Help: "VFS options"},
+		},
 		Help: `rclone allows Linux, FreeBSD, macOS and Windows to mount any of
 Rclone's cloud storage systems as a file system with FUSE.
 
@@ -148,6 +155,7 @@ func init() {
 		AuthRequired: true,
 		Fn:           unMountRc,
 		Title:        "Unmount selected active mount",
+		Params:       []rc.Param{{Name: "mountPoint", Type: rc.TypeString, Required: true, Help: "path on the local machine of the mount"}},
 		Help: `
 rclone allows Linux, FreeBSD, macOS and Windows to
 mount any of Rclone's cloud storage systems as a file system with
@@ -189,6 +197,9 @@ func init() {
 		AuthRequired: true,
 		Fn:           mountTypesRc,
 		Title:        "Show all possible mount types",
+		Returns: []rc.Param{
+			{Name: "mountTypes", Type: rc.TypeArray, Items: rc.TypeString, Required: true, Help: "list of mount types"},
+		},
 		Help: `This shows all possible mount types and returns them as a list.
 
 This takes no parameters and returns
@@ -225,6 +236,9 @@ func init() {
 		AuthRequired: true,
 		Fn:           listMountsRc,
 		Title:        "Show current mount points",
+		Returns: []rc.Param{
+			{Name: "mountPoints", Type: rc.TypeArray, Items: rc.TypeObject, Required: true, Help: "list of current mounts with Fs, MountPoint and MountedOn"},
+		},
 		Help: `This shows currently mounted points, which can be used for performing an unmount.
 
 This takes no parameters and returns
@@ -166,7 +166,7 @@ func doCall(ctx context.Context, path string, in rc.Params) (out rc.Params, err
 		if call == nil {
 			return nil, fmt.Errorf("method %q not found", path)
 		}
-		_, out, err := jobs.NewJob(ctx, call.Fn, in)
+		_, out, err := jobs.NewJob(jobs.WithCall(ctx, path), call.Fn, in)
 		if err != nil {
 			return nil, fmt.Errorf("loopback call failed: %w", err)
 		}
@@ -129,6 +129,7 @@ type Options struct {
 	BasicUser          string        // single username for basic auth if not using Htpasswd
 	BasicPass          string        // password for BasicUser
 	Auth               AuthFn        `json:"-"` // custom Auth (not set by command line flags)
+	TokenAuth          TokenAuthFn   `json:"-"` // custom bearer token Auth (not set by command line flags)
 	Template           string        // User specified template
 	MinTLSVersion      string        // MinTLSVersion contains the minimum TLS version that is acceptable
 }
@@ -139,6 +140,17 @@ type Options struct {
 // If a non nil value is returned then it is added to the context under the key
 type AuthFn func(user, pass string) (value interface{}, err error)
 
+// TokenAuthFn if used will be used to authenticate requests with an
+// "Authorization: Bearer token" header. If an error is returned then
+// the request is not authenticated.
+//
+// If a non nil value is returned then it is added to the context
+// under ContextAuthKey.
+//
+// Requests without a bearer token are authenticated as usual, or let
+// through if no other authentication is configured.
+type TokenAuthFn func(token string) (value interface{}, err error)
+
 // DefaultOpt is the default values used for Options
 var DefaultOpt = Options{
 	ListenAddr:         "localhost:8080",
@@ -201,6 +213,15 @@ func parseAuthorization(r *http.Request) (user, pass string, ok bool) {
 	return
 }
 
+// parseBearer parses a bearer token from the Authorization header
+func parseBearer(r *http.Request) (token string, ok bool) {
+	s := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(s) == 2 && s[0] == "Bearer" && s[1] != "" {
+		return s[1], true
+	}
+	return "", false
+}
+
 // NewServer creates an http server.  The opt can be nil in which case
 // the default options will be used.
 func NewServer(handler http.Handler, opt *Options) *Server {
@@ -216,9 +237,10 @@ func NewServer(handler http.Handler, opt *Options) *Server {
 	}
 
 	// Use htpasswd if required on everything
-	if s.Opt.HtPasswd != "" || s.Opt.BasicUser != "" || s.Opt.Auth != nil {
+	basicAuth := s.Opt.HtPasswd != "" || s.Opt.BasicUser != "" || s.Opt.Auth != nil
+	if basicAuth || s.Opt.TokenAuth != nil {
 		var authenticator *auth.BasicAuth
-		if s.Opt.Auth == nil {
+		if basicAuth && s.Opt.Auth == nil {
 			var secretProvider auth.SecretProvider
 			if s.Opt.HtPasswd != "" {
 				fs.Infof(nil, "Using %q as htpasswd storage", s.Opt.HtPasswd)
@@ -242,6 +264,23 @@ func NewServer(handler http.Handler, opt *Options) *Server {
 				w.Header().Set("WWW-Authenticate", `Basic realm="`+s.Opt.Realm+`"`)
 				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 			}
+			if token, ok := parseBearer(r); ok && s.Opt.TokenAuth != nil {
+				value, err := s.Opt.TokenAuth(token)
+				if err != nil {
+					fs.Infof(r.URL.Path, "%s: Token auth failed: %v", r.RemoteAddr, err)
+					unauthorized()
+					return
+				}
+				if value != nil {
+					r = r.WithContext(context.WithValue(r.Context(), ContextAuthKey, value))
+				}
+				oldHandler.ServeHTTP(w, r)
+				return
+			}
+			if !basicAuth {
+				oldHandler.ServeHTTP(w, r)
+				return
+			}
 			user, pass, authValid := parseAuthorization(r)
 			if !authValid {
 				unauthorized()
@@ -268,7 +307,7 @@ func NewServer(handler http.Handler, opt *Options) *Server {
 			r = r.WithContext(context.WithValue(r.Context(), ContextUserKey, user))
 			oldHandler.ServeHTTP(w, r)
 		})
-		s.usingAuth = true
+		s.usingAuth = basicAuth
 	}
 
 	s.useSSL = s.Opt.SslKey != ""
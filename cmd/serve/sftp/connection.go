@@ -283,7 +283,7 @@ func serveChannel(rwc io.ReadWriteCloser, h sftp.Handlers, what string) error {
 	return nil
 }
 
-func serveStdio(f fs.Fs) error {
+func serveStdio(f fs.Fs, opt *Options) error {
 	if terminal.IsTerminal(int(os.Stdout.Fd())) {
 		return errors.New("refusing to run SFTP server directly on a terminal. Please let sshd start rclone, by connecting with sftp or sshfs")
 	}
@@ -291,7 +291,7 @@ func serveStdio(f fs.Fs) error {
 		stdin:  os.Stdin,
 		stdout: os.Stdout,
 	}
-	handlers := newVFSHandler(vfs.New(f, &vfsflags.Opt))
+	handlers := newVFSHandler(vfs.New(f, &vfsflags.Opt), opt.Locks)
 	return serveChannel(sshChannel, handlers, "stdio")
 }
 
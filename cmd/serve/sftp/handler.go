@@ -4,8 +4,11 @@
 package sftp
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"path"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -17,11 +20,14 @@ import (
 // vfsHandler converts the VFS to be served by SFTP
 type vfsHandler struct {
 	*vfs.VFS
+	locks bool // take VFS locks on files opened
 }
 
 // vfsHandler returns a Handlers object with the test handlers.
-func newVFSHandler(vfs *vfs.VFS) sftp.Handlers {
-	v := vfsHandler{VFS: vfs}
+//
+// If locks is set then files opened are locked in the VFS.
+func newVFSHandler(vfs *vfs.VFS, locks bool) sftp.Handlers {
+	v := vfsHandler{VFS: vfs, locks: locks}
 	return sftp.Handlers{
 		FileGet:  v,
 		FilePut:  v,
@@ -31,19 +37,70 @@ func newVFSHandler(vfs *vfs.VFS) sftp.Handlers {
 }
 
 func (v vfsHandler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
-	file, err := v.OpenFile(r.Filepath, os.O_RDONLY, 0777)
+	return v.open(r.Filepath, os.O_RDONLY, vfs.LockShared)
+}
+
+func (v vfsHandler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	return v.open(r.Filepath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, vfs.LockExclusive)
+}
+
+// open opens the file, taking a whole file VFS lock of lockType if
+// --locks is set.
+func (v vfsHandler) open(name string, flags int, lockType vfs.LockType) (vfs.Handle, error) {
+	if !v.locks {
+		return v.OpenFile(name, flags, 0777)
+	}
+	file, err := v.openLocked(name, flags, lockType)
 	if err != nil {
 		return nil, err
 	}
 	return file, nil
 }
 
-func (v vfsHandler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
-	file, err := v.OpenFile(r.Filepath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0777)
+// lockOwners is used to make a unique VFS lock owner for each file
+// opened
+var lockOwners int64
+
+// lockedHandle is a vfs.Handle holding a VFS advisory lock on the
+// file which is released when it is closed
+type lockedHandle struct {
+	vfs.Handle
+	vfs   *vfs.VFS
+	name  string // name the lock was taken on
+	owner string
+}
+
+// Close closes the handle and releases the lock
+func (h *lockedHandle) Close() error {
+	err := h.Handle.Close()
+	h.vfs.UnlockFile(h.name, h.owner, 0, vfs.LockEOF)
+	return err
+}
+
+// openLocked opens the file taking a whole file VFS lock of lockType
+// so readers and writers over SFTP don't interfere with each other or
+// with other users of the VFS.
+func (v vfsHandler) openLocked(name string, flags int, lockType vfs.LockType) (*lockedHandle, error) {
+	name, err := v.followLinks(name)
 	if err != nil {
 		return nil, err
 	}
-	return file, nil
+	owner := fmt.Sprintf("sftp-%d", atomic.AddInt64(&lockOwners, 1))
+	err = v.TryLockFile(name, vfs.Lock{
+		Owner: owner,
+		Type:  lockType,
+		Start: 0,
+		End:   vfs.LockEOF,
+	})
+	if err != nil {
+		return nil, err
+	}
+	file, err := v.OpenFile(name, flags, 0777)
+	if err != nil {
+		v.UnlockFile(name, owner, 0, vfs.LockEOF)
+		return nil, err
+	}
+	return &lockedHandle{Handle: file, vfs: v.VFS, name: name, owner: owner}, nil
 }
 
 func (v vfsHandler) Filecmd(r *sftp.Request) error {
@@ -74,15 +131,14 @@ func (v vfsHandler) Filecmd(r *sftp.Request) error {
 			return err
 		}
 	case "Symlink":
-		// FIXME
-		// _, err := v.fetch(r.Filepath)
-		// if err != nil {
-		// 	return err
-		// }
-		// link := newMemFile(r.Target, false)
-		// link.symlink = r.Filepath
-		// v.files[r.Target] = link
-		return sftp.ErrSshFxOpUnsupported
+		// r.Filepath is the target and r.Target the new link
+		err := v.Symlink(r.Filepath, r.Target)
+		if err == vfs.ENOSYS {
+			return sftp.ErrSshFxOpUnsupported
+		}
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -125,20 +181,85 @@ func (v vfsHandler) Filelist(r *sftp.Request) (l sftp.ListerAt, err error) {
 		}
 		return listerat(fis), nil
 	case "Stat":
-		node, err = v.Stat(r.Filepath)
+		name, err := v.followLinks(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		node, err = v.Stat(name)
 		if err != nil {
 			return nil, err
 		}
 		return listerat([]os.FileInfo{node}), nil
 	case "Readlink":
-		// FIXME
-		// if file.symlink != "" {
-		// 	file, err = v.fetch(file.symlink)
-		// 	if err != nil {
-		// 		return nil, err
-		// 	}
-		// }
-		// return listerat([]os.FileInfo{file}), nil
+		node, err = v.Stat(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		file, ok := node.(*vfs.File)
+		if !ok {
+			return nil, vfs.EINVAL
+		}
+		target, err := file.Readlink()
+		if err != nil {
+			return nil, err
+		}
+		return listerat([]os.FileInfo{linkTarget{FileInfo: node, target: target}}), nil
 	}
 	return nil, sftp.ErrSshFxOpUnsupported
 }
+
+// Lstat returns the file info of r.Filepath without following a
+// symlink at the end of it
+func (v vfsHandler) Lstat(r *sftp.Request) (sftp.ListerAt, error) {
+	node, err := v.Stat(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	return listerat([]os.FileInfo{node}), nil
+}
+
+var _ sftp.LstatFileLister = vfsHandler{}
+
+// linkTarget is the os.FileInfo of a symlink named as its target which
+// is how the sftp library returns the result of Readlink
+type linkTarget struct {
+	os.FileInfo
+	target string
+}
+
+// Name returns the target of the symlink
+func (l linkTarget) Name() string {
+	return l.target
+}
+
+// maxLinks is the most symlinks followed when resolving a path
+const maxLinks = 40
+
+// followLinks returns name with a symlink at the end of it resolved
+//
+// Targets are relative to the directory of the symlink or absolute
+// from the root of the VFS. A name which doesn't exist is returned as
+// is so it can be created.
+func (v vfsHandler) followLinks(name string) (string, error) {
+	for i := 0; i < maxLinks; i++ {
+		node, err := v.Stat(name)
+		if err == vfs.ENOENT {
+			return name, nil
+		} else if err != nil {
+			return "", err
+		}
+		file, ok := node.(*vfs.File)
+		if !ok || !file.IsSymlink() {
+			return name, nil
+		}
+		target, err := file.Readlink()
+		if err != nil {
+			return "", err
+		}
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(name), target)
+		}
+		name = target
+	}
+	return "", syscall.ELOOP
+}
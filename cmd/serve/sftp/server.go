@@ -108,7 +108,7 @@ func (s *server) acceptConnection(nConn net.Conn) {
 		_ = nConn.Close()
 		return
 	}
-	c.handlers = newVFSHandler(c.vfs)
+	c.handlers = newVFSHandler(c.vfs, s.opt.Locks)
 
 	// Accept all channels
 	go c.handleChannels(chans)
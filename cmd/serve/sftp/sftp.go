@@ -28,6 +28,7 @@ type Options struct {
 	Pass           string   // password for user
 	NoAuth         bool     // allow no authentication on connections
 	Stdio          bool     // serve on stdio
+	Locks          bool     // lock files opened in the VFS
 }
 
 // DefaultOpt is the default values used for Options
@@ -49,6 +50,7 @@ func AddFlags(flagSet *pflag.FlagSet, Opt *Options) {
 	flags.StringVarP(flagSet, &Opt.Pass, "pass", "", Opt.Pass, "Password for authentication")
 	flags.BoolVarP(flagSet, &Opt.NoAuth, "no-auth", "", Opt.NoAuth, "Allow connections with no authentication if set")
 	flags.BoolVarP(flagSet, &Opt.Stdio, "stdio", "", Opt.Stdio, "Run an sftp server on stdin/stdout")
+	flags.BoolVarP(flagSet, &Opt.Locks, "locks", "", Opt.Locks, "Lock files opened for reading or writing in the VFS")
 }
 
 func init() {
@@ -113,6 +115,12 @@ used. Omitting "restrict" and using  ` + "`--sftp-path-override`" + ` to enable
 checksumming is possible but less secure and you could use the SFTP server
 provided by OpenSSH in this case.
 
+SFTP clients can't ask for files to be locked, so if ` + "`--locks`" + ` is set
+rclone takes a shared lock on files opened for reading and an exclusive
+lock on files opened for writing. Opening a file fails if a conflicting
+lock is held, for example by a program using the file on a mount of the
+same VFS. See the VFS File Locking section below.
+
 ` + vfs.Help + proxy.Help,
 	Run: func(command *cobra.Command, args []string) {
 		var f fs.Fs
@@ -124,7 +132,7 @@ provided by OpenSSH in this case.
 		}
 		cmd.Run(false, true, command, func() error {
 			if Opt.Stdio {
-				return serveStdio(f)
+				return serveStdio(f, &Opt)
 			}
 			s := newServer(context.Background(), f, &Opt)
 			err := s.Serve()
@@ -0,0 +1,120 @@
+package webdav
+
+import (
+	"time"
+
+	"github.com/rclone/rclone/vfs"
+	"golang.org/x/net/webdav"
+)
+
+// lockSystem is a webdav.LockSystem which keeps the WebDAV locks in
+// memory like webdav.NewMemLS and mirrors them into the VFS advisory
+// locks so they are respected by anything else using the VFS.
+//
+// Files locked through the VFS by someone else can't be locked or
+// changed over WebDAV.
+//
+// The VFS locks are owned by the WebDAV lock token. They move with
+// the file if it is renamed, so they are released by token rather
+// than by the root of the WebDAV lock.
+type lockSystem struct {
+	webdav.LockSystem
+	vfs *vfs.VFS
+}
+
+// newLockSystem makes a new lockSystem for VFS
+func newLockSystem(VFS *vfs.VFS) *lockSystem {
+	return &lockSystem{
+		LockSystem: webdav.NewMemLS(),
+		vfs:        VFS,
+	}
+}
+
+// check interface
+var _ webdav.LockSystem = (*lockSystem)(nil)
+
+// vfsLock returns the VFS lock for the WebDAV lock token
+func vfsLock(now time.Time, token string, duration time.Duration) vfs.Lock {
+	lock := vfs.Lock{
+		Owner: token,
+		Type:  vfs.LockExclusive,
+		Start: 0,
+		End:   vfs.LockEOF,
+	}
+	// A negative duration means the lock never expires
+	if duration >= 0 {
+		lock.Expires = now.Add(duration)
+	}
+	return lock
+}
+
+// lockedByOther returns true if name has a VFS lock held by an owner
+// not in conditions
+func (ls *lockSystem) lockedByOther(name string, conditions []webdav.Condition) bool {
+	if name == "" {
+		return false
+	}
+outer:
+	for _, lock := range ls.vfs.Locks(name) {
+		for _, condition := range conditions {
+			if !condition.Not && condition.Token == lock.Owner {
+				continue outer
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Confirm confirms that the caller can claim all of the locks
+// specified by the given conditions, and that holding the union of
+// all of those locks gives exclusive access to all of the named
+// resources.
+func (ls *lockSystem) Confirm(now time.Time, name0, name1 string, conditions ...webdav.Condition) (release func(), err error) {
+	release, err = ls.LockSystem.Confirm(now, name0, name1, conditions...)
+	if err != nil {
+		return nil, err
+	}
+	if ls.lockedByOther(name0, conditions) || ls.lockedByOther(name1, conditions) {
+		release()
+		return nil, webdav.ErrConfirmationFailed
+	}
+	return release, nil
+}
+
+// Create creates a lock with the given depth, duration, owner and
+// root (name).
+func (ls *lockSystem) Create(now time.Time, details webdav.LockDetails) (token string, err error) {
+	token, err = ls.LockSystem.Create(now, details)
+	if err != nil {
+		return "", err
+	}
+	err = ls.vfs.TryLockFile(details.Root, vfsLock(now, token, details.Duration))
+	if err != nil {
+		_ = ls.LockSystem.Unlock(now, token)
+		return "", webdav.ErrLocked
+	}
+	return token, nil
+}
+
+// Refresh refreshes the lock with the given token.
+func (ls *lockSystem) Refresh(now time.Time, token string, duration time.Duration) (webdav.LockDetails, error) {
+	details, err := ls.LockSystem.Refresh(now, token, duration)
+	if err != nil {
+		return details, err
+	}
+	// Replacing our own lock can't conflict with anyone else's
+	// unless it had expired in the meantime
+	err = ls.vfs.TryLockFile(details.Root, vfsLock(now, token, details.Duration))
+	if err != nil {
+		_ = ls.Unlock(now, token)
+		return webdav.LockDetails{}, webdav.ErrNoSuchLock
+	}
+	return details, nil
+}
+
+// Unlock unlocks the lock with the given token.
+func (ls *lockSystem) Unlock(now time.Time, token string) error {
+	ls.vfs.UnlockOwner(token)
+	return ls.LockSystem.Unlock(now, token)
+}
@@ -0,0 +1,69 @@
+package webdav
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+func TestLockSystem(t *testing.T) {
+	f, err := fs.NewFs(context.Background(), t.TempDir())
+	require.NoError(t, err)
+	VFS := vfs.New(f, nil)
+	defer VFS.Shutdown()
+	ls := newLockSystem(VFS)
+	now := time.Now()
+
+	// A WebDAV lock is seen by the VFS
+	token, err := ls.Create(now, webdav.LockDetails{Root: "/file", Duration: time.Minute})
+	require.NoError(t, err)
+	locks := VFS.Locks("file")
+	require.Len(t, locks, 1)
+	assert.Equal(t, token, locks[0].Owner)
+	assert.Equal(t, vfs.LockExclusive, locks[0].Type)
+	assert.Equal(t, now.Add(time.Minute), locks[0].Expires)
+
+	// Refreshing changes the expiry
+	_, err = ls.Refresh(now, token, 2*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(2*time.Minute), VFS.Locks("file")[0].Expires)
+
+	// Unlocking removes it
+	require.NoError(t, ls.Unlock(now, token))
+	assert.Empty(t, VFS.Locks("file"))
+
+	// A VFS lock stops WebDAV locking or changing the file
+	require.NoError(t, VFS.TryLockFile("file", vfs.Lock{Owner: "other", Type: vfs.LockShared, End: vfs.LockEOF}))
+	_, err = ls.Create(now, webdav.LockDetails{Root: "/file", Duration: time.Minute})
+	assert.Equal(t, webdav.ErrLocked, err)
+	VFS.UnlockFile("file", "other", 0, vfs.LockEOF)
+
+	// The lock holder can confirm but not after a VFS lock is taken
+	token, err = ls.Create(now, webdav.LockDetails{Root: "/", Duration: -1})
+	require.NoError(t, err)
+	assert.True(t, VFS.Locks("")[0].Expires.IsZero())
+	release, err := ls.Confirm(now, "/file", "", webdav.Condition{Token: token})
+	require.NoError(t, err)
+	release()
+	require.NoError(t, VFS.TryLockFile("file", vfs.Lock{Owner: "other", Type: vfs.LockShared, End: vfs.LockEOF}))
+	_, err = ls.Confirm(now, "/file", "", webdav.Condition{Token: token})
+	assert.Equal(t, webdav.ErrConfirmationFailed, err)
+	VFS.UnlockFile("file", "other", 0, vfs.LockEOF)
+	require.NoError(t, ls.Unlock(now, token))
+
+	// Unlocking releases the lock after the file is renamed
+	require.NoError(t, VFS.Mkdir("dir", 0777))
+	token, err = ls.Create(now, webdav.LockDetails{Root: "/dir", Duration: -1})
+	require.NoError(t, err)
+	require.NoError(t, VFS.Rename("dir", "dir2"))
+	require.Len(t, VFS.Locks("dir2"), 1)
+	require.NoError(t, ls.Unlock(now, token))
+	assert.Empty(t, VFS.Locks("dir2"))
+}
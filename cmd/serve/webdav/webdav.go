@@ -134,10 +134,17 @@ func newWebDAV(ctx context.Context, f fs.Fs, opt *httplib.Options) *WebDAV {
 		w._vfs = vfs.New(f, &vfsflags.Opt)
 	}
 	w.Server = httplib.NewServer(http.HandlerFunc(w.handler), opt)
+	// Share the locks with the VFS if there is only one
+	var lockSystem webdav.LockSystem
+	if w._vfs != nil {
+		lockSystem = newLockSystem(w._vfs)
+	} else {
+		lockSystem = webdav.NewMemLS()
+	}
 	webdavHandler := &webdav.Handler{
 		Prefix:     w.Server.Opt.BaseURL,
 		FileSystem: w,
-		LockSystem: webdav.NewMemLS(),
+		LockSystem: lockSystem,
 		Logger:     w.logRequest, // FIXME
 	}
 	w.webdavhandler = webdavHandler
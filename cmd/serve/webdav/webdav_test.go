@@ -79,6 +79,50 @@ func TestWebDav(t *testing.T) {
 	servetest.Run(t, "webdav", start)
 }
 
+// TestMoveThenCopy checks the lock taken for a MOVE doesn't stop the
+// destination being written afterwards
+func TestMoveThenCopy(t *testing.T) {
+	f, err := fs.NewFs(context.Background(), t.TempDir())
+	require.NoError(t, err)
+	opt := httplib.DefaultOpt
+	opt.ListenAddr = testBindAddress
+	w := newWebDAV(context.Background(), f, &opt)
+	require.NoError(t, w.serve())
+	defer func() {
+		w.Close()
+		w.Wait()
+	}()
+	testURL := w.Server.URL()
+
+	do := func(method, path, body string, headers ...string) int {
+		req, err := http.NewRequest(method, testURL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, test := range []struct {
+		method  string
+		path    string
+		headers []string
+	}{
+		{"PUT", "src.txt", nil},
+		{"MOVE", "src.txt", []string{"Destination", testURL + "dst.txt"}},
+		{"PUT", "src.txt", nil},
+		{"COPY", "src.txt", []string{"Destination", testURL + "dst.txt"}},
+		{"PUT", "dst.txt", nil},
+	} {
+		status := do(test.method, test.path, "potato", test.headers...)
+		assert.True(t, status == http.StatusCreated || status == http.StatusNoContent, "%s %s: status %d", test.method, test.path, status)
+	}
+	assert.Empty(t, w._vfs.Locks("dst.txt"))
+}
+
 // Test serve http functionality in serve webdav
 // While similar to http serve, there are some inconsistencies
 // in the handling of some requests such as POST requests
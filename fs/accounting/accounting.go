@@ -62,6 +62,8 @@ type Account struct {
 	withBuf bool          // is using a buffered in
 
 	tokenBucket buckets // per file bandwidth limiter (may be nil)
+	srcFs       string  // name of the remote being read from for per remote limits (may be "")
+	dstFs       string  // name of the remote being written to for per remote limits (may be "")
 
 	values accountValues
 }
@@ -323,8 +325,10 @@ func (acc *Account) accountRead(n int) {
 	acc.values.mu.Unlock()
 
 	acc.stats.Bytes(int64(n))
+	traffic.add(acc.srcFs, acc.dstFs, n)
 
 	TokenBucket.LimitBandwidth(TokenBucketSlotAccounting, n)
+	TokenBucket.LimitTransfer(acc.ci, acc.srcFs, acc.dstFs, acc.stats.group, n)
 	acc.limitPerFileBandwidth(n)
 }
 
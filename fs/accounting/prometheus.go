@@ -21,6 +21,7 @@ type RcloneCollector struct {
 	renames          *prometheus.Desc
 	fatalError       *prometheus.Desc
 	retryError       *prometheus.Desc
+	remoteBytes      *prometheus.Desc
 }
 
 // NewRcloneCollector make a new RcloneCollector
@@ -67,6 +68,10 @@ func NewRcloneCollector(ctx context.Context) *RcloneCollector {
 			"Whether there has been an error that will be retried",
 			nil, nil,
 		),
+		remoteBytes: prometheus.NewDesc(namespace+"remote_bytes_transferred_total",
+			"Total bytes transferred to (up) or from (down) each remote since the start of the Rclone process",
+			[]string{"remote", "direction"}, nil,
+		),
 	}
 }
 
@@ -82,6 +87,7 @@ func (c *RcloneCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.renames
 	ch <- c.fatalError
 	ch <- c.retryError
+	ch <- c.remoteBytes
 }
 
 // Collect is part of the Collector interface: https://godoc.org/github.com/prometheus/client_golang/prometheus#Collector
@@ -101,6 +107,11 @@ func (c *RcloneCollector) Collect(ch chan<- prometheus.Metric) {
 	ch <- prometheus.MustNewConstMetric(c.retryError, prometheus.GaugeValue, bool2Float(s.retryError))
 
 	s.mu.RUnlock()
+
+	traffic.each(func(name string, down, up int64) {
+		ch <- prometheus.MustNewConstMetric(c.remoteBytes, prometheus.CounterValue, float64(down), name, "down")
+		ch <- prometheus.MustNewConstMetric(c.remoteBytes, prometheus.CounterValue, float64(up), name, "up")
+	})
 }
 
 // bool2Float is a small function to convert a boolean into a float64 value that can be used for Prometheus
@@ -0,0 +1,72 @@
+package accounting
+
+import (
+	"sort"
+	"sync"
+)
+
+// remoteTraffic holds the bytes transferred to and from each remote
+// since the start of the process for the metrics.
+type remoteTraffic struct {
+	mu      sync.Mutex
+	remotes map[string]*remoteBytes
+}
+
+// remoteBytes holds the bytes transferred to and from a remote
+type remoteBytes struct {
+	down int64 // bytes read from the remote
+	up   int64 // bytes written to the remote
+}
+
+// traffic is the global bytes transferred per remote
+var traffic remoteTraffic
+
+// add n bytes read from the remote srcFs and written to the remote
+// dstFs. Either may be "" if not known.
+func (rt *remoteTraffic) add(srcFs, dstFs string, n int) {
+	if n <= 0 || (srcFs == "" && dstFs == "") {
+		return
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if srcFs != "" {
+		rt._get(srcFs).down += int64(n)
+	}
+	if dstFs != "" {
+		rt._get(dstFs).up += int64(n)
+	}
+}
+
+// _get the counters for the remote called name making them if necessary
+//
+// Call with lock held
+func (rt *remoteTraffic) _get(name string) *remoteBytes {
+	name = remoteLimitName(name)
+	rb := rt.remotes[name]
+	if rb == nil {
+		if rt.remotes == nil {
+			rt.remotes = make(map[string]*remoteBytes)
+		}
+		rb = &remoteBytes{}
+		rt.remotes[name] = rb
+	}
+	return rb
+}
+
+// each calls fn with the bytes transferred for each remote in name order
+func (rt *remoteTraffic) each(fn func(name string, down, up int64)) {
+	rt.mu.Lock()
+	names := make([]string, 0, len(rt.remotes))
+	for name := range rt.remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	counts := make([]remoteBytes, len(names))
+	for i, name := range names {
+		counts[i] = *rt.remotes[name]
+	}
+	rt.mu.Unlock()
+	for i, name := range names {
+		fn(name, counts[i].down, counts[i].up)
+	}
+}
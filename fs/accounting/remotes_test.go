@@ -0,0 +1,29 @@
+package accounting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteTraffic(t *testing.T) {
+	var rt remoteTraffic
+	rt.add("src", "dst{AbCdE}", 10)
+	rt.add("src", "", 5)
+	rt.add("", "dst", 1)
+	rt.add("", "", 100)
+	rt.add("src", "dst", 0)
+
+	type counts struct {
+		name     string
+		down, up int64
+	}
+	var got []counts
+	rt.each(func(name string, down, up int64) {
+		got = append(got, counts{name, down, up})
+	})
+	assert.Equal(t, []counts{
+		{"dst", 0, 11},
+		{"src", 15, 0},
+	}, got)
+}
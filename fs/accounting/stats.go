@@ -114,6 +114,9 @@ func (s *StatsInfo) RemoteStats() (out rc.Params, err error) {
 	} else {
 		out["eta"] = nil
 	}
+	if s.errors > 0 && s.lastError != nil {
+		out["lastError"] = s.lastError.Error()
+	}
 	s.mu.RUnlock()
 
 	if !s.checking.empty() {
@@ -122,10 +125,6 @@ func (s *StatsInfo) RemoteStats() (out rc.Params, err error) {
 	if !s.transferring.empty() {
 		out["transferring"] = s.transferring.rcStats(s.inProgress)
 	}
-	if s.errors > 0 {
-		out["lastError"] = s.lastError.Error()
-	}
-
 	return out, nil
 }
 
@@ -475,6 +474,11 @@ func (s *StatsInfo) Transferred() []TransferSnapshot {
 	return ts
 }
 
+// Transferring returns snapshots of the transfers in progress
+func (s *StatsInfo) Transferring() []TransferSnapshot {
+	return s.transferring.snapshots()
+}
+
 // Log outputs the StatsInfo to the log
 func (s *StatsInfo) Log() {
 	if s.ci.UseJSONLog {
@@ -697,8 +701,11 @@ func (s *StatsInfo) GetTransfers() int64 {
 }
 
 // NewTransfer adds a transfer to the stats from the object.
-func (s *StatsInfo) NewTransfer(obj fs.DirEntry) *Transfer {
-	tr := newTransfer(s, obj)
+//
+// dstFs is the remote being transferred to, or nil if not known,
+// and is used to apply any per remote bandwidth limits.
+func (s *StatsInfo) NewTransfer(obj fs.DirEntry, dstFs fs.Info) *Transfer {
+	tr := newTransfer(s, obj, dstFs)
 	s.transferring.add(tr)
 	s.startAverageLoop()
 	return tr
@@ -706,7 +713,7 @@ func (s *StatsInfo) NewTransfer(obj fs.DirEntry) *Transfer {
 
 // NewTransferRemoteSize adds a transfer to the stats based on remote and size.
 func (s *StatsInfo) NewTransferRemoteSize(remote string, size int64) *Transfer {
-	tr := newTransferRemoteSize(s, remote, size, false)
+	tr := newTransferRemoteSize(s, remote, size, false, "", "")
 	s.transferring.add(tr)
 	s.startAverageLoop()
 	return tr
@@ -285,6 +285,13 @@ func StatsGroup(ctx context.Context, group string) *StatsInfo {
 	return stats
 }
 
+// StatsGroups returns the names of the stats groups in memory
+func StatsGroups() []string {
+	groups.mu.Lock()
+	defer groups.mu.Unlock()
+	return append([]string(nil), groups.order...)
+}
+
 // GlobalStats returns special stats used for global accounting.
 func GlobalStats() *StatsInfo {
 	return StatsGroup(context.Background(), globalStats)
@@ -422,6 +429,7 @@ func (sg *statsGroups) delete(group string) {
 	stats.ResetErrors()
 	stats.ResetCounters()
 	delete(sg.m, group)
+	TokenBucket.removeGroup(group)
 
 	// Remove group reference from the ordering slice.
 	tmp := sg.order[:0]
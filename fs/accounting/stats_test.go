@@ -1,6 +1,7 @@
 package accounting
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/rclone/rclone/fstest/mockobject"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -435,3 +438,16 @@ func TestPruneTransfers(t *testing.T) {
 		})
 	}
 }
+
+func TestNewTransferRemotes(t *testing.T) {
+	ctx := context.Background()
+	s := NewStats(ctx)
+	dstFs := mockfs.NewFs(ctx, "dst", "root")
+	tr := s.NewTransfer(mockobject.New("file"), dstFs)
+	defer tr.Done(ctx, nil)
+	assert.Equal(t, "", tr.srcFs)
+	assert.Equal(t, "dst", tr.dstFs)
+	acc := tr.Account(ctx, io.NopCloser(bytes.NewBufferString("hello")))
+	assert.Equal(t, "", acc.srcFs)
+	assert.Equal(t, "dst", acc.dstFs)
+}
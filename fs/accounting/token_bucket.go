@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,6 +37,42 @@ type tokenBucket struct {
 	toggledOff  bool
 	currLimitMu sync.Mutex // protects changes to the timeslot
 	currLimit   fs.BwTimeSlot
+	limitsMu    sync.RWMutex             // protects the per remote and per group limits
+	remotes     map[string]*remoteBucket // per remote limits - nil if the remote has no limit
+	groups      map[string]*rate.Limiter // per stats group limits
+}
+
+// remoteBucket holds the limits for a single remote
+type remoteBucket struct {
+	bandwidth fs.BwPair
+	tx        *rate.Limiter // limits data sent to the remote (may be nil)
+	rx        *rate.Limiter // limits data received from the remote (may be nil)
+}
+
+// newRemoteBucket makes a limiter for a remote with the bandwidth
+// given or returns nil if the bandwidth is unlimited.
+func newRemoteBucket(bandwidth fs.BwPair) *remoteBucket {
+	if !bandwidth.IsSet() {
+		return nil
+	}
+	rb := &remoteBucket{bandwidth: bandwidth}
+	if bandwidth.Tx > 0 {
+		rb.tx = newEmptyTokenBucket(bandwidth.Tx)
+	}
+	if bandwidth.Rx > 0 {
+		rb.rx = newEmptyTokenBucket(bandwidth.Rx)
+	}
+	return rb
+}
+
+// remoteLimitName returns the name limits are looked up with for the
+// remote called name. This removes the suffix added to the names of
+// remotes with overridden parameters, e.g. "s3{AbCdE}".
+func remoteLimitName(name string) string {
+	if i := strings.IndexRune(name, '{'); i > 0 {
+		name = name[:i]
+	}
+	return name
 }
 
 // Return true if limit is disabled
@@ -179,6 +216,124 @@ func (tb *tokenBucket) LimitBandwidth(i TokenBucketSlot, n int) {
 	tb.mu.RUnlock()
 }
 
+// remoteLimit returns the limits for the remote called name or nil if
+// it has none.
+//
+// The first time a remote is seen its limit is read from
+// --bwlimit-remote or, failing that, the bwlimit key in the config
+// file section for the remote.
+func (tb *tokenBucket) remoteLimit(ci *fs.ConfigInfo, name string) *remoteBucket {
+	name = remoteLimitName(name)
+	tb.limitsMu.RLock()
+	rb, found := tb.remotes[name]
+	tb.limitsMu.RUnlock()
+	if found {
+		return rb
+	}
+	bandwidth, found := ci.BwLimitRemote[name]
+	if !found {
+		bandwidth = fs.BwPair{Tx: -1, Rx: -1}
+		if value, ok := fs.ConfigFileGet(name, "bwlimit"); ok && value != "" {
+			if err := bandwidth.Set(value); err != nil {
+				fs.Errorf(nil, "Ignoring bad bwlimit %q for remote %q: %v", value, name, err)
+			}
+		}
+	}
+	tb.limitsMu.Lock()
+	defer tb.limitsMu.Unlock()
+	if rb, found = tb.remotes[name]; found {
+		return rb
+	}
+	rb = newRemoteBucket(bandwidth)
+	if rb != nil {
+		fs.Infof(nil, "Limiting bandwidth of remote %q to %v", name, &bandwidth)
+	}
+	if tb.remotes == nil {
+		tb.remotes = make(map[string]*remoteBucket)
+	}
+	tb.remotes[name] = rb
+	return rb
+}
+
+// SetRemoteBwLimit sets the bandwidth limit for the remote called
+// name, overriding any limit from the flags or the config file.
+func (tb *tokenBucket) SetRemoteBwLimit(name string, bandwidth fs.BwPair) {
+	name = remoteLimitName(name)
+	rb := newRemoteBucket(bandwidth)
+	tb.limitsMu.Lock()
+	defer tb.limitsMu.Unlock()
+	if tb.remotes == nil {
+		tb.remotes = make(map[string]*remoteBucket)
+	}
+	tb.remotes[name] = rb
+	if rb != nil {
+		fs.Logf(nil, "Bandwidth limit for remote %q set to %v", name, bandwidth)
+	} else {
+		fs.Logf(nil, "Bandwidth limit for remote %q reset to unlimited", name)
+	}
+}
+
+// SetGroupBwLimit sets the bandwidth limit for all the transfers in
+// the stats group. A bandwidth <= 0 removes the limit.
+func (tb *tokenBucket) SetGroupBwLimit(group string, bandwidth fs.SizeSuffix) {
+	tb.limitsMu.Lock()
+	defer tb.limitsMu.Unlock()
+	if bandwidth > 0 {
+		if tb.groups == nil {
+			tb.groups = make(map[string]*rate.Limiter)
+		}
+		tb.groups[group] = newEmptyTokenBucket(bandwidth)
+		fs.Logf(nil, "Bandwidth limit for group %q set to %v", group, bandwidth)
+	} else if _, found := tb.groups[group]; found {
+		delete(tb.groups, group)
+		fs.Logf(nil, "Bandwidth limit for group %q reset to unlimited", group)
+	}
+}
+
+// groupLimit returns the limit for the stats group or nil if none
+func (tb *tokenBucket) groupLimit(group string) *rate.Limiter {
+	tb.limitsMu.RLock()
+	defer tb.limitsMu.RUnlock()
+	return tb.groups[group]
+}
+
+// removeGroup removes any limit for the stats group as it is being deleted
+func (tb *tokenBucket) removeGroup(group string) {
+	tb.limitsMu.Lock()
+	delete(tb.groups, group)
+	tb.limitsMu.Unlock()
+}
+
+// waitN waits for n tokens from limiter if it isn't nil
+func waitN(limiter *rate.Limiter, n int) {
+	if limiter == nil {
+		return
+	}
+	err := limiter.WaitN(context.Background(), n)
+	if err != nil {
+		fs.Errorf(nil, "Token bucket error: %v", err)
+	}
+}
+
+// LimitTransfer sleeps for the correct amount of time for the passage
+// of n bytes from the remote srcFs to the remote dstFs in the stats
+// group according to the per remote and per group limits.
+//
+// srcFs or dstFs may be "" if not known.
+func (tb *tokenBucket) LimitTransfer(ci *fs.ConfigInfo, srcFs, dstFs, group string, n int) {
+	if srcFs != "" {
+		if rb := tb.remoteLimit(ci, srcFs); rb != nil {
+			waitN(rb.rx, n)
+		}
+	}
+	if dstFs != "" {
+		if rb := tb.remoteLimit(ci, dstFs); rb != nil {
+			waitN(rb.tx, n)
+		}
+	}
+	waitN(tb.groupLimit(group), n)
+}
+
 // SetBwLimit sets the current bandwidth limit
 func (tb *tokenBucket) SetBwLimit(bandwidth fs.BwPair) {
 	tb.mu.Lock()
@@ -194,6 +349,18 @@ func (tb *tokenBucket) SetBwLimit(bandwidth fs.BwPair) {
 
 // read and set the bandwidth limits
 func (tb *tokenBucket) rcBwlimit(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	remote, err := in.GetString("remote")
+	if rc.NotErrParamNotFound(err) {
+		return out, err
+	}
+	group, err := in.GetString("group")
+	if rc.NotErrParamNotFound(err) {
+		return out, err
+	}
+	if remote != "" && group != "" {
+		return out, errors.New("can't set both remote and group")
+	}
+	remote = strings.TrimSuffix(remote, ":")
 	if in["rate"] != nil {
 		bwlimit, err := in.GetString("rate")
 		if err != nil {
@@ -208,7 +375,40 @@ func (tb *tokenBucket) rcBwlimit(ctx context.Context, in rc.Params) (out rc.Para
 			return out, errors.New("need exactly 1 bandwidth setting")
 		}
 		bw := bws[0]
-		tb.SetBwLimit(bw.Bandwidth)
+		switch {
+		case remote != "":
+			tb.SetRemoteBwLimit(remote, bw.Bandwidth)
+		case group != "":
+			if bw.Bandwidth.Tx != bw.Bandwidth.Rx {
+				return out, errors.New("need a single rate for a group bandwidth limit")
+			}
+			tb.SetGroupBwLimit(group, bw.Bandwidth.Tx)
+		default:
+			tb.SetBwLimit(bw.Bandwidth)
+		}
+	}
+	switch {
+	case remote != "":
+		var bp = fs.BwPair{Tx: -1, Rx: -1}
+		if rb := tb.remoteLimit(fs.GetConfig(ctx), remote); rb != nil {
+			bp = rb.bandwidth
+		}
+		return rc.Params{
+			"remote":           remote,
+			"rate":             bp.String(),
+			"bytesPerSecondTx": int64(bp.Tx),
+			"bytesPerSecondRx": int64(bp.Rx),
+		}, nil
+	case group != "":
+		bytesPerSecond := fs.SizeSuffix(-1)
+		if limiter := tb.groupLimit(group); limiter != nil {
+			bytesPerSecond = fs.SizeSuffix(limiter.Limit())
+		}
+		return rc.Params{
+			"group":          group,
+			"rate":           bytesPerSecond.String(),
+			"bytesPerSecond": int64(bytesPerSecond),
+		}, nil
 	}
 	tb.mu.RLock()
 	bytesPerSecond := int64(-1)
@@ -240,6 +440,19 @@ func init() {
 			return TokenBucket.rcBwlimit(ctx, in)
 		},
 		Title: "Set the bandwidth limit.",
+		Params: []rc.Param{
+			{Name: "rate", Type: rc.TypeString, Help: "the bandwidth limit to set, e.g. \"1M\", \"10M:1M\" or \"off\" - if not set the limit is read"},
+			{Name: "remote", Type: rc.TypeString, Help: "set or read the limit for this remote rather than the global limit"},
+			{Name: "group", Type: rc.TypeString, Help: "set or read the limit for this stats group, e.g. \"job/1\", rather than the global limit"},
+		},
+		Returns: []rc.Param{
+			{Name: "rate", Type: rc.TypeString, Required: true, Help: "the bandwidth limit as a human-readable string"},
+			{Name: "bytesPerSecond", Type: rc.TypeInteger, Help: "the bandwidth limit in bytes per second or -1 for unlimited"},
+			{Name: "bytesPerSecondTx", Type: rc.TypeInteger, Help: "the upload bandwidth limit in bytes per second or -1 for unlimited"},
+			{Name: "bytesPerSecondRx", Type: rc.TypeInteger, Help: "the download bandwidth limit in bytes per second or -1 for unlimited"},
+			{Name: "remote", Type: rc.TypeString, Help: "the remote if one was passed in"},
+			{Name: "group", Type: rc.TypeString, Help: "the group if one was passed in"},
+		},
 		Help: `
 This sets the bandwidth limit to the string passed in. This should be
 a single bandwidth limit entry or a pair of upload:download bandwidth.
@@ -284,6 +497,36 @@ except only one bandwidth may be specified.
 
 In either case "rate" is returned as a human-readable string, and
 "bytesPerSecond" is returned as a number.
+
+If the "remote" parameter is supplied then the limit for that remote
+is set or queried instead of the global limit. The upload rate limits
+data sent to the remote and the download rate limits data read from
+it. This overrides any limit set with --bwlimit-remote or in the
+config file.
+
+    rclone rc core/bwlimit remote=s3 rate=1M:off
+    {
+        "bytesPerSecondRx": -1,
+        "bytesPerSecondTx": 1048576,
+        "rate": "1Mi:off",
+        "remote": "s3"
+    }
+
+If the "group" parameter is supplied then the limit for all the
+transfers in that stats group is set or queried. Each rc job runs in
+its own group "job/ID" unless "_group" was set so this can be used to
+limit a single job. Only a single rate may be given for a group.
+
+    rclone rc core/bwlimit group=job/3 rate=500k
+    {
+        "bytesPerSecond": 512000,
+        "group": "job/3",
+        "rate": "500Ki"
+    }
+
+The per remote and per group limits apply as well as the global
+limit, so a transfer runs at the lowest of the limits that apply to
+it.
 `,
 	})
 }
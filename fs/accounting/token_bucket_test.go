@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/rc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -90,3 +91,129 @@ func TestRcBwLimit(t *testing.T) {
 	}, out)
 
 }
+
+func TestRemoteLimitName(t *testing.T) {
+	assert.Equal(t, "s3", remoteLimitName("s3"))
+	assert.Equal(t, "s3", remoteLimitName("s3{AbCdE}"))
+	assert.Equal(t, ":s3", remoteLimitName(":s3"))
+}
+
+func TestRemoteLimit(t *testing.T) {
+	var tb tokenBucket
+	oldConfigFileGet := fs.ConfigFileGet
+	fs.ConfigFileGet = func(section, key string) (string, bool) {
+		if key != "bwlimit" {
+			return "", false
+		}
+		switch section {
+		case "fromconfig", "both":
+			return "2M", true
+		case "bad":
+			return "potato", true
+		}
+		return "", false
+	}
+	defer func() {
+		fs.ConfigFileGet = oldConfigFileGet
+	}()
+	ci := &fs.ConfigInfo{
+		BwLimitRemote: map[string]fs.BwPair{
+			"fromflag": {Tx: 1024 * 1024, Rx: -1},
+			"both":     {Tx: 3 * 1024 * 1024, Rx: 3 * 1024 * 1024},
+		},
+	}
+
+	rb := tb.remoteLimit(ci, "fromflag")
+	require.NotNil(t, rb)
+	assert.Equal(t, rate.Limit(1024*1024), rb.tx.Limit())
+	assert.Nil(t, rb.rx)
+
+	rb = tb.remoteLimit(ci, "fromconfig{AbCdE}")
+	require.NotNil(t, rb)
+	assert.Equal(t, rate.Limit(2*1024*1024), rb.tx.Limit())
+	assert.Equal(t, rate.Limit(2*1024*1024), rb.rx.Limit())
+
+	// The flag takes precedence over the config file
+	rb = tb.remoteLimit(ci, "both")
+	require.NotNil(t, rb)
+	assert.Equal(t, rate.Limit(3*1024*1024), rb.tx.Limit())
+
+	assert.Nil(t, tb.remoteLimit(ci, "nolimit"))
+	assert.Nil(t, tb.remoteLimit(ci, "bad"))
+
+	// Setting the limit overrides the config
+	tb.SetRemoteBwLimit("fromconfig", fs.BwPair{Tx: -1, Rx: -1})
+	assert.Nil(t, tb.remoteLimit(ci, "fromconfig"))
+	tb.SetRemoteBwLimit("nolimit", fs.BwPair{Tx: 1024, Rx: 2048})
+	rb = tb.remoteLimit(ci, "nolimit")
+	require.NotNil(t, rb)
+	assert.Equal(t, rate.Limit(1024), rb.tx.Limit())
+	assert.Equal(t, rate.Limit(2048), rb.rx.Limit())
+}
+
+func TestGroupLimit(t *testing.T) {
+	var tb tokenBucket
+	assert.Nil(t, tb.groupLimit("job/1"))
+	tb.SetGroupBwLimit("job/1", 1024*1024)
+	require.NotNil(t, tb.groupLimit("job/1"))
+	assert.Equal(t, rate.Limit(1024*1024), tb.groupLimit("job/1").Limit())
+	assert.Nil(t, tb.groupLimit("job/2"))
+	tb.SetGroupBwLimit("job/1", -1)
+	assert.Nil(t, tb.groupLimit("job/1"))
+	tb.SetGroupBwLimit("job/1", 1024)
+	tb.removeGroup("job/1")
+	assert.Nil(t, tb.groupLimit("job/1"))
+
+	// Nothing to wait for
+	tb.LimitTransfer(&fs.ConfigInfo{}, "", "", "job/1", 1024)
+}
+
+func TestRcBwLimitRemoteAndGroup(t *testing.T) {
+	call := rc.Calls.Get("core/bwlimit")
+	assert.NotNil(t, call)
+	ctx := context.Background()
+	defer func() {
+		TokenBucket.SetRemoteBwLimit("rcremote", fs.BwPair{Tx: -1, Rx: -1})
+		TokenBucket.SetGroupBwLimit("rcgroup", -1)
+	}()
+
+	// Set remote
+	out, err := call.Fn(ctx, rc.Params{"remote": "rcremote:", "rate": "1M:off"})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"remote":           "rcremote",
+		"bytesPerSecondTx": int64(1048576),
+		"bytesPerSecondRx": int64(-1),
+		"rate":             "1Mi:off",
+	}, out)
+
+	// Query remote
+	out, err = call.Fn(ctx, rc.Params{"remote": "rcremote"})
+	require.NoError(t, err)
+	assert.Equal(t, "1Mi:off", out["rate"])
+
+	// Set group
+	out, err = call.Fn(ctx, rc.Params{"group": "rcgroup", "rate": "500k"})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"group":          "rcgroup",
+		"bytesPerSecond": int64(512000),
+		"rate":           "500Ki",
+	}, out)
+
+	// Query group
+	out, err = call.Fn(ctx, rc.Params{"group": "rcgroup"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(512000), out["bytesPerSecond"])
+
+	// The global limit is unaffected
+	out, err = call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, "off", out["rate"])
+
+	// Errors
+	_, err = call.Fn(ctx, rc.Params{"group": "rcgroup", "rate": "1M:2M"})
+	assert.Error(t, err)
+	_, err = call.Fn(ctx, rc.Params{"group": "rcgroup", "remote": "rcremote"})
+	assert.Error(t, err)
+}
@@ -50,6 +50,8 @@ type Transfer struct {
 	size      int64
 	startedAt time.Time
 	checking  bool
+	srcFs     string // name of the source remote or "" if not known
+	dstFs     string // name of the destination remote or "" if not known
 
 	// Protects all below
 	//
@@ -64,21 +66,35 @@ type Transfer struct {
 
 // newCheckingTransfer instantiates new checking of the object.
 func newCheckingTransfer(stats *StatsInfo, obj fs.DirEntry) *Transfer {
-	return newTransferRemoteSize(stats, obj.Remote(), obj.Size(), true)
+	return newTransferRemoteSize(stats, obj.Remote(), obj.Size(), true, "", "")
 }
 
 // newTransfer instantiates new transfer.
-func newTransfer(stats *StatsInfo, obj fs.DirEntry) *Transfer {
-	return newTransferRemoteSize(stats, obj.Remote(), obj.Size(), false)
+func newTransfer(stats *StatsInfo, obj fs.DirEntry, dstFs fs.Info) *Transfer {
+	var srcFs fs.Info
+	if o, ok := obj.(fs.ObjectInfo); ok {
+		srcFs = o.Fs()
+	}
+	return newTransferRemoteSize(stats, obj.Remote(), obj.Size(), false, fsName(srcFs), fsName(dstFs))
+}
+
+// fsName returns the config name of f or "" if f is nil
+func fsName(f fs.Info) string {
+	if f == nil {
+		return ""
+	}
+	return f.Name()
 }
 
-func newTransferRemoteSize(stats *StatsInfo, remote string, size int64, checking bool) *Transfer {
+func newTransferRemoteSize(stats *StatsInfo, remote string, size int64, checking bool, srcFs, dstFs string) *Transfer {
 	tr := &Transfer{
 		stats:     stats,
 		remote:    remote,
 		size:      size,
 		startedAt: time.Now(),
 		checking:  checking,
+		srcFs:     srcFs,
+		dstFs:     dstFs,
 	}
 	stats.AddTransfer(tr)
 	return tr
@@ -144,6 +160,7 @@ func (tr *Transfer) Account(ctx context.Context, in io.ReadCloser) *Account {
 	tr.mu.Lock()
 	if tr.acc == nil {
 		tr.acc = newAccountSizeName(ctx, tr.stats, in, tr.size, tr.remote)
+		tr.acc.srcFs, tr.acc.dstFs = tr.srcFs, tr.dstFs
 	} else {
 		tr.acc.UpdateReader(ctx, in)
 	}
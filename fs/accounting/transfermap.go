@@ -90,6 +90,18 @@ func (tm *transferMap) _sortedSlice() []*Transfer {
 	return s
 }
 
+// snapshots returns snapshots of the transfers sorted by start time
+func (tm *transferMap) snapshots() []TransferSnapshot {
+	tm.mu.RLock()
+	transfers := tm._sortedSlice()
+	tm.mu.RUnlock()
+	out := make([]TransferSnapshot, 0, len(transfers))
+	for _, tr := range transfers {
+		out = append(out, tr.Snapshot())
+	}
+	return out
+}
+
 // String returns string representation of map items excluding any in
 // exclude (if set).
 func (tm *transferMap) String(ctx context.Context, progress *inProgress, exclude *transferMap) string {
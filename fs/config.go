@@ -95,6 +95,7 @@ type ConfigInfo struct {
 	BufferSize              SizeSuffix
 	BwLimit                 BwTimetable
 	BwLimitFile             BwTimetable
+	BwLimitRemote           map[string]BwPair // per remote bandwidth limits keyed on remote name
 	TPSLimit                float64
 	TPSLimitBurst           int
 	BindAddr                net.IP
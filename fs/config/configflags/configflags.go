@@ -38,6 +38,7 @@ var (
 	downloadHeaders []string
 	headers         []string
 	metadataSet     []string
+	bwLimitRemote   []string
 )
 
 // AddFlags adds the non filing system specific flags to the command
@@ -104,6 +105,7 @@ func AddFlags(ci *fs.ConfigInfo, flagSet *pflag.FlagSet) {
 	flags.FVarP(flagSet, &ci.StatsLogLevel, "stats-log-level", "", "Log level to show --stats output DEBUG|INFO|NOTICE|ERROR")
 	flags.FVarP(flagSet, &ci.BwLimit, "bwlimit", "", "Bandwidth limit in KiB/s, or use suffix B|K|M|G|T|P or a full timetable")
 	flags.FVarP(flagSet, &ci.BwLimitFile, "bwlimit-file", "", "Bandwidth limit per file in KiB/s, or use suffix B|K|M|G|T|P or a full timetable")
+	flags.StringArrayVarP(flagSet, &bwLimitRemote, "bwlimit-remote", "", nil, "Bandwidth limit for a remote in the form remote=rate, e.g. s3=1M or s3=1M:off (can be repeated)")
 	flags.FVarP(flagSet, &ci.BufferSize, "buffer-size", "", "In memory buffer size when reading files for each --transfer")
 	flags.FVarP(flagSet, &ci.StreamingUploadCutoff, "streaming-upload-cutoff", "", "Cutoff for switching to chunked upload if file size is unknown, upload starts after reaching cutoff or when file ends")
 	flags.FVarP(flagSet, &ci.Dump, "dump", "", "List of items to dump from: "+fs.DumpFlagsList)
@@ -278,6 +280,20 @@ func SetFlags(ci *fs.ConfigInfo) {
 	if len(headers) != 0 {
 		ci.Headers = ParseHeaders(headers)
 	}
+	if len(bwLimitRemote) != 0 {
+		ci.BwLimitRemote = make(map[string]fs.BwPair, len(bwLimitRemote))
+		for _, kv := range bwLimitRemote {
+			equal := strings.IndexRune(kv, '=')
+			if equal < 0 {
+				log.Fatalf("Failed to parse '%s' as --bwlimit-remote remote=rate.", kv)
+			}
+			var bw fs.BwPair
+			if err := bw.Set(kv[equal+1:]); err != nil {
+				log.Fatalf("Failed to parse '%s' as --bwlimit-remote remote=rate: %v", kv, err)
+			}
+			ci.BwLimitRemote[strings.TrimSuffix(kv[:equal], ":")] = bw
+		}
+	}
 	if len(metadataSet) != 0 {
 		ci.MetadataSet = make(fs.Metadata, len(metadataSet))
 		for _, kv := range metadataSet {
@@ -10,9 +10,12 @@ import (
 
 func init() {
 	rc.Add(rc.Call{
-		Path:         "config/dump",
-		Fn:           rcDump,
-		Title:        "Dumps the config file.",
+		Path:  "config/dump",
+		Fn:    rcDump,
+		Title: "Dumps the config file.",
+		Returns: []rc.Param{
+			{Name: "<remote>", Type: rc.TypeObject, Help: "the config parameters for each remote keyed by remote name"},
+		},
 		AuthRequired: true,
 		Help: `
 Returns a JSON object:
@@ -35,6 +38,7 @@ func init() {
 		Path:         "config/get",
 		Fn:           rcGet,
 		Title:        "Get a remote in the config file.",
+		Params:       []rc.Param{{Name: "name", Type: rc.TypeString, Required: true, Help: "name of the remote"}},
 		AuthRequired: true,
 		Help: `
 Parameters:
@@ -57,9 +61,12 @@ func rcGet(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 
 func init() {
 	rc.Add(rc.Call{
-		Path:         "config/listremotes",
-		Fn:           rcListRemotes,
-		Title:        "Lists the remotes in the config file.",
+		Path:  "config/listremotes",
+		Fn:    rcListRemotes,
+		Title: "Lists the remotes in the config file.",
+		Returns: []rc.Param{
+			{Name: "remotes", Type: rc.TypeArray, Items: rc.TypeString, Required: true, Help: "array of remote names"},
+		},
 		AuthRequired: true,
 		Help: `
 Returns
@@ -81,9 +88,12 @@ func rcListRemotes(ctx context.Context, in rc.Params) (out rc.Params, err error)
 
 func init() {
 	rc.Add(rc.Call{
-		Path:         "config/providers",
-		Fn:           rcProviders,
-		Title:        "Shows how providers are configured in the config file.",
+		Path:  "config/providers",
+		Fn:    rcProviders,
+		Title: "Shows how providers are configured in the config file.",
+		Returns: []rc.Param{
+			{Name: "providers", Type: rc.TypeArray, Items: rc.TypeObject, Required: true, Help: "the backends and their options"},
+		},
 		AuthRequired: true,
 		Help: `
 Returns a JSON object:
@@ -106,8 +116,13 @@ func init() {
 	for _, name := range []string{"create", "update", "password"} {
 		name := name
 		extraHelp := ""
+		params := []rc.Param{
+			{Name: "name", Type: rc.TypeString, Required: true, Help: "name of the remote"},
+			{Name: "parameters", Type: rc.TypeObject, Required: true, Help: "a map of key value pairs"},
+		}
 		if name == "create" {
 			extraHelp = "- type - type of the new remote\n"
+			params = append(params, rc.Param{Name: "type", Type: rc.TypeString, Required: true, Help: "type of the new remote"})
 		}
 		if name == "create" || name == "update" {
 			extraHelp += `- opt - a dictionary of options to control the configuration
@@ -119,6 +134,7 @@ func init() {
     - state - state to restart with - used with continue
     - result - result to restart with - used with continue
 `
+			params = append(params, rc.Param{Name: "opt", Type: rc.TypeObject, Help: "a dictionary of options to control the configuration"})
 		}
 		rc.Add(rc.Call{
 			Path:         "config/" + name,
@@ -126,7 +142,8 @@ func init() {
 			Fn: func(ctx context.Context, in rc.Params) (rc.Params, error) {
 				return rcConfig(ctx, in, name)
 			},
-			Title: name + " the config for a remote.",
+			Title:  name + " the config for a remote.",
+			Params: params,
 			Help: `This takes the following parameters:
 
 - name - name of remote
@@ -197,6 +214,7 @@ func init() {
 		Path:         "config/delete",
 		Fn:           rcDelete,
 		Title:        "Delete a remote in the config file.",
+		Params:       []rc.Param{{Name: "name", Type: rc.TypeString, Required: true, Help: "name of the remote"}},
 		AuthRequired: true,
 		Help: `
 Parameters:
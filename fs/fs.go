@@ -16,6 +16,9 @@ const (
 	ModTimeNotSupported = 100 * 365 * 24 * time.Hour
 	// MaxLevel is a sentinel representing an infinite depth for listings
 	MaxLevel = math.MaxInt32
+	// LinkSuffix is the suffix added to a symbolic link translated
+	// into a regular file holding the link target
+	LinkSuffix = ".rclonelink"
 )
 
 // Globals
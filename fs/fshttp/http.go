@@ -11,13 +11,17 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httputil"
+	"net/url"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/tracing"
 	"github.com/rclone/rclone/lib/structs"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/publicsuffix"
 )
 
@@ -27,7 +31,7 @@ const (
 )
 
 var (
-	transport    http.RoundTripper
+	transport    *Transport
 	noTransport  = new(sync.Once)
 	cookieJar, _ = cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
 	logMutex     sync.Mutex
@@ -43,6 +47,12 @@ func ResetTransport() {
 // The customize function is called if set to give the caller an opportunity to
 // customize any defaults in the Transport.
 func NewTransportCustom(ctx context.Context, customize func(*http.Transport)) http.RoundTripper {
+	return newTransportCustom(ctx, customize).forRemote(ctx)
+}
+
+// newTransportCustom makes the Transport for NewTransportCustom
+// without labelling it with the remote.
+func newTransportCustom(ctx context.Context, customize func(*http.Transport)) *Transport {
 	ci := fs.GetConfig(ctx)
 	// Start with a sensible set of defaults then override.
 	// This also means we get new stuff when it gets added to go
@@ -116,9 +126,9 @@ func NewTransportCustom(ctx context.Context, customize func(*http.Transport)) ht
 // NewTransport returns an http.RoundTripper with the correct timeouts
 func NewTransport(ctx context.Context) http.RoundTripper {
 	(*noTransport).Do(func() {
-		transport = NewTransportCustom(ctx, nil)
+		transport = newTransportCustom(ctx, nil)
 	})
-	return transport
+	return transport.forRemote(ctx)
 }
 
 // NewClient returns an http.Client with the correct timeouts
@@ -144,6 +154,8 @@ type Transport struct {
 	userAgent     string
 	headers       []*fs.HTTPOption
 	metrics       *Metrics
+	remote        string // name of the remote for metrics, may be ""
+	backend       string // type of the backend for metrics, may be ""
 }
 
 // newTransport wraps the http.Transport passed in and logs all
@@ -158,6 +170,21 @@ func newTransport(ci *fs.ConfigInfo, transport *http.Transport) *Transport {
 	}
 }
 
+// forRemote returns a Transport labelled with the remote that ctx was
+// passed to fs.NewFs for, if any, so requests can be counted per
+// remote.
+//
+// The returned Transport shares the connection pool with t.
+func (t *Transport) forRemote(ctx context.Context) *Transport {
+	remote, backend := fs.RemoteFromContext(ctx)
+	if remote == "" && backend == "" {
+		return t
+	}
+	newT := *t
+	newT.remote, newT.backend = remote, backend
+	return &newT
+}
+
 // SetRequestFilter sets a filter to be used on each request
 func (t *Transport) SetRequestFilter(f func(req *http.Request)) {
 	t.filterRequest = f
@@ -243,8 +270,31 @@ func cleanAuths(buf []byte) []byte {
 	return buf
 }
 
+// traceURL returns u without any query or user info for use in a
+// trace, as these often contain secrets such as signatures.
+func traceURL(u *url.URL) string {
+	return (&url.URL{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   u.Path,
+	}).String()
+}
+
 // RoundTrip implements the RoundTripper interface.
 func (t *Transport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	// Trace the request if required
+	ctx, span := tracing.StartSpan(req.Context(), "HTTP "+req.Method,
+		semconv.HTTPMethodKey.String(req.Method),
+		semconv.HTTPURLKey.String(traceURL(req.URL)),
+	)
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(resp.StatusCode, trace.SpanKindClient))
+		}
+		tracing.End(span, err)
+	}()
+	req = req.WithContext(ctx)
 	// Limit transactions per second if required
 	accounting.LimitTPS(req.Context())
 	// Force user agent
@@ -271,7 +321,9 @@ func (t *Transport) RoundTrip(req *http.Request) (resp *http.Response, err error
 		logMutex.Unlock()
 	}
 	// Do round trip
+	start := time.Now()
 	resp, err = t.Transport.RoundTrip(req)
+	duration := time.Since(start)
 	// Logf response
 	if t.dump&(fs.DumpHeaders|fs.DumpBodies|fs.DumpAuth|fs.DumpRequests|fs.DumpResponses) != 0 {
 		logMutex.Lock()
@@ -287,7 +339,7 @@ func (t *Transport) RoundTrip(req *http.Request) (resp *http.Response, err error
 		logMutex.Unlock()
 	}
 	// Update metrics
-	t.metrics.onResponse(req, resp)
+	t.metrics.onResponse(t, req, resp, duration)
 
 	if err == nil {
 		checkServerTime(req, resp)
@@ -0,0 +1,50 @@
+package fshttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	m := NewMetrics("test")
+	oldMetrics := DefaultMetrics
+	DefaultMetrics = m
+	ResetTransport()
+	defer func() {
+		DefaultMetrics = oldMetrics
+		ResetTransport()
+	}()
+
+	get := func(ctx context.Context, path string) {
+		resp, err := NewClient(ctx).Get(server.URL + path)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+	get(ctx, "/")
+	get(fs.WithRemote(ctx, "remote", "s3"), "/")
+	get(fs.WithRemote(ctx, "remote", "s3"), "/missing")
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("", "", "GET", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("remote", "s3", "GET", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("remote", "s3", "GET", "404")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.Duration))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.StatusCode))
+
+	// The shared transport mustn't pick up the labels
+	assert.Equal(t, "", transport.remote)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
@@ -92,6 +93,43 @@ var LogPrint = func(level LogLevel, text string) {
 	_ = log.Output(4, text)
 }
 
+// logObservers are called with every log line
+var logObservers struct {
+	mu     sync.RWMutex
+	fns    map[int]func(LogLevel, string)
+	nextID int
+}
+
+// ObserveLog calls fn with the level and text of every log line made
+// with LogPrintf as well as logging it. It returns a function to stop
+// observing.
+//
+// fn is called synchronously so it should not block or log.
+func ObserveLog(fn func(level LogLevel, text string)) (stop func()) {
+	logObservers.mu.Lock()
+	defer logObservers.mu.Unlock()
+	if logObservers.fns == nil {
+		logObservers.fns = map[int]func(LogLevel, string){}
+	}
+	id := logObservers.nextID
+	logObservers.nextID++
+	logObservers.fns[id] = fn
+	return func() {
+		logObservers.mu.Lock()
+		delete(logObservers.fns, id)
+		logObservers.mu.Unlock()
+	}
+}
+
+// notifyLog sends the log line to the observers
+func notifyLog(level LogLevel, text string) {
+	logObservers.mu.RLock()
+	defer logObservers.mu.RUnlock()
+	for _, fn := range logObservers.fns {
+		fn(level, text)
+	}
+}
+
 // LogValueItem describes keyed item for a JSON log entry
 type LogValueItem struct {
 	key    string
@@ -134,6 +172,11 @@ func (j LogValueItem) String() string {
 // LogPrintf produces a log string from the arguments passed in
 func LogPrintf(level LogLevel, o interface{}, text string, args ...interface{}) {
 	out := fmt.Sprintf(text, args...)
+	line := out
+	if o != nil {
+		line = fmt.Sprintf("%v: %s", o, out)
+	}
+	notifyLog(level, line)
 
 	if GetConfig(context.TODO()).UseJSONLog {
 		fields := logrus.Fields{}
@@ -163,10 +206,7 @@ func LogPrintf(level LogLevel, o interface{}, text string, args ...interface{})
 			logrus.WithFields(fields).Panic(out)
 		}
 	} else {
-		if o != nil {
-			out = fmt.Sprintf("%v: %s", o, out)
-		}
-		LogPrint(level, out)
+		LogPrint(level, line)
 	}
 }
 
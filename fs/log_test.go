@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -90,3 +91,34 @@ func TestLogLevelUnmarshalJSON(t *testing.T) {
 		assert.Equal(t, test.want, logLevel, test.in)
 	}
 }
+
+func TestObserveLog(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+	stop := ObserveLog(func(level LogLevel, text string) {
+		mu.Lock()
+		lines = append(lines, fmt.Sprintf("%v: %s", level, text))
+		mu.Unlock()
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			LogPrintf(LogLevelDebug, withString{}, "potato %d", i)
+		}(i)
+	}
+	wg.Wait()
+	stop()
+	LogPrintf(LogLevelDebug, nil, "not observed")
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch(t, []string{
+		"DEBUG: hello: potato 0",
+		"DEBUG: hello: potato 1",
+		"DEBUG: hello: potato 2",
+		"DEBUG: hello: potato 3",
+	}, lines)
+}
@@ -13,7 +13,9 @@ import (
 	"github.com/rclone/rclone/fs/dirtree"
 	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/list"
+	"github.com/rclone/rclone/fs/tracing"
 	"github.com/rclone/rclone/fs/walk"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/text/unicode/norm"
 )
 
@@ -32,8 +34,9 @@ type March struct {
 	NoCheckDest            bool            // transfer all objects regardless without checking dst
 	NoUnicodeNormalization bool            // don't normalize unicode characters in filenames
 	// internal state
-	srcListDir listDirFn // function to call to list a directory in the src
-	dstListDir listDirFn // function to call to list a directory in the dst
+	listCtx    context.Context // context for listings which carries the trace span for Run
+	srcListDir listDirFn       // function to call to list a directory in the src
+	dstListDir listDirFn       // function to call to list a directory in the dst
 	transforms []matchTransformFn
 }
 
@@ -83,7 +86,14 @@ func (m *March) makeListDir(ctx context.Context, f fs.Fs, includeAll bool) listD
 	if !(ci.UseListR && f.Features().ListR != nil) && // !--fast-list active and
 		!(ci.NoTraverse && fi.HaveFilesFrom()) { // !(--files-from and --no-traverse)
 		return func(dir string) (entries fs.DirEntries, err error) {
-			dirCtx := filter.SetUseFilter(m.Ctx, f.Features().FilterAware && !includeAll) // make filter-aware backends constrain List
+			listCtx, span := tracing.StartSpan(m.listCtx, "march.list",
+				tracing.KeyRemote.String(tracing.FsString(f)),
+				tracing.KeyDir.String(dir),
+			)
+			defer func() {
+				endListSpan(span, entries, err)
+			}()
+			dirCtx := filter.SetUseFilter(listCtx, f.Features().FilterAware && !includeAll) // make filter-aware backends constrain List
 			return list.DirSorted(dirCtx, f, includeAll, dir)
 		}
 	}
@@ -100,8 +110,14 @@ func (m *March) makeListDir(ctx context.Context, f fs.Fs, includeAll bool) listD
 		mu.Lock()
 		defer mu.Unlock()
 		if !started {
-			dirCtx := filter.SetUseFilter(m.Ctx, f.Features().FilterAware && !includeAll) // make filter-aware backends constrain List
+			listCtx, span := tracing.StartSpan(m.listCtx, "march.listR",
+				tracing.KeyRemote.String(tracing.FsString(f)),
+				tracing.KeyDir.String(m.Dir),
+			)
+			dirCtx := filter.SetUseFilter(listCtx, f.Features().FilterAware && !includeAll) // make filter-aware backends constrain List
 			dirs, dirsErr = walk.NewDirTree(dirCtx, f, m.Dir, includeAll, ci.MaxDepth)
+			span.SetAttributes(tracing.KeyEntries.Int(len(dirs)))
+			tracing.End(span, dirsErr)
 			started = true
 		}
 		if dirsErr != nil {
@@ -117,6 +133,16 @@ func (m *March) makeListDir(ctx context.Context, f fs.Fs, includeAll bool) listD
 	}
 }
 
+// endListSpan ends the span for a directory listing. Missing
+// directories aren't marked as errors as they are expected.
+func endListSpan(span trace.Span, entries fs.DirEntries, err error) {
+	span.SetAttributes(tracing.KeyEntries.Int(len(entries)))
+	if err == fs.ErrorDirNotFound {
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
 // listDirJob describe a directory listing that needs to be done
 type listDirJob struct {
 	srcRemote string
@@ -128,9 +154,18 @@ type listDirJob struct {
 }
 
 // Run starts the matching process off
-func (m *March) Run(ctx context.Context) error {
+func (m *March) Run(ctx context.Context) (err error) {
 	ci := fs.GetConfig(ctx)
 	fi := filter.GetConfig(ctx)
+	listCtx, span := tracing.StartSpan(m.Ctx, "march.Run",
+		tracing.KeySrc.String(tracing.FsString(m.Fsrc)),
+		tracing.KeyDst.String(tracing.FsString(m.Fdst)),
+		tracing.KeyDir.String(m.Dir),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+	m.listCtx = listCtx
 	m.init(ctx)
 
 	srcDepth := ci.MaxDepth
@@ -32,6 +32,7 @@ func NewFs(ctx context.Context, path string) (Fs, error) {
 	if err != nil {
 		return nil, err
 	}
+	ctx = WithRemote(ctx, configName, fsInfo.Name)
 	overridden := fsInfo.Options.Overridden(config)
 	if len(overridden) > 0 {
 		extraConfig := overridden.String()
@@ -57,6 +58,36 @@ func NewFs(ctx context.Context, path string) (Fs, error) {
 	return f, err
 }
 
+// remoteKeyType is the type of the key used to store remoteInfo in a context
+type remoteKeyType struct{}
+
+// remoteKey is the key used to store remoteInfo in a context
+var remoteKey remoteKeyType
+
+// remoteInfo describes the remote a backend is being made for
+type remoteInfo struct {
+	remote  string // name of the remote, e.g. "drive"
+	backend string // type of the backend, e.g. "s3"
+}
+
+// WithRemote returns a copy of ctx noting that it is being used to
+// make a backend of type backend for the remote called remote.
+func WithRemote(ctx context.Context, remote, backend string) context.Context {
+	return context.WithValue(ctx, remoteKey, remoteInfo{remote: remote, backend: backend})
+}
+
+// RemoteFromContext returns the name of the remote and the type of
+// the backend that ctx was passed to NewFs for.
+//
+// This is used to label metrics with the remote they are for. It
+// returns empty strings if not known.
+func RemoteFromContext(ctx context.Context) (remote, backend string) {
+	if info, ok := ctx.Value(remoteKey).(remoteInfo); ok {
+		return info.remote, info.backend
+	}
+	return "", ""
+}
+
 // ConfigFs makes the config for calling NewFs with.
 //
 // It parses the path which is of the form remote:path
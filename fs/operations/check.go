@@ -34,15 +34,17 @@ type checkFn func(ctx context.Context, a, b fs.Object) (differ bool, noHash bool
 
 // CheckOpt contains options for the Check functions
 type CheckOpt struct {
-	Fdst, Fsrc   fs.Fs     // fses to check
-	Check        checkFn   // function to use for checking
-	OneWay       bool      // one way only?
-	Combined     io.Writer // a file with file names with leading sigils
-	MissingOnSrc io.Writer // files only in the destination
-	MissingOnDst io.Writer // files only in the source
-	Match        io.Writer // matching files
-	Differ       io.Writer // differing files
-	Error        io.Writer // files with errors of some kind
+	Fdst, Fsrc   fs.Fs        // fses to check
+	Check        checkFn      // function to use for checking
+	OneWay       bool         // one way only?
+	Combined     io.Writer    // a file with file names with leading sigils
+	MissingOnSrc io.Writer    // files only in the destination
+	MissingOnDst io.Writer    // files only in the source
+	Match        io.Writer    // matching files
+	Differ       io.Writer    // differing files
+	Error        io.Writer    // files with errors of some kind
+	Report       *CheckReport // if set, collects a machine readable report of each file
+	Sample       *CheckSample // if set, only check a sample of the files in both
 }
 
 // checkMarch is used to march over two Fses in the same way as
@@ -57,6 +59,8 @@ type checkMarch struct {
 	dstFilesMissing int32
 	matches         int32
 	opt             CheckOpt
+	ctx             context.Context
+	sample          *checkSampler
 }
 
 // report outputs the fileName to out if required and to the combined log
@@ -73,6 +77,37 @@ func (c *checkMarch) reportFilename(filename string, out io.Writer, sigil rune)
 	}
 }
 
+// newResult starts a CheckReport entry if a report is being made
+func (c *checkMarch) newResult(remote string, src, dst fs.DirEntry) *CheckResult {
+	if c.opt.Report == nil {
+		return nil
+	}
+	res := &CheckResult{
+		Path: remote,
+	}
+	if src != nil {
+		res.Src = newCheckFileInfo(c.ctx, src)
+	}
+	if dst != nil {
+		res.Dst = newCheckFileInfo(c.ctx, dst)
+	}
+	return res
+}
+
+// addResult completes a CheckReport entry
+func (c *checkMarch) addResult(res *CheckResult, status string, err error, noHash bool) {
+	if res == nil {
+		return
+	}
+	res.Status = status
+	if err != nil {
+		res.Error = err.Error()
+	} else if status == CheckStatusDiffer && res.Error == "" {
+		res.Error = "files differ"
+	}
+	c.opt.Report.add(res, noHash)
+}
+
 // DstOnly have an object which is in the destination only
 func (c *checkMarch) DstOnly(dst fs.DirEntry) (recurse bool) {
 	switch dst.(type) {
@@ -86,6 +121,7 @@ func (c *checkMarch) DstOnly(dst fs.DirEntry) (recurse bool) {
 		atomic.AddInt32(&c.differences, 1)
 		atomic.AddInt32(&c.srcFilesMissing, 1)
 		c.report(dst, c.opt.MissingOnSrc, '-')
+		c.addResult(c.newResult(dst.Remote(), nil, dst), CheckStatusMissingOnSrc, err, false)
 	case fs.Directory:
 		// Do the same thing to the entire contents of the directory
 		if c.opt.OneWay {
@@ -108,6 +144,7 @@ func (c *checkMarch) SrcOnly(src fs.DirEntry) (recurse bool) {
 		atomic.AddInt32(&c.differences, 1)
 		atomic.AddInt32(&c.dstFilesMissing, 1)
 		c.report(src, c.opt.MissingOnDst, '+')
+		c.addResult(c.newResult(src.Remote(), src, nil), CheckStatusMissingOnDst, err, false)
 	case fs.Directory:
 		// Do the same thing to the entire contents of the directory
 		return true
@@ -124,9 +161,11 @@ func (c *checkMarch) checkIdentical(ctx context.Context, dst, src fs.Object) (di
 	defer func() {
 		tr.Done(ctx, err)
 	}()
+	checkRecordCompared(ctx, "size")
 	if sizeDiffers(ctx, src, dst) {
 		err = fmt.Errorf("sizes differ")
 		fs.Errorf(src, "%v", err)
+		checkRecordReason(ctx, err)
 		return true, false, nil
 	}
 	if ci.SizeOnly {
@@ -135,6 +174,47 @@ func (c *checkMarch) checkIdentical(ctx context.Context, dst, src fs.Object) (di
 	return c.opt.Check(ctx, dst, src)
 }
 
+// checkPair checks dst and src are identical in the background and
+// reports the result
+func (c *checkMarch) checkPair(ctx context.Context, dst, src fs.Object) {
+	c.wg.Add(1)
+	c.tokens <- struct{}{} // put a token to limit concurrency
+	go func() {
+		defer func() {
+			<-c.tokens // get the token back to free up a slot
+			c.wg.Done()
+		}()
+		res := c.newResult(src.Remote(), src, dst)
+		differ, noHash, err := c.checkIdentical(withCheckResult(ctx, res), dst, src)
+		if c.sample != nil {
+			c.sample.checked(dst.Remote(), err == nil && !differ)
+		}
+		if err != nil {
+			fs.Errorf(src, "%v", err)
+			_ = fs.CountError(err)
+			c.report(src, c.opt.Error, '!')
+			c.addResult(res, CheckStatusError, err, false)
+		} else if differ {
+			atomic.AddInt32(&c.differences, 1)
+			err := errors.New("files differ")
+			// the checkFn has already logged the reason
+			_ = fs.CountError(err)
+			c.report(src, c.opt.Differ, '*')
+			c.addResult(res, CheckStatusDiffer, nil, false)
+		} else {
+			atomic.AddInt32(&c.matches, 1)
+			c.report(src, c.opt.Match, '=')
+			c.addResult(res, CheckStatusMatch, nil, noHash)
+			if noHash {
+				atomic.AddInt32(&c.noHashes, 1)
+				fs.Debugf(dst, "OK - could not check hash")
+			} else {
+				fs.Debugf(dst, "OK")
+			}
+		}
+	}()
+}
+
 // Match is called when src and dst are present, so sync src to dst
 func (c *checkMarch) Match(ctx context.Context, dst, src fs.DirEntry) (recurse bool) {
 	switch srcX := src.(type) {
@@ -144,35 +224,11 @@ func (c *checkMarch) Match(ctx context.Context, dst, src fs.DirEntry) (recurse b
 			if SkipDestructive(ctx, src, "check") {
 				return false
 			}
-			c.wg.Add(1)
-			c.tokens <- struct{}{} // put a token to limit concurrency
-			go func() {
-				defer func() {
-					<-c.tokens // get the token back to free up a slot
-					c.wg.Done()
-				}()
-				differ, noHash, err := c.checkIdentical(ctx, dstX, srcX)
-				if err != nil {
-					fs.Errorf(src, "%v", err)
-					_ = fs.CountError(err)
-					c.report(src, c.opt.Error, '!')
-				} else if differ {
-					atomic.AddInt32(&c.differences, 1)
-					err := errors.New("files differ")
-					// the checkFn has already logged the reason
-					_ = fs.CountError(err)
-					c.report(src, c.opt.Differ, '*')
-				} else {
-					atomic.AddInt32(&c.matches, 1)
-					c.report(src, c.opt.Match, '=')
-					if noHash {
-						atomic.AddInt32(&c.noHashes, 1)
-						fs.Debugf(dstX, "OK - could not check hash")
-					} else {
-						fs.Debugf(dstX, "OK")
-					}
-				}
-			}()
+			if c.sample != nil {
+				c.sample.add(ctx, dstX, srcX)
+				return false
+			}
+			c.checkPair(ctx, dstX, srcX)
 		} else {
 			err := fmt.Errorf("is file on %v but directory on %v", c.opt.Fsrc, c.opt.Fdst)
 			fs.Errorf(src, "%v", err)
@@ -180,6 +236,7 @@ func (c *checkMarch) Match(ctx context.Context, dst, src fs.DirEntry) (recurse b
 			atomic.AddInt32(&c.differences, 1)
 			atomic.AddInt32(&c.dstFilesMissing, 1)
 			c.report(src, c.opt.MissingOnDst, '+')
+			c.addResult(c.newResult(src.Remote(), src, nil), CheckStatusMissingOnDst, err, false)
 		}
 	case fs.Directory:
 		// Do the same thing to the entire contents of the directory
@@ -193,6 +250,7 @@ func (c *checkMarch) Match(ctx context.Context, dst, src fs.DirEntry) (recurse b
 		atomic.AddInt32(&c.differences, 1)
 		atomic.AddInt32(&c.srcFilesMissing, 1)
 		c.report(dst, c.opt.MissingOnSrc, '-')
+		c.addResult(c.newResult(dst.Remote(), nil, dst), CheckStatusMissingOnSrc, err, false)
 
 	default:
 		panic("Bad object in DirEntries")
@@ -215,6 +273,10 @@ func CheckFn(ctx context.Context, opt *CheckOpt) error {
 	c := &checkMarch{
 		tokens: make(chan struct{}, ci.Checkers),
 		opt:    *opt,
+		ctx:    ctx,
+	}
+	if c.opt.Report != nil {
+		c.opt.Report.begin(fs.ConfigString(c.opt.Fsrc), fs.ConfigString(c.opt.Fdst))
 	}
 
 	// set up a march over fdst and fsrc
@@ -227,14 +289,47 @@ func CheckFn(ctx context.Context, opt *CheckOpt) error {
 		NoTraverse:             ci.NoTraverse,
 		NoUnicodeNormalization: ci.NoUnicodeNormalization,
 	}
+	if c.opt.Sample != nil {
+		var err error
+		c.sample, err = newCheckSampler(ctx, *c.opt.Sample, c.opt.Fdst)
+		if err != nil {
+			return err
+		}
+	}
 	fs.Debugf(c.opt.Fdst, "Waiting for checks to finish")
 	err := m.Run(ctx)
+	if c.sample != nil {
+		sample := c.sample.choose()
+		if err == nil {
+			for _, item := range sample {
+				c.checkPair(ctx, item.dst, item.src)
+			}
+		}
+		c.wg.Wait()
+		stats := c.sample.finish(sample)
+		c.reportSample(stats)
+	}
 	c.wg.Wait() // wait for background go-routines
 
 	return c.reportResults(ctx, err)
 }
 
+// reportSample logs the statistics for a sampled check
+func (c *checkMarch) reportSample(stats CheckSampleStats) {
+	if c.opt.Report != nil {
+		c.opt.Report.mu.Lock()
+		c.opt.Report.Sample = &stats
+		c.opt.Report.mu.Unlock()
+	}
+	fs.Logf(c.opt.Fdst, "Sampled %d of %d files (%v of %v) with seed %d", stats.SampledFiles, stats.Files, fs.SizeSuffix(stats.SampledBytes), fs.SizeSuffix(stats.Bytes), stats.Seed)
+	fs.Logf(c.opt.Fdst, "Coverage: %.1f%% of files and %.1f%% of bytes checked OK in this or previous runs", stats.CoverageFiles, stats.CoverageBytes)
+	fs.Logf(c.opt.Fdst, "Confidence: %d sampled files failed (%.2f%%) so 95%% confident fewer than %.2f%% of all files would fail", stats.Failed, stats.FailureRate, stats.FailureRateMax)
+}
+
 func (c *checkMarch) reportResults(ctx context.Context, err error) error {
+	if c.opt.Report != nil {
+		c.opt.Report.end()
+	}
 	if c.dstFilesMissing > 0 {
 		fs.Logf(c.opt.Fdst, "%d files missing", c.dstFilesMissing)
 	}
@@ -272,16 +367,22 @@ func (c *checkMarch) reportResults(ctx context.Context, err error) error {
 func Check(ctx context.Context, opt *CheckOpt) error {
 	optCopy := *opt
 	optCopy.Check = func(ctx context.Context, dst, src fs.Object) (differ bool, noHash bool, err error) {
-		same, ht, err := CheckHashes(ctx, src, dst)
+		common := src.Fs().Hashes().Overlap(dst.Fs().Hashes())
+		if common.Count() == 0 {
+			return false, true, nil
+		}
+		same, ht, srcHash, dstHash, err := checkHashes(ctx, src, dst, common.GetOne())
 		if err != nil {
 			return true, false, err
 		}
 		if ht == hash.None {
 			return false, true, nil
 		}
+		CheckRecordHashes(ctx, ht, srcHash, dstHash)
 		if !same {
 			err = fmt.Errorf("%v differ", ht)
 			fs.Errorf(src, "%v", err)
+			checkRecordReason(ctx, err)
 			return true, false, nil
 		}
 		return false, false, nil
@@ -339,7 +440,7 @@ func checkIdenticalDownload(ctx context.Context, dst, src fs.Object) (differ boo
 	if err != nil {
 		return true, fmt.Errorf("failed to open %q: %w", dst, err)
 	}
-	tr1 := accounting.Stats(ctx).NewTransfer(dst)
+	tr1 := accounting.Stats(ctx).NewTransfer(dst, nil)
 	defer func() {
 		tr1.Done(ctx, nil) // error handling is done by the caller
 	}()
@@ -349,7 +450,7 @@ func checkIdenticalDownload(ctx context.Context, dst, src fs.Object) (differ boo
 	if err != nil {
 		return true, fmt.Errorf("failed to open %q: %w", src, err)
 	}
-	tr2 := accounting.Stats(ctx).NewTransfer(dst)
+	tr2 := accounting.Stats(ctx).NewTransfer(dst, nil)
 	defer func() {
 		tr2.Done(ctx, nil) // error handling is done by the caller
 	}()
@@ -365,6 +466,7 @@ func checkIdenticalDownload(ctx context.Context, dst, src fs.Object) (differ boo
 func CheckDownload(ctx context.Context, opt *CheckOpt) error {
 	optCopy := *opt
 	optCopy.Check = func(ctx context.Context, a, b fs.Object) (differ bool, noHash bool, err error) {
+		checkRecordCompared(ctx, "content")
 		differ, err = CheckIdenticalDownload(ctx, a, b)
 		if err != nil {
 			return true, true, fmt.Errorf("failed to download: %w", err)
@@ -408,6 +510,10 @@ func CheckSum(ctx context.Context, fsrc, fsum fs.Fs, sumFile string, hashType ha
 	c := &checkMarch{
 		tokens: make(chan struct{}, ci.Checkers),
 		opt:    *opt,
+		ctx:    ctx,
+	}
+	if c.opt.Report != nil {
+		c.opt.Report.begin(fs.ConfigString(fsum)+"/"+sumFile, fs.ConfigString(c.opt.Fdst))
 	}
 	lastErr := ListFn(ctx, opt.Fdst, func(obj fs.Object) {
 		c.checkSum(ctx, obj, download, hashes, hashType)
@@ -432,6 +538,11 @@ func CheckSum(ctx context.Context, fsrc, fsum fs.Fs, sumFile string, hashType ha
 		}
 		atomic.AddInt32(&c.dstFilesMissing, 1)
 		c.reportFilename(filename, opt.MissingOnDst, '+')
+		if res := c.newResult(filename, nil, nil); res != nil {
+			res.Src = &CheckFileInfo{Size: -1}
+			res.setHashes(hashType, hash, "")
+			c.addResult(res, CheckStatusMissingOnDst, err, false)
+		}
 	}
 
 	return c.reportResults(ctx, lastErr)
@@ -460,13 +571,19 @@ func (c *checkMarch) checkSum(ctx context.Context, obj fs.Object, download bool,
 		atomic.AddInt32(&c.differences, 1)
 		atomic.AddInt32(&c.srcFilesMissing, 1)
 		c.report(obj, c.opt.MissingOnSrc, '-')
+		c.addResult(c.newResult(remote, nil, obj), CheckStatusMissingOnSrc, err, false)
 		return
 	}
 
+	res := c.newResult(remote, nil, obj)
+	if res != nil {
+		res.Src = &CheckFileInfo{Size: -1}
+	}
+
 	if !download {
 		var objHash string
 		objHash, err = obj.Hash(ctx, hashType)
-		c.matchSum(ctx, sumHash, objHash, obj, err, hashType)
+		c.matchSum(ctx, sumHash, objHash, obj, err, hashType, res)
 		return
 	}
 
@@ -479,14 +596,14 @@ func (c *checkMarch) checkSum(ctx context.Context, obj fs.Object, download bool,
 			in      io.ReadCloser
 		)
 		defer func() {
-			c.matchSum(ctx, sumHash, objHash, obj, err, hashType)
+			c.matchSum(ctx, sumHash, objHash, obj, err, hashType, res)
 			<-c.tokens // get the token back to free up a slot
 			c.wg.Done()
 		}()
 		if in, err = obj.Open(ctx); err != nil {
 			return
 		}
-		tr := accounting.Stats(ctx).NewTransfer(obj)
+		tr := accounting.Stats(ctx).NewTransfer(obj, nil)
 		in = tr.Account(ctx, in).WithBuffer() // account and buffer the transfer
 		defer func() {
 			tr.Done(ctx, nil) // will close the stream
@@ -501,27 +618,34 @@ func (c *checkMarch) checkSum(ctx context.Context, obj fs.Object, download bool,
 }
 
 // matchSum sums up the results of hashsum matching for an object
-func (c *checkMarch) matchSum(ctx context.Context, sumHash, objHash string, obj fs.Object, err error, hashType hash.Type) {
+func (c *checkMarch) matchSum(ctx context.Context, sumHash, objHash string, obj fs.Object, err error, hashType hash.Type, res *CheckResult) {
+	if res != nil {
+		res.setHashes(hashType, sumHash, objHash)
+	}
 	switch {
 	case err != nil:
 		_ = fs.CountError(err)
 		fs.Errorf(obj, "Failed to calculate hash: %v", err)
 		c.report(obj, c.opt.Error, '!')
+		c.addResult(res, CheckStatusError, err, false)
 	case sumHash == "":
 		err = errors.New("duplicate file")
 		_ = fs.CountError(err)
 		fs.Errorf(obj, "%v", err)
 		c.report(obj, c.opt.Error, '!')
+		c.addResult(res, CheckStatusError, err, false)
 	case objHash == "":
 		fs.Debugf(nil, "%v = %s (sum)", hashType, sumHash)
 		fs.Debugf(obj, "%v - could not check hash (%v)", hashType, c.opt.Fdst)
 		atomic.AddInt32(&c.noHashes, 1)
 		atomic.AddInt32(&c.matches, 1)
 		c.report(obj, c.opt.Match, '=')
+		c.addResult(res, CheckStatusMatch, nil, true)
 	case objHash == sumHash:
 		fs.Debugf(obj, "%v = %s OK", hashType, sumHash)
 		atomic.AddInt32(&c.matches, 1)
 		c.report(obj, c.opt.Match, '=')
+		c.addResult(res, CheckStatusMatch, nil, false)
 	default:
 		err = errors.New("files differ")
 		_ = fs.CountError(err)
@@ -530,6 +654,7 @@ func (c *checkMarch) matchSum(ctx context.Context, sumHash, objHash string, obj
 		fs.Errorf(obj, "%v", err)
 		atomic.AddInt32(&c.differences, 1)
 		c.report(obj, c.opt.Differ, '*')
+		c.addResult(res, CheckStatusDiffer, err, false)
 	}
 }
 
@@ -0,0 +1,286 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// Check statuses used in the CheckReport
+const (
+	CheckStatusMatch        = "match"          // found in source and destination and identical
+	CheckStatusDiffer       = "differ"         // found in source and destination but different
+	CheckStatusMissingOnSrc = "missing_on_src" // only in the destination
+	CheckStatusMissingOnDst = "missing_on_dst" // only in the source
+	CheckStatusError        = "error"          // error reading or hashing the source or dest
+)
+
+// CheckReportFormats are the formats CheckReport can be written in
+var CheckReportFormats = []string{"json", "html"}
+
+// CheckFileInfo describes one side of a checked file
+type CheckFileInfo struct {
+	Size    int64             `json:"size"`              // size or -1 if not known
+	ModTime string            `json:"modTime,omitempty"` // modification time in RFC3339 format
+	Hashes  map[string]string `json:"hashes,omitempty"`  // hash values compared, keyed by hash name
+}
+
+// CheckResult describes the result of checking a single file
+type CheckResult struct {
+	Path     string         `json:"path"`
+	Status   string         `json:"status"`
+	Src      *CheckFileInfo `json:"src,omitempty"`      // nil if missing on the source
+	Dst      *CheckFileInfo `json:"dst,omitempty"`      // nil if missing on the destination
+	Compared []string       `json:"compared,omitempty"` // what was compared: "size", hash names or "content"
+	Error    string         `json:"error,omitempty"`
+	mu       sync.Mutex
+}
+
+// CheckSummary counts the check results by status
+type CheckSummary struct {
+	Total        int `json:"total"`
+	Match        int `json:"match"`
+	Differ       int `json:"differ"`
+	MissingOnSrc int `json:"missingOnSrc"`
+	MissingOnDst int `json:"missingOnDst"`
+	Error        int `json:"error"`
+	NoHash       int `json:"noHash"` // matching files whose hashes could not be checked
+}
+
+// CheckReport collects the results of a check for machine readable
+// output. Set it in CheckOpt to have it filled in.
+type CheckReport struct {
+	mu      sync.Mutex
+	Src     string            `json:"src"` // the source, or the SUM file for checksum
+	Dst     string            `json:"dst"`
+	Start   time.Time         `json:"start"`
+	End     time.Time         `json:"end"`
+	Summary CheckSummary      `json:"summary"`
+	Sample  *CheckSampleStats `json:"sample,omitempty"` // set if only a sample was checked
+	Files   []*CheckResult    `json:"files"`
+}
+
+// NewCheckReport makes a new empty CheckReport
+func NewCheckReport() *CheckReport {
+	return &CheckReport{
+		Files: []*CheckResult{},
+	}
+}
+
+// begin is called when the check starts
+func (r *CheckReport) begin(src, dst string) {
+	r.mu.Lock()
+	r.Src, r.Dst = src, dst
+	r.Start = time.Now()
+	r.mu.Unlock()
+}
+
+// end is called when the check has finished and sorts the results
+func (r *CheckReport) end() {
+	r.mu.Lock()
+	r.End = time.Now()
+	sort.Slice(r.Files, func(i, j int) bool {
+		return r.Files[i].Path < r.Files[j].Path
+	})
+	r.mu.Unlock()
+}
+
+// add records a finished result
+func (r *CheckReport) add(res *CheckResult, noHash bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Files = append(r.Files, res)
+	r.Summary.Total++
+	switch res.Status {
+	case CheckStatusMatch:
+		r.Summary.Match++
+		if noHash {
+			r.Summary.NoHash++
+		}
+	case CheckStatusDiffer:
+		r.Summary.Differ++
+	case CheckStatusMissingOnSrc:
+		r.Summary.MissingOnSrc++
+	case CheckStatusMissingOnDst:
+		r.Summary.MissingOnDst++
+	case CheckStatusError:
+		r.Summary.Error++
+	}
+}
+
+// Write writes the report to out in the format given which should be
+// one of CheckReportFormats
+func (r *CheckReport) Write(out io.Writer, format string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "\t")
+		return enc.Encode(r)
+	case "html":
+		return checkReportTemplate.Execute(out, r)
+	}
+	return fmt.Errorf("unknown check report format %q - must be one of %v", format, CheckReportFormats)
+}
+
+// CheckReportFormatValid returns an error if the format isn't supported
+func CheckReportFormatValid(format string) error {
+	for _, f := range CheckReportFormats {
+		if strings.EqualFold(f, format) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown check report format %q - must be one of %v", format, CheckReportFormats)
+}
+
+// newCheckFileInfo describes an object for the report
+func newCheckFileInfo(ctx context.Context, o fs.DirEntry) *CheckFileInfo {
+	if o == nil {
+		return nil
+	}
+	return &CheckFileInfo{
+		Size:    o.Size(),
+		ModTime: o.ModTime(ctx).Format(time.RFC3339Nano),
+	}
+}
+
+// compared notes what was compared to make the result
+func (res *CheckResult) compared(what string) {
+	res.mu.Lock()
+	defer res.mu.Unlock()
+	for _, c := range res.Compared {
+		if c == what {
+			return
+		}
+	}
+	res.Compared = append(res.Compared, what)
+}
+
+// setHashes records the hash values compared
+func (res *CheckResult) setHashes(ht hash.Type, srcHash, dstHash string) {
+	res.compared(ht.String())
+	res.mu.Lock()
+	defer res.mu.Unlock()
+	for _, side := range []struct {
+		info *CheckFileInfo
+		hash string
+	}{{res.Src, srcHash}, {res.Dst, dstHash}} {
+		if side.info == nil || side.hash == "" {
+			continue
+		}
+		if side.info.Hashes == nil {
+			side.info.Hashes = map[string]string{}
+		}
+		side.info.Hashes[ht.String()] = side.hash
+	}
+}
+
+type checkResultKeyType struct{}
+
+// checkResultKey is the context key for the CheckResult being made
+var checkResultKey = checkResultKeyType{}
+
+// withCheckResult returns a context carrying res
+func withCheckResult(ctx context.Context, res *CheckResult) context.Context {
+	if res == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, checkResultKey, res)
+}
+
+// getCheckResult returns the CheckResult being made or nil
+func getCheckResult(ctx context.Context) *CheckResult {
+	res, _ := ctx.Value(checkResultKey).(*CheckResult)
+	return res
+}
+
+// CheckRecordHashes records the hash type and values compared by a
+// check function in the CheckReport, if one is being made.
+func CheckRecordHashes(ctx context.Context, ht hash.Type, srcHash, dstHash string) {
+	if res := getCheckResult(ctx); res != nil {
+		res.setHashes(ht, srcHash, dstHash)
+	}
+}
+
+// checkRecordReason records why a check function found the files differ
+// in the CheckReport, if one is being made.
+func checkRecordReason(ctx context.Context, err error) {
+	if res := getCheckResult(ctx); res != nil {
+		res.mu.Lock()
+		res.Error = err.Error()
+		res.mu.Unlock()
+	}
+}
+
+// checkRecordCompared records what a check function compared in the
+// CheckReport, if one is being made.
+func checkRecordCompared(ctx context.Context, what string) {
+	if res := getCheckResult(ctx); res != nil {
+		res.compared(what)
+	}
+}
+
+var checkReportTemplate = template.Must(template.New("check").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>rclone check report</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 2px 6px; text-align: left; }
+.match { color: #080; }
+.differ, .error { color: #c00; }
+.missing_on_src, .missing_on_dst { color: #c60; }
+</style>
+</head>
+<body>
+<h1>rclone check report</h1>
+<table>
+<tr><th>Source</th><td>{{.Src}}</td></tr>
+<tr><th>Destination</th><td>{{.Dst}}</td></tr>
+<tr><th>Started</th><td>{{.Start.Format "2006-01-02 15:04:05"}}</td></tr>
+<tr><th>Finished</th><td>{{.End.Format "2006-01-02 15:04:05"}}</td></tr>
+</table>
+<h2>Summary</h2>
+<table>
+<tr><th>Total</th><td>{{.Summary.Total}}</td></tr>
+<tr><th class="match">Matching</th><td>{{.Summary.Match}}</td></tr>
+<tr><th>Matching without hash check</th><td>{{.Summary.NoHash}}</td></tr>
+<tr><th class="differ">Differing</th><td>{{.Summary.Differ}}</td></tr>
+<tr><th class="missing_on_src">Missing on source</th><td>{{.Summary.MissingOnSrc}}</td></tr>
+<tr><th class="missing_on_dst">Missing on destination</th><td>{{.Summary.MissingOnDst}}</td></tr>
+<tr><th class="error">Errors</th><td>{{.Summary.Error}}</td></tr>
+</table>
+{{- with .Sample}}
+<h2>Sample</h2>
+<table>
+<tr><th>Seed</th><td>{{.Seed}}</td></tr>
+<tr><th>Files sampled</th><td>{{.SampledFiles}} of {{.Files}}</td></tr>
+<tr><th>Bytes sampled</th><td>{{.SampledBytes}} of {{.Bytes}}</td></tr>
+<tr><th>Failed</th><td>{{.Failed}}</td></tr>
+<tr><th>Coverage of files</th><td>{{printf "%.1f" .CoverageFiles}}%</td></tr>
+<tr><th>Coverage of bytes</th><td>{{printf "%.1f" .CoverageBytes}}%</td></tr>
+<tr><th>Failure rate</th><td>{{printf "%.2f" .FailureRate}}% (95% confidence below {{printf "%.2f" .FailureRateMax}}%)</td></tr>
+</table>
+{{- end}}
+<h2>Problems</h2>
+<table>
+<tr><th>Path</th><th>Status</th><th>Source size</th><th>Destination size</th><th>Compared</th><th>Error</th></tr>
+{{- range .Files}}{{if ne .Status "match"}}
+<tr class="{{.Status}}"><td>{{.Path}}</td><td>{{.Status}}</td><td>{{with .Src}}{{.Size}}{{end}}</td><td>{{with .Dst}}{{.Size}}{{end}}</td><td>{{range .Compared}}{{.}} {{end}}</td><td>{{.Error}}</td></tr>
+{{- end}}{{end}}
+</table>
+</body>
+</html>
+`))
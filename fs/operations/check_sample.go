@@ -0,0 +1,362 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/kv"
+)
+
+// CheckSample configures checking only a sample of the files found
+// in both the source and the destination.
+//
+// Files which haven't been checked for the longest time, according
+// to the history kept from previous runs, are chosen first so that
+// successive runs cover the whole tree. Ties are broken at random.
+type CheckSample struct {
+	Percent    float64       // percentage of the files to check
+	Bytes      fs.SizeSuffix // number of bytes of files to check
+	Seed       int64         // seed for the random choice - 0 for a random seed
+	Stratified bool          // choose from each directory in proportion to its size
+}
+
+// CheckSampleStats describes the sample checked and what it says
+// about the files which weren't checked
+type CheckSampleStats struct {
+	Seed           int64   `json:"seed"`           // the seed used to make the sample
+	Files          int     `json:"files"`          // number of files in both source and destination
+	Bytes          int64   `json:"bytes"`          // size of those files
+	SampledFiles   int     `json:"sampledFiles"`   // number of files checked
+	SampledBytes   int64   `json:"sampledBytes"`   // size of the files checked
+	Failed         int     `json:"failed"`         // number of files checked which differed or had errors
+	CoverageFiles  float64 `json:"coverageFiles"`  // percentage of files checked OK in this or previous runs
+	CoverageBytes  float64 `json:"coverageBytes"`  // percentage of bytes checked OK in this or previous runs
+	FailureRate    float64 `json:"failureRate"`    // percentage of the sample which failed
+	FailureRateMax float64 `json:"failureRateMax"` // upper bound of the failure rate at 95% confidence
+}
+
+// Validate checks the sample options are usable
+func (s *CheckSample) Validate() error {
+	if s.Percent < 0 || s.Percent > 100 {
+		return errors.New("sample percentage must be between 0 and 100")
+	}
+	if s.Bytes < 0 {
+		return errors.New("sample bytes must be positive")
+	}
+	if s.Percent == 0 && s.Bytes == 0 {
+		return errors.New("need a sample percentage or number of bytes")
+	}
+	return nil
+}
+
+// checkSampleFacility is the name of the kv database used to record
+// when files were last checked
+const checkSampleFacility = "check"
+
+// sampleItem is a pair of objects which may be checked
+type sampleItem struct {
+	dst, src    fs.Object
+	key         string    // key in the history
+	fingerprint string    // fingerprint of dst and src to spot changed files
+	last        time.Time // when it was last checked OK or zero
+	order       int64     // random order to break ties
+	checked     bool      // set if checked OK in this run
+	failed      bool      // set if the check failed in this run
+}
+
+// checkSampler collects the objects to check then chooses the sample
+type checkSampler struct {
+	opt    CheckSample
+	fdst   fs.Fs
+	mu     sync.Mutex
+	items  []*sampleItem
+	byPath map[string]*sampleItem
+	failed int32
+	db     *kv.DB
+	stats  CheckSampleStats
+}
+
+// newCheckSampler makes a sampler for the files in fdst
+func newCheckSampler(ctx context.Context, opt CheckSample, fdst fs.Fs) (*checkSampler, error) {
+	if err := opt.Validate(); err != nil {
+		return nil, err
+	}
+	s := &checkSampler{
+		opt:    opt,
+		fdst:   fdst,
+		byPath: map[string]*sampleItem{},
+	}
+	if s.opt.Seed == 0 {
+		s.opt.Seed = time.Now().UnixNano()
+	}
+	fs.Infof(fdst, "Checking a sample of the files using seed %d", s.opt.Seed)
+	if kv.Supported() {
+		db, err := kv.Start(ctx, checkSampleFacility, fdst)
+		if err != nil {
+			fs.Errorf(fdst, "Can't open history of checked files: %v", err)
+		} else {
+			s.db = db
+		}
+	}
+	return s, nil
+}
+
+// add notes a pair of objects which could be checked
+func (s *checkSampler) add(ctx context.Context, dst, src fs.Object) {
+	item := &sampleItem{
+		dst:         dst,
+		src:         src,
+		key:         path.Join(s.fdst.Root(), dst.Remote()),
+		fingerprint: fs.Fingerprint(ctx, dst, true) + " " + fs.Fingerprint(ctx, src, true),
+	}
+	s.mu.Lock()
+	s.items = append(s.items, item)
+	s.byPath[dst.Remote()] = item
+	s.mu.Unlock()
+}
+
+// choose returns the sample of objects to check
+func (s *checkSampler) choose() []*sampleItem {
+	// Sort so the random order doesn't depend on the listing order
+	sort.Slice(s.items, func(i, j int) bool {
+		return s.items[i].dst.Remote() < s.items[j].dst.Remote()
+	})
+	s.readHistory()
+	rng := rand.New(rand.NewSource(s.opt.Seed))
+	for _, item := range s.items {
+		item.order = rng.Int63()
+		s.stats.Files++
+		s.stats.Bytes += item.dst.Size()
+	}
+	sort.SliceStable(s.items, func(i, j int) bool {
+		a, b := s.items[i], s.items[j]
+		if !a.last.Equal(b.last) {
+			return a.last.Before(b.last)
+		}
+		return a.order < b.order
+	})
+	if !s.opt.Stratified {
+		return s.take(s.items, 1)
+	}
+	// Group by directory keeping the priority order within each
+	var (
+		dirs   []string
+		strata = map[string][]*sampleItem{}
+	)
+	for _, item := range s.items {
+		dir := path.Dir(item.dst.Remote())
+		if _, found := strata[dir]; !found {
+			dirs = append(dirs, dir)
+		}
+		strata[dir] = append(strata[dir], item)
+	}
+	sort.Strings(dirs)
+	var sample []*sampleItem
+	for _, dir := range dirs {
+		stratum := strata[dir]
+		var bytes int64
+		for _, item := range stratum {
+			bytes += item.dst.Size()
+		}
+		share := 0.0
+		if s.stats.Bytes > 0 {
+			share = float64(bytes) / float64(s.stats.Bytes)
+		}
+		sample = append(sample, s.take(stratum, share)...)
+	}
+	return sample
+}
+
+// take chooses items from the front of items until the percentage
+// of them or the share of the sample bytes is reached
+func (s *checkSampler) take(items []*sampleItem, share float64) (sample []*sampleItem) {
+	maxFiles := len(items)
+	if s.opt.Percent > 0 {
+		maxFiles = int(math.Ceil(float64(len(items)) * s.opt.Percent / 100))
+	}
+	maxBytes := int64(-1)
+	if s.opt.Bytes > 0 {
+		maxBytes = int64(math.Ceil(float64(s.opt.Bytes) * share))
+	}
+	var bytes int64
+	for _, item := range items {
+		if len(sample) >= maxFiles || (maxBytes >= 0 && bytes >= maxBytes) {
+			break
+		}
+		sample = append(sample, item)
+		bytes += item.dst.Size()
+	}
+	return sample
+}
+
+// checked is called with the result of checking an object in the sample
+func (s *checkSampler) checked(remote string, ok bool) {
+	if !ok {
+		atomic.AddInt32(&s.failed, 1)
+	}
+	s.mu.Lock()
+	if item := s.byPath[remote]; item != nil {
+		item.checked = ok
+		item.failed = !ok
+	}
+	s.mu.Unlock()
+}
+
+// finish records the files checked OK in the history and works out
+// the statistics for the sample
+func (s *checkSampler) finish(sample []*sampleItem) CheckSampleStats {
+	st := &s.stats
+	st.Seed = s.opt.Seed
+	st.Failed = int(atomic.LoadInt32(&s.failed))
+	for _, item := range sample {
+		st.SampledFiles++
+		st.SampledBytes += item.dst.Size()
+	}
+	now := time.Now()
+	var coveredFiles int
+	var coveredBytes int64
+	for _, item := range s.items {
+		if item.checked {
+			item.last = now
+		} else if item.failed {
+			item.last = time.Time{}
+		}
+		if !item.last.IsZero() {
+			coveredFiles++
+			coveredBytes += item.dst.Size()
+		}
+	}
+	st.CoverageFiles = percent(int64(coveredFiles), int64(st.Files))
+	st.CoverageBytes = percent(coveredBytes, st.Bytes)
+	st.FailureRate, st.FailureRateMax = sampleFailureRate(st.Failed, st.SampledFiles, st.Files)
+	s.writeHistory(now)
+	return *st
+}
+
+// percent returns a as a percentage of b, or 100 if b is 0
+func percent(a, b int64) float64 {
+	if b == 0 {
+		return 100
+	}
+	return 100 * float64(a) / float64(b)
+}
+
+// sampleFailureRate returns the percentage of failures in a sample
+// of n files out of total and the upper bound of the failure rate of
+// all the files at 95% confidence using the Wilson score interval.
+func sampleFailureRate(failed, n, total int) (rate, rateMax float64) {
+	if n == 0 {
+		return 0, 100
+	}
+	p := float64(failed) / float64(n)
+	if n >= total {
+		// everything was checked so the rate is exact
+		return 100 * p, 100 * p
+	}
+	const z = 1.96
+	nf := float64(n)
+	centre := p + z*z/(2*nf)
+	margin := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf))
+	upper := (centre + margin) / (1 + z*z/nf)
+	return 100 * p, 100 * math.Min(upper, 1)
+}
+
+// readHistory fills in when the items were last checked OK
+func (s *checkSampler) readHistory() {
+	if s.db == nil {
+		return
+	}
+	err := s.db.Do(false, &opSampleRead{items: s.items})
+	if err != nil && err != kv.ErrEmpty {
+		fs.Errorf(s.fdst, "Can't read history of checked files: %v", err)
+	}
+}
+
+// writeHistory records the items checked OK and closes the database
+func (s *checkSampler) writeHistory(now time.Time) {
+	if s.db == nil {
+		return
+	}
+	op := &opSampleWrite{when: now}
+	for _, item := range s.items {
+		if item.checked {
+			op.items = append(op.items, item)
+		} else if item.failed {
+			op.forget = append(op.forget, item.key)
+		}
+	}
+	if len(op.items) > 0 || len(op.forget) > 0 {
+		if err := s.db.Do(true, op); err != nil {
+			fs.Errorf(s.fdst, "Can't write history of checked files: %v", err)
+		}
+	}
+	if err := s.db.Stop(false); err != nil {
+		fs.Debugf(s.fdst, "Failed to close history of checked files: %v", err)
+	}
+	s.db = nil
+}
+
+// The history holds the time each file was last checked OK followed
+// by a space and the fingerprint of the files at the time. A file
+// whose fingerprint has changed since is treated as never checked.
+
+// opSampleRead reads when the items were last checked
+type opSampleRead struct {
+	items []*sampleItem
+}
+
+func (op *opSampleRead) Do(ctx context.Context, b kv.Bucket) error {
+	for _, item := range op.items {
+		data := b.Get([]byte(item.key))
+		if data == nil {
+			continue
+		}
+		when, fingerprint := string(data), ""
+		if i := strings.IndexByte(when, ' '); i >= 0 {
+			when, fingerprint = when[:i], when[i+1:]
+		}
+		last, err := time.Parse(time.RFC3339Nano, when)
+		if err != nil {
+			fs.Debugf(item.key, "Ignoring bad check history: %v", err)
+			continue
+		}
+		if fingerprint != item.fingerprint {
+			fs.Debugf(item.key, "Changed since last checked at %v", last)
+			continue
+		}
+		item.last = last
+	}
+	return nil
+}
+
+// opSampleWrite records items as checked at the time given and
+// forgets the keys which failed so they are checked again soon
+type opSampleWrite struct {
+	items  []*sampleItem
+	forget []string
+	when   time.Time
+}
+
+func (op *opSampleWrite) Do(ctx context.Context, b kv.Bucket) error {
+	when := op.when.Format(time.RFC3339Nano)
+	for _, item := range op.items {
+		if err := b.Put([]byte(item.key), []byte(when+" "+item.fingerprint)); err != nil {
+			return err
+		}
+	}
+	for _, key := range op.forget {
+		if err := b.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/lib/kv"
 	"github.com/rclone/rclone/lib/readers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -215,6 +216,149 @@ func TestCheckSizeOnly(t *testing.T) {
 	TestCheck(t)
 }
 
+func TestCheckReport(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	file1 := r.WriteBoth(ctx, "same", "same contents", t1)
+	file2 := r.WriteFile("differ", "local contents", t2)
+	file3 := r.WriteFile("srconly", "src", t2)
+	file4 := r.WriteObject(ctx, "differ", "remote contents!", t2)
+	file5 := r.WriteObject(ctx, "dstonly", "dst", t3)
+	r.CheckLocalItems(t, file1, file2, file3)
+	r.CheckRemoteItems(t, file1, file4, file5)
+
+	accounting.GlobalStats().ResetCounters()
+	opt := operations.CheckOpt{
+		Fdst:   r.Fremote,
+		Fsrc:   r.Flocal,
+		Report: operations.NewCheckReport(),
+	}
+	require.Error(t, operations.Check(ctx, &opt))
+
+	report := opt.Report
+	assert.Equal(t, operations.CheckSummary{
+		Total:        4,
+		Match:        1,
+		Differ:       1,
+		MissingOnSrc: 1,
+		MissingOnDst: 1,
+	}, report.Summary)
+	require.Len(t, report.Files, 4)
+
+	differ := report.Files[0]
+	assert.Equal(t, "differ", differ.Path)
+	assert.Equal(t, operations.CheckStatusDiffer, differ.Status)
+	assert.Equal(t, int64(14), differ.Src.Size)
+	assert.Equal(t, int64(16), differ.Dst.Size)
+	assert.Equal(t, []string{"size"}, differ.Compared)
+	assert.Equal(t, "sizes differ", differ.Error)
+
+	dstOnly := report.Files[1]
+	assert.Equal(t, "dstonly", dstOnly.Path)
+	assert.Equal(t, operations.CheckStatusMissingOnSrc, dstOnly.Status)
+	assert.Nil(t, dstOnly.Src)
+	assert.Equal(t, int64(3), dstOnly.Dst.Size)
+
+	same := report.Files[2]
+	assert.Equal(t, "same", same.Path)
+	assert.Equal(t, operations.CheckStatusMatch, same.Status)
+	ht := r.Flocal.Hashes().Overlap(r.Fremote.Hashes()).GetOne()
+	if ht != hash.None {
+		assert.Equal(t, []string{"size", ht.String()}, same.Compared)
+		assert.NotEqual(t, "", same.Src.Hashes[ht.String()])
+		assert.Equal(t, same.Src.Hashes, same.Dst.Hashes)
+	}
+
+	srcOnly := report.Files[3]
+	assert.Equal(t, "srconly", srcOnly.Path)
+	assert.Equal(t, operations.CheckStatusMissingOnDst, srcOnly.Status)
+	assert.Nil(t, srcOnly.Dst)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf, "json"))
+	assert.Contains(t, buf.String(), `"status": "missing_on_dst"`)
+	buf.Reset()
+	require.NoError(t, report.Write(&buf, "html"))
+	assert.Contains(t, buf.String(), "<td>srconly</td>")
+	assert.NotContains(t, buf.String(), "<td>same</td>")
+	assert.Error(t, report.Write(&buf, "xml"))
+}
+
+func TestCheckSample(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	if !kv.Supported() {
+		t.Skip("no kv database on this OS")
+	}
+	// Hold the history database open so it isn't reset between runs
+	db, err := kv.Start(ctx, "check", r.Fremote)
+	require.NoError(t, err)
+	defer func() { _ = db.Stop(true) }()
+
+	var items []fstest.Item
+	for i := 0; i < 10; i++ {
+		remote := fmt.Sprintf("dir%d/file%d", i%2, i)
+		items = append(items, r.WriteBoth(ctx, remote, "contents "+remote, t1))
+	}
+	r.CheckLocalItems(t, items...)
+	r.CheckRemoteItems(t, items...)
+
+	run := func(sample operations.CheckSample) *operations.CheckReport {
+		accounting.GlobalStats().ResetCounters()
+		opt := operations.CheckOpt{
+			Fdst:   r.Fremote,
+			Fsrc:   r.Flocal,
+			Report: operations.NewCheckReport(),
+			Sample: &sample,
+		}
+		require.NoError(t, operations.CheckDownload(ctx, &opt))
+		return opt.Report
+	}
+	checked := map[string]bool{}
+	for i := 1; i <= 3; i++ {
+		report := run(operations.CheckSample{Percent: 30, Seed: 42})
+		require.NotNil(t, report.Sample)
+		assert.Equal(t, int64(42), report.Sample.Seed)
+		assert.Equal(t, 10, report.Sample.Files)
+		assert.Equal(t, 3, report.Sample.SampledFiles)
+		assert.Equal(t, 0, report.Sample.Failed)
+		assert.Equal(t, float64(30*i), report.Sample.CoverageFiles)
+		assert.Equal(t, 0.0, report.Sample.FailureRate)
+		assert.True(t, report.Sample.FailureRateMax > 0 && report.Sample.FailureRateMax < 100)
+		require.Len(t, report.Files, 3)
+		for _, res := range report.Files {
+			assert.Equal(t, operations.CheckStatusMatch, res.Status)
+			assert.False(t, checked[res.Path], "%s checked twice", res.Path)
+			checked[res.Path] = true
+		}
+	}
+
+	// A file changed since it was checked is checked again
+	changed := r.WriteBoth(ctx, items[0].Path, "changed contents", t2)
+	items[0] = changed
+	report := run(operations.CheckSample{Percent: 10, Seed: 42})
+	assert.Equal(t, 1, report.Sample.SampledFiles)
+	assert.Equal(t, float64(90), report.Sample.CoverageFiles)
+
+	// Stratified by bytes takes from each directory
+	report = run(operations.CheckSample{Bytes: 1, Stratified: true})
+	require.Len(t, report.Files, 2)
+	assert.True(t, strings.HasPrefix(report.Files[0].Path, "dir0/"))
+	assert.True(t, strings.HasPrefix(report.Files[1].Path, "dir1/"))
+	assert.Equal(t, float64(100), report.Sample.CoverageFiles)
+
+	// Whole tree sampled means the failure rate is exact
+	report = run(operations.CheckSample{Percent: 100})
+	assert.Equal(t, 10, report.Sample.SampledFiles)
+	assert.Equal(t, 0.0, report.Sample.FailureRateMax)
+
+	assert.Error(t, (&operations.CheckSample{}).Validate())
+	assert.Error(t, (&operations.CheckSample{Percent: 101}).Validate())
+}
+
 func TestCheckEqualReaders(t *testing.T) {
 	b65a := make([]byte, 65*1024)
 	b65b := make([]byte, 65*1024)
@@ -131,7 +131,7 @@ func TestMultithreadCopy(t *testing.T) {
 			src, err := r.Fremote.NewObject(ctx, "file1")
 			require.NoError(t, err)
 			accounting.GlobalStats().ResetCounters()
-			tr := accounting.GlobalStats().NewTransfer(src)
+			tr := accounting.GlobalStats().NewTransfer(src, nil)
 
 			defer func() {
 				tr.Done(ctx, err)
@@ -32,6 +32,8 @@ import (
 	"github.com/rclone/rclone/fs/fshttp"
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/fs/tracing"
+	"github.com/rclone/rclone/fs/transferlog"
 	"github.com/rclone/rclone/fs/walk"
 	"github.com/rclone/rclone/lib/atexit"
 	"github.com/rclone/rclone/lib/pacer"
@@ -65,6 +67,9 @@ func CheckHashes(ctx context.Context, src fs.ObjectInfo, dst fs.Object) (equal b
 
 var errNoHash = errors.New("no hash available")
 
+// ErrorMaxDeletesReached is returned when the --max-delete threshold is reached
+var ErrorMaxDeletesReached = errors.New("--max-delete threshold reached")
+
 // checkHashes does the work of CheckHashes but takes a hash.Type and
 // returns the effective hash type used.
 func checkHashes(ctx context.Context, src fs.ObjectInfo, dst fs.Object, ht hash.Type) (equal bool, htOut hash.Type, srcHash, dstHash string, err error) {
@@ -321,10 +326,39 @@ func CommonHash(ctx context.Context, fa, fb fs.Info) (hash.Type, *fs.HashesOptio
 // be nil.
 func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Object) (newDst fs.Object, err error) {
 	ci := fs.GetConfig(ctx)
-	tr := accounting.Stats(ctx).NewTransfer(src)
+	tr := accounting.Stats(ctx).NewTransfer(src, f)
 	defer func() {
 		tr.Done(ctx, err)
 	}()
+	ctx, span := tracing.StartSpan(ctx, "operations.Copy",
+		tracing.KeySrc.String(tracing.FsString(src.Fs())),
+		tracing.KeyDst.String(tracing.FsString(f)),
+		tracing.KeyObject.String(remote),
+		tracing.KeySize.Int64(src.Size()),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+	var (
+		start   = time.Now()
+		action  = transferlog.Copied
+		hashSum string
+	)
+	if dst != nil {
+		action = transferlog.Updated
+	}
+	defer func() {
+		transferlog.Log(ctx, &transferlog.Record{
+			Action:   action,
+			Object:   remote,
+			Src:      transferlog.Path(src.Fs(), src.Remote()),
+			Dst:      transferlog.Path(f, remote),
+			Size:     src.Size(),
+			Hash:     hashSum,
+			Duration: time.Since(start).Seconds(),
+			Error:    transferlog.ErrorString(err),
+		})
+	}()
 	newDst = dst
 	if SkipDestructive(ctx, src, "copy") {
 		in := tr.Account(ctx, nil)
@@ -466,12 +500,14 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 		}
 		if retry {
 			fs.Debugf(src, "Received error: %v - low level retry %d/%d", err, tries, maxTries)
+			tracing.Retry(span, tries, maxTries, err)
 			tr.Reset(ctx) // skip incomplete accounting - will be overwritten by retry
 			continue
 		}
 		// otherwise finish
 		break
 	}
+	span.SetAttributes(tracing.KeyAction.String(actionTaken), tracing.KeyTries.Int(tries))
 	if err != nil {
 		err = fs.CountError(err)
 		fs.Errorf(src, "Failed to copy: %v", err)
@@ -498,6 +534,9 @@ func Copy(ctx context.Context, f fs.Fs, dst fs.Object, remote string, src fs.Obj
 			removeFailedCopy(ctx, dst)
 			return newDst, err
 		}
+		if dstSum != "" {
+			hashSum = hashType.String() + ":" + dstSum
+		}
 	}
 	if newDst != nil && src.String() != newDst.String() {
 		fs.Infof(src, "%s to: %s", actionTaken, newDst.String())
@@ -551,6 +590,24 @@ func Move(ctx context.Context, fdst fs.Fs, dst fs.Object, remote string, src fs.
 		}
 		tr.Done(ctx, err)
 	}()
+	start := time.Now()
+	action := transferlog.Moved
+	if SameConfig(src.Fs(), fdst) && src.Fs().Root() == fdst.Root() {
+		action = transferlog.Renamed
+	}
+	defer func(logCtx context.Context) {
+		transferlog.Log(logCtx, &transferlog.Record{
+			Action:   action,
+			Object:   remote,
+			Src:      transferlog.Path(src.Fs(), src.Remote()),
+			Dst:      transferlog.Path(fdst, remote),
+			Size:     src.Size(),
+			Duration: time.Since(start).Seconds(),
+			Error:    transferlog.ErrorString(err),
+		})
+	}(ctx)
+	// Only log the move, not the copies and deletes it is made from
+	ctx = transferlog.Quiet(ctx)
 	newDst = dst
 	if SkipDestructive(ctx, src, "move") {
 		in := tr.Account(ctx, nil)
@@ -639,17 +696,31 @@ func DeleteFileWithBackupDir(ctx context.Context, dst fs.Object, backupDir fs.Fs
 	}()
 	numDeletes := accounting.Stats(ctx).Deletes(1)
 	if ci.MaxDelete != -1 && numDeletes > ci.MaxDelete {
-		return fserrors.FatalError(errors.New("--max-delete threshold reached"))
+		return fserrors.FatalError(ErrorMaxDeletesReached)
 	}
 	action, actioned := "delete", "Deleted"
+	record := &transferlog.Record{
+		Action: transferlog.Deleted,
+		Object: dst.Remote(),
+		Dst:    transferlog.Path(dst.Fs(), dst.Remote()),
+		Size:   dst.Size(),
+	}
 	if backupDir != nil {
 		action, actioned = "move into backup dir", "Moved into backup dir"
+		record.Action = transferlog.BackedUp
+		record.Src, record.Dst = record.Dst, transferlog.Path(backupDir, SuffixName(ctx, dst.Remote()))
 	}
+	start := time.Now()
+	defer func() {
+		record.Duration = time.Since(start).Seconds()
+		record.Error = transferlog.ErrorString(err)
+		transferlog.Log(ctx, record)
+	}()
 	skip := SkipDestructive(ctx, dst, action)
 	if skip {
 		// do nothing
 	} else if backupDir != nil {
-		err = MoveBackupDir(ctx, backupDir, dst)
+		err = MoveBackupDir(transferlog.Quiet(ctx), backupDir, dst)
 	} else {
 		err = dst.Remove(ctx)
 	}
@@ -960,7 +1031,7 @@ func hashSum(ctx context.Context, ht hash.Type, base64Encoded bool, downloadFlag
 		// Setup: Define accounting, open the file with NewReOpen to provide restarts, account for the transfer, and setup a multi-hasher with the appropriate type
 		// Execution: io.Copy file to hasher, get hash and encode in hex
 
-		tr := accounting.Stats(ctx).NewTransfer(o)
+		tr := accounting.Stats(ctx).NewTransfer(o, nil)
 		defer func() {
 			tr.Done(ctx, err)
 		}()
@@ -1246,7 +1317,7 @@ func Cat(ctx context.Context, f fs.Fs, w io.Writer, offset, count int64) error {
 	ci := fs.GetConfig(ctx)
 	return ListFn(ctx, f, func(o fs.Object) {
 		var err error
-		tr := accounting.Stats(ctx).NewTransfer(o)
+		tr := accounting.Stats(ctx).NewTransfer(o, nil)
 		defer func() {
 			tr.Done(ctx, err)
 		}()
@@ -1581,6 +1652,7 @@ func copyDest(ctx context.Context, fdst fs.Fs, dst, src fs.Object, CopyDest, bac
 			return true, nil
 		}
 		fs.Debugf(src, "Unchanged skipping")
+		logSkipped(ctx, dst, src, "unchanged")
 		return true, nil
 	}
 	fs.Debugf(src, "Destination not found in --copy-dest")
@@ -1596,6 +1668,13 @@ func CompareOrCopyDest(ctx context.Context, fdst fs.Fs, dst, src fs.Object, Comp
 	if len(ci.CompareDest) > 0 {
 		for _, compareF := range CompareOrCopyDest {
 			NoNeedTransfer, err := compareDest(ctx, dst, src, compareF)
+			if NoNeedTransfer && err == nil {
+				remote := src.Remote()
+				if dst != nil {
+					remote = dst.Remote()
+				}
+				logSkippedTo(ctx, fdst, remote, src, "found in --compare-dest")
+			}
 			if NoNeedTransfer || err != nil {
 				return NoNeedTransfer, err
 			}
@@ -1625,6 +1704,7 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 	// If we should ignore existing files, don't transfer
 	if ci.IgnoreExisting {
 		fs.Debugf(src, "Destination exists, skipping")
+		logSkipped(ctx, dst, src, "destination exists (--ignore-existing)")
 		return false
 	}
 	// If we should upload unconditionally
@@ -1648,6 +1728,7 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 		switch {
 		case dt >= modifyWindow:
 			fs.Debugf(src, "Destination is newer than source, skipping")
+			logSkipped(ctx, dst, src, "destination is newer (--update)")
 			return false
 		case dt <= -modifyWindow:
 			// force --checksum on for the check and do update modtimes by default
@@ -1655,6 +1736,7 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 			opt.forceModTimeMatch = true
 			if equal(ctx, src, dst, opt) {
 				fs.Debugf(src, "Unchanged skipping")
+				logSkipped(ctx, dst, src, "unchanged")
 				return false
 			}
 		default:
@@ -1663,6 +1745,7 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 			opt.sizeOnly = !ci.CheckSum
 			if equal(ctx, src, dst, opt) {
 				fs.Debugf(src, "Destination mod time is within %v of source and files identical, skipping", modifyWindow)
+				logSkipped(ctx, dst, src, "unchanged")
 				return false
 			}
 			fs.Debugf(src, "Destination mod time is within %v of source but files differ, transferring", modifyWindow)
@@ -1671,12 +1754,32 @@ func NeedTransfer(ctx context.Context, dst, src fs.Object) bool {
 		// Check to see if changed or not
 		if Equal(ctx, src, dst) {
 			fs.Debugf(src, "Unchanged skipping")
+			logSkipped(ctx, dst, src, "unchanged")
 			return false
 		}
 	}
 	return true
 }
 
+// logSkipped writes a record to the transfer log that src wasn't
+// transferred to dst because of reason
+func logSkipped(ctx context.Context, dst, src fs.Object, reason string) {
+	logSkippedTo(ctx, dst.Fs(), dst.Remote(), src, reason)
+}
+
+// logSkippedTo writes a record to the transfer log that src wasn't
+// transferred to remote on fdst because of reason
+func logSkippedTo(ctx context.Context, fdst fs.Info, remote string, src fs.Object, reason string) {
+	transferlog.Log(ctx, &transferlog.Record{
+		Action: transferlog.Skipped,
+		Object: src.Remote(),
+		Src:    transferlog.Path(src.Fs(), src.Remote()),
+		Dst:    transferlog.Path(fdst, remote),
+		Size:   src.Size(),
+		Reason: reason,
+	})
+}
+
 // RcatSize reads data from the Reader until EOF and uploads it to a file on remote.
 // Pass in size >=0 if known, <0 if not known
 func RcatSize(ctx context.Context, fdst fs.Fs, dstFileName string, in io.ReadCloser, size int64, modTime time.Time, meta fs.Metadata) (dst fs.Object, err error) {
@@ -1822,9 +1925,19 @@ func BackupDir(ctx context.Context, fdst fs.Fs, fsrc fs.Fs, srcFileName string)
 
 // MoveBackupDir moves a file to the backup dir
 func MoveBackupDir(ctx context.Context, backupDir fs.Fs, dst fs.Object) (err error) {
+	start := time.Now()
 	remoteWithSuffix := SuffixName(ctx, dst.Remote())
 	overwritten, _ := backupDir.NewObject(ctx, remoteWithSuffix)
-	_, err = Move(ctx, backupDir, overwritten, remoteWithSuffix, dst)
+	_, err = Move(transferlog.Quiet(ctx), backupDir, overwritten, remoteWithSuffix, dst)
+	transferlog.Log(ctx, &transferlog.Record{
+		Action:   transferlog.BackedUp,
+		Object:   dst.Remote(),
+		Src:      transferlog.Path(dst.Fs(), dst.Remote()),
+		Dst:      transferlog.Path(backupDir, remoteWithSuffix),
+		Size:     dst.Size(),
+		Duration: time.Since(start).Seconds(),
+		Error:    transferlog.ErrorString(err),
+	})
 	return err
 }
 
@@ -1875,7 +1988,7 @@ func moveOrCopyFile(ctx context.Context, fdst fs.Fs, fsrc fs.Fs, dstFileName str
 			}
 			return fmt.Errorf("error while attempting to move file to a temporary location: %w", err)
 		}
-		tr := accounting.Stats(ctx).NewTransfer(srcObj)
+		tr := accounting.Stats(ctx).NewTransfer(srcObj, fdst)
 		defer func() {
 			tr.Done(ctx, err)
 		}()
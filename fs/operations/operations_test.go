@@ -20,15 +20,18 @@
 package operations_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -42,6 +45,7 @@ import (
 	"github.com/rclone/rclone/fs/fshttp"
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/transferlog"
 	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/fstest/fstests"
 	"github.com/stretchr/testify/assert"
@@ -862,6 +866,78 @@ func TestMoveFile(t *testing.T) {
 	r.CheckRemoteItems(t, file2)
 }
 
+func TestTransferLog(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+
+	oldOpt := transferlog.Opt
+	transferlog.Opt.File = filepath.Join(t.TempDir(), "transfer.log")
+	transferlog.Opt.Format = "json"
+	stop, err := transferlog.Start(ctx)
+	require.NoError(t, err)
+	defer func() {
+		transferlog.Opt = oldOpt
+		_ = stop()
+	}()
+
+	file1 := r.WriteFile("file1", "file1 contents", t1)
+	require.NoError(t, operations.CopyFile(ctx, r.Fremote, r.Flocal, "file1", "file1"))
+	require.NoError(t, operations.CopyFile(ctx, r.Fremote, r.Flocal, "file1", "file1"))
+	r.WriteFile("file1", "file1 new contents", t2)
+	require.NoError(t, operations.CopyFile(ctx, r.Fremote, r.Flocal, "file1", "file1"))
+	require.NoError(t, operations.MoveFile(ctx, r.Fremote, r.Fremote, "file2", "file1"))
+	require.NoError(t, operations.MoveFile(ctx, r.Fremote, r.Flocal, "file3", "file1"))
+	file3, err := r.Fremote.NewObject(ctx, "file3")
+	require.NoError(t, err)
+	require.NoError(t, operations.DeleteFile(ctx, file3))
+
+	// Skipped as it is in --compare-dest
+	r.WriteFile("file4", "file4 contents", t1)
+	compareCtx, ci := fs.AddConfig(ctx)
+	ci.CompareDest = []string{r.LocalName}
+	require.NoError(t, operations.CopyFile(compareCtx, r.Fremote, r.Flocal, "file4", "file4"))
+
+	// Skipped as it is unchanged with --copy-dest
+	canCopy := r.Fremote.Features().Copy != nil
+	if canCopy {
+		require.NoError(t, operations.CopyFile(ctx, r.Fremote, r.Flocal, "file4", "file4"))
+		copyCtx, ci := fs.AddConfig(ctx)
+		ci.CopyDest = []string{r.FremoteName}
+		ci.IgnoreTimes = true
+		require.NoError(t, operations.CopyFile(copyCtx, r.Fremote, r.Flocal, "file4", "file4"))
+	}
+	require.NoError(t, stop())
+
+	f, err := os.Open(transferlog.Opt.File)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	var got []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record transferlog.Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		assert.Empty(t, record.Error)
+		got = append(got, fmt.Sprintf("%s %s %d %s", record.Action, record.Object, record.Size, record.Reason))
+	}
+	require.NoError(t, scanner.Err())
+	size := file1.Size
+	newSize := int64(len("file1 new contents"))
+	want := []string{
+		fmt.Sprintf("copied file1 %d ", size),
+		fmt.Sprintf("skipped file1 %d unchanged", size),
+		fmt.Sprintf("updated file1 %d ", newSize),
+		fmt.Sprintf("renamed file2 %d ", newSize),
+		fmt.Sprintf("moved file3 %d ", newSize),
+		fmt.Sprintf("deleted file3 %d ", newSize),
+		"skipped file4 14 found in --compare-dest",
+	}
+	if canCopy {
+		want = append(want, "copied file4 14 ", "skipped file4 14 unchanged")
+	}
+	assert.Equal(t, want, got)
+}
+
 func TestMoveFileWithIgnoreExisting(t *testing.T) {
 	ctx := context.Background()
 	ctx, ci := fs.AddConfig(ctx)
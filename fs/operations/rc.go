@@ -12,15 +12,27 @@ import (
 	"time"
 
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/rc"
 )
 
+// Parameters shared by many of the calls
+var (
+	fsParam      = rc.Param{Name: "fs", Type: rc.TypeString, Required: true, Help: `a remote name string e.g. "drive:"`}
+	remoteParam  = rc.Param{Name: "remote", Type: rc.TypeString, Required: true, Help: `a path within that remote e.g. "dir"`}
+	listOptParam = rc.Param{Name: "opt", Type: rc.TypeObject, Help: "a dictionary of options to control the listing as described in operations/list"}
+)
+
 func init() {
 	rc.Add(rc.Call{
 		Path:         "operations/list",
 		AuthRequired: true,
 		Fn:           rcList,
 		Title:        "List the given remote and path in JSON format",
+		Params:       []rc.Param{fsParam, remoteParam, listOptParam},
+		Returns: []rc.Param{
+			{Name: "list", Type: rc.TypeArray, Items: rc.TypeObject, Required: true, Help: "the items as described in the lsjson command"},
+		},
 		Help: `This takes the following parameters:
 
 - fs - a remote name string e.g. "drive:"
@@ -77,6 +89,10 @@ func init() {
 		AuthRequired: true,
 		Fn:           rcStat,
 		Title:        "Give information about the supplied file or directory",
+		Params:       []rc.Param{fsParam, remoteParam, listOptParam},
+		Returns: []rc.Param{
+			{Name: "item", Type: rc.TypeObject, Required: true, Help: "the item as described in the lsjson command or null if not found"},
+		},
 		Help: `This takes the following parameters
 
 - fs - a remote name string eg "drive:"
@@ -122,6 +138,15 @@ func init() {
 		AuthRequired: true,
 		Fn:           rcAbout,
 		Title:        "Return the space used on the remote",
+		Params:       []rc.Param{fsParam},
+		Returns: []rc.Param{
+			{Name: "total", Type: rc.TypeInteger, Help: "quota of bytes that can be used"},
+			{Name: "used", Type: rc.TypeInteger, Help: "bytes in use"},
+			{Name: "trashed", Type: rc.TypeInteger, Help: "bytes in trash"},
+			{Name: "other", Type: rc.TypeInteger, Help: "other usage e.g. gmail in drive"},
+			{Name: "free", Type: rc.TypeInteger, Help: "bytes which can be uploaded before reaching the quota"},
+			{Name: "objects", Type: rc.TypeInteger, Help: "objects in the storage system"},
+		},
 		Help: `This takes the following parameters:
 
 - fs - a remote name string e.g. "drive:"
@@ -168,6 +193,12 @@ func init() {
 				return rcMoveOrCopyFile(ctx, in, copy)
 			},
 			Title: name + " a file from source remote to destination remote",
+			Params: []rc.Param{
+				{Name: "srcFs", Type: rc.TypeString, Required: true, Help: `a remote name string e.g. "drive:" for the source`},
+				{Name: "srcRemote", Type: rc.TypeString, Required: true, Help: `a path within that remote e.g. "file.txt" for the source`},
+				{Name: "dstFs", Type: rc.TypeString, Required: true, Help: `a remote name string e.g. "drive2:" for the destination`},
+				{Name: "dstRemote", Type: rc.TypeString, Required: true, Help: `a path within that remote e.g. "file2.txt" for the destination`},
+			},
 			Help: `This takes the following parameters:
 
 - srcFs - a remote name string e.g. "drive:" for the source
@@ -199,22 +230,33 @@ func init() {
 		help         string
 		noRemote     bool
 		needsRequest bool
+		params       []rc.Param
 	}{
 		{name: "mkdir", title: "Make a destination directory or container"},
 		{name: "rmdir", title: "Remove an empty directory or container"},
 		{name: "purge", title: "Remove a directory or container and all of its contents"},
-		{name: "rmdirs", title: "Remove all the empty directories in the path", help: "- leaveRoot - boolean, set to true not to delete the root\n"},
+		{name: "rmdirs", title: "Remove all the empty directories in the path", help: "- leaveRoot - boolean, set to true not to delete the root\n", params: []rc.Param{
+			{Name: "leaveRoot", Type: rc.TypeBoolean, Help: "set to true not to delete the root"},
+		}},
 		{name: "delete", title: "Remove files in the path", noRemote: true},
 		{name: "deletefile", title: "Remove the single file pointed to"},
-		{name: "copyurl", title: "Copy the URL to the object", help: "- url - string, URL to read from\n - autoFilename - boolean, set to true to retrieve destination file name from url\n"},
+		{name: "copyurl", title: "Copy the URL to the object", help: "- url - string, URL to read from\n - autoFilename - boolean, set to true to retrieve destination file name from url\n", params: []rc.Param{
+			{Name: "url", Type: rc.TypeString, Required: true, Help: "URL to read from"},
+			{Name: "autoFilename", Type: rc.TypeBoolean, Help: "set to true to retrieve destination file name from url"},
+			{Name: "headerFilename", Type: rc.TypeBoolean, Help: "set to true to use the file name from the Content-Disposition header"},
+			{Name: "noClobber", Type: rc.TypeBoolean, Help: "set to true not to overwrite an existing file"},
+		}},
 		{name: "uploadfile", title: "Upload file using multiform/form-data", help: "- each part in body represents a file to be uploaded\n", needsRequest: true},
 		{name: "cleanup", title: "Remove trashed files in the remote or path", noRemote: true},
 	} {
 		op := op
 		remote := "- remote - a path within that remote e.g. \"dir\"\n"
+		params := []rc.Param{fsParam, remoteParam}
 		if op.noRemote {
 			remote = ""
+			params = params[:1]
 		}
+		params = append(params, op.params...)
 		rc.Add(rc.Call{
 			Path:         "operations/" + op.name,
 			AuthRequired: true,
@@ -222,7 +264,8 @@ func init() {
 			Fn: func(ctx context.Context, in rc.Params) (rc.Params, error) {
 				return rcSingleCommand(ctx, in, op.name, op.noRemote)
 			},
-			Title: op.title,
+			Title:  op.title,
+			Params: params,
 			Help: `This takes the following parameters:
 
 - fs - a remote name string e.g. "drive:"
@@ -326,6 +369,12 @@ func init() {
 		AuthRequired: true,
 		Fn:           rcSize,
 		Title:        "Count the number of bytes and files in remote",
+		Params:       []rc.Param{fsParam},
+		Returns: []rc.Param{
+			{Name: "count", Type: rc.TypeInteger, Required: true, Help: "number of files"},
+			{Name: "bytes", Type: rc.TypeInteger, Required: true, Help: "number of bytes in those files"},
+			{Name: "sizeless", Type: rc.TypeInteger, Required: true, Help: "number of files with unknown size"},
+		},
 		Help: `This takes the following parameters:
 
 - fs - a remote name string e.g. "drive:path/to/dir"
@@ -363,6 +412,15 @@ func init() {
 		AuthRequired: true,
 		Fn:           rcPublicLink,
 		Title:        "Create or retrieve a public link to the given file or folder.",
+		Params: []rc.Param{
+			fsParam,
+			remoteParam,
+			{Name: "unlink", Type: rc.TypeBoolean, Help: "if set removes the link rather than adding it"},
+			{Name: "expire", Type: rc.TypeString, Help: `the expiry time of the link e.g. "1d"`},
+		},
+		Returns: []rc.Param{
+			{Name: "url", Type: rc.TypeString, Required: true, Help: "URL of the resource"},
+		},
 		Help: `This takes the following parameters:
 
 - fs - a remote name string e.g. "drive:"
@@ -403,9 +461,19 @@ func rcPublicLink(ctx context.Context, in rc.Params) (out rc.Params, err error)
 
 func init() {
 	rc.Add(rc.Call{
-		Path:  "operations/fsinfo",
-		Fn:    rcFsInfo,
-		Title: "Return information about the remote",
+		Path:   "operations/fsinfo",
+		Fn:     rcFsInfo,
+		Title:  "Return information about the remote",
+		Params: []rc.Param{fsParam},
+		Returns: []rc.Param{
+			{Name: "Features", Type: rc.TypeObject, Required: true, Help: "optional features and whether they are available or not"},
+			{Name: "Hashes", Type: rc.TypeArray, Items: rc.TypeString, Required: true, Help: "names of hashes available"},
+			{Name: "Name", Type: rc.TypeString, Required: true, Help: "name as created"},
+			{Name: "Precision", Type: rc.TypeInteger, Required: true, Help: "precision of timestamps in ns"},
+			{Name: "Root", Type: rc.TypeString, Required: true, Help: "path as created"},
+			{Name: "String", Type: rc.TypeString, Required: true, Help: "how the remote will appear in logs"},
+			{Name: "MetadataInfo", Type: rc.TypeObject, Help: "information about the system metadata for this backend"},
+		},
 		Help: `This takes the following parameters:
 
 - fs - a remote name string e.g. "drive:"
@@ -620,3 +688,207 @@ func rcBackend(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	out["result"] = result
 	return out, nil
 }
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "operations/check",
+		AuthRequired: true,
+		Fn:           rcCheck,
+		Title:        "check the source and destination are the same",
+		Params: []rc.Param{
+			{Name: "srcFs", Type: rc.TypeString, Help: `a remote name string e.g. "drive:" for the source`},
+			{Name: "dstFs", Type: rc.TypeString, Required: true, Help: `a remote name string e.g. "drive2:" for the destination`},
+			{Name: "download", Type: rc.TypeBoolean, Help: "check by downloading rather than with hash"},
+			{Name: "checkFileHash", Type: rc.TypeString, Help: "the hash type of the SUM file"},
+			{Name: "checkFileFs", Type: rc.TypeString, Help: "the remote with the SUM file"},
+			{Name: "checkFileRemote", Type: rc.TypeString, Help: "the path of the SUM file in checkFileFs"},
+			{Name: "oneWay", Type: rc.TypeBoolean, Help: "check one way only, source files must exist on remote"},
+			{Name: "samplePercent", Type: rc.TypeNumber, Help: "only check this percentage of the files in both"},
+			{Name: "sampleBytes", Type: rc.TypeString, Help: `only check this many bytes of the files in both e.g. "10G"`},
+			{Name: "sampleSeed", Type: rc.TypeInteger, Help: "seed for choosing the sample"},
+			{Name: "sampleStratified", Type: rc.TypeBoolean, Help: "sample each directory in proportion to its size"},
+		},
+		Returns: []rc.Param{
+			{Name: "success", Type: rc.TypeBoolean, Required: true, Help: "true if no error, false otherwise"},
+			{Name: "status", Type: rc.TypeString, Required: true, Help: "textual summary of check, OK or text string"},
+			{Name: "hashType", Type: rc.TypeString, Help: "hash used in check"},
+			{Name: "src", Type: rc.TypeString, Help: "the source or SUM file checked"},
+			{Name: "dst", Type: rc.TypeString, Help: "the destination checked"},
+			{Name: "start", Type: rc.TypeString, Help: "time the check started"},
+			{Name: "end", Type: rc.TypeString, Help: "time the check finished"},
+			{Name: "summary", Type: rc.TypeObject, Help: "the number of files for each status"},
+			{Name: "sample", Type: rc.TypeObject, Help: "the coverage and confidence figures if sampling"},
+			{Name: "files", Type: rc.TypeArray, Items: rc.TypeObject, Help: "the files checked"},
+		},
+		Help: `Checks the files in the source and destination match.  It compares
+sizes and hashes and returns a report of files that don't match.
+It doesn't alter the source or destination.
+
+This takes the following parameters:
+
+- srcFs - a remote name string e.g. "drive:" for the source, "/" for local filesystem
+- dstFs - a remote name string e.g. "drive2:" for the destination, "/" for local filesystem
+- download - check by downloading rather than with hash
+- checkFileHash - treat checkFileFs:checkFileRemote as a SUM file with hashes of given type
+- checkFileFs - treat checkFileFs:checkFileRemote as a SUM file with hashes of given type
+- checkFileRemote - treat checkFileFs:checkFileRemote as a SUM file with hashes of given type
+- oneWay -  check one way only, source files must exist on remote
+- samplePercent - only check this percentage of the files in both
+- sampleBytes - only check this many bytes of the files in both e.g. "10G"
+- sampleSeed - seed for choosing the sample
+- sampleStratified - sample each directory in proportion to its size
+
+If checkFileHash is set then srcFs is not needed and dstFs is the
+remote checked against the SUM file.
+
+Returns:
+
+- success - true if no error, false otherwise
+- status - textual summary of check, OK or text string
+- hashType - hash used in check, may be missing
+- src - the source or SUM file checked
+- dst - the destination checked
+- start - time the check started
+- end - time the check finished
+- summary - the number of files for each status
+- sample - the coverage and confidence figures if sampling
+- files - a list of the files checked, each with
+    - path - the path of the file
+    - status - one of match, differ, missing_on_src, missing_on_dst or error
+    - src, dst - the size, modTime and hashes compared, if present
+    - compared - what was compared e.g. size, a hash name or content
+    - error - why the file differs or the error checking it
+
+This is the same structure as written by [check](/commands/rclone_check/)
+with the --report-format json flag.
+`,
+	})
+}
+
+// Check two directories are the same
+func rcCheck(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	srcFs, err := rc.GetFsNamed(ctx, in, "srcFs")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+
+	dstFs, err := rc.GetFsNamed(ctx, in, "dstFs")
+	if err != nil {
+		return nil, err
+	}
+
+	checkFileFs, err := rc.GetFsNamed(ctx, in, "checkFileFs")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+
+	checkFileRemote, err := in.GetString("checkFileRemote")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+
+	checkFileHash, err := in.GetString("checkFileHash")
+	if err != nil && !rc.IsErrParamNotFound(err) {
+		return nil, err
+	}
+
+	checkFileSet := 0
+	if checkFileHash != "" {
+		checkFileSet++
+	}
+	if checkFileFs != nil {
+		checkFileSet++
+	}
+	if checkFileRemote != "" {
+		checkFileSet++
+	}
+	if checkFileSet > 0 && checkFileSet < 3 {
+		return nil, fmt.Errorf("need all of checkFileFs, checkFileRemote, checkFileHash to be set together")
+	}
+	if checkFileSet == 0 && srcFs == nil {
+		return nil, rc.NewErrParamInvalid(fmt.Errorf("need srcFs parameter"))
+	}
+
+	var checkFileHashType hash.Type
+	if checkFileHash != "" {
+		if err := checkFileHashType.Set(checkFileHash); err != nil {
+			return nil, err
+		}
+	}
+
+	oneway, _ := in.GetBool("oneWay")
+	download, _ := in.GetBool("download")
+
+	opt := &CheckOpt{
+		Fsrc:   srcFs,
+		Fdst:   dstFs,
+		OneWay: oneway,
+		Report: NewCheckReport(),
+	}
+
+	var sample CheckSample
+	sample.Percent, err = in.GetFloat64("samplePercent")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	sampleBytes, err := in.Get("sampleBytes")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	if size, ok := sampleBytes.(string); ok {
+		if err := sample.Bytes.Set(size); err != nil {
+			return nil, rc.NewErrParamInvalid(fmt.Errorf("bad sampleBytes: %w", err))
+		}
+	} else if sampleBytes != nil {
+		size, err := in.GetInt64("sampleBytes")
+		if err != nil {
+			return nil, err
+		}
+		sample.Bytes = fs.SizeSuffix(size)
+	}
+	sample.Seed, err = in.GetInt64("sampleSeed")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	sample.Stratified, err = in.GetBool("sampleStratified")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	if sample.Percent != 0 || sample.Bytes != 0 || sample.Stratified {
+		if checkFileSet == 3 {
+			return nil, rc.NewErrParamInvalid(fmt.Errorf("can't sample when checking a SUM file"))
+		}
+		if err := sample.Validate(); err != nil {
+			return nil, rc.NewErrParamInvalid(err)
+		}
+		opt.Sample = &sample
+	}
+
+	out = rc.Params{}
+	if checkFileSet == 3 {
+		err = CheckSum(ctx, dstFs, checkFileFs, checkFileRemote, checkFileHashType, opt, download)
+		out["hashType"] = checkFileHashType.String()
+	} else if download {
+		err = CheckDownload(ctx, opt)
+	} else {
+		hashType := srcFs.Hashes().Overlap(dstFs.Hashes()).GetOne()
+		if hashType == hash.None {
+			fs.Errorf(nil, "No common hash found - not using a hash for checks")
+		} else {
+			fs.Infof(nil, "Using %v for hash comparisons", hashType)
+			out["hashType"] = hashType.String()
+		}
+		err = Check(ctx, opt)
+	}
+
+	if err := rc.Reshape(&out, opt.Report); err != nil {
+		return nil, err
+	}
+	out["success"] = err == nil
+	if err != nil {
+		out["status"] = err.Error()
+	} else {
+		out["status"] = "OK"
+	}
+	return out, nil
+}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
@@ -55,6 +56,87 @@ func TestRcAbout(t *testing.T) {
 	assert.NotEqual(t, int64(0), out["Total"])
 }
 
+// operations/check: check the source and destination are the same
+func TestRcCheck(t *testing.T) {
+	ctx := context.Background()
+	r, call := rcNewRun(t, "operations/check")
+	defer r.Finalise()
+	r.Mkdir(ctx, r.Fremote)
+
+	MD5SUMS := `
+0ef726ce9b1a7692357ff70dd321d595  file1
+deadbeefcafe00000000000000000000  subdir/file2
+0386a8b8fbf90d3d45fc3a77f3ef4fb5  subdir/subsubdir/file4
+`
+
+	file1 := r.WriteBoth(ctx, "file1", "file1 contents", t1)
+	file2 := r.WriteFile("subdir/file2", MD5SUMS, t2)
+	file3 := r.WriteObject(ctx, "subdir/subsubdir/file3", "file3 contents", t3)
+	file4a := r.WriteFile("subdir/subsubdir/file4", "file4 contents", t3)
+	file4b := r.WriteObject(ctx, "subdir/subsubdir/file4", "file4 different contents", t3)
+
+	r.CheckLocalItems(t, file1, file2, file4a)
+	r.CheckRemoteItems(t, file1, file3, file4b)
+
+	in := rc.Params{
+		"dstFs": r.FremoteName,
+	}
+	_, err := call.Fn(ctx, in)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "need srcFs parameter")
+
+	in = rc.Params{
+		"srcFs": r.LocalName,
+		"dstFs": r.FremoteName,
+	}
+	out, err := call.Fn(ctx, in)
+	require.NoError(t, err)
+	assert.Equal(t, false, out["success"])
+	assert.Equal(t, "3 differences found", out["status"])
+	summary, ok := out["summary"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, float64(4), summary["total"])
+	assert.Equal(t, float64(1), summary["match"])
+	assert.Equal(t, float64(1), summary["differ"])
+	assert.Equal(t, float64(1), summary["missingOnSrc"])
+	assert.Equal(t, float64(1), summary["missingOnDst"])
+	files, ok := out["files"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, files, 4)
+	first := files[0].(map[string]interface{})
+	assert.Equal(t, "file1", first["path"])
+	assert.Equal(t, "match", first["status"])
+
+	in = rc.Params{
+		"dstFs":           r.FremoteName,
+		"checkFileFs":     r.LocalName,
+		"checkFileRemote": file2.Path,
+		"checkFileHash":   "md5",
+	}
+	out, err = call.Fn(ctx, in)
+	require.NoError(t, err)
+	assert.Equal(t, false, out["success"])
+	assert.Equal(t, "md5", out["hashType"])
+	summary = out["summary"].(map[string]interface{})
+	assert.Equal(t, float64(4), summary["total"])
+	assert.Equal(t, float64(1), summary["differ"])
+	assert.Equal(t, float64(1), summary["missingOnDst"])
+	assert.Equal(t, float64(1), summary["missingOnSrc"])
+
+	// Sample options are checked as they are by the command
+	for _, in := range []rc.Params{
+		{"sampleStratified": true},
+		{"samplePercent": 101},
+		{"sampleBytes": "-1"},
+	} {
+		in["srcFs"] = r.LocalName
+		in["dstFs"] = r.FremoteName
+		_, err = call.Fn(ctx, in)
+		require.Error(t, err, in)
+		assert.True(t, rc.IsErrParamInvalid(err), in)
+	}
+}
+
 // operations/cleanup: Remove trashed files in the remote or path
 func TestRcCleanup(t *testing.T) {
 	r, call := rcNewRun(t, "operations/cleanup")
@@ -595,3 +677,17 @@ func TestRcCommand(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), errTxt)
 }
+
+// Check the operations calls describe their parameters
+func TestRcParamsDescribed(t *testing.T) {
+	for _, call := range rc.Calls.List() {
+		if !strings.HasPrefix(call.Path, "operations/") {
+			continue
+		}
+		assert.NotEmpty(t, call.Params, call.Path)
+		for _, param := range call.Params {
+			assert.NotEqual(t, "", param.Name, call.Path)
+			assert.NotEqual(t, "", param.Type, call.Path)
+		}
+	}
+}
@@ -26,12 +26,14 @@ func NewPacer(ctx context.Context, c pacer.Calculator) *Pacer {
 	if retries <= 0 {
 		retries = 1
 	}
+	remote, backend := RemoteFromContext(ctx)
 	p := &Pacer{
 		Pacer: pacer.New(
 			pacer.InvokerOption(pacerInvoker),
 			pacer.MaxConnectionsOption(ci.Checkers+ci.Transfers),
 			pacer.RetriesOption(retries),
 			pacer.CalculatorOption(c),
+			pacer.RemoteOption(remote, backend),
 		),
 	}
 	p.SetCalculator(c)
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+)
+
+// Types of Event
+const (
+	EventStarted  = "started"
+	EventFinished = "finished"
+)
+
+// Event describes a job starting or finishing
+type Event struct {
+	Type     string    `json:"type"`
+	ID       int64     `json:"id"`
+	Group    string    `json:"group"`
+	Call     string    `json:"call,omitempty"`
+	Time     time.Time `json:"time"`
+	Success  bool      `json:"success,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Err      error     `json:"-"` // the error the job returned if any
+	Duration float64   `json:"duration,omitempty"`
+}
+
+// observers are called with every Event
+var observers struct {
+	mu     sync.RWMutex
+	fns    map[int]func(Event)
+	nextID int
+}
+
+// Observe calls fn with an Event whenever a job starts or finishes.
+// It returns a function to stop observing.
+//
+// fn is called synchronously so it should not block.
+func Observe(fn func(Event)) (stop func()) {
+	observers.mu.Lock()
+	defer observers.mu.Unlock()
+	if observers.fns == nil {
+		observers.fns = map[int]func(Event){}
+	}
+	id := observers.nextID
+	observers.nextID++
+	observers.fns[id] = fn
+	return func() {
+		observers.mu.Lock()
+		delete(observers.fns, id)
+		observers.mu.Unlock()
+	}
+}
+
+// notify sends the event to the observers
+func notify(ev Event) {
+	observers.mu.RLock()
+	defer observers.mu.RUnlock()
+	for _, fn := range observers.fns {
+		fn(ev)
+	}
+}
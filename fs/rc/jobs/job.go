@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime/debug"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,6 +22,7 @@ type Job struct {
 	mu        sync.Mutex
 	ID        int64     `json:"id"`
 	Group     string    `json:"group"`
+	Call      string    `json:"call,omitempty"` // the rc path of the job if known
 	StartTime time.Time `json:"startTime"`
 	EndTime   time.Time `json:"endTime"`
 	Error     string    `json:"error"`
@@ -28,8 +30,10 @@ type Job struct {
 	Success   bool      `json:"success"`
 	Duration  float64   `json:"duration"`
 	Output    rc.Params `json:"output"`
+	Stats     rc.Params `json:"stats,omitempty"` // snapshot of the stats when finished if the job store is in use
 	Stop      func()    `json:"-"`
 	listeners []*func()
+	store     *jobStore // where to persist the job or nil
 
 	// realErr is the Error before printing it as a string, it's used to return
 	// the real error to the upper application layers while still printing the
@@ -56,13 +60,28 @@ func (job *Job) finish(out rc.Params, err error) {
 		job.Success = true
 	}
 	job.Finished = true
+	if job.store != nil {
+		job.store.put(job)
+	}
 
 	// Notify listeners that the job is finished
 	for i := range job.listeners {
 		go (*job.listeners[i])()
 	}
+	ev := Event{
+		Type:     EventFinished,
+		ID:       job.ID,
+		Group:    job.Group,
+		Call:     job.Call,
+		Time:     job.EndTime,
+		Success:  job.Success,
+		Error:    job.Error,
+		Err:      job.realErr,
+		Duration: job.Duration,
+	}
 
 	job.mu.Unlock()
+	notify(ev)
 	running.kickExpire() // make sure this job gets expired
 }
 
@@ -90,7 +109,23 @@ func (job *Job) run(ctx context.Context, fn rc.Func, in rc.Params) {
 			job.finish(nil, fmt.Errorf("panic received: %v \n%s", r, string(debug.Stack())))
 		}
 	}()
-	job.finish(fn(ctx, in))
+	out, err := fn(ctx, in)
+	if job.store != nil {
+		job.snapshotStats(ctx)
+	}
+	job.finish(out, err)
+}
+
+// snapshotStats records the stats of the job so they can be stored
+func (job *Job) snapshotStats(ctx context.Context) {
+	stats, err := accounting.Stats(ctx).RemoteStats()
+	if err != nil {
+		fs.Debugf(nil, "job %d: failed to read stats: %v", job.ID, err)
+		return
+	}
+	job.mu.Lock()
+	job.Stats = stats
+	job.mu.Unlock()
 }
 
 // Jobs describes a collection of running tasks
@@ -99,6 +134,7 @@ type Jobs struct {
 	jobs          map[int64]*Job
 	opt           *rc.Options
 	expireRunning bool
+	store         *jobStore // persistent store of jobs or nil
 }
 
 var (
@@ -148,6 +184,9 @@ func (jobs *Jobs) Expire() {
 		}
 		job.mu.Unlock()
 	}
+	if jobs.store != nil {
+		go jobs.store.prune(now)
+	}
 	if len(jobs.jobs) != 0 {
 		time.AfterFunc(jobs.opt.JobExpireInterval, jobs.Expire)
 		jobs.expireRunning = true
@@ -174,6 +213,61 @@ func (jobs *Jobs) Get(ID int64) *Job {
 	return jobs.jobs[ID]
 }
 
+// getStored gets a job with a given ID from memory or, if it has
+// expired from memory, from the job store. It returns nil if it
+// doesn't exist.
+func (jobs *Jobs) getStored(ID int64) *Job {
+	jobs.mu.RLock()
+	job, store := jobs.jobs[ID], jobs.store
+	jobs.mu.RUnlock()
+	if job == nil && store != nil {
+		job = store.get(ID)
+	}
+	return job
+}
+
+// list returns a copy of the information about the jobs in memory
+// and in the job store, sorted by ID
+func (jobs *Jobs) list() ([]*Job, error) {
+	jobs.mu.RLock()
+	store := jobs.store
+	byID := make(map[int64]*Job, len(jobs.jobs))
+	for ID, job := range jobs.jobs {
+		job.mu.Lock()
+		byID[ID] = &Job{
+			ID:        job.ID,
+			Group:     job.Group,
+			StartTime: job.StartTime,
+			EndTime:   job.EndTime,
+			Error:     job.Error,
+			Finished:  job.Finished,
+			Success:   job.Success,
+			Duration:  job.Duration,
+		}
+		job.mu.Unlock()
+	}
+	jobs.mu.RUnlock()
+	if store != nil {
+		stored, err := store.list()
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range stored {
+			if _, found := byID[job.ID]; !found {
+				byID[job.ID] = job
+			}
+		}
+	}
+	list := make([]*Job, 0, len(byID))
+	for _, job := range byID {
+		list = append(list, job)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
 // Check to see if the group is set
 func getGroup(ctx context.Context, in rc.Params, id int64) (context.Context, string, error) {
 	group, err := in.GetString("_group")
@@ -188,6 +282,36 @@ func getGroup(ctx context.Context, in rc.Params, id int64) (context.Context, str
 	return ctx, group, nil
 }
 
+type callKey struct{}
+
+// WithCall returns a context which records that jobs made with it
+// are running the rc call at path.
+func WithCall(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, callKey{}, path)
+}
+
+// getCall returns the rc call path set by WithCall or ""
+func getCall(ctx context.Context) string {
+	path, _ := ctx.Value(callKey{}).(string)
+	return path
+}
+
+type scopeKey struct{}
+
+// WithScope returns a context which records that the rc call was made
+// with credentials limited to scope, e.g. the ID of an API token. Jobs
+// made with it keep the scope even if they are run with _async.
+func WithScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// Scope returns the scope set by WithScope or "" if the call wasn't
+// made with limited credentials
+func Scope(ctx context.Context) string {
+	scope, _ := ctx.Value(scopeKey{}).(string)
+	return scope
+}
+
 // See if _async is set returning a boolean and a possible new context
 func getAsync(ctx context.Context, in rc.Params) (context.Context, bool, error) {
 	isAsync, err := in.GetBool("_async")
@@ -241,11 +365,16 @@ func getFilter(ctx context.Context, in rc.Params) (context.Context, error) {
 func (jobs *Jobs) NewJob(ctx context.Context, fn rc.Func, in rc.Params) (job *Job, out rc.Params, err error) {
 	id := atomic.AddInt64(&jobID, 1)
 	in = in.Copy() // copy input so we can change it
+	call := getCall(ctx)
+	scope := Scope(ctx)
 
 	ctx, isAsync, err := getAsync(ctx, in)
 	if err != nil {
 		return nil, nil, err
 	}
+	if scope != "" {
+		ctx = WithScope(ctx, scope)
+	}
 
 	ctx, err = getConfig(ctx, in)
 	if err != nil {
@@ -271,12 +400,26 @@ func (jobs *Jobs) NewJob(ctx context.Context, fn rc.Func, in rc.Params) (job *Jo
 	job = &Job{
 		ID:        id,
 		Group:     group,
+		Call:      call,
 		StartTime: time.Now(),
 		Stop:      stop,
 	}
 	jobs.mu.Lock()
 	jobs.jobs[job.ID] = job
+	job.store = jobs.store
 	jobs.mu.Unlock()
+	if job.store != nil {
+		job.mu.Lock()
+		job.store.put(job)
+		job.mu.Unlock()
+	}
+	notify(Event{
+		Type:  EventStarted,
+		ID:    job.ID,
+		Group: job.Group,
+		Call:  job.Call,
+		Time:  job.StartTime,
+	})
 	if isAsync {
 		go job.run(ctx, fn, in)
 		out = make(rc.Params)
@@ -296,6 +439,11 @@ func NewJob(ctx context.Context, fn rc.Func, in rc.Params) (job *Job, out rc.Par
 	return running.NewJob(ctx, fn, in)
 }
 
+// Get gets the Job with the given ID from the global job queue or nil
+func Get(ID int64) *Job {
+	return running.Get(ID)
+}
+
 // OnFinish adds listener to jobid that will be triggered when job is finished.
 // It returns a function to cancel listening.
 func OnFinish(jobID int64, fn func()) (func(), error) {
@@ -313,9 +461,24 @@ func OnFinish(jobID int64, fn func()) (func(), error) {
 
 func init() {
 	rc.Add(rc.Call{
-		Path:  "job/status",
-		Fn:    rcJobStatus,
-		Title: "Reads the status of the job ID",
+		Path:   "job/status",
+		Fn:     rcJobStatus,
+		Title:  "Reads the status of the job ID",
+		Params: []rc.Param{{Name: "jobid", Type: rc.TypeInteger, Required: true, Help: "id of the job"}},
+		Returns: []rc.Param{
+			{Name: "id", Type: rc.TypeInteger, Required: true, Help: "id of the job"},
+			{Name: "group", Type: rc.TypeString, Help: "stats group of the job"},
+			{Name: "call", Type: rc.TypeString, Help: "the rc call the job is running if known"},
+			{Name: "startTime", Type: rc.TypeString, Help: "time the job started"},
+			{Name: "endTime", Type: rc.TypeString, Help: "time the job finished"},
+			{Name: "duration", Type: rc.TypeNumber, Help: "time in seconds that the job ran for"},
+			{Name: "finished", Type: rc.TypeBoolean, Required: true, Help: "whether the job has finished or not"},
+			{Name: "success", Type: rc.TypeBoolean, Required: true, Help: "true for success false otherwise"},
+			{Name: "error", Type: rc.TypeString, Help: "error from the job or empty string for no error"},
+			{Name: "output", Type: rc.TypeObject, Help: "output of the job as would have been returned if called synchronously"},
+			{Name: "progress", Type: rc.TypeObject, Help: "output of the progress related to the underlying job"},
+			{Name: "stats", Type: rc.TypeObject, Help: "a snapshot of the stats of the job when it finished"},
+		},
 		Help: `Parameters:
 
 - jobid - id of the job (integer).
@@ -328,10 +491,16 @@ Results:
 - error - error from the job or empty string for no error
 - finished - boolean whether the job has finished or not
 - id - as passed in above
+- call - the rc call the job is running, e.g. "sync/copy", if known
 - startTime - time the job started (e.g. "2018-10-26T18:50:20.528336039+01:00")
 - success - boolean - true for success false otherwise
 - output - output of the job as would have been returned if called synchronously
 - progress - output of the progress related to the underlying job
+- stats - a snapshot of the stats of the job when it finished, if
+  the job store is enabled with --rc-job-store
+
+If the job store is enabled then finished jobs can be read after
+they have expired from memory and after rclone has been restarted.
 `,
 	})
 }
@@ -342,7 +511,7 @@ func rcJobStatus(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	if err != nil {
 		return nil, err
 	}
-	job := running.Get(jobID)
+	job := running.getStored(jobID)
 	if job == nil {
 		return nil, errors.New("job not found")
 	}
@@ -361,27 +530,133 @@ func init() {
 		Path:  "job/list",
 		Fn:    rcJobList,
 		Title: "Lists the IDs of the running jobs",
-		Help: `Parameters: None.
+		Params: []rc.Param{
+			{Name: "group", Type: rc.TypeString, Help: "only list jobs in this group"},
+			{Name: "finished", Type: rc.TypeBoolean, Help: "only list jobs which have or haven't finished"},
+			{Name: "success", Type: rc.TypeBoolean, Help: "only list jobs which succeeded or failed"},
+			{Name: "since", Type: rc.TypeString, Help: "only list jobs started at or after this RFC 3339 time"},
+			{Name: "offset", Type: rc.TypeInteger, Help: "skip this many of the matching jobs"},
+			{Name: "limit", Type: rc.TypeInteger, Help: "list at most this many jobs"},
+		},
+		Returns: []rc.Param{
+			{Name: "jobids", Type: rc.TypeArray, Items: rc.TypeInteger, Required: true, Help: "the job ids"},
+			{Name: "jobs", Type: rc.TypeArray, Items: rc.TypeObject, Required: true, Help: "the jobs as returned by job/status"},
+			{Name: "total", Type: rc.TypeInteger, Required: true, Help: "the number of jobs matching before offset and limit"},
+		},
+		Help: `Parameters - all optional:
+
+- group - only list jobs in this group (string)
+- finished - only list jobs which have (true) or haven't (false) finished
+- success - only list jobs which succeeded (true) or failed (false)
+- since - only list jobs started at or after this time e.g. "2021-06-16T10:17:30Z"
+- offset - skip this many of the matching jobs (integer)
+- limit - list at most this many jobs (integer)
 
 Results:
 
 - jobids - array of integer job ids.
+- jobs - array of the jobs, each with id, group, startTime, endTime,
+  error, finished, success and duration as returned by job/status
+- total - the number of jobs matching before offset and limit
+
+Jobs are listed in order of their ids. Finished jobs are only listed
+until they expire (see --rc-job-expire-duration) unless the job store
+is enabled with --rc-job-store, in which case jobs from before a
+restart are listed too.
 `,
 	})
 }
 
 // Returns list of job ids.
 func rcJobList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	group, err := in.GetString("group")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	_, filterFinished := in["finished"]
+	finished, err := in.GetBool("finished")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	_, filterSuccess := in["success"]
+	success, err := in.GetBool("success")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	var since time.Time
+	sinceString, err := in.GetString("since")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	if sinceString != "" {
+		since, err = time.Parse(time.RFC3339, sinceString)
+		if err != nil {
+			return nil, rc.NewErrParamInvalid(fmt.Errorf("bad since: %w", err))
+		}
+	}
+	offset, err := in.GetInt64("offset")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	limit, err := in.GetInt64("limit")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	if offset < 0 || limit < 0 {
+		return nil, rc.NewErrParamInvalid(errors.New("offset and limit must be positive"))
+	}
+
+	all, err := running.list()
+	if err != nil {
+		return nil, err
+	}
+	var matched []*Job
+	for _, job := range all {
+		if (group != "" && job.Group != group) ||
+			(filterFinished && job.Finished != finished) ||
+			(filterSuccess && (!job.Finished || job.Success != success)) ||
+			job.StartTime.Before(since) {
+			continue
+		}
+		matched = append(matched, job)
+	}
+	total := len(matched)
+	if offset >= int64(len(matched)) {
+		matched = nil
+	} else {
+		matched = matched[offset:]
+	}
+	if limit > 0 && int64(len(matched)) > limit {
+		matched = matched[:limit]
+	}
+	jobIDs := []int64{}
+	jobList := []rc.Params{}
+	for _, job := range matched {
+		jobIDs = append(jobIDs, job.ID)
+		jobList = append(jobList, rc.Params{
+			"id":        job.ID,
+			"group":     job.Group,
+			"startTime": job.StartTime,
+			"endTime":   job.EndTime,
+			"error":     job.Error,
+			"finished":  job.Finished,
+			"success":   job.Success,
+			"duration":  job.Duration,
+		})
+	}
 	out = make(rc.Params)
-	out["jobids"] = running.IDs()
+	out["jobids"] = jobIDs
+	out["jobs"] = jobList
+	out["total"] = total
 	return out, nil
 }
 
 func init() {
 	rc.Add(rc.Call{
-		Path:  "job/stop",
-		Fn:    rcJobStop,
-		Title: "Stop the running job",
+		Path:   "job/stop",
+		Fn:     rcJobStop,
+		Title:  "Stop the running job",
+		Params: []rc.Param{{Name: "jobid", Type: rc.TypeInteger, Required: true, Help: "id of the job"}},
 		Help: `Parameters:
 
 - jobid - id of the job (integer).
@@ -411,6 +686,9 @@ func init() {
 		Path:  "job/stopgroup",
 		Fn:    rcGroupStop,
 		Title: "Stop all running jobs in a group",
+		Params: []rc.Param{
+			{Name: "group", Type: rc.TypeString, Required: true, Help: "name of the group"},
+		},
 		Help: `Parameters:
 
 - group - name of the group (string).
@@ -316,6 +316,46 @@ func TestExecuteJobWithGroup(t *testing.T) {
 	assert.Equal(t, true, called)
 }
 
+func TestExecuteJobWithCall(t *testing.T) {
+	ctx := context.Background()
+	jobID = 0
+	jobs := newJobs()
+	var events []Event
+	stop := Observe(func(ev Event) {
+		events = append(events, ev)
+	})
+	defer stop()
+	testErr := errors.New("potato")
+	job, _, err := jobs.NewJob(WithCall(ctx, "test/call"), func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return nil, testErr
+	}, rc.Params{})
+	require.Equal(t, testErr, err)
+	assert.Equal(t, "test/call", job.Call)
+	require.Equal(t, 2, len(events))
+	assert.Equal(t, "test/call", events[0].Call)
+	assert.Equal(t, "test/call", events[1].Call)
+	assert.Equal(t, testErr, events[1].Err)
+}
+
+func TestExecuteJobWithScope(t *testing.T) {
+	ctx := context.Background()
+	jobID = 0
+	jobs := newJobs()
+	scopes := make(chan string, 1)
+	jobFn := func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		scopes <- Scope(ctx)
+		return nil, nil
+	}
+	_, _, err := jobs.NewJob(WithScope(ctx, "token1"), jobFn, rc.Params{
+		"_async": true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "token1", <-scopes)
+	_, _, err = jobs.NewJob(ctx, jobFn, rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, "", <-scopes)
+}
+
 func TestExecuteJobErrorPropagation(t *testing.T) {
 	ctx := context.Background()
 	jobID = 0
@@ -368,7 +408,8 @@ func TestRcJobList(t *testing.T) {
 	out, err := call.Fn(context.Background(), in)
 	require.NoError(t, err)
 	require.NotNil(t, out)
-	assert.Equal(t, rc.Params{"jobids": []int64{1}}, out)
+	assert.Equal(t, []int64{1}, out["jobids"])
+	assert.Equal(t, 1, out["total"])
 }
 
 func TestRcAsyncJobStop(t *testing.T) {
@@ -532,3 +573,46 @@ func TestOnFinishAlreadyFinished(t *testing.T) {
 		t.Fatal("Timeout waiting for OnFinish to fire")
 	}
 }
+
+func TestRcJobListFilter(t *testing.T) {
+	ctx := context.Background()
+	saved := running
+	running = newJobs()
+	defer func() { running = saved }()
+
+	job1, _, err := running.NewJob(ctx, noopFn, rc.Params{"_group": "potato"})
+	require.NoError(t, err)
+	job2, _, err := running.NewJob(ctx, func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return nil, errors.New("boom")
+	}, rc.Params{"_group": "potato"})
+	require.Error(t, err)
+	job3, _, err := running.NewJob(ctx, longFn, rc.Params{"_async": true})
+	require.NoError(t, err)
+
+	call := rc.Calls.Get("job/list")
+	assert.NotNil(t, call)
+	for _, test := range []struct {
+		in    rc.Params
+		want  []int64
+		total int
+	}{
+		{rc.Params{}, []int64{job1.ID, job2.ID, job3.ID}, 3},
+		{rc.Params{"group": "potato"}, []int64{job1.ID, job2.ID}, 2},
+		{rc.Params{"finished": false}, []int64{job3.ID}, 1},
+		{rc.Params{"success": true}, []int64{job1.ID}, 1},
+		{rc.Params{"success": false}, []int64{job2.ID}, 1},
+		{rc.Params{"since": time.Now().Add(time.Hour).Format(time.RFC3339)}, []int64{}, 0},
+		{rc.Params{"offset": 1, "limit": 1}, []int64{job2.ID}, 3},
+		{rc.Params{"offset": 5}, []int64{}, 3},
+	} {
+		out, err := call.Fn(ctx, test.in)
+		require.NoError(t, err)
+		assert.Equal(t, test.want, out["jobids"], test.in)
+		assert.Equal(t, test.total, out["total"], test.in)
+		assert.Equal(t, len(test.want), len(out["jobs"].([]rc.Params)), test.in)
+	}
+	_, err = call.Fn(ctx, rc.Params{"since": "yesterday"})
+	assert.Error(t, err)
+	_, err = call.Fn(ctx, rc.Params{"limit": -1})
+	assert.Error(t, err)
+}
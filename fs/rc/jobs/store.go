@@ -0,0 +1,288 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/kv"
+)
+
+// jobStoreFacility is the name of the kv database holding the jobs
+const jobStoreFacility = "rcjobs"
+
+// jobStorePruneInterval is the most often expired jobs are removed
+// from the store while rclone is running
+const jobStorePruneInterval = time.Hour
+
+// errInterrupted is recorded for jobs which were running when rclone stopped
+const errInterrupted = "interrupted: rclone stopped before the job finished"
+
+// jobStore keeps a record of the jobs in a key-value database so
+// their status and output persist across restarts of the rc server.
+type jobStore struct {
+	db     *kv.DB
+	maxAge time.Duration // forget finished jobs older than this if set
+
+	mu        sync.Mutex
+	lastPrune time.Time // when expired jobs were last removed
+}
+
+// jobKey makes the database key for a job ID so that keys sort by ID
+func jobKey(id int64) []byte {
+	return []byte(fmt.Sprintf("%020d", id))
+}
+
+// StartStore opens the persistent job store if it is enabled in the
+// options set with SetOpt.
+//
+// Jobs which were running when rclone last stopped are marked as
+// failed and jobs older than the maximum age are removed.
+func StartStore(ctx context.Context) error {
+	return running.startStore(ctx)
+}
+
+func (jobs *Jobs) startStore(ctx context.Context) error {
+	if !jobs.opt.JobStore {
+		return nil
+	}
+	if !kv.Supported() {
+		return fmt.Errorf("job store: %w", kv.ErrUnsupported)
+	}
+	db, err := kv.Start(ctx, jobStoreFacility, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open job store: %w", err)
+	}
+	store := &jobStore{
+		db:     db,
+		maxAge: jobs.opt.JobStoreMaxAge,
+	}
+	op := &opRecoverJobs{maxAge: store.maxAge}
+	err = db.Do(true, op)
+	if err != nil {
+		return fmt.Errorf("failed to read job store: %w", err)
+	}
+	if op.interrupted > 0 {
+		fs.Logf(nil, "Marked %d jobs interrupted by a restart as failed", op.interrupted)
+	}
+	fs.Debugf(nil, "Opened job store %q with %d jobs", db.Path(), op.count)
+	// Make sure new jobs don't reuse IDs of stored jobs
+	for {
+		current := atomic.LoadInt64(&jobID)
+		if op.maxID <= current || atomic.CompareAndSwapInt64(&jobID, current, op.maxID) {
+			break
+		}
+	}
+	jobs.mu.Lock()
+	jobs.store = store
+	jobs.mu.Unlock()
+	return nil
+}
+
+// put saves the job in the store - call with job.mu held
+func (s *jobStore) put(job *Job) {
+	data, err := json.Marshal(job)
+	if err == nil {
+		err = s.db.Do(true, &opPutJob{id: job.ID, data: data})
+	}
+	if err != nil {
+		fs.Errorf(nil, "Failed to save job %d: %v", job.ID, err)
+	}
+}
+
+// prune removes the finished jobs older than maxAge from the store
+// unless it has been done recently. It is called from Expire so the
+// store doesn't grow while rclone keeps running.
+func (s *jobStore) prune(now time.Time) {
+	if s.maxAge <= 0 {
+		return
+	}
+	interval := jobStorePruneInterval
+	if s.maxAge < interval {
+		interval = s.maxAge
+	}
+	s.mu.Lock()
+	if now.Sub(s.lastPrune) < interval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPrune = now
+	s.mu.Unlock()
+	op := &opPruneJobs{maxAge: s.maxAge, now: now}
+	err := s.db.Do(true, op)
+	if err != nil && err != kv.ErrEmpty {
+		fs.Errorf(nil, "Failed to remove expired jobs from the job store: %v", err)
+		return
+	}
+	if op.removed > 0 {
+		fs.Debugf(nil, "Removed %d expired jobs from the job store", op.removed)
+	}
+}
+
+// get reads the job with the ID from the store or returns nil
+func (s *jobStore) get(id int64) *Job {
+	op := &opGetJob{id: id}
+	err := s.db.Do(false, op)
+	if err != nil && err != kv.ErrEmpty {
+		fs.Errorf(nil, "Failed to read job %d: %v", id, err)
+	}
+	return op.job
+}
+
+// list returns all the jobs in the store in ID order
+func (s *jobStore) list() ([]*Job, error) {
+	op := &opListJobs{}
+	err := s.db.Do(false, op)
+	if err == kv.ErrEmpty {
+		err = nil
+	}
+	return op.jobs, err
+}
+
+// decodeJob decodes a job record from the database
+func decodeJob(key, data []byte) (*Job, error) {
+	job := &Job{}
+	if err := json.Unmarshal(data, job); err != nil {
+		return nil, fmt.Errorf("bad job record %s: %w", key, err)
+	}
+	return job, nil
+}
+
+// opPutJob saves a job record
+type opPutJob struct {
+	id   int64
+	data []byte
+}
+
+func (op *opPutJob) Do(ctx context.Context, b kv.Bucket) error {
+	return b.Put(jobKey(op.id), op.data)
+}
+
+// opGetJob reads a job record
+type opGetJob struct {
+	id  int64
+	job *Job
+}
+
+func (op *opGetJob) Do(ctx context.Context, b kv.Bucket) (err error) {
+	key := jobKey(op.id)
+	data := b.Get(key)
+	if data == nil {
+		return nil
+	}
+	op.job, err = decodeJob(key, data)
+	return err
+}
+
+// opListJobs reads all the job records
+type opListJobs struct {
+	jobs []*Job
+}
+
+func (op *opListJobs) Do(ctx context.Context, b kv.Bucket) error {
+	return b.ForEach(func(key, data []byte) error {
+		job, err := decodeJob(key, data)
+		if err != nil {
+			fs.Debugf(nil, "Ignoring %v", err)
+			return nil
+		}
+		op.jobs = append(op.jobs, job)
+		return nil
+	})
+}
+
+// opPruneJobs removes finished jobs older than maxAge
+type opPruneJobs struct {
+	maxAge  time.Duration
+	now     time.Time
+	removed int
+}
+
+func (op *opPruneJobs) Do(ctx context.Context, b kv.Bucket) error {
+	var expired [][]byte
+	err := b.ForEach(func(key, data []byte) error {
+		job, err := decodeJob(key, data)
+		if err != nil {
+			return nil // left for opRecoverJobs to remove
+		}
+		if job.Finished && op.now.Sub(job.EndTime) > op.maxAge {
+			expired = append(expired, append([]byte{}, key...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range expired {
+		if err := b.Delete(key); err != nil {
+			return err
+		}
+		op.removed++
+	}
+	return nil
+}
+
+// opRecoverJobs marks jobs left running as failed, removes expired
+// jobs and finds the largest job ID in use
+type opRecoverJobs struct {
+	maxAge      time.Duration
+	maxID       int64
+	count       int
+	interrupted int
+}
+
+func (op *opRecoverJobs) Do(ctx context.Context, b kv.Bucket) error {
+	var (
+		now     = time.Now()
+		expired [][]byte
+		updated = map[string][]byte{}
+	)
+	err := b.ForEach(func(key, data []byte) error {
+		if id, err := strconv.ParseInt(string(key), 10, 64); err == nil && id > op.maxID {
+			op.maxID = id
+		}
+		job, err := decodeJob(key, data)
+		if err != nil {
+			fs.Debugf(nil, "Removing %v", err)
+			expired = append(expired, append([]byte{}, key...))
+			return nil
+		}
+		if !job.Finished {
+			job.Finished = true
+			job.Success = false
+			job.Error = errInterrupted
+			job.EndTime = now
+			job.Duration = job.EndTime.Sub(job.StartTime).Seconds()
+			data, err := json.Marshal(job)
+			if err != nil {
+				return err
+			}
+			updated[string(key)] = data
+			op.interrupted++
+		} else if op.maxAge > 0 && now.Sub(job.EndTime) > op.maxAge {
+			expired = append(expired, append([]byte{}, key...))
+			return nil
+		}
+		op.count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for key, data := range updated {
+		if err := b.Put([]byte(key), data); err != nil {
+			return err
+		}
+	}
+	for _, key := range expired {
+		if err := b.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/lib/kv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStoreJobs makes a Jobs with the job store enabled
+func newStoreJobs(t *testing.T) *Jobs {
+	jobs := newJobs()
+	opt := *jobs.opt
+	opt.JobStore = true
+	jobs.opt = &opt
+	require.NoError(t, jobs.startStore(context.Background()))
+	require.NotNil(t, jobs.store)
+	t.Cleanup(func() { _ = jobs.store.db.Stop(false) })
+	return jobs
+}
+
+func TestJobStore(t *testing.T) {
+	if !kv.Supported() {
+		t.Skip("no kv database on this OS")
+	}
+	ctx := context.Background()
+	jobs := newStoreJobs(t)
+
+	job1, _, err := jobs.NewJob(ctx, func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return rc.Params{"potato": "sausage"}, nil
+	}, rc.Params{"_group": "group1"})
+	require.NoError(t, err)
+	job2, _, err := jobs.NewJob(ctx, func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return nil, assert.AnError
+	}, rc.Params{})
+	require.Error(t, err)
+	job3, _, err := jobs.NewJob(ctx, longFn, rc.Params{"_async": true, "_group": "group1"})
+	require.NoError(t, err)
+
+	// Finished jobs are in the store with their output and stats
+	stored := jobs.store.get(job1.ID)
+	require.NotNil(t, stored)
+	assert.Equal(t, true, stored.Finished)
+	assert.Equal(t, true, stored.Success)
+	assert.Equal(t, "group1", stored.Group)
+	assert.Equal(t, "sausage", stored.Output["potato"])
+	assert.NotNil(t, stored.Stats)
+	stored = jobs.store.get(job2.ID)
+	require.NotNil(t, stored)
+	assert.Equal(t, false, stored.Success)
+	assert.Equal(t, assert.AnError.Error(), stored.Error)
+	stored = jobs.store.get(job3.ID)
+	require.NotNil(t, stored)
+	assert.Equal(t, false, stored.Finished)
+
+	// Simulate a restart
+	jobs = newStoreJobs(t)
+	assert.Nil(t, jobs.Get(job1.ID))
+	assert.NotNil(t, jobs.getStored(job1.ID))
+	stored = jobs.getStored(job3.ID)
+	require.NotNil(t, stored)
+	assert.Equal(t, true, stored.Finished)
+	assert.Equal(t, false, stored.Success)
+	assert.Equal(t, errInterrupted, stored.Error)
+	assert.False(t, stored.EndTime.IsZero())
+
+	// New jobs don't reuse the IDs
+	job4, _, err := jobs.NewJob(ctx, noopFn, rc.Params{})
+	require.NoError(t, err)
+	assert.True(t, job4.ID > job3.ID)
+
+	list, err := jobs.list()
+	require.NoError(t, err)
+	var ids []int64
+	for _, job := range list {
+		if job.ID >= job1.ID {
+			ids = append(ids, job.ID)
+		}
+	}
+	assert.Equal(t, []int64{job1.ID, job2.ID, job3.ID, job4.ID}, ids)
+}
+
+func TestJobStoreExpire(t *testing.T) {
+	if !kv.Supported() {
+		t.Skip("no kv database on this OS")
+	}
+	ctx := context.Background()
+	jobs := newStoreJobs(t)
+	job, _, err := jobs.NewJob(ctx, noopFn, rc.Params{})
+	require.NoError(t, err)
+	job.mu.Lock()
+	job.EndTime = time.Now().Add(-2 * jobs.opt.JobStoreMaxAge)
+	jobs.store.put(job)
+	job.mu.Unlock()
+
+	jobs = newStoreJobs(t)
+	assert.Nil(t, jobs.getStored(job.ID))
+}
+
+func TestJobStorePrune(t *testing.T) {
+	if !kv.Supported() {
+		t.Skip("no kv database on this OS")
+	}
+	ctx := context.Background()
+	jobs := newStoreJobs(t)
+	oldJob, _, err := jobs.NewJob(ctx, noopFn, rc.Params{})
+	require.NoError(t, err)
+	newJob, _, err := jobs.NewJob(ctx, noopFn, rc.Params{})
+	require.NoError(t, err)
+	oldJob.mu.Lock()
+	oldJob.EndTime = time.Now().Add(-2 * jobs.opt.JobStoreMaxAge)
+	jobs.store.put(oldJob)
+	oldJob.mu.Unlock()
+
+	// Expired jobs are removed from the store without a restart
+	jobs.Expire()
+	assert.Eventually(t, func() bool {
+		return jobs.store.get(oldJob.ID) == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, jobs.getStored(oldJob.ID))
+	assert.NotNil(t, jobs.store.get(newJob.ID))
+
+	// But not too often
+	oldJob.mu.Lock()
+	jobs.store.put(oldJob)
+	oldJob.mu.Unlock()
+	jobs.store.prune(time.Now())
+	assert.NotNil(t, jobs.store.get(oldJob.ID))
+}
@@ -0,0 +1,161 @@
+// Generate an OpenAPI description of the registry
+
+package rc
+
+import (
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// specialParams are the parameters understood by every call when run
+// by the rc server
+var specialParams = []Param{
+	{Name: "_async", Type: TypeBoolean, Help: "run the call in the background returning a jobid"},
+	{Name: "_group", Type: TypeString, Help: "put any stats for the call in this group"},
+	{Name: "_config", Type: TypeObject, Help: "override config flags for this call"},
+	{Name: "_filter", Type: TypeObject, Help: "override filter flags for this call"},
+}
+
+// schema makes a JSON schema describing an object with the params
+//
+// Other properties are allowed as not all calls describe all their
+// parameters.
+func schema(params []Param, extra []Param) Params {
+	out := Params{
+		"type":                 TypeObject,
+		"additionalProperties": true,
+	}
+	if len(params) == 0 && len(extra) == 0 {
+		return out
+	}
+	properties := Params{}
+	var required []string
+	for _, p := range append(append([]Param{}, params...), extra...) {
+		property := Params{}
+		if p.Type != "" {
+			property["type"] = p.Type
+		}
+		if p.Type == TypeArray {
+			items := Params{}
+			if p.Items != "" {
+				items["type"] = p.Items
+			}
+			property["items"] = items
+		}
+		if p.Help != "" {
+			property["description"] = p.Help
+		}
+		properties[p.Name] = property
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	out["properties"] = properties
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+// operationID makes an OpenAPI operationId from the path, e.g.
+// "operations/copyfile" becomes "operationsCopyfile"
+func operationID(path string) string {
+	var out strings.Builder
+	upper := false
+	for _, c := range path {
+		switch {
+		case c == '/' || c == '-' || c == '_':
+			upper = true
+		case upper:
+			out.WriteString(strings.ToUpper(string(c)))
+			upper = false
+		default:
+			out.WriteRune(c)
+		}
+	}
+	return out.String()
+}
+
+// OpenAPI returns an OpenAPI 3.0 description of the calls in the
+// registry suitable for serialising as JSON.
+//
+// Every call is described as a POST taking and returning a JSON
+// object. Calls with Params or Returns set have their inputs and
+// outputs described, the others accept and return any object.
+func (r *Registry) OpenAPI() Params {
+	paths := Params{}
+	for _, call := range r.List() {
+		tag := call.Path
+		if i := strings.IndexRune(tag, '/'); i >= 0 {
+			tag = tag[:i]
+		}
+		operation := Params{
+			"operationId": operationID(call.Path),
+			"summary":     call.Title,
+			"description": call.Help,
+			"tags":        []string{tag},
+			"requestBody": Params{
+				"content": Params{
+					"application/json": Params{
+						"schema": schema(call.Params, specialParams),
+					},
+				},
+			},
+			"responses": Params{
+				"200": Params{
+					"description": "Success - if _async was set this is an object with the jobid",
+					"content": Params{
+						"application/json": Params{
+							"schema": schema(call.Returns, nil),
+						},
+					},
+				},
+				"default": Params{
+					"description": "Error",
+					"content": Params{
+						"application/json": Params{
+							"schema": Params{"$ref": "#/components/schemas/Error"},
+						},
+					},
+				},
+			},
+		}
+		if call.AuthRequired {
+			operation["security"] = []Params{
+				{"basicAuth": []string{}},
+				{"bearerAuth": []string{}},
+			}
+		}
+		paths["/"+call.Path] = Params{"post": operation}
+	}
+	return Params{
+		"openapi": "3.0.3",
+		"info": Params{
+			"title":       "rclone rc API",
+			"description": "The remote control API for rclone. See https://rclone.org/rc/ for more information.",
+			"version":     fs.Version,
+		},
+		"paths": paths,
+		"components": Params{
+			"securitySchemes": Params{
+				"basicAuth": Params{
+					"type":   "http",
+					"scheme": "basic",
+				},
+				"bearerAuth": Params{
+					"type":   "http",
+					"scheme": "bearer",
+				},
+			},
+			"schemas": Params{
+				"Error": schema([]Param{
+					{Name: "error", Type: TypeString, Required: true, Help: "the error message"},
+					{Name: "input", Type: TypeObject, Help: "the input parameters of the call"},
+					{Name: "path", Type: TypeString, Help: "the path of the call"},
+					{Name: "status", Type: TypeInteger, Help: "the HTTP status code"},
+				}, nil),
+			},
+		},
+	}
+}
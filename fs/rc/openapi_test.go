@@ -0,0 +1,108 @@
+package rc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationID(t *testing.T) {
+	assert.Equal(t, "operationsCopyfile", operationID("operations/copyfile"))
+	assert.Equal(t, "vfsPollInterval", operationID("vfs/poll-interval"))
+	assert.Equal(t, "coreStats", operationID("core/stats"))
+}
+
+func TestOpenAPI(t *testing.T) {
+	r := NewRegistry()
+	r.Add(Call{
+		Path:         "test/described",
+		AuthRequired: true,
+		Title:        "A described call",
+		Help:         "Help for the call",
+		Params: []Param{
+			{Name: "fs", Type: TypeString, Required: true, Help: "a remote"},
+			{Name: "names", Type: TypeArray, Items: TypeString},
+		},
+		Returns: []Param{
+			{Name: "count", Type: TypeInteger, Required: true},
+		},
+	})
+	r.Add(Call{
+		Path:  "other/undescribed",
+		Title: "An undescribed call",
+	})
+
+	// Check it round trips through JSON
+	data, err := json.Marshal(r.OpenAPI())
+	require.NoError(t, err)
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]struct {
+			Post struct {
+				OperationID string                `json:"operationId"`
+				Summary     string                `json:"summary"`
+				Description string                `json:"description"`
+				Tags        []string              `json:"tags"`
+				Security    []map[string][]string `json:"security"`
+				RequestBody struct {
+					Content map[string]struct {
+						Schema jsonSchema `json:"schema"`
+					} `json:"content"`
+				} `json:"requestBody"`
+				Responses map[string]struct {
+					Content map[string]struct {
+						Schema jsonSchema `json:"schema"`
+					} `json:"content"`
+				} `json:"responses"`
+			} `json:"post"`
+		} `json:"paths"`
+		Components struct {
+			SecuritySchemes map[string]interface{} `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(data, &spec))
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	assert.Contains(t, spec.Components.SecuritySchemes, "basicAuth")
+	assert.Contains(t, spec.Components.SecuritySchemes, "bearerAuth")
+	require.Equal(t, 2, len(spec.Paths))
+
+	described := spec.Paths["/test/described"].Post
+	assert.Equal(t, "testDescribed", described.OperationID)
+	assert.Equal(t, "A described call", described.Summary)
+	assert.Equal(t, "Help for the call", described.Description)
+	assert.Equal(t, []string{"test"}, described.Tags)
+	assert.Equal(t, 2, len(described.Security))
+	in := described.RequestBody.Content["application/json"].Schema
+	assert.Equal(t, "object", in.Type)
+	assert.Equal(t, []string{"fs"}, in.Required)
+	assert.Equal(t, "string", in.Properties["fs"].Type)
+	assert.Equal(t, "a remote", in.Properties["fs"].Description)
+	assert.Equal(t, "array", in.Properties["names"].Type)
+	assert.Equal(t, "string", in.Properties["names"].Items.Type)
+	assert.Equal(t, "boolean", in.Properties["_async"].Type)
+	out := described.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, []string{"count"}, out.Required)
+	assert.Equal(t, "integer", out.Properties["count"].Type)
+	assert.Contains(t, described.Responses, "default")
+
+	undescribed := spec.Paths["/other/undescribed"].Post
+	assert.Nil(t, undescribed.Security)
+	assert.Equal(t, []string{"other"}, undescribed.Tags)
+	in = undescribed.RequestBody.Content["application/json"].Schema
+	assert.Nil(t, in.Required)
+	assert.Contains(t, in.Properties, "_config")
+	out = undescribed.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, "object", out.Type)
+	assert.Nil(t, out.Properties)
+}
+
+// jsonSchema is the part of a JSON schema the tests look at
+type jsonSchema struct {
+	Type        string                `json:"type"`
+	Description string                `json:"description"`
+	Required    []string              `json:"required"`
+	Properties  map[string]jsonSchema `json:"properties"`
+	Items       *jsonSchema           `json:"items"`
+}
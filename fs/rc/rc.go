@@ -32,6 +32,10 @@ type Options struct {
 	EnableMetrics            bool   // set to disable prometheus metrics on /metrics
 	JobExpireDuration        time.Duration
 	JobExpireInterval        time.Duration
+	JobStore                 bool          // set to keep jobs in a database so they persist across restarts
+	JobStoreMaxAge           time.Duration // forget stored jobs which finished longer ago than this
+	ScheduleFile             string        // file to keep the schedules in
+	TokenFile                string        // file to keep the API tokens in
 }
 
 // DefaultOpt is the default values used for Options
@@ -40,6 +44,7 @@ var DefaultOpt = Options{
 	Enabled:           false,
 	JobExpireDuration: 60 * time.Second,
 	JobExpireInterval: 10 * time.Second,
+	JobStoreMaxAge:    7 * 24 * time.Hour,
 }
 
 func init() {
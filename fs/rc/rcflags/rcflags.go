@@ -29,5 +29,9 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &Opt.EnableMetrics, "rc-enable-metrics", "", false, "Enable prometheus metrics on /metrics")
 	flags.DurationVarP(flagSet, &Opt.JobExpireDuration, "rc-job-expire-duration", "", Opt.JobExpireDuration, "Expire finished async jobs older than this value")
 	flags.DurationVarP(flagSet, &Opt.JobExpireInterval, "rc-job-expire-interval", "", Opt.JobExpireInterval, "Interval to check for expired async jobs")
+	flags.BoolVarP(flagSet, &Opt.JobStore, "rc-job-store", "", false, "Keep the status and output of jobs in a database so they persist across restarts")
+	flags.DurationVarP(flagSet, &Opt.JobStoreMaxAge, "rc-job-store-max-age", "", Opt.JobStoreMaxAge, "Remove jobs from the job store which finished longer ago than this")
+	flags.StringVarP(flagSet, &Opt.ScheduleFile, "rc-schedule-file", "", "", "File to keep rc schedules in (default schedules.json next to the config file)")
+	flags.StringVarP(flagSet, &Opt.TokenFile, "rc-token-file", "", "", "File to keep rc API tokens in (default rc-tokens.json next to the config file)")
 	httpflags.AddFlagsPrefix(flagSet, "rc-", &Opt.HTTPOptions)
 }
@@ -0,0 +1,298 @@
+package rcserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/rc/jobs"
+)
+
+const (
+	eventsDefaultInterval = time.Second
+	eventsMinInterval     = 100 * time.Millisecond
+	eventsKeepAlive       = 15 * time.Second
+	eventsBuffer          = 256
+)
+
+// event is a single server-sent event
+type event struct {
+	name string
+	data interface{}
+}
+
+// logEvent is the data for a log event
+type logEvent struct {
+	Level string    `json:"level"`
+	Time  time.Time `json:"time"`
+	Text  string    `json:"text"`
+}
+
+// eventStream is a subscriber to the /events endpoint
+type eventStream struct {
+	jobIDs    map[int64]bool
+	groups    map[string]bool
+	wantLog   bool
+	logLevel  fs.LogLevel
+	interval  time.Duration
+	events    chan event
+	dropped   int64
+	lastStats map[string]rc.Params // last stats sent by group
+	lastBytes map[string]int64     // last bytes sent by group and transfer name of running transfers
+}
+
+// newEventStream makes an eventStream from the query parameters
+func newEventStream(r *http.Request) (*eventStream, error) {
+	es := &eventStream{
+		jobIDs:    map[int64]bool{},
+		groups:    map[string]bool{},
+		interval:  eventsDefaultInterval,
+		events:    make(chan event, eventsBuffer),
+		lastStats: map[string]rc.Params{},
+		lastBytes: map[string]int64{},
+	}
+	query := r.URL.Query()
+	for _, value := range splitValues(query["jobid"]) {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad jobid %q: %w", value, err)
+		}
+		es.jobIDs[id] = true
+	}
+	for _, group := range splitValues(query["group"]) {
+		es.groups[group] = true
+	}
+	if level := query.Get("log"); level != "" {
+		if err := es.logLevel.Set(strings.ToUpper(level)); err != nil {
+			return nil, fmt.Errorf("bad log level: %w", err)
+		}
+		es.wantLog = true
+	}
+	if interval := query.Get("interval"); interval != "" {
+		d, err := fs.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("bad interval: %w", err)
+		}
+		if d < eventsMinInterval {
+			d = eventsMinInterval
+		}
+		es.interval = d
+	}
+	return es, nil
+}
+
+// splitValues splits comma separated query values
+func splitValues(values []string) (out []string) {
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+// filtered returns true if the stream is restricted to some jobs or groups
+func (es *eventStream) filtered() bool {
+	return len(es.jobIDs) > 0 || len(es.groups) > 0
+}
+
+// wantJob returns true if events for the job should be sent
+func (es *eventStream) wantJob(id int64, group string) bool {
+	return !es.filtered() || es.jobIDs[id] || es.groups[group]
+}
+
+// send queues an event dropping it if the subscriber isn't keeping up
+func (es *eventStream) send(name string, data interface{}) {
+	select {
+	case es.events <- event{name: name, data: data}:
+	default:
+		atomic.AddInt64(&es.dropped, 1)
+	}
+}
+
+// statsGroups returns the stats groups to report on. An empty group
+// name means the total of all the groups.
+func (es *eventStream) statsGroups() []string {
+	if !es.filtered() {
+		return []string{""}
+	}
+	seen := map[string]bool{}
+	var groups []string
+	add := func(group string) {
+		if !seen[group] {
+			seen[group] = true
+			groups = append(groups, group)
+		}
+	}
+	for group := range es.groups {
+		add(group)
+	}
+	for id := range es.jobIDs {
+		if job := jobs.Get(id); job != nil {
+			add(job.Group)
+		}
+	}
+	return groups
+}
+
+// pollStats queues stats deltas and transfer progress for the groups
+func (es *eventStream) pollStats(ctx context.Context) {
+	statsCall := rc.Calls.Get("core/stats")
+	running := make(map[string]struct{}, len(es.lastBytes))
+	for _, group := range es.statsGroups() {
+		in := rc.Params{}
+		if group != "" {
+			in["group"] = group
+		}
+		stats, err := statsCall.Fn(ctx, in)
+		if err != nil {
+			fs.Debugf(nil, "rc: events: failed to read stats: %v", err)
+			continue
+		}
+		// Transfers are sent separately
+		delete(stats, "transferring")
+		delete(stats, "checking")
+		last := es.lastStats[group]
+		delta := rc.Params{}
+		for k, v := range stats {
+			if old, found := last[k]; !found || !reflect.DeepEqual(old, v) {
+				delta[k] = v
+			}
+		}
+		es.lastStats[group] = stats
+		if len(delta) > 0 {
+			es.send("stats", rc.Params{"group": group, "stats": delta})
+		}
+
+		transferGroups := []string{group}
+		if group == "" {
+			transferGroups = accounting.StatsGroups()
+		}
+		for _, name := range transferGroups {
+			for _, tr := range accounting.StatsGroup(ctx, name).Transferring() {
+				key := name + "\x00" + tr.Name
+				running[key] = struct{}{}
+				if bytes, found := es.lastBytes[key]; found && bytes == tr.Bytes {
+					continue
+				}
+				es.lastBytes[key] = tr.Bytes
+				es.send("transfer", tr)
+			}
+		}
+	}
+	// Forget the transfers which have finished
+	for key := range es.lastBytes {
+		if _, found := running[key]; !found {
+			delete(es.lastBytes, key)
+		}
+	}
+}
+
+// serveEvents streams events to the client as server-sent events
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request, path string) {
+	if tokenFromContext(r.Context()) == nil && !s.opt.NoAuth && !s.UsingAuth() {
+		writeError(path, nil, w, fmt.Errorf("authentication must be set up on the rc server to use %q or the --rc-no-auth flag must be in use", path), http.StatusForbidden)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(path, nil, w, fmt.Errorf("streaming not supported"), http.StatusInternalServerError)
+		return
+	}
+	es, err := newEventStream(r)
+	if err != nil {
+		writeError(path, nil, w, err, http.StatusBadRequest)
+		return
+	}
+
+	stopObserving := jobs.Observe(func(ev jobs.Event) {
+		if es.wantJob(ev.ID, ev.Group) {
+			es.send("job", ev)
+		}
+	})
+	defer stopObserving()
+	if es.wantLog {
+		stopLog := fs.ObserveLog(func(level fs.LogLevel, text string) {
+			if level <= es.logLevel {
+				es.send("log", logEvent{Level: level.String(), Time: time.Now(), Text: text})
+			}
+		})
+		defer stopLog()
+		// Log lines are only made if they pass --log-level so let
+		// the client know if it asked for more than that
+		if logLevel := fs.GetConfig(r.Context()).LogLevel; es.logLevel > logLevel {
+			es.send("log", logEvent{
+				Level: fs.LogLevelNotice.String(),
+				Time:  time.Now(),
+				Text:  fmt.Sprintf("rc: events: log lines less important than %v aren't available as that is the --log-level", logLevel),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	ticker := time.NewTicker(es.interval)
+	defer ticker.Stop()
+	var (
+		id        int64
+		lastWrite = time.Now()
+	)
+	write := func(ev event) error {
+		data, err := json.Marshal(ev.data)
+		if err != nil {
+			return err
+		}
+		id++
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, ev.name, data)
+		lastWrite = time.Now()
+		return err
+	}
+	es.pollStats(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-es.events:
+			if err := write(ev); err != nil {
+				fs.Debugf(nil, "rc: events: write failed: %v", err)
+				return
+			}
+			// Send any other queued events before flushing
+			for n := len(es.events); n > 0; n-- {
+				if err := write(<-es.events); err != nil {
+					fs.Debugf(nil, "rc: events: write failed: %v", err)
+					return
+				}
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if dropped := atomic.SwapInt64(&es.dropped, 0); dropped > 0 {
+				es.send("dropped", rc.Params{"count": dropped})
+			}
+			es.pollStats(ctx)
+			if time.Since(lastWrite) >= eventsKeepAlive {
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+				lastWrite = time.Now()
+				flusher.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package rcserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/rc/jobs"
+)
+
+// sseEvent is an event as read from the stream
+type sseEvent struct {
+	id   string
+	name string
+	data string
+}
+
+// readEvents reads server-sent events from the stream onto a channel
+func readEvents(t *testing.T, resp *http.Response) <-chan sseEvent {
+	out := make(chan sseEvent, 100)
+	go func() {
+		defer close(out)
+		scanner := bufio.NewScanner(resp.Body)
+		var ev sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if ev.name != "" {
+					out <- ev
+				}
+				ev = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				ev.id = line[4:]
+			case strings.HasPrefix(line, "event: "):
+				ev.name = line[7:]
+			case strings.HasPrefix(line, "data: "):
+				ev.data = line[6:]
+			}
+		}
+	}()
+	return out
+}
+
+// waitEvent waits for an event with the name for which match returns true
+func waitEvent(t *testing.T, events <-chan sseEvent, name string, match func(data string) bool) sseEvent {
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			require.True(t, ok, "stream closed waiting for %q", name)
+			if ev.name == name && (match == nil || match(ev.data)) {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %q event", name)
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := newTestOpt()
+	opt.Serve = false
+	opt.Files = ""
+	opt.NoAuth = true
+	mux := http.NewServeMux()
+	newServer(ctx, &opt, mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/events?log=notice&interval=100ms", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	events := readEvents(t, resp)
+
+	// The first stats event contains all the stats
+	ev := waitEvent(t, events, "stats", nil)
+	var stats struct {
+		Group string    `json:"group"`
+		Stats rc.Params `json:"stats"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(ev.data), &stats))
+	assert.Equal(t, "", stats.Group)
+	assert.Contains(t, stats.Stats, "bytes")
+	assert.NotContains(t, stats.Stats, "transferring")
+
+	// Run a job and check it starts and finishes
+	job, _, err := jobs.NewJob(context.Background(), rc.Calls.Get("rc/noop").Fn, rc.Params{"_async": true})
+	require.NoError(t, err)
+	isJob := func(data string) bool {
+		var jobEv jobs.Event
+		require.NoError(t, json.Unmarshal([]byte(data), &jobEv))
+		return jobEv.ID == job.ID
+	}
+	ev = waitEvent(t, events, "job", isJob)
+	assert.Contains(t, ev.data, `"type":"started"`)
+	ev = waitEvent(t, events, "job", isJob)
+	assert.Contains(t, ev.data, `"type":"finished"`)
+	assert.Contains(t, ev.data, `"success":true`)
+
+	// Check log lines are sent
+	fs.Logf(nil, "event stream test")
+	ev = waitEvent(t, events, "log", func(data string) bool {
+		return strings.Contains(data, "event stream test")
+	})
+	assert.Contains(t, ev.data, `"level":"NOTICE"`)
+
+	// Debug messages are below the requested level
+	fs.Debugf(nil, "event stream debug")
+	fs.Logf(nil, "event stream marker")
+	ev = waitEvent(t, events, "log", nil)
+	assert.Contains(t, ev.data, "event stream marker")
+}
+
+func TestEventsFilter(t *testing.T) {
+	es, err := newEventStream(httptest.NewRequest("GET", "/events?jobid=1,2&jobid=3&group=a&interval=1ms", nil))
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]bool{1: true, 2: true, 3: true}, es.jobIDs)
+	assert.Equal(t, map[string]bool{"a": true}, es.groups)
+	assert.Equal(t, eventsMinInterval, es.interval)
+	assert.False(t, es.wantLog)
+	assert.True(t, es.wantJob(2, "b"))
+	assert.True(t, es.wantJob(4, "a"))
+	assert.False(t, es.wantJob(4, "b"))
+
+	_, err = newEventStream(httptest.NewRequest("GET", "/events?jobid=potato", nil))
+	assert.Error(t, err)
+	_, err = newEventStream(httptest.NewRequest("GET", "/events?log=potato", nil))
+	assert.Error(t, err)
+}
+
+func TestEventsTransfers(t *testing.T) {
+	ctx := accounting.WithStatsGroup(context.Background(), "events-test")
+	defer func() {
+		_, _ = rc.Calls.Get("core/stats-delete").Fn(ctx, rc.Params{"group": "events-test"})
+	}()
+	es, err := newEventStream(httptest.NewRequest("GET", "/events?group=events-test", nil))
+	require.NoError(t, err)
+
+	tr := accounting.Stats(ctx).NewTransferRemoteSize("file.txt", 100)
+	es.pollStats(ctx)
+	assert.Len(t, es.lastBytes, 1)
+
+	// Finished transfers are forgotten
+	tr.Done(ctx, nil)
+	es.pollStats(ctx)
+	assert.Len(t, es.lastBytes, 0)
+}
+
+func TestEventsLogLevel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ci := fs.GetConfig(ctx)
+	oldLogLevel := ci.LogLevel
+	ci.LogLevel = fs.LogLevelNotice
+	defer func() { ci.LogLevel = oldLogLevel }()
+	opt := newTestOpt()
+	opt.Serve = false
+	opt.Files = ""
+	opt.NoAuth = true
+	mux := http.NewServeMux()
+	newServer(ctx, &opt, mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/events?log=debug", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	events := readEvents(t, resp)
+
+	// The client is told it won't get the debug lines
+	ev := waitEvent(t, events, "log", nil)
+	assert.Contains(t, ev.data, `"level":"NOTICE"`)
+	assert.Contains(t, ev.data, "less important than NOTICE")
+}
+
+func TestEventsAuthRequired(t *testing.T) {
+	tests := []testRun{{
+		Name:   "events",
+		URL:    "events",
+		Status: http.StatusForbidden,
+		Expected: `{
+	"error": "authentication must be set up on the rc server to use \"events\" or the --rc-no-auth flag must be in use",
+	"input": null,
+	"path": "events",
+	"status": 403
+}
+`,
+	}}
+	opt := newTestOpt()
+	opt.Serve = false
+	opt.Files = ""
+	opt.NoAuth = false
+	testServer(t, tests, &opt)
+}
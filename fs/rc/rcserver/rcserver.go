@@ -30,9 +30,12 @@ import (
 	"github.com/rclone/rclone/fs/rc"
 	"github.com/rclone/rclone/fs/rc/jobs"
 	"github.com/rclone/rclone/fs/rc/rcflags"
+	"github.com/rclone/rclone/fs/rc/schedule"
 	"github.com/rclone/rclone/fs/rc/webgui"
 	"github.com/rclone/rclone/lib/http/serve"
+	"github.com/rclone/rclone/lib/pacer"
 	"github.com/rclone/rclone/lib/random"
+	"github.com/rclone/rclone/vfs/vfscache"
 	"github.com/skratchdot/open-golang/open"
 )
 
@@ -49,6 +52,14 @@ func init() {
 	}
 	fshttp.DefaultMetrics = m
 
+	pm := pacer.NewMetrics("rclone")
+	for _, c := range pm.Collectors() {
+		prometheus.MustRegister(c)
+	}
+	pacer.DefaultMetrics = pm
+
+	prometheus.MustRegister(vfscache.NewCollector("rclone"))
+
 	promHandler = promhttp.Handler()
 }
 
@@ -58,6 +69,15 @@ func init() {
 func Start(ctx context.Context, opt *rc.Options) (*Server, error) {
 	jobs.SetOpt(opt) // set the defaults for jobs
 	if opt.Enabled {
+		if err := jobs.StartStore(ctx); err != nil {
+			return nil, err
+		}
+		if err := schedule.Start(ctx, opt); err != nil {
+			return nil, err
+		}
+		if err := startTokens(opt); err != nil {
+			return nil, err
+		}
 		// Serve on the DefaultServeMux so can have global registrations appear
 		s := newServer(ctx, opt, http.DefaultServeMux)
 		return s, s.Serve()
@@ -118,8 +138,11 @@ func newServer(ctx context.Context, opt *rc.Options, mux *http.ServeMux) *Server
 		pluginsHandler = http.FileServer(http.Dir(webgui.PluginsPath))
 	}
 
+	// Accept API tokens as well as any other authentication
+	httpOpt := opt.HTTPOptions
+	httpOpt.TokenAuth = tokens.authenticate
 	s := &Server{
-		Server:         httplib.NewServer(mux, &opt.HTTPOptions),
+		Server:         httplib.NewServer(mux, &httpOpt),
 		ctx:            ctx,
 		opt:            opt,
 		files:          fileHandler,
@@ -260,7 +283,14 @@ func (s *Server) handlePost(w http.ResponseWriter, r *http.Request, path string)
 	}
 
 	// Check to see if it requires authorisation
-	if !s.opt.NoAuth && call.AuthRequired && !s.UsingAuth() {
+	if t := tokenFromContext(ctx); t != nil {
+		if err := t.allowed(path, in); err != nil {
+			writeError(path, in, w, err, http.StatusForbidden)
+			return
+		}
+		// So schedules can check the token when they run
+		ctx = jobs.WithScope(ctx, t.ID)
+	} else if !s.opt.NoAuth && call.AuthRequired && !s.UsingAuth() {
 		writeError(path, in, w, fmt.Errorf("authentication must be set up on the rc server to use %q or the --rc-no-auth flag must be in use", path), http.StatusForbidden)
 		return
 	}
@@ -277,7 +307,7 @@ func (s *Server) handlePost(w http.ResponseWriter, r *http.Request, path string)
 	}
 
 	fs.Debugf(nil, "rc: %q: with parameters %+v", path, in)
-	job, out, err := jobs.NewJob(ctx, call.Fn, in)
+	job, out, err := jobs.NewJob(jobs.WithCall(ctx, path), call.Fn, in)
 	if job != nil {
 		w.Header().Add("x-rclone-jobid", fmt.Sprintf("%d", job.ID))
 	}
@@ -359,6 +389,15 @@ func (s *Server) serveRemote(w http.ResponseWriter, r *http.Request, path string
 var fsMatch = regexp.MustCompile(`^\[(.*?)\](.*)$`)
 
 func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, path string) {
+	// API tokens may only be used for the API description, the event
+	// stream and metrics
+	if t := tokenFromContext(r.Context()); t != nil && path != "openapi.json" {
+		if (path != "events" && path != "metrics") || !matchAny(t.Paths, path) {
+			writeError(path, nil, w, fmt.Errorf("token %q is not allowed to get %q", t.ID, path), http.StatusForbidden)
+			return
+		}
+	}
+
 	// Look to see if this has an fs in the path
 	fsMatchResult := fsMatch.FindStringSubmatch(path)
 
@@ -370,6 +409,15 @@ func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, path string)
 	case path == "metrics" && s.opt.EnableMetrics:
 		promHandler.ServeHTTP(w, r)
 		return
+	case path == "events":
+		s.serveEvents(w, r, path)
+		return
+	case path == "openapi.json":
+		w.Header().Set("Content-Type", "application/json")
+		if err := rc.WriteJSON(w, rc.Calls.OpenAPI()); err != nil {
+			fs.Errorf(nil, "rc: failed to write OpenAPI description: %v", err)
+		}
+		return
 	case path == "*" && s.opt.Serve:
 		// Serve /* as the remote listing
 		s.serveRoot(w, r)
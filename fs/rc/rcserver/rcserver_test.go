@@ -713,6 +713,22 @@ func TestAuthRequired(t *testing.T) {
 	testServer(t, tests, &opt)
 }
 
+func TestOpenAPI(t *testing.T) {
+	tests := []testRun{{
+		Name:     "openapi",
+		URL:      "openapi.json",
+		Status:   http.StatusOK,
+		Contains: regexp.MustCompile(`(?s)"openapi": "3\.0\.3".*"/rc/noopauth": \{`),
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+	}}
+	opt := newTestOpt()
+	opt.Serve = false
+	opt.Files = ""
+	testServer(t, tests, &opt)
+}
+
 func TestNoAuth(t *testing.T) {
 	tests := []testRun{{
 		Name:        "auth",
@@ -0,0 +1,526 @@
+package rcserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/cmd/serve/httplib"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/rc/schedule"
+	"github.com/rclone/rclone/lib/random"
+)
+
+// Token is an API token for the rc server. It allows calling the rc
+// paths matching Paths on the remotes matching Remotes.
+type Token struct {
+	ID      string     `json:"id"`
+	Name    string     `json:"name,omitempty"`
+	Hash    string     `json:"hash,omitempty"` // SHA-256 of the secret - never returned by the rc
+	Paths   []string   `json:"paths"`
+	Remotes []string   `json:"remotes,omitempty"` // any remote is allowed if empty
+	Created time.Time  `json:"created"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// tokenStore holds the API tokens for the rc server
+type tokenStore struct {
+	mu     sync.Mutex
+	path   string            // file to persist the tokens in or "" for none
+	tokens map[string]*Token // by ID
+}
+
+// tokens are the API tokens in use by the rc server
+var tokens = &tokenStore{tokens: map[string]*Token{}}
+
+func init() {
+	schedule.CheckScope = tokens.check
+}
+
+// DefaultTokenPath returns the default file the API tokens are kept in
+func DefaultTokenPath() string {
+	dir := config.GetCacheDir()
+	if configPath := config.GetConfigPath(); configPath != "" {
+		dir = filepath.Dir(configPath)
+	}
+	return filepath.Join(dir, "rc-tokens.json")
+}
+
+// startTokens loads the API tokens from the file configured in opt
+func startTokens(opt *rc.Options) error {
+	path := opt.TokenFile
+	if path == "" {
+		path = DefaultTokenPath()
+	}
+	return tokens.load(path)
+}
+
+// load reads the tokens from path
+func (ts *tokenStore) load(path string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.path = path
+	ts.tokens = map[string]*Token{}
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read rc tokens: %w", err)
+	}
+	var tokenList []*Token
+	if err = json.Unmarshal(data, &tokenList); err != nil {
+		return fmt.Errorf("failed to parse rc tokens in %q: %w", path, err)
+	}
+	for _, t := range tokenList {
+		ts.tokens[t.ID] = t
+	}
+	fs.Infof(nil, "Loaded %d rc tokens from %q", len(ts.tokens), path)
+	return nil
+}
+
+// save writes the tokens to the file - call with the lock held
+func (ts *tokenStore) save() error {
+	if ts.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(ts.list(), "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to encode rc tokens: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(ts.path), 0700); err != nil {
+		return fmt.Errorf("failed to save rc tokens: %w", err)
+	}
+	tmp := ts.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err == nil {
+		err = os.Rename(tmp, ts.path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save rc tokens: %w", err)
+	}
+	return nil
+}
+
+// list returns the tokens sorted by creation - call with the lock held
+func (ts *tokenStore) list() []*Token {
+	tokenList := make([]*Token, 0, len(ts.tokens))
+	for _, t := range ts.tokens {
+		tokenList = append(tokenList, t)
+	}
+	sort.Slice(tokenList, func(i, j int) bool {
+		if !tokenList[i].Created.Equal(tokenList[j].Created) {
+			return tokenList[i].Created.Before(tokenList[j].Created)
+		}
+		return tokenList[i].ID < tokenList[j].ID
+	})
+	return tokenList
+}
+
+// hashSecret returns the hex SHA-256 of the secret
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// create makes a new token returning it and the bearer token to use
+func (ts *tokenStore) create(t *Token) (bearer string, err error) {
+	for _, patterns := range [][]string{t.Paths, t.Remotes} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return "", fmt.Errorf("bad pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	if len(t.Paths) == 0 {
+		return "", errors.New("need at least one path the token is allowed to call")
+	}
+	secret, err := random.Password(256)
+	if err != nil {
+		return "", fmt.Errorf("failed to make token: %w", err)
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for {
+		t.ID = strings.ToLower(random.String(12))
+		if ts.tokens[t.ID] == nil {
+			break
+		}
+	}
+	t.Hash = hashSecret(secret)
+	t.Created = time.Now()
+	ts.tokens[t.ID] = t
+	if err = ts.save(); err != nil {
+		delete(ts.tokens, t.ID)
+		return "", err
+	}
+	return t.ID + "." + secret, nil
+}
+
+// revoke removes the token with id
+func (ts *tokenStore) revoke(id string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	t := ts.tokens[id]
+	if t == nil {
+		return fmt.Errorf("token %q not found", id)
+	}
+	delete(ts.tokens, id)
+	if err := ts.save(); err != nil {
+		ts.tokens[id] = t
+		return err
+	}
+	return nil
+}
+
+// authenticate checks the bearer token returning the *Token it is for
+//
+// It is used as the httplib.TokenAuthFn for the server.
+func (ts *tokenStore) authenticate(bearer string) (interface{}, error) {
+	parts := strings.SplitN(bearer, ".", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("malformed token")
+	}
+	ts.mu.Lock()
+	t := ts.tokens[parts[0]]
+	ts.mu.Unlock()
+	if t == nil || subtle.ConstantTimeCompare([]byte(hashSecret(parts[1])), []byte(t.Hash)) != 1 {
+		return nil, errors.New("unknown token")
+	}
+	if t.Expires != nil && time.Now().After(*t.Expires) {
+		return nil, fmt.Errorf("token %q expired", t.ID)
+	}
+	return t, nil
+}
+
+// check returns an error unless the token with id exists and may
+// call path with in. It is used to check the runs of schedules added
+// with a token.
+func (ts *tokenStore) check(id, path string, in rc.Params) error {
+	ts.mu.Lock()
+	t := ts.tokens[id]
+	ts.mu.Unlock()
+	if t == nil {
+		return fmt.Errorf("token %q not found - it may have been revoked", id)
+	}
+	if t.Expires != nil && time.Now().After(*t.Expires) {
+		return fmt.Errorf("token %q expired", t.ID)
+	}
+	return t.allowed(path, in)
+}
+
+// tokenFromContext returns the token the request was authenticated
+// with or nil if it wasn't authenticated with a token
+func tokenFromContext(ctx context.Context) *Token {
+	t, _ := ctx.Value(httplib.ContextAuthKey).(*Token)
+	return t
+}
+
+// matchAny returns true if name matches any of the patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Match the numbered path parameters used by sync/bisync
+var pathParamRe = regexp.MustCompile(`^path[0-9]+$`)
+
+// isFsParam returns true if the parameter key names a remote
+func isFsParam(key string) bool {
+	return key == "fs" || strings.HasSuffix(key, "Fs") || pathParamRe.MatchString(key)
+}
+
+// localPathParams are the parameters which name a path on the
+// machine running rclone. They are checked as the remote ":local".
+var localPathParams = map[string]bool{
+	"mountPoint":  true, // mount/mount and mount/unmount
+	"workdir":     true, // sync/bisync
+	"filtersFile": true, // sync/bisync
+}
+
+// configFsKeys are the keys of the _config parameter which name
+// remotes
+var configFsKeys = []string{"BackupDir", "CompareDest", "CopyDest"}
+
+// nestedCalls are the rc paths which run the rc call in their "call"
+// parameter with their "params" parameter. The token must be allowed
+// to make the nested call too.
+var nestedCalls = []string{"schedule/add"}
+
+// remotesDenied are the rc paths which can use any remote in ways
+// which can't be checked so tokens limited to some remotes may not
+// call them.
+var remotesDenied = []string{"core/command"}
+
+// remoteName returns the name of the remote in a parameter naming
+// one. Local paths are returned as ":local".
+func remoteName(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		parsed, err := fspath.Parse(v)
+		if err != nil {
+			return "", err
+		}
+		if parsed.Name == "" {
+			return ":local", nil
+		}
+		return parsed.Name, nil
+	case map[string]interface{}:
+		if name, ok := v["_name"].(string); ok && name != "" {
+			return name, nil
+		}
+		if backend, ok := v["type"].(string); ok && backend != "" {
+			return ":" + backend, nil
+		}
+	}
+	return "", fmt.Errorf("can't find remote name in %v", value)
+}
+
+// configRemotes returns the values of the keys of the _config
+// parameter which name remotes
+func configRemotes(config interface{}) (values []interface{}) {
+	m, ok := config.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for key, value := range m {
+		for _, fsKey := range configFsKeys {
+			if !strings.EqualFold(key, fsKey) {
+				continue
+			}
+			if list, ok := value.([]interface{}); ok {
+				values = append(values, list...)
+			} else if value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// allowed returns an error unless the token may call path with in
+func (t *Token) allowed(path string, in rc.Params) error {
+	// Tokens can't be used to manage tokens
+	if strings.HasPrefix(path, "token/") || !matchAny(t.Paths, path) {
+		return fmt.Errorf("token %q is not allowed to call %q", t.ID, path)
+	}
+	for _, nested := range nestedCalls {
+		if path == nested {
+			if err := t.allowedNested(in); err != nil {
+				return err
+			}
+		}
+	}
+	if len(t.Remotes) == 0 {
+		return nil
+	}
+	for _, denied := range remotesDenied {
+		if path == denied {
+			return fmt.Errorf("token %q is limited to some remotes so can't call %q", t.ID, path)
+		}
+	}
+	check := func(key string, value interface{}) error {
+		name, err := remoteName(value)
+		if err != nil {
+			return fmt.Errorf("token %q: parameter %q: %w", t.ID, key, err)
+		}
+		if !matchAny(t.Remotes, name) {
+			return fmt.Errorf("token %q is not allowed to use remote %q", t.ID, name)
+		}
+		return nil
+	}
+	for key, value := range in {
+		var err error
+		switch {
+		case isFsParam(key):
+			err = check(key, value)
+		case localPathParams[key]:
+			if !matchAny(t.Remotes, ":local") {
+				err = fmt.Errorf("token %q is not allowed to use local paths in %q", t.ID, key)
+			}
+		case key == "_config":
+			for _, value := range configRemotes(value) {
+				if err = check(key, value); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allowedNested returns an error unless the token may make the call
+// in the "call" and "params" parameters of in
+func (t *Token) allowedNested(in rc.Params) error {
+	call, err := in.GetString("call")
+	if rc.IsErrParamNotFound(err) {
+		return nil // the call will fail without it
+	} else if err != nil {
+		return err
+	}
+	params := rc.Params{}
+	if err = in.GetStructMissingOK("params", &params); err != nil {
+		return err
+	}
+	return t.allowed(call, params)
+}
+
+// public returns a copy of the token without its hash
+func (t *Token) public() *Token {
+	out := *t
+	out.Hash = ""
+	return &out
+}
+
+// getStringList gets a list of strings from in[key] which may be
+// either a list or a comma separated string. It is empty if missing.
+func getStringList(in rc.Params, key string) (out []string, err error) {
+	s, err := in.GetString(key)
+	if err == nil {
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+		return out, nil
+	}
+	if rc.IsErrParamNotFound(err) {
+		return nil, nil
+	}
+	err = in.GetStruct(key, &out)
+	return out, err
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "token/create",
+		AuthRequired: true,
+		Fn:           rcTokenCreate,
+		Title:        "Create an API token for the rc server",
+		Help: `This creates a token which can be used to make a limited set of calls
+to the rc server with an "Authorization: Bearer TOKEN" header.
+
+Parameters:
+
+- paths - rc paths the token may call as a list or comma separated e.g. "core/stats,job/*"
+- remotes - remote names the token may use, as above, e.g. "s3-*" (optional - default any)
+- name - a description of the token (optional)
+- expires - how long the token lasts e.g. "30d" (optional - default forever)
+
+Paths and remotes are matched with glob patterns where "*" matches
+anything except "/". Any parameter called "fs", "path1", "path2" etc
+or ending in "Fs" is checked against the remote names, as are the
+BackupDir, CompareDest and CopyDest in "_config". Local paths have
+the remote name ":local", which is also needed for the "mountPoint",
+"workdir" and "filtersFile" parameters. Tokens with remotes can't call
+core/command.
+
+The token must also be allowed to make the call given to
+schedule/add. Schedules added with a token are checked against it
+each time they run, and stop running if it is revoked.
+
+Returns:
+
+- token - the token to use - this is only shown once
+- id - the ID of the token for token/revoke
+
+Tokens can't be used to call the token/ calls themselves.
+`,
+	})
+	rc.Add(rc.Call{
+		Path:         "token/list",
+		AuthRequired: true,
+		Fn:           rcTokenList,
+		Title:        "List the API tokens for the rc server",
+		Help: `This lists the API tokens with what they are allowed to do. The
+tokens themselves are not shown.
+
+Returns:
+
+- tokens - a list of tokens with id, name, paths, remotes, created and expires
+`,
+	})
+	rc.Add(rc.Call{
+		Path:         "token/revoke",
+		AuthRequired: true,
+		Fn:           rcTokenRevoke,
+		Title:        "Revoke an API token for the rc server",
+		Help: `This removes the API token so it can no longer be used.
+
+Parameters:
+
+- id - the ID of the token as returned by token/create or token/list
+`,
+	})
+}
+
+func rcTokenCreate(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	t := &Token{}
+	if t.Paths, err = getStringList(in, "paths"); err != nil {
+		return nil, err
+	}
+	if t.Remotes, err = getStringList(in, "remotes"); err != nil {
+		return nil, err
+	}
+	if t.Name, err = in.GetString("name"); rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	expires, err := in.GetDuration("expires")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	if expires > 0 {
+		expiry := time.Now().Add(expires)
+		t.Expires = &expiry
+	}
+	bearer, err := tokens.create(t)
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{
+		"token": bearer,
+		"id":    t.ID,
+	}, nil
+}
+
+func rcTokenList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	tokens.mu.Lock()
+	defer tokens.mu.Unlock()
+	tokenList := []*Token{}
+	for _, t := range tokens.list() {
+		tokenList = append(tokenList, t.public())
+	}
+	return rc.Params{"tokens": tokenList}, nil
+}
+
+func rcTokenRevoke(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	id, err := in.GetString("id")
+	if err != nil {
+		return nil, err
+	}
+	return nil, tokens.revoke(id)
+}
@@ -0,0 +1,211 @@
+package rcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rclone/rclone/fs/rc"
+)
+
+func TestTokenAllowed(t *testing.T) {
+	token := &Token{
+		ID:      "test",
+		Paths:   []string{"core/stats", "sync/*"},
+		Remotes: []string{"s3-*", ":local"},
+	}
+	for _, test := range []struct {
+		path string
+		in   rc.Params
+		ok   bool
+	}{
+		{"core/stats", rc.Params{}, true},
+		{"core/version", rc.Params{}, false},
+		{"sync/copy", rc.Params{"srcFs": "/tmp", "dstFs": "s3-backup:bucket"}, true},
+		{"sync/copy", rc.Params{"srcFs": "/tmp", "dstFs": "drive:bucket"}, false},
+		{"sync/copy", rc.Params{"srcFs": "s3-a,provider=AWS:bucket", "dstFs": "s3-b:bucket"}, true},
+		{"sync/copy", rc.Params{"srcFs": map[string]interface{}{"_name": "s3-a"}, "dstFs": "/tmp"}, true},
+		{"sync/copy", rc.Params{"srcFs": map[string]interface{}{"type": "drive"}, "dstFs": "/tmp"}, false},
+		{"sync/bisync", rc.Params{"path1": "/tmp", "path2": "other:"}, false},
+		{"sync/bisync", rc.Params{"path1": "/tmp", "path2": "s3-a:", "path3": "other:"}, false},
+		{"sync/bisync", rc.Params{"path1": "/tmp", "path2": "s3-a:", "path3": "s3-b:"}, true},
+		{"sync/copy", rc.Params{"srcFs": 7, "dstFs": "/tmp"}, false},
+		{"sync/copy", rc.Params{"srcFs": "/tmp", "dstFs": "s3-a:", "_config": map[string]interface{}{"BackupDir": "other:old"}}, false},
+		{"sync/copy", rc.Params{"srcFs": "/tmp", "dstFs": "s3-a:", "_config": map[string]interface{}{"copyDest": []interface{}{"s3-b:", "other:"}}}, false},
+		{"sync/copy", rc.Params{"srcFs": "/tmp", "dstFs": "s3-a:", "_config": map[string]interface{}{"BackupDir": "s3-a:old", "Transfers": 8}}, true},
+		{"sync/bisync", rc.Params{"path1": "s3-a:", "path2": "s3-b:", "workdir": "/tmp/bisync"}, true},
+		{"token/create", rc.Params{}, false},
+	} {
+		err := token.allowed(test.path, test.in)
+		if test.ok {
+			assert.NoError(t, err, test)
+		} else {
+			assert.Error(t, err, test)
+		}
+	}
+
+	// Local paths need :local and core/command is denied
+	token = &Token{
+		ID:      "test",
+		Paths:   []string{"mount/*", "sync/*", "core/command"},
+		Remotes: []string{"s3-*"},
+	}
+	assert.Error(t, token.allowed("core/command", rc.Params{"command": "ls", "arg": []interface{}{"s3-a:"}}))
+	assert.Error(t, token.allowed("mount/mount", rc.Params{"fs": "s3-a:", "mountPoint": "/mnt/s3"}))
+	assert.Error(t, token.allowed("mount/unmount", rc.Params{"mountPoint": "/mnt/s3"}))
+	assert.Error(t, token.allowed("sync/bisync", rc.Params{"path1": "s3-a:", "path2": "s3-b:", "workdir": "/tmp/bisync"}))
+
+	// The calls made by schedules are checked
+	token.Paths = append(token.Paths, "schedule/add")
+	assert.Error(t, token.allowed("schedule/add", rc.Params{"cron": "@daily", "call": "core/command", "params": map[string]interface{}{"command": "ls"}}))
+	assert.Error(t, token.allowed("schedule/add", rc.Params{"cron": "@daily", "call": "sync/sync", "params": map[string]interface{}{"srcFs": "s3-a:", "dstFs": "other:"}}))
+	assert.Error(t, token.allowed("schedule/add", rc.Params{"cron": "@daily", "call": "sync/sync", "params": `{"srcFs":"s3-a:","dstFs":"other:"}`}))
+	assert.Error(t, token.allowed("schedule/add", rc.Params{"cron": "@daily", "call": "core/quit"}))
+	assert.NoError(t, token.allowed("schedule/add", rc.Params{"cron": "@daily", "call": "sync/sync", "params": map[string]interface{}{"srcFs": "s3-a:", "dstFs": "s3-b:"}}))
+
+	// All remotes allowed if none given
+	token.Remotes = nil
+	assert.NoError(t, token.allowed("sync/copy", rc.Params{"srcFs": "/tmp", "dstFs": "drive:bucket"}))
+	assert.NoError(t, token.allowed("core/command", rc.Params{"command": "ls", "arg": []interface{}{"drive:"}}))
+}
+
+func TestTokens(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "tokens.json")
+	require.NoError(t, tokens.load(tokenFile))
+	defer func() {
+		require.NoError(t, tokens.load(""))
+	}()
+
+	opt := newTestOpt()
+	opt.Serve = false
+	opt.Files = ""
+	opt.HTTPOptions.ListenAddr = testBindAddress
+	opt.HTTPOptions.BasicUser = "user"
+	opt.HTTPOptions.BasicPass = "pass"
+	mux := http.NewServeMux()
+	rcServer := newServer(context.Background(), &opt, mux)
+	require.NoError(t, rcServer.Serve())
+	defer func() {
+		rcServer.Close()
+		rcServer.Wait()
+	}()
+	testURL := rcServer.Server.URL()
+
+	call := func(path string, in rc.Params, setAuth func(*http.Request)) (int, rc.Params) {
+		body, err := json.Marshal(in)
+		require.NoError(t, err)
+		req, err := http.NewRequest("POST", testURL+path, bytes.NewBuffer(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		setAuth(req)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		out := rc.Params{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	basic := func(req *http.Request) {
+		req.SetBasicAuth("user", "pass")
+	}
+	bearer := func(token string) func(*http.Request) {
+		return func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	// Create a token
+	status, out := call("token/create", rc.Params{
+		"paths":   []string{"core/stats", "rc/noop"},
+		"remotes": ":local",
+		"name":    "test token",
+		"expires": "1h",
+	}, basic)
+	require.Equal(t, http.StatusOK, status, out)
+	token, _ := out["token"].(string)
+	id, _ := out["id"].(string)
+	require.NotEqual(t, "", token)
+	require.NotEqual(t, "", id)
+
+	// The secret isn't stored
+	data, err := os.ReadFile(tokenFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), id)
+	assert.NotContains(t, string(data), token)
+
+	// Check it can be listed without showing the hash
+	status, out = call("token/list", rc.Params{}, basic)
+	require.Equal(t, http.StatusOK, status, out)
+	var listed []Token
+	require.NoError(t, rc.Reshape(&listed, out["tokens"]))
+	require.Equal(t, 1, len(listed))
+	assert.Equal(t, id, listed[0].ID)
+	assert.Equal(t, "test token", listed[0].Name)
+	assert.Equal(t, []string{"core/stats", "rc/noop"}, listed[0].Paths)
+	assert.Equal(t, []string{":local"}, listed[0].Remotes)
+	assert.Equal(t, "", listed[0].Hash)
+	require.NotNil(t, listed[0].Expires)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *listed[0].Expires, time.Minute)
+
+	// Use the token
+	status, out = call("core/stats", rc.Params{}, bearer(token))
+	assert.Equal(t, http.StatusOK, status, out)
+	status, out = call("rc/noop", rc.Params{"fs": testFs}, bearer(token))
+	assert.Equal(t, http.StatusOK, status, out)
+
+	// Check what it isn't allowed to do
+	status, out = call("core/version", rc.Params{}, bearer(token))
+	assert.Equal(t, http.StatusForbidden, status, out)
+	status, out = call("rc/noop", rc.Params{"fs": "remote:"}, bearer(token))
+	assert.Equal(t, http.StatusForbidden, status, out)
+	assert.Contains(t, out["error"], `not allowed to use remote "remote"`)
+	status, out = call("token/list", rc.Params{}, bearer(token))
+	assert.Equal(t, http.StatusForbidden, status, out)
+
+	// Bad tokens aren't let in
+	status, _ = call("core/stats", rc.Params{}, bearer(id+".potato"))
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = call("core/stats", rc.Params{}, bearer("potato"))
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// Tokens persist
+	require.NoError(t, tokens.load(tokenFile))
+	status, out = call("core/stats", rc.Params{}, bearer(token))
+	assert.Equal(t, http.StatusOK, status, out)
+
+	// Schedules are checked against the token
+	assert.NoError(t, tokens.check(id, "rc/noop", rc.Params{"fs": testFs}))
+	assert.Error(t, tokens.check(id, "rc/noop", rc.Params{"fs": "remote:"}))
+
+	// Revoke the token
+	status, out = call("token/revoke", rc.Params{"id": id}, basic)
+	require.Equal(t, http.StatusOK, status, out)
+	assert.Error(t, tokens.check(id, "rc/noop", rc.Params{"fs": testFs}))
+	status, _ = call("core/stats", rc.Params{}, bearer(token))
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, out = call("token/revoke", rc.Params{"id": id}, basic)
+	assert.Equal(t, http.StatusInternalServerError, status, out)
+}
+
+func TestTokenCreateErrors(t *testing.T) {
+	require.NoError(t, tokens.load(""))
+	_, err := tokens.create(&Token{})
+	assert.Error(t, err)
+	_, err = tokens.create(&Token{Paths: []string{"["}})
+	assert.Error(t, err)
+
+	// Expired tokens aren't allowed
+	expired := time.Now().Add(-time.Minute)
+	bearer, err := tokens.create(&Token{Paths: []string{"*/*"}, Expires: &expired})
+	require.NoError(t, err)
+	_, err = tokens.authenticate(bearer)
+	assert.Error(t, err)
+}
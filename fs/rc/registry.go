@@ -15,13 +15,36 @@ type Func func(ctx context.Context, in Params) (out Params, err error)
 // Call defines info about a remote control function and is used in
 // the Add function to create new entry points.
 type Call struct {
-	Path          string // path to activate this RC
-	Fn            Func   `json:"-"` // function to call
-	Title         string // help for the function
-	AuthRequired  bool   // if set then this call requires authorisation to be set
-	Help          string // multi-line markdown formatted help
-	NeedsRequest  bool   // if set then this call will be passed the original request object as _request
-	NeedsResponse bool   // if set then this call will be passed the original response object as _response
+	Path          string  // path to activate this RC
+	Fn            Func    `json:"-"` // function to call
+	Title         string  // help for the function
+	AuthRequired  bool    // if set then this call requires authorisation to be set
+	Help          string  // multi-line markdown formatted help
+	NeedsRequest  bool    // if set then this call will be passed the original request object as _request
+	NeedsResponse bool    // if set then this call will be passed the original response object as _response
+	Params        []Param `json:",omitempty"` // structured description of the input parameters (optional)
+	Returns       []Param `json:",omitempty"` // structured description of the output (optional)
+}
+
+// Types for Param
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeObject  = "object"
+	TypeArray   = "array"
+)
+
+// Param describes a parameter passed to or returned from a remote
+// control function. These are used to make the OpenAPI description
+// of the API.
+type Param struct {
+	Name     string // name of the parameter
+	Type     string // JSON type of the parameter - one of the Type constants
+	Items    string // JSON type of the items if Type is TypeArray (optional)
+	Required bool   // set if the parameter must be supplied
+	Help     string // one line description of the parameter
 }
 
 // Registry holds the list of all the registered remote control functions
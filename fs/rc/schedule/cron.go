@@ -0,0 +1,217 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron expression
+//
+// It understands the usual 5 fields - minute, hour, day of month,
+// month and day of week - each of which may be "*", a number, a name
+// for months and days, a range "a-b", a list "a,b,c" and a step
+// "*/n" or "a-b/n". It also understands the shortcuts "@yearly",
+// "@monthly", "@weekly", "@daily", "@hourly" and "@every duration".
+type Cron struct {
+	minute   uint64
+	hour     uint64
+	dom      uint64
+	month    uint64
+	dow      uint64
+	domStar  bool          // set if day of month was "*"
+	dowStar  bool          // set if day of week was "*"
+	every    time.Duration // set for @every
+	original string
+}
+
+// cronField describes one of the fields in a cron expression
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names for the values starting at min
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	cronDow    = cronField{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// cronShortcuts are the @ names and what they stand for
+var cronShortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression
+func ParseCron(expr string) (*Cron, error) {
+	c := &Cron{original: expr}
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(expr[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("bad cron expression %q: %w", c.original, err)
+		}
+		if every < time.Second {
+			return nil, fmt.Errorf("bad cron expression %q: interval must be at least 1s", c.original)
+		}
+		c.every = every
+		return c, nil
+	}
+	if shortcut, ok := cronShortcuts[strings.ToLower(expr)]; ok {
+		expr = shortcut
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("bad cron expression %q: need 5 fields but found %d", c.original, len(fields))
+	}
+	var err error
+	for _, x := range []struct {
+		field *cronField
+		value string
+		bits  *uint64
+	}{
+		{&cronMinute, fields[0], &c.minute},
+		{&cronHour, fields[1], &c.hour},
+		{&cronDom, fields[2], &c.dom},
+		{&cronMonth, fields[3], &c.month},
+		{&cronDow, fields[4], &c.dow},
+	} {
+		*x.bits, err = x.field.parse(x.value)
+		if err != nil {
+			return nil, fmt.Errorf("bad cron expression %q: %w", c.original, err)
+		}
+	}
+	// Sunday may be written as 0 or 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// String returns the expression as originally supplied
+func (c *Cron) String() string {
+	return c.original
+}
+
+// parse a comma separated list of values, ranges and steps into a bitset
+func (f *cronField) parse(s string) (bits uint64, err error) {
+	for _, part := range strings.Split(s, ",") {
+		lo, hi, step := f.min, f.max, 1
+		rangePart := part
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			rangePart = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("bad step in %s %q", f.name, part)
+			}
+		}
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			i := strings.IndexByte(rangePart, '-')
+			if lo, err = f.value(rangePart[:i]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(rangePart[i+1:]); err != nil {
+				return 0, err
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("bad range in %s %q", f.name, part)
+			}
+		default:
+			if lo, err = f.value(rangePart); err != nil {
+				return 0, err
+			}
+			if rangePart == part {
+				hi = lo
+			}
+		}
+		for i := lo; i <= hi; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a single number or name
+func (f *cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad %s %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s %d out of range %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// errNoNext is returned if no time matching the expression could be found
+var errNoNext = errors.New("no matching time found")
+
+// has returns whether bit i is set in bits
+func has(bits uint64, i int) bool {
+	return bits&(1<<uint(i)) != 0
+}
+
+// dayMatches returns whether t is on a day matching the expression
+//
+// As in cron, if both day of month and day of week are restricted
+// then a day matching either will do.
+func (c *Cron) dayMatches(t time.Time) bool {
+	domOK := has(c.dom, t.Day())
+	dowOK := has(c.dow, int(t.Weekday()))
+	if c.domStar || c.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// Next returns the first time matching the expression which is
+// after t, in the location of t.
+func (c *Cron) Next(t time.Time) (time.Time, error) {
+	if c.every > 0 {
+		return t.Add(c.every).Truncate(time.Second), nil
+	}
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Give up after 5 years which is enough to find Feb 29
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !has(c.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !has(c.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !has(c.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cron expression %q: %w", c.original, errNoNext)
+}
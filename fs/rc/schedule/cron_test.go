@@ -0,0 +1,76 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"x * * * *",
+		"* * * foo *",
+		"@every",
+		"@every 10ms",
+		"@fortnightly",
+	} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Wednesday 2021-06-16 10:17:30
+	start := time.Date(2021, 6, 16, 10, 17, 30, 0, time.UTC)
+	for _, test := range []struct {
+		expr string
+		want string
+	}{
+		{"* * * * *", "2021-06-16 10:18"},
+		{"*/15 * * * *", "2021-06-16 10:30"},
+		{"17 * * * *", "2021-06-16 11:17"},
+		{"0 3 * * *", "2021-06-17 03:00"},
+		{"30 2 1 * *", "2021-07-01 02:30"},
+		{"0 0 * * mon-fri", "2021-06-17 00:00"},
+		{"0 0 * * 0", "2021-06-20 00:00"},
+		{"0 0 * * 7", "2021-06-20 00:00"},
+		{"0 0 * * sun", "2021-06-20 00:00"},
+		{"0 9 1,15 * *", "2021-07-01 09:00"},
+		{"0 9 15 * mon", "2021-06-21 09:00"}, // day of month or day of week
+		{"0 0 29 feb *", "2024-02-29 00:00"},
+		{"5/20 10-12 * * *", "2021-06-16 10:25"},
+		{"0 0 1 jan *", "2022-01-01 00:00"},
+		{"@daily", "2021-06-17 00:00"},
+		{"@hourly", "2021-06-16 11:00"},
+		{"@weekly", "2021-06-20 00:00"},
+		{"@monthly", "2021-07-01 00:00"},
+		{"@yearly", "2022-01-01 00:00"},
+		{"@every 90m", "2021-06-16 11:47"},
+	} {
+		c, err := ParseCron(test.expr)
+		require.NoError(t, err, test.expr)
+		got, err := c.Next(start)
+		require.NoError(t, err, test.expr)
+		assert.Equal(t, test.want, got.Format("2006-01-02 15:04"), test.expr)
+		assert.Equal(t, test.expr, c.String())
+	}
+}
+
+func TestCronNextImpossible(t *testing.T) {
+	c, err := ParseCron("0 0 31 feb *")
+	require.NoError(t, err)
+	_, err = c.Next(time.Now())
+	assert.ErrorIs(t, err, errNoNext)
+}
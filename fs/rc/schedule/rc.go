@@ -0,0 +1,222 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/rc/jobs"
+)
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "schedule/add",
+		AuthRequired: true,
+		Fn:           rcAdd,
+		Title:        "Add a schedule to run an rc call repeatedly",
+		Help: `This adds a schedule to the rc server which runs an rc call at the
+times given by a cron expression. Schedules are saved in the file
+given by --rc-schedule-file so they persist when the server restarts.
+
+Parameters:
+
+- cron - when to run as a cron expression e.g. "30 2 * * *" for 02:30 every day
+- call - the rc call to run e.g. "sync/sync"
+- params - the parameters for the call as an object (optional)
+- overlap - what to do if the previous run is still going (optional)
+    - skip - don't start a new run (the default)
+    - queue - start a new run when the previous one has finished
+    - cancel-previous - stop the previous run and start a new one
+- jitter - start each run after a random delay up to this long e.g. "5m" (optional)
+
+The cron expression has the usual 5 fields - minute, hour, day of
+month, month and day of week - in local time. Each field may be "*",
+a number, a range "1-5", a list "1,3,5" or a step "*/15". Months and
+days of the week may be given by name e.g. "mon-fri". The shortcuts
+"@yearly", "@monthly", "@weekly", "@daily" and "@hourly" are
+understood, as is "@every DURATION" e.g. "@every 1h30m".
+
+Each run is started as an async job in the group "schedule/ID"
+unless the params set "_group".
+
+If the schedule is added with an API token then the token must be
+allowed to make the call with the params. This is checked again
+before each run, so the schedule stops running if the token is
+revoked or expires.
+
+Results:
+
+- id - the id of the new schedule
+- nextRun - when it will next run
+
+Eg
+
+    rclone rc schedule/add cron="0 3 * * *" call=sync/sync params='{"srcFs":"/home","dstFs":"remote:backup"}' overlap=queue
+`,
+	})
+}
+
+// Add a schedule
+func rcAdd(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	s, err := get()
+	if err != nil {
+		return nil, err
+	}
+	sch := &Schedule{}
+	sch.Cron, err = in.GetString("cron")
+	if err != nil {
+		return nil, err
+	}
+	sch.Call, err = in.GetString("call")
+	if err != nil {
+		return nil, err
+	}
+	if err = in.GetStructMissingOK("params", &sch.Params); err != nil {
+		return nil, err
+	}
+	sch.Overlap, err = in.GetString("overlap")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	jitter, err := in.GetDuration("jitter")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	sch.Jitter = fs.Duration(jitter)
+	sch.Scope = jobs.Scope(ctx)
+	sch, err = s.add(sch)
+	if err != nil {
+		return nil, rc.NewErrParamInvalid(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return rc.Params{
+		"id":      sch.ID,
+		"nextRun": sch.NextRun,
+	}, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "schedule/list",
+		AuthRequired: true,
+		Fn:           rcList,
+		Title:        "List the schedules",
+		Help: `Parameters: None.
+
+Results:
+
+- schedules - a list of schedules each with
+    - id - the id of the schedule
+    - cron, call, params, overlap, jitter - as passed to schedule/add
+    - scope - the ID of the API token it was added with, if any
+    - created - when the schedule was added
+    - nextRun - when it will next run
+    - history - the most recent runs, oldest first, each with
+        - jobid - the id of the job for the run
+        - trigger - "schedule" or "manual" for schedule/run-now
+        - startTime, endTime - when the run started and finished
+        - status - one of running, success, error, skipped, queued, cancelled or interrupted
+        - error - the error if the run failed or why it was skipped
+
+Runs which were in progress when the rc server stopped are marked as
+interrupted when it starts again.
+`,
+	})
+}
+
+// List the schedules
+func rcList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	s, err := get()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out = rc.Params{}
+	err = rc.Reshape(&out, struct {
+		Schedules []*Schedule `json:"schedules"`
+	}{Schedules: s.list()})
+	if err != nil {
+		return nil, fmt.Errorf("reshape failed in schedule list: %w", err)
+	}
+	return out, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "schedule/remove",
+		AuthRequired: true,
+		Fn:           rcRemove,
+		Title:        "Remove a schedule",
+		Help: `Parameters:
+
+- id - the id of the schedule to remove
+
+A run which is in progress is left to finish - use job/stop or
+job/stopgroup with group "schedule/ID" to stop it.
+`,
+	})
+}
+
+// Remove a schedule
+func rcRemove(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	s, err := get()
+	if err != nil {
+		return nil, err
+	}
+	id, err := in.GetInt64("id")
+	if err != nil {
+		return nil, err
+	}
+	return rc.Params{}, s.remove(id)
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:         "schedule/run-now",
+		AuthRequired: true,
+		Fn:           rcRunNow,
+		Title:        "Run a schedule straight away",
+		Help: `Parameters:
+
+- id - the id of the schedule to run
+
+The run obeys the overlap policy of the schedule, so it may be
+skipped or queued if a run is already in progress. It doesn't change
+when the schedule next runs. If it is called with an API token then
+the token must be allowed to make the call of the schedule.
+
+Results:
+
+- status - the status of the run - running, queued or skipped
+- jobid - the id of the job started, if any
+`,
+	})
+}
+
+// Run a schedule now
+func rcRunNow(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	s, err := get()
+	if err != nil {
+		return nil, err
+	}
+	id, err := in.GetInt64("id")
+	if err != nil {
+		return nil, err
+	}
+	run, err := s.runNow(id, jobs.Scope(ctx))
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out = rc.Params{
+		"status": run.Status,
+	}
+	if run.JobID != 0 {
+		out["jobid"] = run.JobID
+	}
+	return out, nil
+}
@@ -0,0 +1,500 @@
+// Package schedule runs rc calls on a timetable for the rc server.
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/rc/jobs"
+)
+
+// Overlap policies - what to do when a schedule fires while the
+// previous run is still going
+const (
+	OverlapSkip   = "skip"            // don't start a new run
+	OverlapQueue  = "queue"           // start a new run when the current one finishes
+	OverlapCancel = "cancel-previous" // stop the current run and start a new one
+)
+
+// Run statuses
+const (
+	RunRunning     = "running"
+	RunSuccess     = "success"
+	RunError       = "error"
+	RunSkipped     = "skipped"
+	RunQueued      = "queued"
+	RunCancelled   = "cancelled"
+	RunInterrupted = "interrupted"
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// maxHistory is the number of runs kept for each schedule
+const maxHistory = 50
+
+// Run records one run of a schedule
+type Run struct {
+	JobID     int64     `json:"jobid,omitempty"`
+	Trigger   string    `json:"trigger"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime,omitempty"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Schedule describes an rc call to be run on a timetable
+type Schedule struct {
+	ID      int64       `json:"id"`
+	Cron    string      `json:"cron"`
+	Call    string      `json:"call"`
+	Params  rc.Params   `json:"params"`
+	Overlap string      `json:"overlap"`
+	Jitter  fs.Duration `json:"jitter"`
+	Scope   string      `json:"scope,omitempty"` // scope of the credentials it was added with
+	Created time.Time   `json:"created"`
+	NextRun time.Time   `json:"nextRun"`
+	History []*Run      `json:"history"`
+
+	s       *Scheduler
+	cron    *Cron
+	timer   *time.Timer
+	current *Run      // run in progress or nil
+	job     *jobs.Job // job of the run in progress
+	queued  *Run      // run waiting for current to finish
+	removed bool
+}
+
+// Scheduler runs the schedules
+type Scheduler struct {
+	mu        sync.Mutex
+	path      string // file to persist the schedules in or "" for none
+	schedules map[int64]*Schedule
+	nextID    int64
+	ctx       context.Context
+}
+
+// CheckScope is set by the rc server to check that credentials
+// limited to scope may make the rc call path with in.
+//
+// Schedules added with limited credentials are checked again each
+// time they run so they stop running if the credentials are revoked.
+var CheckScope func(scope, path string, in rc.Params) error
+
+// checkScope returns an error unless scope may call path with in
+func checkScope(scope, path string, in rc.Params) error {
+	if scope == "" {
+		return nil
+	}
+	if CheckScope == nil {
+		return fmt.Errorf("can't check the calls scope %q may make", scope)
+	}
+	return CheckScope(scope, path, in)
+}
+
+// running is the Scheduler used by the rc calls
+var (
+	runningMu sync.Mutex
+	running   *Scheduler
+)
+
+// DefaultPath returns the default file the schedules are kept in
+func DefaultPath() string {
+	dir := config.GetCacheDir()
+	if configPath := config.GetConfigPath(); configPath != "" {
+		dir = filepath.Dir(configPath)
+	}
+	return filepath.Join(dir, "schedules.json")
+}
+
+// Start loads the schedules from the file configured in opt and
+// starts running them.
+//
+// It is safe to call more than once - only the first call has an effect.
+func Start(ctx context.Context, opt *rc.Options) error {
+	runningMu.Lock()
+	defer runningMu.Unlock()
+	if running != nil {
+		return nil
+	}
+	path := opt.ScheduleFile
+	if path == "" {
+		path = DefaultPath()
+	}
+	s, err := newScheduler(ctx, path)
+	if err != nil {
+		return err
+	}
+	running = s
+	return nil
+}
+
+// newScheduler makes a new Scheduler loading its schedules from path
+func newScheduler(ctx context.Context, path string) (*Scheduler, error) {
+	s := &Scheduler{
+		path:      path,
+		schedules: map[int64]*Schedule{},
+		nextID:    1,
+		ctx:       ctx,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// get returns the running Scheduler or an error
+func get() (*Scheduler, error) {
+	runningMu.Lock()
+	defer runningMu.Unlock()
+	if running == nil {
+		return nil, errors.New("scheduler not running - it is started by the rc server")
+	}
+	return running, nil
+}
+
+// load reads the schedules from the file and starts them
+func (s *Scheduler) load() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schedules: %w", err)
+	}
+	var schedules []*Schedule
+	if err = json.Unmarshal(data, &schedules); err != nil {
+		return fmt.Errorf("failed to parse schedules in %q: %w", s.path, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sch := range schedules {
+		sch.cron, err = ParseCron(sch.Cron)
+		if err != nil {
+			fs.Errorf(nil, "schedule %d: ignoring: %v", sch.ID, err)
+			continue
+		}
+		// Runs which were going when we stopped won't finish now
+		for _, run := range sch.History {
+			if run.Status == RunRunning || run.Status == RunQueued {
+				run.Status = RunInterrupted
+				run.Error = "rclone stopped before the run finished"
+				if run.EndTime.IsZero() {
+					run.EndTime = time.Now()
+				}
+			}
+		}
+		sch.s = s
+		s.schedules[sch.ID] = sch
+		if sch.ID >= s.nextID {
+			s.nextID = sch.ID + 1
+		}
+		sch.arm(time.Now())
+	}
+	fs.Infof(nil, "Loaded %d schedules from %q", len(s.schedules), s.path)
+	return nil
+}
+
+// save writes the schedules to the file - call with the lock held
+func (s *Scheduler) save() {
+	if s.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(s.list(), "", "\t")
+	if err != nil {
+		fs.Errorf(nil, "Failed to encode schedules: %v", err)
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		fs.Errorf(nil, "Failed to save schedules: %v", err)
+		return
+	}
+	tmp := s.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err != nil {
+		fs.Errorf(nil, "Failed to save schedules: %v", err)
+	}
+}
+
+// list returns the schedules sorted by ID - call with the lock held
+func (s *Scheduler) list() []*Schedule {
+	schedules := make([]*Schedule, 0, len(s.schedules))
+	for _, sch := range s.schedules {
+		schedules = append(schedules, sch)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules
+}
+
+// add makes a new schedule and starts it
+func (s *Scheduler) add(sch *Schedule) (*Schedule, error) {
+	var err error
+	sch.cron, err = ParseCron(sch.Cron)
+	if err != nil {
+		return nil, err
+	}
+	switch sch.Overlap {
+	case "":
+		sch.Overlap = OverlapSkip
+	case OverlapSkip, OverlapQueue, OverlapCancel:
+	default:
+		return nil, fmt.Errorf("unknown overlap policy %q - must be %q, %q or %q", sch.Overlap, OverlapSkip, OverlapQueue, OverlapCancel)
+	}
+	if sch.Jitter < 0 {
+		return nil, errors.New("jitter must be positive")
+	}
+	if rc.Calls.Get(sch.Call) == nil {
+		return nil, fmt.Errorf("couldn't find method %q", sch.Call)
+	}
+	if sch.Params == nil {
+		sch.Params = rc.Params{}
+	}
+	if err := checkScope(sch.Scope, sch.Call, sch.Params); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sch.ID = s.nextID
+	s.nextID++
+	sch.Created = time.Now()
+	sch.History = []*Run{}
+	sch.s = s
+	s.schedules[sch.ID] = sch
+	sch.arm(time.Now())
+	s.save()
+	return sch, nil
+}
+
+// find returns the schedule with the ID or an error - call with the lock held
+func (s *Scheduler) find(id int64) (*Schedule, error) {
+	sch := s.schedules[id]
+	if sch == nil {
+		return nil, fmt.Errorf("schedule %d not found", id)
+	}
+	return sch, nil
+}
+
+// remove stops and deletes the schedule. Any run in progress is
+// left to finish.
+func (s *Scheduler) remove(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sch, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	if sch.timer != nil {
+		sch.timer.Stop()
+	}
+	sch.removed = true
+	sch.queued = nil
+	delete(s.schedules, id)
+	s.save()
+	return nil
+}
+
+// runNow runs the schedule straight away, subject to its overlap
+// policy, if scope may make its call
+func (s *Scheduler) runNow(id int64, scope string) (*Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sch, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkScope(scope, sch.Call, sch.Params); err != nil {
+		return nil, err
+	}
+	run := sch.trigger(TriggerManual)
+	s.save()
+	return run, nil
+}
+
+// stop stops all the timers - used in tests
+func (s *Scheduler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sch := range s.schedules {
+		if sch.timer != nil {
+			sch.timer.Stop()
+		}
+		sch.removed = true
+	}
+}
+
+// arm sets the timer for the next run after t - call with the lock held
+func (sch *Schedule) arm(t time.Time) {
+	next, err := sch.cron.Next(t)
+	if err != nil {
+		fs.Errorf(nil, "schedule %d: %v", sch.ID, err)
+		sch.NextRun = time.Time{}
+		return
+	}
+	sch.NextRun = next
+	delay := time.Until(next)
+	if sch.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(sch.Jitter)))
+	}
+	sch.timer = time.AfterFunc(delay, func() {
+		sch.fire(next)
+	})
+}
+
+// fire is called by the timer when the schedule is due
+func (sch *Schedule) fire(due time.Time) {
+	s := sch.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sch.removed {
+		return
+	}
+	sch.trigger(TriggerSchedule)
+	// Arm from the due time so a short run can't fire twice in the same minute
+	now := time.Now()
+	if due.After(now) {
+		now = due
+	}
+	sch.arm(now)
+	s.save()
+}
+
+// trigger starts a run according to the overlap policy - call with
+// the lock held
+func (sch *Schedule) trigger(trigger string) *Run {
+	run := &Run{
+		Trigger:   trigger,
+		StartTime: time.Now(),
+	}
+	sch.addHistory(run)
+	if sch.current == nil {
+		sch.start(run)
+		return run
+	}
+	switch sch.Overlap {
+	case OverlapQueue:
+		if sch.queued != nil {
+			// Only one run is queued - later ones are merged into it
+			run.Status = RunSkipped
+			run.EndTime = run.StartTime
+			run.Error = "a run is already queued"
+			return run
+		}
+		run.Status = RunQueued
+		sch.queued = run
+	case OverlapCancel:
+		fs.Infof(nil, "schedule %d: cancelling job %d to start a new run", sch.ID, sch.current.JobID)
+		sch.current.Status = RunCancelled
+		job := sch.job
+		sch.finished(sch.current, errors.New("cancelled by a new run"))
+		go job.Stop()
+		sch.start(run)
+	default:
+		run.Status = RunSkipped
+		run.EndTime = run.StartTime
+		run.Error = fmt.Sprintf("job %d is still running", sch.current.JobID)
+		fs.Infof(nil, "schedule %d: skipping run as %s", sch.ID, run.Error)
+	}
+	return run
+}
+
+// start starts the run as an rc job - call with the lock held
+func (sch *Schedule) start(run *Run) {
+	call := rc.Calls.Get(sch.Call)
+	if call == nil {
+		sch.finished(run, fmt.Errorf("couldn't find method %q", sch.Call))
+		return
+	}
+	if err := checkScope(sch.Scope, sch.Call, sch.Params); err != nil {
+		fs.Errorf(nil, "schedule %d: not running: %v", sch.ID, err)
+		run.Status = RunError
+		sch.finished(run, err)
+		return
+	}
+	in := sch.Params.Copy()
+	in["_async"] = true
+	if _, ok := in["_group"]; !ok {
+		in["_group"] = fmt.Sprintf("schedule/%d", sch.ID)
+	}
+	fn := func(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+		defer func() {
+			sch.s.mu.Lock()
+			sch.finished(run, err)
+			sch.s.save()
+			sch.s.mu.Unlock()
+		}()
+		return call.Fn(ctx, in)
+	}
+	run.Status = RunRunning
+	run.StartTime = time.Now()
+	sch.current = run
+	ctx := jobs.WithCall(sch.s.ctx, sch.Call)
+	if sch.Scope != "" {
+		ctx = jobs.WithScope(ctx, sch.Scope)
+	}
+	job, _, err := jobs.NewJob(ctx, fn, in)
+	if err != nil {
+		sch.finished(run, err)
+		return
+	}
+	run.JobID = job.ID
+	sch.job = job
+	fs.Debugf(nil, "schedule %d: started %q as job %d", sch.ID, sch.Call, job.ID)
+}
+
+// finished records the end of the run and starts any queued run -
+// call with the lock held
+func (sch *Schedule) finished(run *Run, err error) {
+	if !run.EndTime.IsZero() {
+		return // already finished, e.g. cancelled
+	}
+	run.EndTime = time.Now()
+	if run.Status == RunRunning {
+		if err != nil {
+			run.Status = RunError
+		} else {
+			run.Status = RunSuccess
+		}
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if sch.current != run {
+		return
+	}
+	sch.current = nil
+	sch.job = nil
+	if queued := sch.queued; queued != nil && !sch.removed {
+		sch.queued = nil
+		sch.start(queued)
+	}
+}
+
+// addHistory adds the run to the history trimming it if necessary -
+// call with the lock held
+func (sch *Schedule) addHistory(run *Run) {
+	sch.History = append(sch.History, run)
+	if len(sch.History) > maxHistory {
+		sch.History = sch.History[len(sch.History)-maxHistory:]
+	}
+}
@@ -0,0 +1,259 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockCalls receives a channel for each call of the test method
+// which the test closes to let the call finish
+var blockCalls = make(chan chan struct{}, 10)
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "schedule/test-block",
+		Title: "Block until released - used in the tests",
+		Fn: func(ctx context.Context, in rc.Params) (rc.Params, error) {
+			release := make(chan struct{})
+			blockCalls <- release
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return in, nil
+		},
+	})
+}
+
+// waitFor waits for the condition to be true or fails the test
+func waitFor(t *testing.T, s *Scheduler, cond func() bool) {
+	for i := 0; i < 500; i++ {
+		s.mu.Lock()
+		ok := cond()
+		s.mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for condition")
+}
+
+func newTestScheduler(t *testing.T, path string) *Scheduler {
+	s, err := newScheduler(context.Background(), path)
+	require.NoError(t, err)
+	t.Cleanup(s.stop)
+	return s
+}
+
+func TestScheduleAddErrors(t *testing.T) {
+	s := newTestScheduler(t, "")
+	for _, sch := range []*Schedule{
+		{Cron: "bad", Call: "rc/noop"},
+		{Cron: "@daily", Call: "rc/notfound"},
+		{Cron: "@daily", Call: "rc/noop", Overlap: "wait"},
+		{Cron: "@daily", Call: "rc/noop", Jitter: -1},
+	} {
+		_, err := s.add(sch)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 0, len(s.schedules))
+}
+
+func TestScheduleOverlap(t *testing.T) {
+	for _, test := range []struct {
+		overlap string
+		status  string // of the second run
+		want    []string
+	}{
+		{OverlapSkip, RunSkipped, []string{RunSuccess, RunSkipped}},
+		{OverlapQueue, RunQueued, []string{RunSuccess, RunSuccess}},
+		{OverlapCancel, RunRunning, []string{RunCancelled, RunSuccess}},
+	} {
+		t.Run(test.overlap, func(t *testing.T) {
+			s := newTestScheduler(t, "")
+			sch, err := s.add(&Schedule{
+				Cron:    "@yearly",
+				Call:    "schedule/test-block",
+				Overlap: test.overlap,
+				Params:  rc.Params{"potato": 1},
+			})
+			require.NoError(t, err)
+			assert.True(t, sch.NextRun.After(time.Now()))
+
+			run1, err := s.runNow(sch.ID, "")
+			require.NoError(t, err)
+			assert.Equal(t, RunRunning, run1.Status)
+			assert.NotEqual(t, int64(0), run1.JobID)
+			release1 := <-blockCalls
+
+			run2, err := s.runNow(sch.ID, "")
+			require.NoError(t, err)
+			s.mu.Lock()
+			assert.Equal(t, test.status, run2.Status)
+			s.mu.Unlock()
+
+			close(release1)
+			if test.status != RunSkipped {
+				close(<-blockCalls)
+			}
+			waitFor(t, s, func() bool {
+				return sch.current == nil && sch.queued == nil
+			})
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			var got []string
+			for _, run := range sch.History {
+				got = append(got, run.Status)
+				assert.Equal(t, TriggerManual, run.Trigger)
+				assert.False(t, run.EndTime.IsZero())
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestSchedulePersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	s := newTestScheduler(t, path)
+	sch, err := s.add(&Schedule{
+		Cron: "0 3 * * *",
+		Call: "schedule/test-block",
+	})
+	require.NoError(t, err)
+	_, err = s.add(&Schedule{
+		Cron:   "@every 1s",
+		Call:   "rc/noop",
+		Jitter: 0,
+	})
+	require.NoError(t, err)
+	_, err = s.runNow(sch.ID, "")
+	require.NoError(t, err)
+	release := <-blockCalls
+	defer close(release)
+	s.stop()
+
+	// Load as if restarted while the run was going
+	s2 := newTestScheduler(t, path)
+	s2.mu.Lock()
+	require.Equal(t, 2, len(s2.schedules))
+	sch2 := s2.schedules[sch.ID]
+	assert.Equal(t, "0 3 * * *", sch2.Cron)
+	assert.Equal(t, OverlapSkip, sch2.Overlap)
+	require.Equal(t, 1, len(sch2.History))
+	assert.Equal(t, RunInterrupted, sch2.History[0].Status)
+	assert.Equal(t, int64(3), s2.nextID)
+	s2.mu.Unlock()
+
+	// The @every schedule fires on its own
+	every := s2.schedules[2]
+	waitFor(t, s2, func() bool {
+		return len(every.History) > 0 && every.History[0].Status == RunSuccess
+	})
+	assert.Equal(t, TriggerSchedule, every.History[0].Trigger)
+
+	require.NoError(t, s2.remove(every.ID))
+	assert.Error(t, s2.remove(every.ID))
+	s3 := newTestScheduler(t, path)
+	assert.Equal(t, 1, len(s3.schedules))
+}
+
+func TestScheduleRc(t *testing.T) {
+	runningMu.Lock()
+	running = newTestScheduler(t, "")
+	runningMu.Unlock()
+	defer func() {
+		runningMu.Lock()
+		running = nil
+		runningMu.Unlock()
+	}()
+	ctx := context.Background()
+
+	call := rc.Calls.Get("schedule/add")
+	require.NotNil(t, call)
+	out, err := call.Fn(ctx, rc.Params{
+		"cron":    "@daily",
+		"call":    "rc/noop",
+		"params":  `{"potato":"sausage"}`,
+		"overlap": "queue",
+		"jitter":  "1m",
+	})
+	require.NoError(t, err)
+	id := out["id"].(int64)
+
+	call = rc.Calls.Get("schedule/run-now")
+	require.NotNil(t, call)
+	out, err = call.Fn(ctx, rc.Params{"id": id})
+	require.NoError(t, err)
+	assert.Equal(t, RunRunning, out["status"])
+	assert.NotNil(t, out["jobid"])
+
+	call = rc.Calls.Get("schedule/list")
+	require.NotNil(t, call)
+	out, err = call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	schedules := out["schedules"].([]interface{})
+	require.Equal(t, 1, len(schedules))
+	sch := schedules[0].(map[string]interface{})
+	assert.Equal(t, "@daily", sch["cron"])
+	assert.Equal(t, "queue", sch["overlap"])
+	assert.Equal(t, map[string]interface{}{"potato": "sausage"}, sch["params"])
+	assert.Equal(t, 1, len(sch["history"].([]interface{})))
+
+	call = rc.Calls.Get("schedule/remove")
+	require.NotNil(t, call)
+	_, err = call.Fn(ctx, rc.Params{"id": id})
+	require.NoError(t, err)
+	_, err = call.Fn(ctx, rc.Params{"id": id})
+	require.Error(t, err)
+}
+
+func TestScheduleScope(t *testing.T) {
+	revoked := false
+	oldCheckScope := CheckScope
+	CheckScope = func(scope, path string, in rc.Params) error {
+		if revoked || path != "rc/noop" {
+			return fmt.Errorf("%q can't call %q", scope, path)
+		}
+		return nil
+	}
+	defer func() {
+		CheckScope = oldCheckScope
+	}()
+	s := newTestScheduler(t, "")
+
+	// The scope is checked when the schedule is added
+	_, err := s.add(&Schedule{Cron: "@yearly", Call: "schedule/test-block", Scope: "token1"})
+	assert.Error(t, err)
+	sch, err := s.add(&Schedule{Cron: "@yearly", Call: "rc/noop", Scope: "token1"})
+	require.NoError(t, err)
+	run, err := s.runNow(sch.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, RunRunning, run.Status)
+	waitFor(t, s, func() bool {
+		return sch.current == nil
+	})
+
+	// And again when it runs
+	revoked = true
+	run, err = s.runNow(sch.ID, "")
+	require.NoError(t, err)
+	s.mu.Lock()
+	assert.Equal(t, RunError, run.Status)
+	assert.Contains(t, run.Error, "can't call")
+	s.mu.Unlock()
+
+	// The scope of the caller is checked by run-now
+	admin, err := s.add(&Schedule{Cron: "@yearly", Call: "schedule/test-block"})
+	require.NoError(t, err)
+	_, err = s.runNow(admin.ID, "token1")
+	assert.Error(t, err)
+}
@@ -18,6 +18,8 @@ import (
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/march"
 	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/tracing"
+	"github.com/rclone/rclone/fs/transferlog"
 )
 
 type syncCopyMove struct {
@@ -347,6 +349,7 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 				if s.ci.Immutable && pair.Dst != nil {
 					err := fs.CountError(fserrors.NoRetryError(fs.ErrorImmutableModified))
 					fs.Errorf(pair.Dst, "Source and destination exist but do not match: %v", err)
+					s.logSkipped(pair, "source and destination differ (--immutable)", err)
 					s.processError(err)
 				} else {
 					// If destination already exists, then we must move it into --backup-dir if required
@@ -382,11 +385,31 @@ func (s *syncCopyMove) pairChecker(in *pipe, out *pipe, fraction int, wg *sync.W
 					}
 				}
 			}
+		} else {
+			s.logSkipped(pair, "not storable", nil)
 		}
 		tr.Done(s.ctx, err)
 	}
 }
 
+// logSkipped writes a record to the transfer log that the src of pair
+// wasn't transferred because of reason
+func (s *syncCopyMove) logSkipped(pair fs.ObjectPair, reason string, err error) {
+	if !transferlog.Enabled(s.ctx) {
+		return
+	}
+	src := pair.Src
+	transferlog.Log(s.ctx, &transferlog.Record{
+		Action: transferlog.Skipped,
+		Object: src.Remote(),
+		Src:    transferlog.Path(src.Fs(), src.Remote()),
+		Dst:    transferlog.Path(s.fdst, src.Remote()),
+		Size:   src.Size(),
+		Reason: reason,
+		Error:  transferlog.ErrorString(err),
+	})
+}
+
 // pairRenamer reads Objects~s on in and attempts to rename them,
 // otherwise it sends them out if they need transferring.
 func (s *syncCopyMove) pairRenamer(in *pipe, out *pipe, fraction int, wg *sync.WaitGroup) {
@@ -815,7 +838,7 @@ func (s *syncCopyMove) tryRename(src fs.Object) bool {
 	dstOverwritten, _ := s.fdst.NewObject(s.ctx, src.Remote())
 
 	// Rename dst to have name src.Remote()
-	_, err := operations.Move(s.ctx, s.fdst, dstOverwritten, src.Remote(), dst)
+	_, err := operations.Move(transferlog.WithReason(s.ctx, "--track-renames"), s.fdst, dstOverwritten, src.Remote(), dst)
 	if err != nil {
 		fs.Debugf(src, "Failed to rename to %q: %v", dst.Remote(), err)
 		return false
@@ -830,9 +853,12 @@ func (s *syncCopyMove) tryRename(src fs.Object) bool {
 	return true
 }
 
-// errorMaxDurationReached defines error when transfer duration is reached
+// ErrorMaxDurationReached defines error when transfer duration is reached
+var ErrorMaxDurationReached = errors.New("max transfer duration reached as set by --max-duration")
+
+// errorMaxDurationReached is the fatal version of ErrorMaxDurationReached.
 // Used for checking on exit and matching to correct exit code.
-var errorMaxDurationReached = fserrors.FatalError(errors.New("max transfer duration reached as set by --max-duration"))
+var errorMaxDurationReached = fserrors.FatalError(ErrorMaxDurationReached)
 
 // Syncs fsrc into fdst
 //
@@ -1087,8 +1113,21 @@ func (s *syncCopyMove) Match(ctx context.Context, dst, src fs.DirEntry) (recurse
 // If DoMove is true then files will be moved instead of copied.
 //
 // dir is the start directory, "" for root
-func runSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.DeleteMode, DoMove bool, deleteEmptySrcDirs bool, copyEmptySrcDirs bool) error {
+func runSyncCopyMove(ctx context.Context, fdst, fsrc fs.Fs, deleteMode fs.DeleteMode, DoMove bool, deleteEmptySrcDirs bool, copyEmptySrcDirs bool) (err error) {
 	ci := fs.GetConfig(ctx)
+	spanName := "sync.CopyDir"
+	if DoMove {
+		spanName = "sync.MoveDir"
+	} else if deleteMode != fs.DeleteModeOff {
+		spanName = "sync.Sync"
+	}
+	ctx, span := tracing.StartSpan(ctx, spanName,
+		tracing.KeySrc.String(tracing.FsString(fsrc)),
+		tracing.KeyDst.String(tracing.FsString(fdst)),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
 	if deleteMode != fs.DeleteModeOff && DoMove {
 		return fserrors.FatalError(errors.New("can't delete and move at the same time"))
 	}
@@ -0,0 +1,151 @@
+// Package tracing records OpenTelemetry spans for rclone operations
+// and exports them to an OTLP collector or a local JSON file.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+	libtracing "github.com/rclone/rclone/lib/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// Options contains options for tracing
+type Options struct {
+	OTLP string // URL of an OTLP/HTTP collector to send spans to
+	File string // file to write spans to as JSON
+}
+
+// DefaultOpt is the default values used for Opt
+var DefaultOpt = Options{}
+
+// Opt is the options for tracing
+var Opt = DefaultOpt
+
+// These are defined in lib/tracing so low level packages can use
+// them too.
+const (
+	TracerName = libtracing.TracerName
+	KeyRemote  = libtracing.KeyRemote
+	KeySrc     = libtracing.KeySrc
+	KeyDst     = libtracing.KeyDst
+	KeyObject  = libtracing.KeyObject
+	KeyDir     = libtracing.KeyDir
+	KeySize    = libtracing.KeySize
+	KeyEntries = libtracing.KeyEntries
+	KeyAction  = libtracing.KeyAction
+	KeyTry     = libtracing.KeyTry
+	KeyTries   = libtracing.KeyTries
+)
+
+// These are defined in lib/tracing so low level packages can use
+// them too.
+var (
+	StartSpan = libtracing.StartSpan
+	End       = libtracing.End
+	Retry     = libtracing.Retry
+)
+
+// Start starts exporting spans if Opt asks for it.
+//
+// It returns a function which flushes any spans not yet exported and
+// stops the exporters.
+func Start(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	shutdown = func(context.Context) error { return nil }
+	if Opt.OTLP == "" && Opt.File == "" {
+		return shutdown, nil
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String("rclone"),
+		semconv.ServiceVersionKey.String(fs.Version),
+	))
+	if err != nil {
+		return shutdown, fmt.Errorf("failed to make trace resource: %w", err)
+	}
+	options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	var closers []func() error
+	if Opt.OTLP != "" {
+		exporter, err := newOTLPExporter(ctx, Opt.OTLP)
+		if err != nil {
+			return shutdown, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+	if Opt.File != "" {
+		f, err := os.OpenFile(Opt.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return shutdown, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return shutdown, fmt.Errorf("failed to make trace file exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+		closers = append(closers, f.Close)
+	}
+	tp := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(tp)
+	fs.Debugf(nil, "Tracing started")
+	shutdown = func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		for _, closer := range closers {
+			if closeErr := closer(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}
+	return shutdown, nil
+}
+
+// newOTLPExporter makes an exporter sending spans to the OTLP/HTTP
+// collector at rawURL, e.g. "http://localhost:4318"
+func newOTLPExporter(ctx context.Context, rawURL string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("bad OTLP URL: %w", err)
+	}
+	if u.Host == "" {
+		return nil, errors.New("bad OTLP URL: need a URL like http://localhost:4318")
+	}
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	switch u.Scheme {
+	case "http":
+		options = append(options, otlptracehttp.WithInsecure())
+	case "https":
+	default:
+		return nil, fmt.Errorf("bad OTLP URL: unknown scheme %q", u.Scheme)
+	}
+	if path := strings.TrimRight(u.Path, "/"); path != "" {
+		options = append(options, otlptracehttp.WithURLPath(path))
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make OTLP exporter: %w", err)
+	}
+	return exporter, nil
+}
+
+// FsString returns the remote f as a string for use in an attribute
+func FsString(f fs.Info) string {
+	switch f := f.(type) {
+	case nil:
+		return ""
+	case fs.Fs:
+		return fs.ConfigString(f)
+	default:
+		return f.Name() + ":" + f.Root()
+	}
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestFsString(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", FsString(nil))
+	assert.Equal(t, "remote:path", FsString(mockfs.NewFs(ctx, "remote", "path")))
+}
+
+func TestNewOTLPExporter(t *testing.T) {
+	ctx := context.Background()
+	for _, bad := range []string{"localhost:4318", "ftp://localhost:4318", "http://", "://"} {
+		_, err := newOTLPExporter(ctx, bad)
+		assert.Error(t, err, bad)
+	}
+	exporter, err := newOTLPExporter(ctx, "http://localhost:4318/custom/v1/traces")
+	require.NoError(t, err)
+	require.NoError(t, exporter.Shutdown(ctx))
+}
+
+func TestStartFile(t *testing.T) {
+	ctx := context.Background()
+	oldOpt, oldProvider := Opt, otel.GetTracerProvider()
+	defer func() {
+		Opt = oldOpt
+		otel.SetTracerProvider(oldProvider)
+	}()
+
+	// Nothing to do if not configured
+	Opt = DefaultOpt
+	shutdown, err := Start(ctx)
+	require.NoError(t, err)
+	require.NoError(t, shutdown(ctx))
+
+	Opt.File = filepath.Join(t.TempDir(), "trace.json")
+	shutdown, err = Start(ctx)
+	require.NoError(t, err)
+
+	ctx, parent := StartSpan(ctx, "parent", KeyRemote.String("remote:"))
+	_, child := StartSpan(ctx, "child", KeyObject.String("file.txt"))
+	Retry(child, 1, 3, errors.New("try again"))
+	End(child, errors.New("failed"))
+	End(parent, nil)
+	require.NoError(t, shutdown(context.Background()))
+
+	// Check the spans were written as JSON lines
+	f, err := os.Open(Opt.File)
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	type span struct {
+		Name        string
+		SpanContext struct{ SpanID string }
+		Parent      struct{ SpanID string }
+		Status      struct{ Code string }
+		Events      []struct{ Name string }
+		Attributes  []struct{ Key string }
+	}
+	spans := map[string]span{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var s span
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &s))
+		spans[s.Name] = s
+	}
+	require.NoError(t, scanner.Err())
+	require.Contains(t, spans, "parent")
+	require.Contains(t, spans, "child")
+	assert.Equal(t, spans["parent"].SpanContext.SpanID, spans["child"].Parent.SpanID)
+	assert.Equal(t, "Error", spans["child"].Status.Code)
+	assert.Equal(t, "rclone.object", spans["child"].Attributes[0].Key)
+	var events []string
+	for _, event := range spans["child"].Events {
+		events = append(events, event.Name)
+	}
+	assert.Equal(t, []string{"retry", "exception"}, events)
+}
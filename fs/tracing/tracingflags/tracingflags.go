@@ -0,0 +1,14 @@
+// Package tracingflags implements command line flags to set up tracing
+package tracingflags
+
+import (
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/tracing"
+	"github.com/spf13/pflag"
+)
+
+// AddFlags adds the tracing flags to the flagSet
+func AddFlags(flagSet *pflag.FlagSet) {
+	flags.StringVarP(flagSet, &tracing.Opt.OTLP, "trace-otlp", "", tracing.Opt.OTLP, "Send OpenTelemetry traces to this OTLP/HTTP collector, e.g. http://localhost:4318")
+	flags.StringVarP(flagSet, &tracing.Opt.File, "trace-file", "", tracing.Opt.File, "Write OpenTelemetry traces to this file as JSON")
+}
@@ -0,0 +1,78 @@
+package transferlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// templateFields are the % escapes which may be used in a template
+// and the values they expand to.
+var templateFields = map[byte]func(r *Record) string{
+	't': func(r *Record) string { return r.Time.Format("2006/01/02 15:04:05") },
+	'o': func(r *Record) string { return string(r.Action) },
+	'n': func(r *Record) string { return r.Object },
+	's': func(r *Record) string { return r.Src },
+	'd': func(r *Record) string { return r.Dst },
+	'l': func(r *Record) string { return strconv.FormatInt(r.Size, 10) },
+	'h': func(r *Record) string { return r.Hash },
+	'T': func(r *Record) string {
+		return (time.Duration(r.Duration * float64(time.Second))).Round(time.Millisecond).String()
+	},
+	'r': func(r *Record) string { return r.Reason },
+	'e': func(r *Record) string { return r.Error },
+}
+
+// template is a parsed template for formatting records
+type template struct {
+	parts []func(r *Record) string
+}
+
+// parseTemplate parses a template like rsync's --out-format, where
+// %o, %n etc are replaced with fields of the record
+func parseTemplate(format string) (*template, error) {
+	t := &template{}
+	var literal strings.Builder
+	addLiteral := func() {
+		if literal.Len() == 0 {
+			return
+		}
+		s := literal.String()
+		t.parts = append(t.parts, func(*Record) string { return s })
+		literal.Reset()
+	}
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			literal.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(format) {
+			return nil, fmt.Errorf("transfer log template %q ends with %%", format)
+		}
+		c = format[i]
+		if c == '%' {
+			literal.WriteByte(c)
+			continue
+		}
+		field, ok := templateFields[c]
+		if !ok {
+			return nil, fmt.Errorf("transfer log template %q has unknown escape %%%c", format, c)
+		}
+		addLiteral()
+		t.parts = append(t.parts, field)
+	}
+	addLiteral()
+	return t, nil
+}
+
+// expand the template with the fields from r
+func (t *template) expand(r *Record) string {
+	var out strings.Builder
+	for _, part := range t.parts {
+		out.WriteString(part(r))
+	}
+	return out.String()
+}
@@ -0,0 +1,259 @@
+// Package transferlog writes a record of each change rclone makes to
+// a file as JSON lines, CSV or a template like rsync's --out-format.
+package transferlog
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// Options contains options for the transfer log
+type Options struct {
+	File   string // file to append the log to
+	Format string // "json", "csv" or a template
+}
+
+// DefaultOpt is the default values used for Opt
+var DefaultOpt = Options{
+	Format: "json",
+}
+
+// Opt is the options for the transfer log
+var Opt = DefaultOpt
+
+// Action is what was done to a file
+type Action string
+
+// Actions which are logged
+const (
+	Copied   Action = "copied"  // a file was copied to a new destination
+	Updated  Action = "updated" // a file was copied over an existing destination
+	Moved    Action = "moved"   // a file was moved
+	Renamed  Action = "renamed" // a file was moved within the same remote
+	Deleted  Action = "deleted" // a file was deleted
+	BackedUp Action = "backup"  // a file was moved into --backup-dir
+	Skipped  Action = "skipped" // a file was left alone for Reason
+)
+
+// Record is a single entry in the transfer log
+type Record struct {
+	Time     time.Time `json:"time"`             // when the action finished
+	Action   Action    `json:"action"`           // what was done
+	Object   string    `json:"object"`           // path of the file relative to the root of the remote
+	Src      string    `json:"src,omitempty"`    // source of the file as remote:path
+	Dst      string    `json:"dst,omitempty"`    // destination of the file as remote:path
+	Size     int64     `json:"size"`             // size of the file or -1 if not known
+	Hash     string    `json:"hash,omitempty"`   // hash of the file as type:value if checked
+	Duration float64   `json:"duration"`         // time taken in seconds
+	Reason   string    `json:"reason,omitempty"` // why the action was taken or skipped
+	Error    string    `json:"error,omitempty"`  // error if the action failed
+	DryRun   bool      `json:"dryRun,omitempty"` // set if --dry-run was in effect
+}
+
+// csvHeader is the header line for the CSV format
+var csvHeader = []string{"time", "action", "object", "src", "dst", "size", "hash", "duration", "reason", "error", "dryRun"}
+
+// fields returns the fields of r for the CSV format
+func (r *Record) fields() []string {
+	return []string{
+		r.Time.Format(time.RFC3339Nano),
+		string(r.Action),
+		r.Object,
+		r.Src,
+		r.Dst,
+		strconv.FormatInt(r.Size, 10),
+		r.Hash,
+		strconv.FormatFloat(r.Duration, 'f', 3, 64),
+		r.Reason,
+		r.Error,
+		strconv.FormatBool(r.DryRun),
+	}
+}
+
+// logger writes records to the log file
+type logger struct {
+	mu     sync.Mutex
+	out    io.WriteCloser
+	format func(w io.Writer, r *Record) error
+}
+
+var (
+	activeMu sync.RWMutex
+	active   *logger // the transfer log in use or nil
+)
+
+// Start starts writing the transfer log if Opt asks for it.
+//
+// It returns a function which closes the log.
+func Start(ctx context.Context) (stop func() error, err error) {
+	stop = func() error { return nil }
+	if Opt.File == "" {
+		return stop, nil
+	}
+	format, err := newFormat(Opt.Format)
+	if err != nil {
+		return stop, err
+	}
+	f, err := os.OpenFile(Opt.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return stop, fmt.Errorf("failed to open transfer log: %w", err)
+	}
+	if Opt.Format == "csv" {
+		fi, err := f.Stat()
+		if err == nil && fi.Size() == 0 {
+			err = writeCSV(f, csvHeader)
+		}
+		if err != nil {
+			_ = f.Close()
+			return stop, fmt.Errorf("failed to write transfer log header: %w", err)
+		}
+	}
+	l := &logger{out: f, format: format}
+	activeMu.Lock()
+	active = l
+	activeMu.Unlock()
+	fs.Debugf(nil, "Transfer log started")
+	stop = func() error {
+		activeMu.Lock()
+		if active == l {
+			active = nil
+		}
+		activeMu.Unlock()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.out.Close()
+	}
+	return stop, nil
+}
+
+// newFormat returns the function to write records in format
+func newFormat(format string) (func(w io.Writer, r *Record) error, error) {
+	switch format {
+	case "json":
+		return func(w io.Writer, r *Record) error {
+			return json.NewEncoder(w).Encode(r)
+		}, nil
+	case "csv":
+		return func(w io.Writer, r *Record) error {
+			return writeCSV(w, r.fields())
+		}, nil
+	case "":
+		return nil, errors.New("transfer log format must be json, csv or a template")
+	}
+	t, err := parseTemplate(format)
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, r *Record) error {
+		_, err := io.WriteString(w, t.expand(r)+"\n")
+		return err
+	}, nil
+}
+
+// writeCSV writes a line of CSV to w
+func writeCSV(w io.Writer, fields []string) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write(fields)
+	cw.Flush()
+	return cw.Error()
+}
+
+type reasonKeyType struct{}
+
+type quietKeyType struct{}
+
+// reasonKey is the context key for the reason to add to records
+var reasonKey reasonKeyType
+
+// quietKey is the context key for suppressing records
+var quietKey quietKeyType
+
+// WithReason returns a copy of ctx so that records logged with it
+// which don't have a reason of their own are given reason.
+func WithReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, reasonKey, reason)
+}
+
+// Quiet returns a copy of ctx which doesn't log records.
+//
+// This is used by operations made of other operations, so only one
+// record is written for them.
+func Quiet(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quietKey, true)
+}
+
+// Enabled returns true if records logged with ctx will be written.
+//
+// Use this to avoid making records which won't be used.
+func Enabled(ctx context.Context) bool {
+	activeMu.RLock()
+	l := active
+	activeMu.RUnlock()
+	return l != nil && ctx.Value(quietKey) == nil
+}
+
+// Log writes r to the transfer log if it is enabled.
+//
+// The Time, Reason and DryRun fields are filled in if not set.
+func Log(ctx context.Context, r *Record) {
+	if ctx.Value(quietKey) != nil {
+		return
+	}
+	activeMu.RLock()
+	l := active
+	activeMu.RUnlock()
+	if l == nil {
+		return
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	if r.Reason == "" {
+		r.Reason, _ = ctx.Value(reasonKey).(string)
+	}
+	if fs.GetConfig(ctx).DryRun {
+		r.DryRun = true
+	}
+	l.mu.Lock()
+	err := l.format(l.out, r)
+	l.mu.Unlock()
+	if err != nil {
+		fs.Errorf(nil, "Failed to write transfer log: %v", err)
+	}
+}
+
+// Path returns remote on f as a remote:path string for a record
+func Path(f fs.Info, remote string) string {
+	var root string
+	switch f := f.(type) {
+	case nil:
+		return remote
+	case fs.Fs:
+		root = fs.ConfigString(f)
+	default:
+		root = f.Name() + ":" + f.Root()
+	}
+	if remote == "" || strings.HasSuffix(root, ":") || strings.HasSuffix(root, "/") {
+		return root + remote
+	}
+	return root + "/" + remote
+}
+
+// ErrorString returns err as a string for a record, or "" if err is nil
+func ErrorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,164 @@
+package transferlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fstest/mockfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRecord = Record{
+	Time:     time.Date(2022, 11, 12, 13, 14, 15, 0, time.UTC),
+	Action:   Copied,
+	Object:   "dir/file.txt",
+	Src:      "/tmp/src/dir/file.txt",
+	Dst:      "remote:dst/dir/file.txt",
+	Size:     1234,
+	Hash:     "md5:f3a6ab5ce0fb2b3a9d1e6e7e9f8c1d2b",
+	Duration: 1.5,
+}
+
+// start the transfer log in format returning its path
+func start(t *testing.T, format string) string {
+	oldOpt := Opt
+	Opt.File = filepath.Join(t.TempDir(), "transfer.log")
+	Opt.Format = format
+	stop, err := Start(context.Background())
+	require.NoError(t, err)
+	file := Opt.File
+	t.Cleanup(func() {
+		Opt = oldOpt
+		_ = stop()
+	})
+	return file
+}
+
+func TestNotStarted(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, Enabled(ctx))
+	Log(ctx, &Record{Action: Deleted}) // must not crash
+}
+
+func TestJSON(t *testing.T) {
+	ctx := context.Background()
+	file := start(t, "json")
+	assert.True(t, Enabled(ctx))
+	assert.False(t, Enabled(Quiet(ctx)))
+
+	r := testRecord
+	Log(ctx, &r)
+	Log(Quiet(ctx), &Record{Action: Deleted, Object: "quiet"})
+	Log(WithReason(ctx, "bisync delete1"), &Record{Action: Deleted, Object: "gone", Size: -1, Error: "failed"})
+	ctx, ci := fs.AddConfig(ctx)
+	ci.DryRun = true
+	Log(ctx, &Record{Action: Skipped, Object: "same", Reason: "unchanged"})
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, 3, len(records))
+	assert.Equal(t, testRecord, records[0])
+	assert.Equal(t, "gone", records[1].Object)
+	assert.Equal(t, "bisync delete1", records[1].Reason)
+	assert.Equal(t, "failed", records[1].Error)
+	assert.False(t, records[1].Time.IsZero())
+	assert.Equal(t, "unchanged", records[2].Reason)
+	assert.True(t, records[2].DryRun)
+}
+
+func TestCSV(t *testing.T) {
+	ctx := context.Background()
+	file := start(t, "csv")
+	r := testRecord
+	r.Reason = "has, a comma"
+	Log(ctx, &r)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, `time,action,object,src,dst,size,hash,duration,reason,error,dryRun
+2022-11-12T13:14:15Z,copied,dir/file.txt,/tmp/src/dir/file.txt,remote:dst/dir/file.txt,1234,md5:f3a6ab5ce0fb2b3a9d1e6e7e9f8c1d2b,1.500,"has, a comma",,false
+`, string(data))
+}
+
+func TestTemplate(t *testing.T) {
+	ctx := context.Background()
+	file := start(t, "%t %o %n %l %h %T %% [%r]")
+	r := testRecord
+	Log(ctx, &r)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "2022/11/12 13:14:15 copied dir/file.txt 1234 md5:f3a6ab5ce0fb2b3a9d1e6e7e9f8c1d2b 1.5s % []\n", string(data))
+}
+
+func TestParseTemplate(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+		err  string
+	}{
+		{in: "plain", want: "plain"},
+		{in: "%o:%s->%d", want: "copied:/tmp/src/dir/file.txt->remote:dst/dir/file.txt"},
+		{in: "100%%", want: "100%"},
+		{in: "%e%r", want: ""},
+		{in: "%", err: "ends with %"},
+		{in: "%z", err: "unknown escape %z"},
+	} {
+		tmpl, err := parseTemplate(test.in)
+		if test.err != "" {
+			require.Error(t, err, test.in)
+			assert.True(t, strings.Contains(err.Error(), test.err), err.Error())
+			continue
+		}
+		require.NoError(t, err, test.in)
+		r := testRecord
+		assert.Equal(t, test.want, tmpl.expand(&r), test.in)
+	}
+}
+
+func TestStartErrors(t *testing.T) {
+	oldOpt := Opt
+	defer func() { Opt = oldOpt }()
+	Opt.File = filepath.Join(t.TempDir(), "transfer.log")
+	Opt.Format = ""
+	_, err := Start(context.Background())
+	assert.Error(t, err)
+	Opt.Format = "%q"
+	_, err = Start(context.Background())
+	assert.Error(t, err)
+	Opt.Format = "json"
+	Opt.File = filepath.Join(t.TempDir(), "missing", "transfer.log")
+	_, err = Start(context.Background())
+	assert.Error(t, err)
+}
+
+func TestPath(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "file", Path(nil, "file"))
+	assert.Equal(t, "remote:path/file", Path(mockfs.NewFs(ctx, "remote", "path"), "file"))
+	assert.Equal(t, "remote:file", Path(mockfs.NewFs(ctx, "remote", ""), "file"))
+	assert.Equal(t, "remote:path", Path(mockfs.NewFs(ctx, "remote", "path"), ""))
+}
+
+func TestErrorString(t *testing.T) {
+	assert.Equal(t, "", ErrorString(nil))
+	assert.Equal(t, "potato", ErrorString(errors.New("potato")))
+}
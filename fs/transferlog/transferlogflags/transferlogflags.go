@@ -0,0 +1,14 @@
+// Package transferlogflags implements command line flags to set up the transfer log
+package transferlogflags
+
+import (
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/transferlog"
+	"github.com/spf13/pflag"
+)
+
+// AddFlags adds the transfer log flags to the flagSet
+func AddFlags(flagSet *pflag.FlagSet) {
+	flags.StringVarP(flagSet, &transferlog.Opt.File, "transfer-log", "", transferlog.Opt.File, "Append a record of each file changed or skipped to this file")
+	flags.StringVarP(flagSet, &transferlog.Opt.Format, "transfer-log-format", "", transferlog.Opt.Format, "Format of the --transfer-log: json, csv or a template like \"%t %o %n %l\"")
+}
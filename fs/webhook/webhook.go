@@ -0,0 +1,404 @@
+// Package webhook sends notifications about jobs to HTTP endpoints
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/rc/jobs"
+	fssync "github.com/rclone/rclone/fs/sync"
+)
+
+// Types of event which can be sent
+const (
+	EventStart     = "start"     // a job has started
+	EventFinish    = "finish"    // a job has finished, successfully or not
+	EventFailure   = "failure"   // a job has finished with an error
+	EventThreshold = "threshold" // a job was stopped by --max-transfer, --max-delete or --max-duration
+)
+
+var allEvents = []string{EventStart, EventFinish, EventFailure, EventThreshold}
+
+// Options contains options for the webhooks
+type Options struct {
+	URLs    []string    // URLs to POST the events to
+	Events  string      // comma separated list of events to send
+	Headers []string    // extra headers to send in the form "Key: Value"
+	Retries int         // number of times to try sending each event
+	Timeout fs.Duration // timeout for each attempt
+}
+
+// DefaultOpt is the default values used for Opt
+var DefaultOpt = Options{
+	Events:  EventFailure + "," + EventThreshold,
+	Retries: 3,
+	Timeout: fs.Duration(10 * time.Second),
+}
+
+// Opt is the options for the webhooks
+var Opt = DefaultOpt
+
+// Payload is the JSON body POSTed to the webhook URLs
+type Payload struct {
+	Event     string    `json:"event"`               // the type of event, e.g. "failure"
+	Time      time.Time `json:"time"`                // when the event happened
+	Hostname  string    `json:"hostname,omitempty"`  // the host rclone is running on
+	JobID     int64     `json:"jobid,omitempty"`     // the rc job ID, 0 for command line runs
+	Command   string    `json:"command,omitempty"`   // the rc call or the command line command
+	Group     string    `json:"group,omitempty"`     // the stats group of the job
+	Success   bool      `json:"success"`             // whether the job succeeded - false until finished
+	Error     string    `json:"error,omitempty"`     // the error the job returned if any
+	Threshold string    `json:"threshold,omitempty"` // the threshold flag reached if any, e.g. "max-transfer"
+	Duration  float64   `json:"duration,omitempty"`  // time in seconds the job ran for
+	Stats     rc.Params `json:"stats,omitempty"`     // the stats of the job, as returned by core/stats
+}
+
+var (
+	pending    waitGroup     // tracks the events being sent in the background
+	retrySleep = time.Second // initial time to sleep between retries
+)
+
+// enabled returns true if event should be sent
+func (opt *Options) enabled(event string) bool {
+	if len(opt.URLs) == 0 {
+		return false
+	}
+	for _, name := range strings.Split(opt.Events, ",") {
+		if strings.TrimSpace(name) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// check the options are valid
+func (opt *Options) check() error {
+	for _, name := range strings.Split(opt.Events, ",") {
+		name = strings.TrimSpace(name)
+		found := name == ""
+		for _, event := range allEvents {
+			if name == event {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown webhook event %q - must be one of %s", name, strings.Join(allEvents, ","))
+		}
+	}
+	for _, header := range opt.Headers {
+		if !strings.Contains(header, ":") {
+			return fmt.Errorf("webhook header %q must be in the form \"Key: Value\"", header)
+		}
+	}
+	if opt.Retries < 1 {
+		return errors.New("webhook retries must be at least 1")
+	}
+	return nil
+}
+
+// Threshold returns the name of the threshold flag that caused err or
+// "" if err wasn't caused by reaching a threshold.
+func Threshold(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, accounting.ErrorMaxTransferLimitReached):
+		return "max-transfer"
+	case errors.Is(err, operations.ErrorMaxDeletesReached):
+		return "max-delete"
+	case errors.Is(err, fssync.ErrorMaxDurationReached):
+		return "max-duration"
+	}
+	return ""
+}
+
+// payloads makes the payloads to send for a job which has finished
+// with err, returning only the events which are enabled.
+func (opt *Options) payloads(base Payload, err error) (out []Payload) {
+	base.Success = err == nil
+	if err != nil {
+		base.Error = err.Error()
+	}
+	base.Threshold = Threshold(err)
+	add := func(event string) {
+		if opt.enabled(event) {
+			p := base
+			p.Event = event
+			out = append(out, p)
+		}
+	}
+	add(EventFinish)
+	if err != nil {
+		add(EventFailure)
+	}
+	if base.Threshold != "" {
+		add(EventThreshold)
+	}
+	return out
+}
+
+// Start checks the options and, if any webhook URLs are configured,
+// starts sending notifications for rc jobs.
+//
+// It returns a function to stop sending notifications for rc jobs.
+func Start(ctx context.Context) (stop func(), err error) {
+	if len(Opt.URLs) == 0 {
+		return func() {}, nil
+	}
+	if err := Opt.check(); err != nil {
+		return nil, err
+	}
+	opt := Opt
+	stop = jobs.Observe(func(ev jobs.Event) {
+		base := Payload{
+			Time:     ev.Time,
+			JobID:    ev.ID,
+			Command:  ev.Call,
+			Group:    ev.Group,
+			Duration: ev.Duration,
+		}
+		var toSend []Payload
+		switch ev.Type {
+		case jobs.EventStarted:
+			if opt.enabled(EventStart) {
+				base.Event = EventStart
+				toSend = append(toSend, base)
+			}
+		case jobs.EventFinished:
+			toSend = opt.payloads(base, ev.Err)
+		}
+		if len(toSend) == 0 {
+			return
+		}
+		// Read the stats now as the observer is called synchronously
+		if ev.Type == jobs.EventFinished && ev.Group != "" {
+			stats, err := accounting.StatsGroup(ctx, ev.Group).RemoteStats()
+			if err == nil {
+				for i := range toSend {
+					toSend[i].Stats = stats
+				}
+			}
+		}
+		for _, p := range toSend {
+			opt.sendBackground(ctx, p)
+		}
+	})
+	return stop, nil
+}
+
+// CommandStarted sends a start event for command run from the
+// command line.
+//
+// The event is sent in the background.
+func CommandStarted(ctx context.Context, command string) {
+	if !Opt.enabled(EventStart) {
+		return
+	}
+	if err := Opt.check(); err != nil {
+		fs.Errorf(nil, "webhook: %v", err)
+		return
+	}
+	Opt.sendBackground(ctx, Payload{
+		Event:   EventStart,
+		Time:    time.Now(),
+		Command: command,
+	})
+}
+
+// CommandFinished sends the finish, failure and threshold events for
+// the command run from the command line which started at startTime
+// and returned err.
+//
+// It waits for all the events, including any sent in the background,
+// to be delivered or to fail before returning, but for no longer than
+// it takes to send one event with all its retries.
+func CommandFinished(ctx context.Context, command string, startTime time.Time, err error) {
+	ctx, cancel := context.WithTimeout(ctx, Opt.maxSendTime())
+	defer cancel()
+	defer wait(ctx)
+	if len(Opt.URLs) == 0 {
+		return
+	}
+	if checkErr := Opt.check(); checkErr != nil {
+		fs.Errorf(nil, "webhook: %v", checkErr)
+		return
+	}
+	now := time.Now()
+	toSend := Opt.payloads(Payload{
+		Time:     now,
+		Command:  command,
+		Duration: now.Sub(startTime).Seconds(),
+	}, err)
+	if len(toSend) == 0 {
+		return
+	}
+	stats, statsErr := accounting.GlobalStats().RemoteStats()
+	for _, p := range toSend {
+		if statsErr == nil {
+			p.Stats = stats
+		}
+		Opt.sendBackground(ctx, p)
+	}
+}
+
+// maxSendTime returns the longest it should take to send an event
+// including all the retries and the sleeps between them.
+func (opt *Options) maxSendTime() time.Duration {
+	timeout := time.Duration(opt.Timeout)
+	if timeout <= 0 {
+		timeout = time.Duration(DefaultOpt.Timeout)
+	}
+	total := timeout
+	sleep := retrySleep
+	for try := 1; try < opt.Retries; try++ {
+		total += sleep + timeout
+		sleep *= 2
+	}
+	return total
+}
+
+// waitGroup is like sync.WaitGroup but can be waited on with a context
+type waitGroup struct {
+	mu   sync.Mutex
+	n    int
+	done chan struct{} // closed when n drops to 0
+}
+
+// Add one to the count
+func (wg *waitGroup) Add() {
+	wg.mu.Lock()
+	defer wg.mu.Unlock()
+	if wg.n == 0 {
+		wg.done = make(chan struct{})
+	}
+	wg.n++
+}
+
+// Done takes one from the count
+func (wg *waitGroup) Done() {
+	wg.mu.Lock()
+	defer wg.mu.Unlock()
+	wg.n--
+	if wg.n == 0 {
+		close(wg.done)
+	}
+}
+
+// Wait for the count to drop to 0 or for ctx to be done returning
+// the number still outstanding.
+func (wg *waitGroup) Wait(ctx context.Context) int {
+	wg.mu.Lock()
+	n, done := wg.n, wg.done
+	wg.mu.Unlock()
+	if n == 0 {
+		return 0
+	}
+	select {
+	case <-done:
+		return 0
+	case <-ctx.Done():
+		wg.mu.Lock()
+		defer wg.mu.Unlock()
+		return wg.n
+	}
+}
+
+// wait for the events being sent in the background until they are
+// done or ctx is done, whichever comes first.
+func wait(ctx context.Context) {
+	if n := pending.Wait(ctx); n > 0 {
+		fs.Errorf(nil, "webhook: gave up waiting for %d events to be sent: %v", n, ctx.Err())
+	}
+}
+
+// sendBackground sends p to all the URLs in the background
+func (opt *Options) sendBackground(ctx context.Context, p Payload) {
+	p.Hostname, _ = os.Hostname()
+	body, err := json.Marshal(p)
+	if err != nil {
+		fs.Errorf(nil, "webhook: failed to encode %s event: %v", p.Event, err)
+		return
+	}
+	for _, url := range opt.URLs {
+		url := url
+		pending.Add()
+		go func() {
+			defer pending.Done()
+			err := opt.send(ctx, url, body)
+			if err != nil {
+				fs.Errorf(nil, "webhook: failed to send %s event to %q: %v", p.Event, url, err)
+			} else {
+				fs.Debugf(nil, "webhook: sent %s event to %q", p.Event, url)
+			}
+		}()
+	}
+}
+
+// send body to url retrying with exponential backoff if necessary
+func (opt *Options) send(ctx context.Context, url string, body []byte) (err error) {
+	client := fshttp.NewClient(ctx)
+	sleep := retrySleep
+	for try := 1; try <= opt.Retries; try++ {
+		var retry bool
+		retry, err = opt.sendOnce(ctx, client, url, body)
+		if err == nil || !retry {
+			return err
+		}
+		if try < opt.Retries {
+			fs.Debugf(nil, "webhook: attempt %d/%d to %q failed: %v", try, opt.Retries, url, err)
+			select {
+			case <-time.After(sleep):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			sleep *= 2
+		}
+	}
+	return err
+}
+
+// sendOnce POSTs body to url, returning whether it is worth retrying
+// if there was an error.
+func (opt *Options) sendOnce(ctx context.Context, client *http.Client, url string, body []byte) (retry bool, err error) {
+	if opt.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(opt.Timeout))
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fs.GetConfig(ctx).UserAgent)
+	for _, header := range opt.Headers {
+		kv := strings.SplitN(header, ":", 2)
+		req.Header.Set(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
+	}
+	return false, nil
+}
@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/rc/jobs"
+)
+
+// receiver is a webhook endpoint for testing
+type receiver struct {
+	mu       sync.Mutex
+	payloads []Payload
+	headers  []http.Header
+	fail     int           // fail this many requests with a 500 error first
+	hang     chan struct{} // if set, block requests until this is closed
+}
+
+func (rx *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rx.hang != nil {
+		select {
+		case <-rx.hang:
+		case <-r.Context().Done():
+		}
+	}
+	rx.mu.Lock()
+	defer rx.mu.Unlock()
+	if rx.fail > 0 {
+		rx.fail--
+		http.Error(w, "try again", http.StatusInternalServerError)
+		return
+	}
+	var p Payload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rx.payloads = append(rx.payloads, p)
+	rx.headers = append(rx.headers, r.Header)
+}
+
+// events returns the events received so far
+func (rx *receiver) events() (events []string) {
+	rx.mu.Lock()
+	defer rx.mu.Unlock()
+	for _, p := range rx.payloads {
+		events = append(events, p.Event)
+	}
+	return events
+}
+
+// setup a receiver and the options to send to it
+func setup(t *testing.T, events string) *receiver {
+	rx := &receiver{}
+	server := httptest.NewServer(rx)
+	oldOpt, oldSleep := Opt, retrySleep
+	Opt = DefaultOpt
+	Opt.URLs = []string{server.URL}
+	Opt.Events = events
+	retrySleep = time.Millisecond
+	t.Cleanup(func() {
+		server.Close()
+		Opt, retrySleep = oldOpt, oldSleep
+	})
+	return rx
+}
+
+func TestThreshold(t *testing.T) {
+	assert.Equal(t, "", Threshold(nil))
+	assert.Equal(t, "", Threshold(errors.New("potato")))
+	assert.Equal(t, "max-transfer", Threshold(accounting.ErrorMaxTransferLimitReachedFatal))
+	assert.Equal(t, "max-delete", Threshold(fserrors.FatalError(operations.ErrorMaxDeletesReached)))
+}
+
+func TestCheck(t *testing.T) {
+	opt := DefaultOpt
+	assert.NoError(t, opt.check())
+	opt.Events = "start, finish"
+	assert.NoError(t, opt.check())
+	opt.Events = "potato"
+	assert.Error(t, opt.check())
+	opt = DefaultOpt
+	opt.Headers = []string{"NoColon"}
+	assert.Error(t, opt.check())
+	opt = DefaultOpt
+	opt.Retries = 0
+	assert.Error(t, opt.check())
+}
+
+func TestCommand(t *testing.T) {
+	ctx := context.Background()
+	rx := setup(t, "start,finish,failure,threshold")
+	Opt.Headers = []string{"X-Test: potato"}
+
+	CommandStarted(ctx, "rclone sync")
+	CommandFinished(ctx, "rclone sync", time.Now().Add(-time.Second), fserrors.FatalError(operations.ErrorMaxDeletesReached))
+	assert.ElementsMatch(t, []string{"start", "finish", "failure", "threshold"}, rx.events())
+	for i, p := range rx.payloads {
+		assert.Equal(t, "rclone sync", p.Command)
+		assert.Equal(t, int64(0), p.JobID)
+		assert.Equal(t, "potato", rx.headers[i].Get("X-Test"))
+		assert.Equal(t, "application/json", rx.headers[i].Get("Content-Type"))
+		if p.Event == EventStart {
+			continue
+		}
+		assert.False(t, p.Success)
+		assert.Equal(t, "--max-delete threshold reached", p.Error)
+		assert.Equal(t, "max-delete", p.Threshold)
+		assert.True(t, p.Duration >= 1)
+		assert.NotNil(t, p.Stats)
+	}
+
+	// Only the enabled events are sent
+	rx = setup(t, "failure")
+	CommandStarted(ctx, "rclone copy")
+	CommandFinished(ctx, "rclone copy", time.Now(), nil)
+	assert.Equal(t, []string(nil), rx.events())
+	CommandFinished(ctx, "rclone copy", time.Now(), errors.New("potato"))
+	assert.Equal(t, []string{"failure"}, rx.events())
+	assert.Equal(t, "", rx.payloads[0].Threshold)
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+	rx := setup(t, "finish")
+
+	// Succeeds on the last try
+	rx.fail = 2
+	CommandFinished(ctx, "rclone copy", time.Now(), nil)
+	assert.Equal(t, []string{"finish"}, rx.events())
+	assert.True(t, rx.payloads[0].Success)
+
+	// Runs out of retries
+	rx.fail = 3
+	CommandFinished(ctx, "rclone copy", time.Now(), nil)
+	assert.Equal(t, []string{"finish"}, rx.events())
+	assert.Equal(t, 0, rx.fail)
+}
+
+func TestHang(t *testing.T) {
+	ctx := context.Background()
+	rx := setup(t, "finish")
+	rx.hang = make(chan struct{})
+	defer close(rx.hang)
+	Opt.Timeout = fs.Duration(10 * time.Millisecond)
+	Opt.Retries = 2
+
+	start := time.Now()
+	CommandFinished(ctx, "rclone copy", time.Now(), nil)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, []string(nil), rx.events())
+	pending.Wait(ctx) // let the abandoned sends finish
+
+	// Bounded by the context too
+	Opt.Timeout = 0
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	CommandFinished(ctx, "rclone copy", time.Now(), nil)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, []string(nil), rx.events())
+	pending.Wait(context.Background())
+}
+
+func TestJobs(t *testing.T) {
+	ctx := context.Background()
+	rx := setup(t, "start,finish,failure")
+	stop, err := Start(ctx)
+	require.NoError(t, err)
+	defer stop()
+
+	fn := func(ctx context.Context, in rc.Params) (rc.Params, error) {
+		return nil, errors.New("job failed")
+	}
+	job, _, err := jobs.NewJob(jobs.WithCall(ctx, "test/fail"), fn, rc.Params{})
+	require.Error(t, err)
+	pending.Wait(ctx)
+
+	assert.ElementsMatch(t, []string{"start", "finish", "failure"}, rx.events())
+	for _, p := range rx.payloads {
+		assert.Equal(t, job.ID, p.JobID)
+		assert.Equal(t, "test/fail", p.Command)
+		assert.Equal(t, job.Group, p.Group)
+		if p.Event != EventStart {
+			assert.Equal(t, "job failed", p.Error)
+			assert.NotNil(t, p.Stats)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Package webhookflags implements command line flags to set up webhooks
+package webhookflags
+
+import (
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/fs/webhook"
+	"github.com/spf13/pflag"
+)
+
+// AddFlags adds the webhook flags to the flagSet
+func AddFlags(flagSet *pflag.FlagSet) {
+	rc.AddOption("webhook", &webhook.Opt)
+
+	flags.StringArrayVarP(flagSet, &webhook.Opt.URLs, "webhook-url", "", webhook.Opt.URLs, "URL to POST job notifications to (can be repeated)")
+	flags.StringVarP(flagSet, &webhook.Opt.Events, "webhook-events", "", webhook.Opt.Events, "Comma separated list of events to notify: start,finish,failure,threshold")
+	flags.StringArrayVarP(flagSet, &webhook.Opt.Headers, "webhook-header", "", webhook.Opt.Headers, "Set HTTP header for webhook requests in the form \"Key: Value\" (can be repeated)")
+	flags.IntVarP(flagSet, &webhook.Opt.Retries, "webhook-retries", "", webhook.Opt.Retries, "Number of times to try sending each webhook")
+	flags.FVarP(flagSet, &webhook.Opt.Timeout, "webhook-timeout", "", "Timeout for each webhook request")
+}
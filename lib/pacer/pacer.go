@@ -2,10 +2,14 @@
 package pacer
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	liberrors "github.com/rclone/rclone/lib/errors"
+	"github.com/rclone/rclone/lib/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // State represents the public Pacer state that will be passed to the
@@ -37,6 +41,9 @@ type pacerOptions struct {
 	retries        int         // Max number of retries
 	calculator     Calculator  // switchable pacing algorithm - call with mu held
 	invoker        InvokerFunc // wrapper function used to invoke the target function
+	metrics        *Metrics    // metrics to record calls in, may be nil
+	remote         string      // name of the remote for metrics
+	backend        string      // type of the backend for metrics
 }
 
 // InvokerFunc is the signature of the wrapper function used to invoke the
@@ -66,6 +73,19 @@ func InvokerOption(invoker InvokerFunc) Option {
 	return func(p *pacerOptions) { p.invoker = invoker }
 }
 
+// MetricsOption sets the Metrics for the new Pacer.
+//
+// If not set DefaultMetrics is used.
+func MetricsOption(m *Metrics) Option {
+	return func(p *pacerOptions) { p.metrics = m }
+}
+
+// RemoteOption sets the name of the remote and the type of the
+// backend the new Pacer is for, to label its metrics.
+func RemoteOption(remote, backend string) Option {
+	return func(p *pacerOptions) { p.remote, p.backend = remote, backend }
+}
+
 // Paced is a function which is called by the Call and CallNoRetry
 // methods.  It should return a boolean, true if it would like to be
 // retried, and an error.  This error may be returned or returned
@@ -77,6 +97,7 @@ func New(options ...Option) *Pacer {
 	opts := pacerOptions{
 		maxConnections: 10,
 		retries:        3,
+		metrics:        DefaultMetrics,
 	}
 	for _, o := range options {
 		o(&opts)
@@ -190,15 +211,50 @@ func (p *Pacer) endCall(retry bool, err error) {
 }
 
 // call implements Call but with settable retries
-func (p *Pacer) call(fn Paced, retries int) (err error) {
-	var retry bool
-	for i := 1; i <= retries; i++ {
+//
+// If ctx has a span which is being recorded then a span is recorded
+// for the call. Otherwise a span is only recorded if the call needs
+// to be retried, so retries can be seen in traces.
+func (p *Pacer) call(ctx context.Context, fn Paced, retries int) (err error) {
+	var (
+		retry   bool
+		span    trace.Span
+		start   = time.Now()
+		waited  time.Duration
+		tries   int
+		retried int
+	)
+	startSpan := func() {
+		_, span = tracing.StartSpanWith(ctx, "pacer.Call", trace.WithTimestamp(start))
+	}
+	if trace.SpanFromContext(ctx).IsRecording() {
+		startSpan()
+	}
+	for tries = 1; tries <= retries; tries++ {
+		beginWait := time.Now()
 		p.beginCall()
-		retry, err = p.invoker(i, retries, fn)
+		waited += time.Since(beginWait)
+		retry, err = p.invoker(tries, retries, fn)
 		p.endCall(retry, err)
 		if !retry {
 			break
 		}
+		retried++
+		if span == nil {
+			startSpan()
+		}
+		tracing.Retry(span, tries, retries, err)
+	}
+	p.metrics.onCall(p, retried, waited)
+	if span != nil {
+		if tries > retries {
+			tries = retries
+		}
+		span.SetAttributes(
+			tracing.KeyTries.Int(tries),
+			attribute.Int64("rclone.pacer_wait_ms", waited.Milliseconds()),
+		)
+		tracing.End(span, err)
 	}
 	return err
 }
@@ -210,10 +266,16 @@ func (p *Pacer) call(fn Paced, retries int) (err error) {
 // error. This error may be returned wrapped in a RetryError if the
 // number of retries is exceeded.
 func (p *Pacer) Call(fn Paced) (err error) {
+	return p.CallContext(context.Background(), fn)
+}
+
+// CallContext is like Call but records trace spans as children of
+// any span in ctx.
+func (p *Pacer) CallContext(ctx context.Context, fn Paced) (err error) {
 	p.mu.Lock()
 	retries := p.retries
 	p.mu.Unlock()
-	return p.call(fn, retries)
+	return p.call(ctx, fn, retries)
 }
 
 // CallNoRetry paces the remote operations to not exceed the limits
@@ -222,7 +284,13 @@ func (p *Pacer) Call(fn Paced) (err error) {
 // This calls fn and wraps the output in a RetryError if it would like
 // it to be retried
 func (p *Pacer) CallNoRetry(fn Paced) error {
-	return p.call(fn, 1)
+	return p.call(context.Background(), fn, 1)
+}
+
+// CallNoRetryContext is like CallNoRetry but records trace spans as
+// children of any span in ctx.
+func (p *Pacer) CallNoRetryContext(ctx context.Context, fn Paced) error {
+	return p.call(ctx, fn, 1)
 }
 
 func invoke(try, tries int, f Paced) (bool, error) {
@@ -1,12 +1,18 @@
 package pacer
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestNew(t *testing.T) {
@@ -325,7 +331,7 @@ func TestCallFixed(t *testing.T) {
 	p := New(CalculatorOption(NewDefault(MinSleep(1*time.Millisecond), MaxSleep(2*time.Millisecond))))
 
 	dp := &dummyPaced{retry: false}
-	err := p.call(dp.fn, 10)
+	err := p.call(context.Background(), dp.fn, 10)
 	assert.Equal(t, 1, dp.called)
 	assert.Equal(t, errFoo, err)
 }
@@ -334,11 +340,68 @@ func Test_callRetry(t *testing.T) {
 	p := New(CalculatorOption(NewDefault(MinSleep(1*time.Millisecond), MaxSleep(2*time.Millisecond))))
 
 	dp := &dummyPaced{retry: true}
-	err := p.call(dp.fn, 10)
+	err := p.call(context.Background(), dp.fn, 10)
 	assert.Equal(t, 10, dp.called)
 	assert.Equal(t, errFoo, err)
 }
 
+func TestCallTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	oldProvider := otel.GetTracerProvider()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(oldProvider)
+	})
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	p := New(RetriesOption(5), CalculatorOption(NewDefault(MinSleep(1*time.Millisecond), MaxSleep(2*time.Millisecond))))
+
+	// No span if the call doesn't retry
+	require.NoError(t, p.Call(func() (bool, error) { return false, nil }))
+	assert.Equal(t, 0, len(recorder.Ended()))
+
+	// A span with an event for each retry if it does
+	tries := 0
+	require.NoError(t, p.Call(func() (bool, error) {
+		tries++
+		if tries < 3 {
+			return true, errFoo
+		}
+		return false, nil
+	}))
+	spans := recorder.Ended()
+	require.Equal(t, 1, len(spans))
+	assert.Equal(t, "pacer.Call", spans[0].Name())
+	assert.Equal(t, 2, len(spans[0].Events()))
+	assert.Equal(t, "retry", spans[0].Events()[0].Name)
+
+	// Always a span if there is a parent span being recorded
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	require.NoError(t, p.CallContext(ctx, func() (bool, error) { return false, nil }))
+	parent.End()
+	spans = recorder.Ended()
+	require.Equal(t, 3, len(spans))
+	assert.Equal(t, "pacer.Call", spans[1].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[1].Parent().SpanID())
+}
+
+func TestCallMetrics(t *testing.T) {
+	m := NewMetrics("test")
+	p := New(RetriesOption(5), MetricsOption(m), RemoteOption("remote", "s3"), CalculatorOption(NewDefault(MinSleep(1*time.Millisecond), MaxSleep(2*time.Millisecond))))
+
+	require.NoError(t, p.Call(func() (bool, error) { return false, nil }))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.Retries.WithLabelValues("remote", "s3")))
+
+	tries := 0
+	require.NoError(t, p.Call(func() (bool, error) {
+		tries++
+		if tries < 3 {
+			return true, errFoo
+		}
+		return false, nil
+	}))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.Retries.WithLabelValues("remote", "s3")))
+	assert.Greater(t, testutil.ToFloat64(m.Wait.WithLabelValues("remote", "s3")), 0.0)
+}
+
 func TestCall(t *testing.T) {
 	p := New(RetriesOption(20), CalculatorOption(NewDefault(MinSleep(1*time.Millisecond), MaxSleep(2*time.Millisecond))))
 
@@ -0,0 +1,56 @@
+package pacer
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics provide Pacer level metrics.
+type Metrics struct {
+	Retries *prometheus.CounterVec
+	Wait    *prometheus.CounterVec
+}
+
+// NewMetrics creates a new metrics instance, the instance shall be assigned to
+// DefaultMetrics before any Pacers are created.
+func NewMetrics(namespace string) *Metrics {
+	return &Metrics{
+		Retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "pacer",
+			Name:      "retries_total",
+			Help:      "Number of calls which asked to be retried, usually because of rate limiting",
+		}, []string{"remote", "backend"}),
+		Wait: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "pacer",
+			Name:      "wait_seconds_total",
+			Help:      "Total time calls spent waiting for the pacer before being made",
+		}, []string{"remote", "backend"}),
+	}
+}
+
+// DefaultMetrics specifies metrics used for new Pacers.
+var DefaultMetrics = (*Metrics)(nil)
+
+// Collectors returns all prometheus metrics as collectors for registration.
+func (m *Metrics) Collectors() []prometheus.Collector {
+	if m == nil {
+		return nil
+	}
+	return []prometheus.Collector{
+		m.Retries,
+		m.Wait,
+	}
+}
+
+func (m *Metrics) onCall(p *Pacer, retries int, waited time.Duration) {
+	if m == nil {
+		return
+	}
+	if retries > 0 {
+		m.Retries.WithLabelValues(p.remote, p.backend).Add(float64(retries))
+	}
+	m.Wait.WithLabelValues(p.remote, p.backend).Add(waited.Seconds())
+}
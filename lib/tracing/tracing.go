@@ -0,0 +1,64 @@
+// Package tracing contains helpers for recording OpenTelemetry spans
+// which don't depend on the rest of rclone so they can be used from
+// low level packages like lib/pacer.
+//
+// Use fs/tracing to start exporting the spans.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer used for rclone's spans
+const TracerName = "github.com/rclone/rclone"
+
+// Attribute keys used on rclone's spans
+const (
+	KeyRemote  = attribute.Key("rclone.remote")  // the remote being operated on
+	KeySrc     = attribute.Key("rclone.src")     // the source remote
+	KeyDst     = attribute.Key("rclone.dst")     // the destination remote
+	KeyObject  = attribute.Key("rclone.object")  // the path of the object within the remote
+	KeyDir     = attribute.Key("rclone.dir")     // the path of the directory within the remote
+	KeySize    = attribute.Key("rclone.size")    // the size of the object
+	KeyEntries = attribute.Key("rclone.entries") // the number of directory entries
+	KeyAction  = attribute.Key("rclone.action")  // what was done, e.g. "Copied (new)"
+	KeyTry     = attribute.Key("rclone.try")     // the number of the attempt which failed
+	KeyTries   = attribute.Key("rclone.tries")   // the number of attempts allowed or made
+)
+
+// StartSpan starts a span called name as a child of any span in ctx.
+//
+// If tracing isn't enabled this returns a span which does nothing.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return StartSpanWith(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartSpanWith is like StartSpan but takes span options, for
+// example to set the start time.
+func StartSpanWith(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, options...)
+}
+
+// End ends the span, marking it as failed if err is set
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Retry records on span that attempt try out of tries failed with err
+// and will be retried.
+func Retry(span trace.Span, try, tries int, err error) {
+	attrs := []attribute.KeyValue{KeyTry.Int(try), KeyTries.Int(tries)}
+	if err != nil {
+		attrs = append(attrs, attribute.String("error", err.Error()))
+	}
+	span.AddEvent("retry", trace.WithAttributes(attrs...))
+}
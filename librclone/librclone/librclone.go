@@ -114,7 +114,7 @@ func RPC(method string, input string) (output string, status int) {
 
 	fs.Debugf(nil, "rc: %q: with parameters %+v", method, in)
 
-	_, out, err := jobs.NewJob(context.Background(), call.Fn, in)
+	_, out, err := jobs.NewJob(jobs.WithCall(context.Background(), method), call.Fn, in)
 	if err != nil {
 		return writeError(method, in, err, http.StatusInternalServerError)
 	}
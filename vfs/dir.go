@@ -175,13 +175,23 @@ func (d *Dir) Node() Node {
 // It returns true if the directory or any of its children had virtual entries
 // so could not be forgotten. Children which didn't have virtual entries and
 // children with virtual entries will be forgotten even if true is returned.
+//
+// The directory and its children are removed from the persistent
+// directory cache too.
 func (d *Dir) ForgetAll() (hasVirtual bool) {
+	d.vfs.dirStore.forgetTree(d.Path())
+	return d.forgetAll()
+}
+
+// forgetAll does the work for ForgetAll without touching the
+// persistent directory cache
+func (d *Dir) forgetAll() (hasVirtual bool) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	fs.Debugf(d.path, "forgetting directory cache")
 	for _, node := range d.items {
 		if dir, ok := node.(*Dir); ok {
-			if dir.ForgetAll() {
+			if dir.forgetAll() {
 				hasVirtual = true
 			}
 		}
@@ -210,6 +220,11 @@ func (d *Dir) ForgetAll() (hasVirtual bool) {
 func (d *Dir) forgetDirPath(relativePath string) {
 	dir := d.cachedDir(relativePath)
 	if dir == nil {
+		// It may still be in the persistent directory cache
+		d.mu.RLock()
+		absPath := path.Join(d.path, relativePath)
+		d.mu.RUnlock()
+		d.vfs.dirStore.forgetTree(absPath)
 		return
 	}
 	dir.ForgetAll()
@@ -217,6 +232,7 @@ func (d *Dir) forgetDirPath(relativePath string) {
 
 // invalidateDir invalidates the directory cache for absPath relative to the root
 func (d *Dir) invalidateDir(absPath string) {
+	d.vfs.dirStore.forget(absPath)
 	node := d.vfs.root.cachedNode(absPath)
 	if dir, ok := node.(*Dir); ok {
 		dir.mu.Lock()
@@ -354,6 +370,7 @@ func (d *Dir) rename(newParent *Dir, fsDir fs.Directory) {
 
 	// Rename any remaining items in the tree that we couldn't forget
 	d.renameTree(d.path)
+	d.vfs.dirStore.forgetTree(newPath)
 
 	// Rename in the cache
 	if d.vfs.cache != nil && d.vfs.cache.DirExists(oldPath) {
@@ -382,7 +399,9 @@ func (d *Dir) addObject(node Node) {
 	}
 	d.virtual[leaf] = vAdd
 	fs.Debugf(d.path, "Added virtual directory entry %v: %q", vAdd, leaf)
+	dPath := d.path
 	d.mu.Unlock()
+	d.vfs.dirStore.forget(dPath)
 }
 
 // AddVirtual adds a virtual object of name and size to the directory
@@ -426,7 +445,10 @@ func (d *Dir) delObject(leaf string) {
 	}
 	d.virtual[leaf] = vDel
 	fs.Debugf(d.path, "Added virtual directory entry %v: %q", vDel, leaf)
+	dPath := d.path
 	d.mu.Unlock()
+	d.vfs.dirStore.forget(dPath)
+	d.vfs.dirStore.forgetTree(path.Join(dPath, leaf))
 }
 
 // DelVirtual removes an object from the directory listing
@@ -450,6 +472,15 @@ func (d *Dir) _readDir() error {
 	} else {
 		return nil
 	}
+	if entries, read, ok := d.vfs.dirStore.get(d.path, when); ok {
+		fs.Debugf(d.path, "Reading directory from persistent directory cache")
+		err := d._readDirFromEntries(entries, nil, time.Time{})
+		if err != nil {
+			return err
+		}
+		d.read = read
+		return nil
+	}
 	entries, err := list.DirSorted(context.TODO(), d.f, false, d.path)
 	if err == fs.ErrorDirNotFound {
 		// We treat directory not found as empty because we
@@ -464,6 +495,7 @@ func (d *Dir) _readDir() error {
 	}
 
 	d.read = when
+	d.vfs.dirStore.put(d.path, entries, when)
 	return nil
 }
 
@@ -527,7 +559,7 @@ func (d *Dir) _purgeVirtual() {
 				// if writing in progress then leave virtual
 				continue
 			}
-			if d.vfs.Opt.CacheMode >= vfscommon.CacheModeMinimal && d.vfs.cache.InUse(f.Path()) {
+			if d.vfs.Opt.CacheMode >= vfscommon.CacheModeMinimal && d.vfs.cache.InUse(f.cachePath()) {
 				// if object in use or dirty then leave virtual
 				continue
 			}
@@ -610,13 +642,34 @@ func (mv manageVirtuals) end(d *Dir) {
 // update d.items and if dirTree is not nil update each dir in the DirTree below this one and
 // set the last read time - must be called with the lock held
 func (d *Dir) _readDirFromEntries(entries fs.DirEntries, dirTree dirtree.DirTree, when time.Time) error {
-	var err error
+	var (
+		err   error
+		links map[string]bool // names in this listing and whether they are symlinks
+	)
+	if d.vfs.Opt.Links {
+		links = make(map[string]bool, len(entries))
+	}
 	mv := d._newManageVirtuals()
 	for _, entry := range entries {
 		name := path.Base(entry.Remote())
-		if name == "." || name == ".." {
+		if name == "." || name == ".." || d.vfs.isTrash(entry.Remote()) {
 			continue
 		}
+		isLink := false
+		if _, ok := entry.(fs.Object); ok {
+			name, isLink = d.vfs.trimLinkSuffix(name)
+		}
+		if links != nil {
+			// If a symlink has the same name as a file or
+			// directory then show the file or directory
+			if wasLink, found := links[name]; found && isLink != wasLink {
+				fs.Logf(path.Join(d.path, name+fs.LinkSuffix), "Ignoring symlink as it has the same name as a file or directory")
+				if isLink {
+					continue
+				}
+			}
+			links[name] = isLink
+		}
 		node := d.items[name]
 		if mv.add(d, name) {
 			continue
@@ -625,10 +678,12 @@ func (d *Dir) _readDirFromEntries(entries fs.DirEntries, dirTree dirtree.DirTree
 		case fs.Object:
 			obj := item
 			// Reuse old file value if it exists
-			if file, ok := node.(*File); node != nil && ok {
+			if file, ok := node.(*File); node != nil && ok && file.isLink == isLink {
 				file.setObjectNoUpdate(obj)
 			} else {
-				node = newFile(d, d.path, obj, name)
+				file := newFile(d, d.path, obj, name)
+				file.isLink = isLink
+				node = file
 			}
 		case fs.Directory:
 			// Reuse old dir value if it exists
@@ -680,6 +735,7 @@ func (d *Dir) readDirTree() error {
 	}
 	fs.Debugf(d.path, "Reading directory tree done in %s", time.Since(when))
 	d.read = when
+	d.vfs.dirStore.putTree(dt, when)
 	return nil
 }
 
@@ -688,6 +744,7 @@ func (d *Dir) readDir() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	d.read = time.Time{}
+	d.vfs.dirStore.forget(d.path)
 	return d._readDir()
 }
 
@@ -925,6 +982,7 @@ func (d *Dir) Remove() error {
 	if d.parent != nil {
 		d.parent.delObject(d.Name())
 	}
+	d.vfs.locks.remove(d.path)
 	return nil
 }
 
@@ -1038,6 +1096,9 @@ func (d *Dir) Rename(oldName, newName string, destDir *Dir) error {
 	d.delObject(oldName)
 	destDir.addObject(oldNode)
 
+	// Move any advisory locks with the file
+	d.vfs.locks.rename(oldPath, newPath)
+
 	// fs.Debugf(newPath, "Dir.Rename renamed from %q", oldPath)
 	// fs.Debugf(d, "AFTER\n%s", d.dump())
 	return nil
@@ -0,0 +1,678 @@
+package vfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/dirtree"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/kv"
+)
+
+// dirStoreFacility is the name of the kv database holding the
+// directory listings
+const dirStoreFacility = "vfsdir"
+
+// dirStore keeps the directory listings read by the VFS in a
+// key-value database so they survive a restart.
+//
+// A listing is only used while it is younger than --dir-cache-time
+// and it is removed whenever the directory is invalidated, so the
+// store never holds anything the in memory directory cache wouldn't.
+//
+// Changes are queued and written to the database in the background
+// so callers holding directory locks don't wait for the disk.
+//
+// All the methods may be called on a nil *dirStore and do nothing.
+type dirStore struct {
+	db     *kv.DB
+	f      fs.Fs
+	prefix string        // prefix of all the keys for this VFS
+	maxAge time.Duration // ignore listings older than this
+
+	mu      sync.Mutex
+	pending []dirChange   // changes not yet written, oldest first
+	writeMu sync.Mutex    // held while writing changes so they are written in order
+	kick    chan struct{} // kick the writer
+	done    chan struct{} // closed to stop the writer
+	wg      sync.WaitGroup
+}
+
+// dirChange is a change to the stored listings
+type dirChange struct {
+	key    string     // key of the record
+	record *dirRecord // record to write or nil to remove the record
+	tree   bool       // if removing, remove all the records starting with key
+	hash   *dirHash   // if set, add this hash to the record instead
+}
+
+// dirHash is a hash of an entry of a stored listing
+type dirHash struct {
+	name    string
+	size    int64
+	modTime time.Time
+	ty      string // name of the hash type
+	sum     string
+}
+
+// dirRecord is the stored listing of a directory
+type dirRecord struct {
+	Read    time.Time        `json:"read"`    // when the listing was read from the remote
+	Entries []dirRecordEntry `json:"entries"` // the entries in the directory
+}
+
+// dirRecordEntry is a stored directory entry
+type dirRecordEntry struct {
+	Name    string            `json:"name"`
+	IsDir   bool              `json:"isDir,omitempty"`
+	Size    int64             `json:"size"`
+	ModTime time.Time         `json:"modTime"`          // zero if not stored
+	Hashes  map[string]string `json:"hashes,omitempty"` // by hash type name
+}
+
+// sameFile returns true if the entries are the same file, as far as
+// the size and modification time can tell. It is false if the
+// modification time isn't known.
+func (entry *dirRecordEntry) sameFile(other *dirRecordEntry) bool {
+	return !entry.IsDir && !other.IsDir &&
+		entry.Name == other.Name &&
+		entry.Size == other.Size &&
+		!entry.ModTime.IsZero() && entry.ModTime.Equal(other.ModTime)
+}
+
+// withHashes returns a copy of record with the hashes of the files in
+// old which haven't changed since, or record itself if there are none.
+func (record *dirRecord) withHashes(old *dirRecord) *dirRecord {
+	oldEntries := make(map[string]*dirRecordEntry, len(old.Entries))
+	for i := range old.Entries {
+		if len(old.Entries[i].Hashes) > 0 {
+			oldEntries[old.Entries[i].Name] = &old.Entries[i]
+		}
+	}
+	if len(oldEntries) == 0 {
+		return record
+	}
+	out := *record
+	out.Entries = make([]dirRecordEntry, len(record.Entries))
+	for i, entry := range record.Entries {
+		if oldEntry := oldEntries[entry.Name]; oldEntry != nil && oldEntry.sameFile(&entry) {
+			hashes := make(map[string]string, len(oldEntry.Hashes)+len(entry.Hashes))
+			for ty, sum := range oldEntry.Hashes {
+				hashes[ty] = sum
+			}
+			for ty, sum := range entry.Hashes {
+				hashes[ty] = sum
+			}
+			entry.Hashes = hashes
+		}
+		out.Entries[i] = entry
+	}
+	return &out
+}
+
+// newDirStore opens the directory store for f, removing any listings
+// which are too old to be used.
+func newDirStore(ctx context.Context, f fs.Fs, maxAge time.Duration) (*dirStore, error) {
+	if !kv.Supported() {
+		return nil, kv.ErrUnsupported
+	}
+	db, err := kv.Start(ctx, dirStoreFacility, f)
+	if err != nil {
+		return nil, err
+	}
+	s := &dirStore{
+		db:     db,
+		f:      f,
+		prefix: fs.ConfigString(f) + "|",
+		maxAge: maxAge,
+		kick:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	op := &opPruneDirs{prefix: s.prefix, before: time.Now().Add(-maxAge)}
+	err = db.Do(true, op)
+	if err != nil {
+		_ = db.Stop(false)
+		return nil, err
+	}
+	fs.Debugf(f, "Opened directory store %q with %d directories, removed %d expired", db.Path(), op.kept, op.removed)
+	s.wg.Add(1)
+	go s.writer()
+	return s, nil
+}
+
+// stop writes any pending changes and closes the store
+func (s *dirStore) stop() {
+	if s == nil {
+		return
+	}
+	close(s.done)
+	s.wg.Wait()
+	if err := s.db.Stop(false); err != nil {
+		fs.Errorf(s.f, "Failed to close directory store: %v", err)
+	}
+}
+
+// key makes the database key for the directory dirPath
+func (s *dirStore) key(dirPath string) string {
+	return s.prefix + dirPath
+}
+
+// writer writes the pending changes in the background until stopped
+func (s *dirStore) writer() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.kick:
+			s.flush()
+		case <-s.done:
+			s.flush()
+			return
+		}
+	}
+}
+
+// queue adds changes to be written in the background
+func (s *dirStore) queue(changes ...dirChange) {
+	s.mu.Lock()
+	s.pending = append(s.pending, changes...)
+	s.mu.Unlock()
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+// flush writes the pending changes to the database
+func (s *dirStore) flush() {
+	if s == nil {
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	changes := s.pending
+	s.mu.Unlock()
+	if len(changes) == 0 {
+		return
+	}
+	if err := s.db.Do(true, &opWriteDirs{changes: changes}); err != nil {
+		fs.Errorf(s.f, "Failed to write directory store: %v", err)
+	}
+	// Remove the changes only once written so get can see them
+	// until then
+	s.mu.Lock()
+	s.pending = s.pending[len(changes):]
+	if len(s.pending) == 0 {
+		s.pending = nil
+	}
+	s.mu.Unlock()
+}
+
+// pendingRecord looks for key in the changes not yet written.
+//
+// It returns found false if there are none, otherwise record is the
+// record or nil if it was removed. The record must not be changed.
+func (s *dirStore) pendingRecord(key string) (record *dirRecord, found bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.pending) - 1; i >= 0; i-- {
+		change := s.pending[i]
+		if change.hash != nil {
+			continue
+		}
+		if change.key == key || (change.record == nil && change.tree && strings.HasPrefix(key, change.key)) {
+			return change.record, true
+		}
+	}
+	return nil, false
+}
+
+// get returns the stored listing for dirPath if it is still valid
+// at when.
+//
+// It returns ok false if there isn't a usable listing.
+func (s *dirStore) get(dirPath string, when time.Time) (entries fs.DirEntries, read time.Time, ok bool) {
+	if s == nil {
+		return nil, read, false
+	}
+	key := s.key(dirPath)
+	var record *dirRecord
+	if pending, found := s.pendingRecord(key); found {
+		if pending == nil {
+			return nil, read, false
+		}
+		record = pending
+	} else {
+		op := &opGetDir{key: key}
+		err := s.db.Do(false, op)
+		if err != nil && err != kv.ErrEmpty {
+			fs.Errorf(dirPath, "Failed to read directory store: %v", err)
+			return nil, read, false
+		}
+		record = op.record
+	}
+	if record == nil || when.Sub(record.Read) > s.maxAge {
+		return nil, read, false
+	}
+	entries = make(fs.DirEntries, 0, len(record.Entries))
+	for _, entry := range record.Entries {
+		remote := path.Join(dirPath, entry.Name)
+		if entry.IsDir {
+			entries = append(entries, fs.NewDir(remote, entry.ModTime))
+		} else {
+			entries = append(entries, &storedObject{
+				s:       s,
+				remote:  remote,
+				size:    entry.Size,
+				modTime: entry.ModTime,
+				hashes:  entry.Hashes,
+			})
+		}
+	}
+	return entries, record.Read, true
+}
+
+// put saves the listing of dirPath read at when
+func (s *dirStore) put(dirPath string, entries fs.DirEntries, when time.Time) {
+	if s == nil {
+		return
+	}
+	s.putTree(dirtree.DirTree{dirPath: entries}, when)
+}
+
+// putTree saves the listings of all the directories in dt read at when
+func (s *dirStore) putTree(dt dirtree.DirTree, when time.Time) {
+	if s == nil {
+		return
+	}
+	changes := make([]dirChange, 0, len(dt))
+	for dirPath, entries := range dt {
+		changes = append(changes, dirChange{key: s.key(dirPath), record: s.newRecord(entries, when)})
+	}
+	s.queue(changes...)
+}
+
+// putHash records the hash of obj, a file in dirPath, so it can be
+// used after a restart. It is only used while the file has the same
+// size and modification time.
+func (s *dirStore) putHash(dirPath string, obj fs.Object, ty hash.Type, sum string) {
+	if s == nil || sum == "" || s.f.Features().SlowModTime {
+		return
+	}
+	s.queue(dirChange{key: s.key(dirPath), hash: &dirHash{
+		name:    path.Base(obj.Remote()),
+		size:    obj.Size(),
+		modTime: obj.ModTime(context.TODO()),
+		ty:      ty.String(),
+		sum:     sum,
+	}})
+}
+
+// newRecord makes a record from the entries read at when
+//
+// Mod times are only stored if the remote can read them without
+// extra transactions, otherwise they are read from the remote when
+// needed.
+//
+// Hashes aren't read here as even remotes which can usually read them
+// from a listing may need an extra transaction for some objects, for
+// example multipart uploads on s3. Instead they are stored with putHash
+// when they are first read and kept when the directory is stored again
+// as long as the size and modification time of the file are the same.
+func (s *dirStore) newRecord(entries fs.DirEntries, when time.Time) *dirRecord {
+	ctx := context.TODO()
+	features := s.f.Features()
+	record := &dirRecord{
+		Read:    when,
+		Entries: make([]dirRecordEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		item := dirRecordEntry{
+			Name: path.Base(entry.Remote()),
+			Size: entry.Size(),
+		}
+		switch x := entry.(type) {
+		case fs.Directory:
+			item.IsDir = true
+			item.ModTime = x.ModTime(ctx)
+		case fs.Object:
+			if !features.SlowModTime {
+				item.ModTime = x.ModTime(ctx)
+			}
+			if o, ok := x.(*storedObject); ok && o.resolved() == nil {
+				item.Hashes = o.storedHashes()
+			}
+		default:
+			continue
+		}
+		record.Entries = append(record.Entries, item)
+	}
+	return record
+}
+
+// forget removes the listing of dirPath
+func (s *dirStore) forget(dirPath string) {
+	if s == nil {
+		return
+	}
+	s.queue(dirChange{key: s.key(dirPath)})
+}
+
+// forgetTree removes the listing of dirPath and all the directories
+// below it
+func (s *dirStore) forgetTree(dirPath string) {
+	if s == nil {
+		return
+	}
+	// Remove the directory then everything below it, taking care
+	// that the root has an empty path
+	key := s.key(dirPath)
+	treeKey := key
+	if dirPath != "" {
+		treeKey += "/"
+	}
+	s.queue(dirChange{key: key}, dirChange{key: treeKey, tree: true})
+}
+
+// getRecord reads the record with key from the bucket or returns nil
+func getRecord(b kv.Bucket, key string) (*dirRecord, error) {
+	data := b.Get([]byte(key))
+	if data == nil {
+		return nil, nil
+	}
+	record := &dirRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, fmt.Errorf("bad directory record %q: %w", key, err)
+	}
+	return record, nil
+}
+
+// putRecord writes the record with key to the bucket
+func putRecord(b kv.Bucket, key string, record *dirRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode directory record %q: %w", key, err)
+	}
+	return b.Put([]byte(key), data)
+}
+
+// opGetDir reads a directory record
+type opGetDir struct {
+	key    string
+	record *dirRecord
+}
+
+func (op *opGetDir) Do(ctx context.Context, b kv.Bucket) (err error) {
+	op.record, err = getRecord(b, op.key)
+	return err
+}
+
+// opWriteDirs writes changes to the directory records in order
+type opWriteDirs struct {
+	changes []dirChange
+}
+
+func (op *opWriteDirs) Do(ctx context.Context, b kv.Bucket) error {
+	for _, change := range op.changes {
+		if err := change.apply(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply the change to the bucket
+func (change *dirChange) apply(b kv.Bucket) error {
+	if change.hash != nil {
+		return change.applyHash(b)
+	}
+	if record := change.record; record != nil {
+		// Keep the hashes of the files which haven't changed
+		old, err := getRecord(b, change.key)
+		if err != nil {
+			fs.Debugf(nil, "Replacing %v", err)
+		} else if old != nil {
+			record = record.withHashes(old)
+		}
+		return putRecord(b, change.key, record)
+	}
+	if !change.tree {
+		return b.Delete([]byte(change.key))
+	}
+	var keys [][]byte
+	c := b.Cursor()
+	for k, _ := c.Seek([]byte(change.key)); k != nil && strings.HasPrefix(string(k), change.key); k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyHash adds the hash to the entry it is for if the record is
+// still stored and the file hasn't changed
+func (change *dirChange) applyHash(b kv.Bucket) error {
+	record, err := getRecord(b, change.key)
+	if err != nil || record == nil {
+		return nil
+	}
+	h := change.hash
+	want := dirRecordEntry{Name: h.name, Size: h.size, ModTime: h.modTime}
+	for i := range record.Entries {
+		entry := &record.Entries[i]
+		if !entry.sameFile(&want) {
+			continue
+		}
+		if entry.Hashes[h.ty] == h.sum {
+			return nil
+		}
+		if entry.Hashes == nil {
+			entry.Hashes = map[string]string{}
+		}
+		entry.Hashes[h.ty] = h.sum
+		return putRecord(b, change.key, record)
+	}
+	return nil
+}
+
+// opPruneDirs removes directory records with prefix read before before
+type opPruneDirs struct {
+	prefix  string
+	before  time.Time
+	kept    int
+	removed int
+}
+
+func (op *opPruneDirs) Do(ctx context.Context, b kv.Bucket) error {
+	var keys [][]byte
+	c := b.Cursor()
+	for k, data := c.Seek([]byte(op.prefix)); k != nil && strings.HasPrefix(string(k), op.prefix); k, data = c.Next() {
+		var record struct {
+			Read time.Time `json:"read"`
+		}
+		if err := json.Unmarshal(data, &record); err != nil || record.Read.Before(op.before) {
+			keys = append(keys, append([]byte(nil), k...))
+		} else {
+			op.kept++
+		}
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+		op.removed++
+	}
+	return nil
+}
+
+// storedObject is an fs.Object made from a stored directory entry.
+//
+// It answers questions about the object from the store and finds the
+// real object on the remote the first time anything else is needed,
+// after which it passes everything on to the real object.
+type storedObject struct {
+	s       *dirStore
+	remote  string
+	size    int64
+	modTime time.Time         // zero if not known
+	hashes  map[string]string // stored hashes by type name - don't change
+
+	mu  sync.Mutex
+	obj fs.Object // the real object once found
+}
+
+// resolve finds the real object on the remote
+func (o *storedObject) resolve(ctx context.Context) (fs.Object, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.obj != nil {
+		return o.obj, nil
+	}
+	obj, err := o.s.f.NewObject(ctx, o.remote)
+	if err != nil {
+		return nil, err
+	}
+	o.obj = obj
+	return obj, nil
+}
+
+// resolved returns the real object if it has been found or nil
+func (o *storedObject) resolved() fs.Object {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.obj
+}
+
+// storedHashes returns a copy of the stored hashes or nil
+func (o *storedObject) storedHashes() map[string]string {
+	if len(o.hashes) == 0 {
+		return nil
+	}
+	hashes := make(map[string]string, len(o.hashes))
+	for ty, sum := range o.hashes {
+		hashes[ty] = sum
+	}
+	return hashes
+}
+
+// Fs returns read only access to the Fs that this object is part of
+func (o *storedObject) Fs() fs.Info {
+	return o.s.f
+}
+
+// String returns a description of the Object
+func (o *storedObject) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *storedObject) Remote() string {
+	return o.remote
+}
+
+// Size returns the size of the file
+func (o *storedObject) Size() int64 {
+	if obj := o.resolved(); obj != nil {
+		return obj.Size()
+	}
+	return o.size
+}
+
+// ModTime returns the modification date of the file
+func (o *storedObject) ModTime(ctx context.Context) time.Time {
+	if obj := o.resolved(); obj != nil {
+		return obj.ModTime(ctx)
+	}
+	if !o.modTime.IsZero() {
+		return o.modTime
+	}
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		fs.Debugf(o, "Failed to read modification time: %v", err)
+		return o.modTime
+	}
+	return obj.ModTime(ctx)
+}
+
+// Hash returns the selected checksum of the file
+//
+// Hashes read from the remote are stored for next time.
+func (o *storedObject) Hash(ctx context.Context, ty hash.Type) (string, error) {
+	if o.resolved() == nil {
+		if sum, ok := o.hashes[ty.String()]; ok {
+			return sum, nil
+		}
+	}
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	sum, err := obj.Hash(ctx, ty)
+	if err == nil {
+		dirPath := path.Dir(o.remote)
+		if dirPath == "." {
+			dirPath = ""
+		}
+		o.s.putHash(dirPath, obj, ty, sum)
+	}
+	return sum, err
+}
+
+// Storable says whether this object can be stored
+func (o *storedObject) Storable() bool {
+	return true
+}
+
+// SetModTime sets the metadata on the object to set the modification date
+func (o *storedObject) SetModTime(ctx context.Context, t time.Time) error {
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	return obj.SetModTime(ctx, t)
+}
+
+// Open opens the file for read
+func (o *storedObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return obj.Open(ctx, options...)
+}
+
+// Update in to the object with the modTime given of the given size
+func (o *storedObject) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	return obj.Update(ctx, in, src, options...)
+}
+
+// Remove this object
+func (o *storedObject) Remove(ctx context.Context) error {
+	obj, err := o.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	return obj.Remove(ctx)
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Object = (*storedObject)(nil)
+)
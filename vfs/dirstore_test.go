@@ -0,0 +1,231 @@
+package vfs
+
+import (
+	"context"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/lib/kv"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// names returns the sorted names of the directory listing
+func names(t *testing.T, vfs *VFS, dirPath string) []string {
+	entries, err := vfs.ReadDir(dirPath)
+	require.NoError(t, err)
+	var out []string
+	for _, entry := range entries {
+		out = append(out, entry.Name())
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestDirStore(t *testing.T) {
+	if !kv.Supported() {
+		t.Skip("persistent directory cache not supported on this OS")
+	}
+	ctx := context.Background()
+	opt := vfscommon.DefaultOpt
+	opt.DirCachePersist = true
+	opt.DirCacheTime = time.Hour
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	r.WriteObject(ctx, "dir/file1", "file1 contents", t1)
+	assert.Equal(t, []string{"dir"}, names(t, vfs, ""))
+	assert.Equal(t, []string{"file1"}, names(t, vfs, "dir"))
+
+	// Add a file behind the back of the VFS
+	r.WriteObject(ctx, "dir/file2", "file2 contents", t2)
+
+	// A new VFS with different options (so it isn't shared) should
+	// read the stored listing rather than the remote once the
+	// changes made by the other VFS have been written
+	vfses := []*VFS{vfs}
+	newVFS := func() *VFS {
+		for _, vfs := range vfses {
+			vfs.dirStore.flush()
+		}
+		opt.ReadAhead++
+		vfs := New(r.Fremote, &opt)
+		t.Cleanup(vfs.Shutdown)
+		vfses = append(vfses, vfs)
+		return vfs
+	}
+	vfs2 := newVFS()
+	assert.Equal(t, []string{"file1"}, names(t, vfs2, "dir"))
+
+	// The stored entries should have the details and the files
+	// should be readable
+	node, err := vfs2.Stat("dir/file1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(14), node.Size())
+	fstest.AssertTimeEqualWithPrecision(t, "dir/file1", t1, node.ModTime(), r.Fremote.Precision())
+	data, err := vfs2.ReadFile("dir/file1")
+	require.NoError(t, err)
+	assert.Equal(t, "file1 contents", string(data))
+
+	// Refreshing the directory reads it from the remote
+	dir, err := vfs2.Stat("dir")
+	require.NoError(t, err)
+	require.NoError(t, dir.(*Dir).readDir())
+	assert.Equal(t, []string{"file1", "file2"}, names(t, vfs2, "dir"))
+
+	// ...and saves it for the next VFS
+	vfs3 := newVFS()
+	assert.Equal(t, []string{"file1", "file2"}, names(t, vfs3, "dir"))
+
+	// Change notify removes the stored listing
+	r.WriteObject(ctx, "dir/file3", "file3 contents", t3)
+	root, err := vfs3.Root()
+	require.NoError(t, err)
+	root.changeNotify("dir/file3", fs.EntryObject)
+	vfs4 := newVFS()
+	assert.Equal(t, []string{"file1", "file2", "file3"}, names(t, vfs4, "dir"))
+
+	// Changes made through the VFS remove the stored listing
+	require.NoError(t, vfs4.Remove("dir/file3"))
+	vfs5 := newVFS()
+	assert.Equal(t, []string{"file1", "file2"}, names(t, vfs5, "dir"))
+
+	// Forgetting the directory cache removes the stored listings
+	r.WriteObject(ctx, "dir/file4", "file4 contents", t3)
+	vfs5.FlushDirCache()
+	vfs6 := newVFS()
+	assert.Equal(t, []string{"file1", "file2", "file4"}, names(t, vfs6, "dir"))
+
+	// Listings older than --dir-cache-time aren't used
+	entries, _, ok := vfs6.dirStore.get("dir", time.Now())
+	assert.True(t, ok)
+	assert.Equal(t, 3, len(entries))
+	_, _, ok = vfs6.dirStore.get("dir", time.Now().Add(2*time.Hour))
+	assert.False(t, ok)
+}
+
+func TestDirStoreHashes(t *testing.T) {
+	if !kv.Supported() {
+		t.Skip("persistent directory cache not supported on this OS")
+	}
+	ctx := context.Background()
+	opt := vfscommon.DefaultOpt
+	opt.DirCachePersist = true
+	opt.DirCacheTime = time.Hour
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+	if r.Fremote.Features().SlowModTime {
+		t.Skip("hashes aren't stored without modification times")
+	}
+	ty := r.Fremote.Hashes().GetOne()
+	if ty == hash.None {
+		t.Skip("no hashes")
+	}
+	r.WriteObject(ctx, "dir/file1", "file1 contents", t1)
+	assert.Equal(t, []string{"file1"}, names(t, vfs, "dir"))
+	vfs.dirStore.flush()
+
+	// storedFile returns the stored object for dir/file1 from a new VFS
+	storedFile := func() *storedObject {
+		opt.ReadAhead++
+		vfs := New(r.Fremote, &opt)
+		t.Cleanup(vfs.Shutdown)
+		node, err := vfs.Stat("dir/file1")
+		require.NoError(t, err)
+		o, ok := node.DirEntry().(*storedObject)
+		require.True(t, ok)
+		return o
+	}
+
+	// The first time the hash is read from the remote and stored
+	o := storedFile()
+	want, err := o.Hash(ctx, ty)
+	require.NoError(t, err)
+	assert.NotNil(t, o.resolved())
+	o.s.flush()
+
+	// The next time it comes from the store
+	o = storedFile()
+	got, err := o.Hash(ctx, ty)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Nil(t, o.resolved())
+
+	// It is kept when the directory is stored again...
+	entries, read, ok := o.s.get("dir", time.Now())
+	require.True(t, ok)
+	o.s.put("dir", entries, read)
+	o.s.flush()
+	o = storedFile()
+	_, err = o.Hash(ctx, ty)
+	require.NoError(t, err)
+	assert.Nil(t, o.resolved())
+
+	// ...but not if the file has changed
+	record := &dirRecord{Entries: []dirRecordEntry{{Name: "file1", Size: 14, ModTime: t1}}}
+	old := &dirRecord{Entries: []dirRecordEntry{{Name: "file1", Size: 14, ModTime: t1, Hashes: map[string]string{"md5": "potato"}}}}
+	assert.Equal(t, map[string]string{"md5": "potato"}, record.withHashes(old).Entries[0].Hashes)
+	record.Entries[0].Size = 15
+	assert.Nil(t, record.withHashes(old).Entries[0].Hashes)
+	record.Entries[0].Size = 14
+	record.Entries[0].ModTime = t2
+	assert.Nil(t, record.withHashes(old).Entries[0].Hashes)
+	assert.Equal(t, map[string]string{"md5": "potato"}, old.Entries[0].Hashes)
+}
+
+func TestDirStoreForgetTree(t *testing.T) {
+	if !kv.Supported() {
+		t.Skip("persistent directory cache not supported on this OS")
+	}
+	opt := vfscommon.DefaultOpt
+	opt.DirCachePersist = true
+	_, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+	s := vfs.dirStore
+	require.NotNil(t, s)
+
+	when := time.Now()
+	for _, dirPath := range []string{"", "a", "a/b", "a/b/c", "a-b", "ab"} {
+		s.put(dirPath, nil, when)
+	}
+	stored := func() (out []string) {
+		for _, dirPath := range []string{"", "a", "a/b", "a/b/c", "a-b", "ab"} {
+			if _, _, ok := s.get(dirPath, when); ok {
+				out = append(out, dirPath)
+			}
+		}
+		return out
+	}
+	assert.Equal(t, []string{"", "a", "a/b", "a/b/c", "a-b", "ab"}, stored())
+	s.forgetTree("a/b")
+	assert.Equal(t, []string{"", "a", "a-b", "ab"}, stored())
+	s.forgetTree("a")
+	assert.Equal(t, []string{"", "a-b", "ab"}, stored())
+	s.forgetTree("")
+	assert.Equal(t, []string(nil), stored())
+
+	// The same once the changes have been written
+	for _, dirPath := range []string{"a", "a/b", "ab"} {
+		s.put(dirPath, nil, when)
+	}
+	s.forgetTree("a")
+	assert.Equal(t, []string{"ab"}, stored())
+	s.flush()
+	assert.Equal(t, 0, len(s.pending))
+	assert.Equal(t, []string{"ab"}, stored())
+	s.forgetTree("")
+	s.flush()
+	assert.Equal(t, []string(nil), stored())
+
+	// Make sure the VFS still works with nothing stored
+	_, err := vfs.ReadDir("")
+	assert.NoError(t, err)
+	_, err = vfs.Stat("notfound")
+	assert.True(t, os.IsNotExist(err))
+}
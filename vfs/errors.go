@@ -20,6 +20,9 @@ const (
 	EBADF
 	EROFS
 	ENOSYS
+	ENOATTR
+	ENOTSUP
+	EAGAIN
 )
 
 // Errors which have exact counterparts in os
@@ -38,6 +41,9 @@ var errorNames = []string{
 	EBADF:     "Bad file descriptor",
 	EROFS:     "Read only file system",
 	ENOSYS:    "Function not implemented",
+	ENOATTR:   "No such attribute",
+	ENOTSUP:   "Operation not supported",
+	EAGAIN:    "Resource temporarily unavailable",
 }
 
 // Error renders the error as a string
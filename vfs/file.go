@@ -52,6 +52,7 @@ type File struct {
 	sys              atomic.Value                    // user defined info to be attached here
 	nwriters         int32                           // len(writers) which is read/updated with atomic
 	appendMode       bool                            // file was opened with O_APPEND
+	isLink           bool                            // file is a symlink stored as leaf+fs.LinkSuffix - read only
 }
 
 // newFile creates a new File
@@ -91,6 +92,9 @@ func (f *File) IsDir() bool {
 
 // Mode bits of the file or directory - satisfies Node interface
 func (f *File) Mode() (mode os.FileMode) {
+	if f.isLink {
+		return os.ModeSymlink | 0777
+	}
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 	mode = f.d.vfs.Opt.FilePerms
@@ -172,9 +176,12 @@ func (f *File) rename(ctx context.Context, destDir *Dir, newName string) error {
 		return err
 	}
 
-	oldPath := f.Path()
+	oldPath, oldCachePath := f.Path(), f.cachePath()
 	// File.mu is unlocked here to call Dir.Path()
 	newPath := path.Join(destDir.Path(), newName)
+	if f.isLink {
+		newPath += fs.LinkSuffix
+	}
 
 	renameCall := func(ctx context.Context) (err error) {
 		// chain rename calls if any
@@ -196,6 +203,15 @@ func (f *File) rename(ctx context.Context, destDir *Dir, newName string) error {
 				return nil // no need to rename
 			}
 
+			// backends can only move their own objects
+			if so, ok := o.(*storedObject); ok {
+				o, err = so.resolve(ctx)
+				if err != nil {
+					fs.Errorf(f.Path(), "File.Rename error: %v", err)
+					return err
+				}
+			}
+
 			// do the move of the remote object
 			dstOverwritten, _ := d.Fs().NewObject(ctx, newPath)
 			newObject, err = operations.Move(ctx, d.Fs(), dstOverwritten, newPath, o)
@@ -212,8 +228,8 @@ func (f *File) rename(ctx context.Context, destDir *Dir, newName string) error {
 			}
 		}
 		// Rename in the cache
-		if d.vfs.cache != nil && d.vfs.cache.Exists(oldPath) {
-			if err := d.vfs.cache.Rename(oldPath, newPath, newObject); err != nil {
+		if d.vfs.cache != nil && d.vfs.cache.Exists(oldCachePath) {
+			if err := d.vfs.cache.Rename(oldCachePath, newPath, newObject); err != nil {
 				fs.Infof(f.Path(), "File.Rename failed in Cache: %v", err)
 			}
 		}
@@ -243,7 +259,7 @@ func (f *File) rename(ctx context.Context, destDir *Dir, newName string) error {
 	CacheMode := d.vfs.Opt.CacheMode
 	if writing &&
 		(CacheMode < vfscommon.CacheModeMinimal ||
-			(CacheMode == vfscommon.CacheModeMinimal && !destDir.vfs.cache.Exists(oldPath))) {
+			(CacheMode == vfscommon.CacheModeMinimal && !destDir.vfs.cache.Exists(oldCachePath))) {
 		fs.Debugf(oldPath, "File is currently open, delaying rename %p", f)
 		f.mu.Lock()
 		f.pendingRenameFun = renameCall
@@ -428,8 +444,12 @@ func (f *File) _applyPendingModTime() error {
 // Apply a pending mod time
 func (f *File) applyPendingModTime() error {
 	f.mu.Lock()
-	defer f.mu.Unlock()
-	return f._applyPendingModTime()
+	err := f._applyPendingModTime()
+	d, dPath := f.d, f.dPath
+	f.mu.Unlock()
+	// The stored listing of the directory has the old time in
+	d.vfs.dirStore.forget(dPath)
+	return err
 }
 
 // _writingInProgress returns true of there are any open writers
@@ -583,18 +603,25 @@ func (f *File) Remove() (err error) {
 
 	// Remove the object from the cache
 	wasWriting := false
-	if d.vfs.cache != nil && d.vfs.cache.Exists(f.Path()) {
-		wasWriting = d.vfs.cache.Remove(f.Path())
+	if cachePath := f.cachePath(); d.vfs.cache != nil && d.vfs.cache.Exists(cachePath) {
+		wasWriting = d.vfs.cache.Remove(cachePath)
 	}
 
 	// Remove the item from the directory listing
 	// called with File.mu released
 	d.delObject(f.Name())
 
+	// Drop any advisory locks on the file
+	d.vfs.locks.remove(f.Path())
+
 	f.muRW.Lock() // muRW must be locked before mu to avoid
 	f.mu.Lock()   // deadlock in RWFileHandle.openPending and .close
 	if f.o != nil {
-		err = f.o.Remove(context.TODO())
+		if d.vfs.Opt.Trash != "" {
+			err = d.vfs.trashObject(context.TODO(), f.o)
+		} else {
+			err = f.o.Remove(context.TODO())
+		}
 	}
 	f.mu.Unlock()
 	f.muRW.Unlock()
@@ -658,6 +685,10 @@ func (f *File) Fs() fs.Fs {
 // We ignore O_SYNC and O_EXCL
 func (f *File) Open(flags int) (fd Handle, err error) {
 	defer log.Trace(f.Path(), "flags=%s", decodeOpenFlags(flags))("fd=%v, err=%v", &fd, &err)
+	// Symlinks can only be read with Readlink
+	if f.isLink {
+		return nil, EINVAL
+	}
 	var (
 		write    bool // if set need write support
 		read     bool // if set need read support
@@ -55,6 +55,40 @@ Or individual files or directories:
 
     rclone rc vfs/forget file=path/to/file dir=path/to/dir
 
+#### Persistent directory cache
+
+Normally the directory cache is only kept in memory so a restarted
+rclone has to list every directory again. With
+!--vfs-dir-cache-persist! rclone keeps the directory listings,
+including sizes, modification times and hashes, in a database in the
+cache directory so a remount is warm immediately.
+
+    --vfs-dir-cache-persist   Keep the directory cache on disk so it survives a restart
+
+Stored listings obey the same rules as the in memory cache. They are
+only used until they are older than !--dir-cache-time!, and they are
+removed when a directory is changed through the VFS, when the backend
+reports a change with polling, on !SIGHUP! and by the !vfs/forget!
+and !vfs/refresh! remote control commands.
+
+Changes made while rclone was not running can't be noticed until the
+listing expires, so set !--dir-cache-time! to the longest you are
+happy to see out of date listings for after a restart.
+
+Modification times are only stored if the backend can read them from
+a listing. Otherwise they are read from the backend when first
+needed.
+
+Hashes are not read when a directory is listed, as that can take an
+extra transaction per file on some backends. Instead a hash read from
+the backend for a stored file is stored with it, so it is only read
+once. Stored hashes are dropped when the size or modification time of
+the file changes, and they aren't stored at all for backends which
+can't read modification times from a listing.
+
+Changes to the stored listings are written in the background so a
+listing read just before rclone is killed may not be stored.
+
 ### VFS File Buffering
 
 The !--buffer-size! flag determines the amount of memory,
@@ -88,8 +122,13 @@ find that you need one or the other or both.
     --vfs-cache-mode CacheMode           Cache mode off|minimal|writes|full (default off)
     --vfs-cache-max-age duration         Max age of objects in the cache (default 1h0m0s)
     --vfs-cache-max-size SizeSuffix      Max total size of objects in the cache (default off)
+    --vfs-cache-evict-policy EvictPolicy Which files to remove first when the cache is over quota lru|lfu|arc|2q (default lru)
+    --vfs-cache-dir-max-size string      Max total size of objects in directories in the cache as dir=size, e.g. downloads=10G,tmp=1G
     --vfs-cache-poll-interval duration   Interval to poll the cache for stale objects (default 1m0s)
+    --vfs-cache-encrypt                  Encrypt the files in the VFS cache
+    --vfs-cache-encrypt-key string       Passphrase to encrypt the VFS cache with (default is a random key for each run)
     --vfs-write-back duration            Time to writeback files after last use when using cache (default 5s)
+    --vfs-write-back-conflict ConflictPolicy What to do if the remote file changed before writeback overwrite|keep-both|refuse (default overwrite)
 
 If run with !-vv! rclone will print the location of the file cache.  The
 files are stored in the user cache file area which is OS dependent but
@@ -106,11 +145,47 @@ seconds. If rclone is quit or dies with files that haven't been
 uploaded, these will be uploaded next time rclone is run with the same
 flags.
 
+If the file on the remote has changed since it was opened, for example
+it was uploaded by another mount or directly with rclone, then what
+happens when the file is written back is chosen by
+!--vfs-write-back-conflict!:
+
+  * !overwrite! - the remote file is overwritten (the default) - the remote isn't checked for changes
+  * !keep-both! - the remote file is renamed to !name.conflict-YYYY-MM-DD-HHMMSS.ext! and the local file is uploaded
+  * !refuse! - the upload is logged as an error and the local changes are kept in the cache
+
+A refused upload isn't retried, even when rclone is restarted, until
+the file is written to again. Refused files are logged as errors when
+rclone starts and counted as !refusedFiles! in !rclone rc vfs/stats!,
+along with the conflicts found.
+
 If using !--vfs-cache-max-size! note that the cache may exceed this size
 for two reasons.  Firstly because it is only checked every
 !--vfs-cache-poll-interval!.  Secondly because open files cannot be
 evicted from the cache.
 
+When the cache is over !--vfs-cache-max-size! the files which aren't
+in use are removed in the order chosen by !--vfs-cache-evict-policy!:
+
+  * !lru! - the least recently used files first (the default)
+  * !lfu! - the least frequently opened files first, then the least recently used
+  * !2q! - files which have been opened once before files opened more often
+  * !arc! - like !2q! but adapts how much of the cache files opened once may use
+
+A single read through lots of files, such as a backup or a search,
+will flush everything else out of the cache with !lru!. With !2q! or
+!arc! the files it read are removed first so the files in regular use
+stay cached. The number of times each file has been opened is stored
+in the cache metadata so it survives a restart.
+
+Use !--vfs-cache-dir-max-size! to stop a single directory taking over
+the cache. It takes a comma separated list of !dir=size! pairs, where
+the directories are relative to the root of the remote, for example
+!--vfs-cache-dir-max-size downloads=10G,tmp/scratch=1G!. Files which
+aren't in use are removed from directories over their limit, in the
+order given by !--vfs-cache-evict-policy!, each time the cache is
+cleaned.
+
 You **should not** run two copies of rclone using the same VFS cache
 with the same or overlapping remotes if using !--vfs-cache-mode > off!.
 This can potentially cause data corruption if you do. You can work
@@ -185,6 +260,79 @@ FAT/exFAT do not. Rclone will perform very badly if the cache
 directory is on a filesystem which doesn't support sparse files and it
 will log an ERROR message if one is detected.
 
+#### Pinning files
+
+With !--vfs-cache-mode full! files and directories can be pinned so
+they are available offline. Pinned files are downloaded completely
+into the cache in the background, at most !--transfers! at once, and
+they are never removed by !--vfs-cache-max-age! or
+!--vfs-cache-max-size!. Pinning a directory pins the files in it and
+its subdirectories at the time it is pinned.
+
+Files are pinned and unpinned with the [remote control](/rc):
+
+    rclone rc vfs/pin path=path/to/dir
+    rclone rc vfs/unpin path=path/to/dir
+
+or on a Linux or FreeBSD !mount! or !mount2! by setting the
+!user.rclone.pinned! extended attribute:
+
+    setfattr -n user.rclone.pinned -v 1 path/to/file
+    setfattr -x user.rclone.pinned path/to/file
+
+Pins are stored with the cache so they survive a restart, and any
+pinned files which aren't completely downloaded are fetched again by
+the cache cleaner. The number of pinned files and the bytes cached
+for them are shown by !rclone rc vfs/stats!.
+
+#### Metadata as extended attributes
+
+On a Linux or FreeBSD !mount! or !mount2! the [metadata](/docs/#metadata)
+of each file is shown as extended attributes in the !user.!
+namespace, so the metadata key !key! is the extended attribute
+!user.key!. This includes both user metadata and the system metadata
+listed by !rclone backend features remote:!.
+
+    getfattr -d path/to/file
+    setfattr -n user.description -v "holiday photos" path/to/file
+
+Setting or removing an extended attribute needs !--vfs-cache-mode
+minimal! or above. The change is stored in the cache and the file is
+uploaded again with its new metadata by the normal write back, so it
+waits for !--vfs-write-back! like any other change, or until the file
+is closed if it is open. Setting an attribute returns straight away -
+any parts of the file which aren't in the cache are downloaded in the
+background before the upload. Read only system
+metadata can't be set. Whether removing an attribute removes it from
+the remote depends on the backend, as some keep metadata which isn't
+set on an upload.
+
+#### Encrypting the cache
+
+The files in the cache hold the contents of the files on the remote in
+the clear, even if the remote is a !crypt! remote. To stop this set
+!--vfs-cache-encrypt! and the data and metadata files in the cache
+will be encrypted with XChaCha20-Poly1305. The data is encrypted in
+64 KiB blocks so the cache files can still be read and written at any
+offset and are still sparse.
+
+By default a random key is used which lasts until rclone exits. This
+means the cache is thrown away each time rclone starts, including any
+files which haven't been uploaded yet. rclone logs an error for each
+file with changes which haven't been uploaded when the cache is shut
+down, and how many files it removed when it starts. To keep the cache
+across restarts set a passphrase with !--vfs-cache-encrypt-key!, or
+the !RCLONE_VFS_CACHE_ENCRYPT_KEY! environment variable to keep it off
+the command line. The key is made from the passphrase with a random
+salt which is kept with the cache. The passphrase is shown as !*****!
+by !vfs/stats! and !options/get!.
+
+Cache files which can't be decrypted, for example because the key has
+changed, they were written without !--vfs-cache-encrypt! or they have
+been tampered with, are removed when rclone starts.
+
+Note that the names of the files in the cache are not encrypted.
+
 #### Fingerprinting
 
 Various parts of the VFS use fingerprinting to see if a local file
@@ -308,6 +456,126 @@ If the flag is not provided on the command line, then its default value depends
 on the operating system where rclone runs: "true" on Windows and macOS, "false"
 otherwise. If the flag is provided without a value, then it is "true".
 
+### Symlinks
+
+By default the VFS has no symlinks. With !--vfs-links! a file on the
+remote whose name ends in !.rclonelink! is shown as a symlink without
+the !.rclonelink! suffix pointing to the target stored in the file.
+This is the same convention the local backend uses with !--links!, so
+symlinks copied to the remote that way can be seen on a mount.
+
+    --vfs-links   Translate symlinks to/from regular files with a '.rclonelink' extension
+
+Symlinks can be created and read through a mount and with
+!rclone serve sftp!. Creating a symlink uploads a new !.rclonelink!
+file holding the target, and renaming or removing the symlink renames
+or removes that file. The target isn't checked, so it may point
+anywhere, including outside of the VFS.
+
+Symlinks can't be opened through the VFS itself. The operating system
+follows symlinks on a mount, and !rclone serve sftp! follows them when
+opening or stat-ing files.
+
+If a directory has both !name! and !name.rclonelink! then !name! is
+shown and the symlink is ignored with a log message. Paths given to
+!vfs/pin! and !vfs/unpin! are the names shown in the VFS, without the
+!.rclonelink! suffix.
+
+### VFS File Locking
+
+The VFS keeps a table of advisory locks on files which can be shared
+(read) or exclusive (write) locks on the whole file or on a range of
+bytes. Like POSIX locks they don't stop anyone reading or writing the
+file, they only conflict with other locks.
+
+- On a mount on Linux and FreeBSD, !flock! and POSIX (!fcntl!) locks
+  taken by programs are kept in the VFS lock table. Locks are released
+  when the file is closed. This isn't supported by !rclone cmount!.
+- !rclone serve webdav! stores WebDAV locks in the VFS lock table, so a
+  file locked on a mount can't be locked or changed over WebDAV.
+  This isn't done when using !--auth-proxy! as each user gets their
+  own VFS.
+- !rclone serve sftp --locks! takes a shared lock on files opened for
+  reading and an exclusive lock on files opened for writing, failing
+  the open if a conflicting lock is held. Without !--locks! files
+  opened over SFTP aren't locked.
+
+Locks belong to the file, so they move with it when it is renamed
+through the VFS and are dropped when it is removed.
+
+Locks are only kept in memory, so they coordinate the users of a
+single rclone process. They are not seen by other rclone instances or
+by anything accessing the remote directly.
+
+### VFS Overlay
+
+    --vfs-overlay    Keep changes in a local overlay instead of writing them to the remote
+
+With !--vfs-overlay! the VFS never changes the remote. Instead the
+remote is used as a read only lower layer and any changes are kept in
+a local upper layer in the cache directory (see !--cache-dir!):
+
+- files written or created are stored in the upper layer and are
+  shown in place of any file with the same name on the remote
+- deleting a file or directory on the remote records a "whiteout"
+  which hides it
+- renaming a file on the remote copies it to the upper layer under
+  the new name and hides the old name
+
+This is useful for mounting a production bucket read only and letting
+jobs modify it without touching the original. The overlay is kept
+across restarts of rclone until it is committed or discarded with
+these remote control commands:
+
+- !vfs/overlay-status! - lists the changed and deleted paths
+- !vfs/overlay-commit! - deletes the hidden paths from the remote,
+  uploads the changed files and empties the overlay
+- !vfs/overlay-discard! - empties the overlay so the remote is shown
+  unchanged
+
+Commit and discard fail if any files are open for writing or waiting
+to be written back, so use them when the VFS is idle.
+
+Limitations:
+
+- directories which exist on the remote can't be renamed
+- setting the modification time of a file on the remote copies it to
+  the upper layer
+- the VFS cache is kept separately from the cache of the remote
+  without !--vfs-overlay!
+
+### VFS Trash
+
+    --vfs-trash string    Move deleted files to this directory on the remote instead of deleting them
+
+Normally files deleted through the VFS are deleted from the remote
+straight away. On remotes without a trash of their own (e.g. s3, sftp
+or local) they are gone for good.
+
+If !--vfs-trash! is set to a directory, e.g. !--vfs-trash .trash!,
+files deleted through the VFS are moved into it instead, using a
+server side move if the remote supports it. Each deletion is put in a
+directory named after the time it happened, keeping the original path
+of the file, e.g.
+
+    .trash/2023-04-05T060708.123456789Z/dir/file.txt
+
+The trash directory is relative to the root of the VFS and isn't
+shown in it. It can't be the root itself - if it is, for example
+!--vfs-trash /!, an error is logged and the VFS is made read only so
+nothing is deleted. Removing a directory recursively moves each of the files
+in it to the trash, whereas removing an empty directory deletes it.
+
+The trash can be managed with these remote control commands:
+
+- !vfs/trash-list! - lists the files in the trash and when they
+  were deleted
+- !vfs/trash-restore! - moves a file in the trash back to where it
+  was deleted from, failing if a file has been put there since
+- !vfs/trash-empty! - permanently deletes the files in the trash, or
+  only those deleted longer ago than !maxAge!, so it can be run
+  regularly to expire old files
+
 ### VFS Disk Options
 
 This flag allows you to manually set the statistics about the filing system.
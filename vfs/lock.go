@@ -0,0 +1,374 @@
+package vfs
+
+// The VFS keeps a table of advisory locks on byte ranges of files
+// which is shared by everything using the VFS, so locks taken on a
+// mount are seen by serve webdav and serve sftp using the same VFS
+// and vice versa.
+//
+// Like POSIX locks they don't stop anyone reading or writing the
+// file - they only conflict with each other. Locks are kept in memory
+// so they only coordinate users of the same rclone process.
+//
+// Locks belong to the file rather than its name so they move with it
+// when it is renamed and are dropped when it is removed.
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LockType is the type of an advisory lock
+type LockType byte
+
+// Lock types
+const (
+	LockShared    LockType = iota // many owners may hold a shared (read) lock
+	LockExclusive                 // only one owner may hold an exclusive (write) lock
+)
+
+// String turns a LockType into a string
+func (t LockType) String() string {
+	switch t {
+	case LockShared:
+		return "shared"
+	case LockExclusive:
+		return "exclusive"
+	}
+	return fmt.Sprintf("LockType(%d)", t)
+}
+
+// LockEOF as the End of a Lock means the lock extends to the end of
+// the file however long it gets.
+const LockEOF = math.MaxInt64
+
+// Lock describes an advisory lock on the bytes from Start to End
+// inclusive of a file. Use Start 0 and End LockEOF to lock the whole
+// file.
+type Lock struct {
+	Owner   string    // identifies the holder of the lock
+	Type    LockType  // shared or exclusive
+	Start   int64     // first byte locked
+	End     int64     // last byte locked or LockEOF
+	Expires time.Time // if set the lock is released at this time
+}
+
+// overlaps returns true if the lock covers any of start..end
+func (l *Lock) overlaps(start, end int64) bool {
+	return l.Start <= end && start <= l.End
+}
+
+// conflicts returns true if l and other can't both be held
+func (l *Lock) conflicts(other *Lock) bool {
+	return l.Owner != other.Owner &&
+		(l.Type == LockExclusive || other.Type == LockExclusive) &&
+		l.overlaps(other.Start, other.End)
+}
+
+// expired returns true if the lock has expired at now
+func (l *Lock) expired(now time.Time) bool {
+	return !l.Expires.IsZero() && !now.Before(l.Expires)
+}
+
+// lockManager holds the advisory locks for a VFS
+type lockManager struct {
+	mu      sync.Mutex
+	locks   map[string][]Lock // locks held on each file
+	changed chan struct{}     // closed when any lock is released
+}
+
+// newLockManager makes a new lockManager
+func newLockManager() *lockManager {
+	return &lockManager{
+		locks:   make(map[string][]Lock),
+		changed: make(chan struct{}),
+	}
+}
+
+// lockName returns the canonical name for the file name
+func lockName(name string) string {
+	return strings.Trim(path.Clean("/"+name), "/")
+}
+
+// _changed wakes anyone waiting for a lock
+//
+// call with mu held
+func (lm *lockManager) _changed() {
+	close(lm.changed)
+	lm.changed = make(chan struct{})
+}
+
+// _purge removes the expired locks on name
+//
+// call with mu held
+func (lm *lockManager) _purge(name string, now time.Time) {
+	locks := lm.locks[name]
+	kept := locks[:0]
+	for _, l := range locks {
+		if !l.expired(now) {
+			kept = append(kept, l)
+		}
+	}
+	if len(kept) == len(locks) {
+		return
+	}
+	if len(kept) == 0 {
+		delete(lm.locks, name)
+	} else {
+		lm.locks[name] = kept
+	}
+	lm._changed()
+}
+
+// _conflict returns the first lock on name which conflicts with lock
+// or nil if there isn't one
+//
+// call with mu held
+func (lm *lockManager) _conflict(name string, lock *Lock) *Lock {
+	lm._purge(name, time.Now())
+	for i := range lm.locks[name] {
+		if l := &lm.locks[name][i]; l.conflicts(lock) {
+			return l
+		}
+	}
+	return nil
+}
+
+// _remove removes the locks owner holds on start..end of name,
+// shrinking or splitting any locks partly inside the range. It
+// returns true if anything was removed.
+//
+// call with mu held
+func (lm *lockManager) _remove(name, owner string, start, end int64) (removed bool) {
+	var kept []Lock
+	for _, l := range lm.locks[name] {
+		if l.Owner != owner || !l.overlaps(start, end) {
+			kept = append(kept, l)
+			continue
+		}
+		removed = true
+		if l.Start < start {
+			before := l
+			before.End = start - 1
+			kept = append(kept, before)
+		}
+		if l.End > end {
+			after := l
+			after.Start = end + 1
+			kept = append(kept, after)
+		}
+	}
+	if !removed {
+		return false
+	}
+	if len(kept) == 0 {
+		delete(lm.locks, name)
+	} else {
+		lm.locks[name] = kept
+	}
+	lm._changed()
+	return true
+}
+
+// _tryLock takes lock on name if possible, returning the conflicting
+// lock if not. Any locks the owner already holds in the range are
+// replaced.
+//
+// call with mu held
+func (lm *lockManager) _tryLock(name string, lock Lock) (conflict *Lock) {
+	if conflict = lm._conflict(name, &lock); conflict != nil {
+		return conflict
+	}
+	lm._remove(name, lock.Owner, lock.Start, lock.End)
+	lm.locks[name] = append(lm.locks[name], lock)
+	return nil
+}
+
+// checkLock checks the lock is valid
+func checkLock(lock *Lock) error {
+	if lock.Start < 0 || lock.End < lock.Start || lock.Owner == "" {
+		return EINVAL
+	}
+	if lock.Type != LockShared && lock.Type != LockExclusive {
+		return EINVAL
+	}
+	return nil
+}
+
+// TryLockFile takes the advisory lock on the file name, failing with
+// EAGAIN if a conflicting lock is held.
+//
+// Any locks the owner already holds on the range are replaced, so a
+// lock can be upgraded or downgraded.
+func (vfs *VFS) TryLockFile(name string, lock Lock) error {
+	if err := checkLock(&lock); err != nil {
+		return err
+	}
+	lm := vfs.locks
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	if lm._tryLock(lockName(name), lock) != nil {
+		return EAGAIN
+	}
+	return nil
+}
+
+// LockFile takes the advisory lock on the file name, waiting for any
+// conflicting locks to be released or expire. It returns the error
+// from ctx if it is cancelled or times out first.
+//
+// Any locks the owner already holds on the range are replaced, so a
+// lock can be upgraded or downgraded.
+func (vfs *VFS) LockFile(ctx context.Context, name string, lock Lock) error {
+	if err := checkLock(&lock); err != nil {
+		return err
+	}
+	name = lockName(name)
+	lm := vfs.locks
+	for {
+		lm.mu.Lock()
+		conflict := lm._tryLock(name, lock)
+		if conflict == nil {
+			lm.mu.Unlock()
+			return nil
+		}
+		changed, expires := lm.changed, conflict.Expires
+		lm.mu.Unlock()
+
+		// Wait for a lock to be released or the conflicting
+		// lock to expire
+		var (
+			timer   *time.Timer
+			expired <-chan time.Time
+		)
+		if !expires.IsZero() {
+			timer = time.NewTimer(time.Until(expires))
+			expired = timer.C
+		}
+		select {
+		case <-changed:
+		case <-expired:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// UnlockFile releases the advisory locks owner holds on the bytes
+// start to end inclusive of the file name. Locks partly inside the
+// range are shrunk or split.
+//
+// It returns true if any locks were released.
+func (vfs *VFS) UnlockFile(name, owner string, start, end int64) bool {
+	lm := vfs.locks
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm._remove(lockName(name), owner, start, end)
+}
+
+// UnlockOwner releases all the advisory locks owner holds on any
+// file, including locks which have moved with a renamed file.
+//
+// It returns true if any locks were released.
+func (vfs *VFS) UnlockOwner(owner string) (removed bool) {
+	lm := vfs.locks
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	for name := range lm.locks {
+		if lm._remove(name, owner, 0, LockEOF) {
+			removed = true
+		}
+	}
+	return removed
+}
+
+// TestLock returns the first lock held on the file name which
+// conflicts with lock or nil if lock could be taken.
+func (vfs *VFS) TestLock(name string, lock Lock) *Lock {
+	lm := vfs.locks
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	conflict := lm._conflict(lockName(name), &lock)
+	if conflict == nil {
+		return nil
+	}
+	l := *conflict
+	return &l
+}
+
+// Locks returns the advisory locks held on the file name
+func (vfs *VFS) Locks(name string) []Lock {
+	name = lockName(name)
+	lm := vfs.locks
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	lm._purge(name, time.Now())
+	return append([]Lock(nil), lm.locks[name]...)
+}
+
+// _clear removes the locks on name and anything inside it if it is
+// a directory. It returns true if anything was removed.
+//
+// call with mu held
+func (lm *lockManager) _clear(name string) (removed bool) {
+	prefix := name + "/"
+	for lockedName := range lm.locks {
+		if lockedName == name || name == "" || strings.HasPrefix(lockedName, prefix) {
+			delete(lm.locks, lockedName)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// remove is called when the file or directory name is removed to
+// drop any locks on it
+func (lm *lockManager) remove(name string) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	if lm._clear(lockName(name)) {
+		lm._changed()
+	}
+}
+
+// rename is called when the file or directory oldName is renamed to
+// newName to move any locks on it. Any locks on a file which was
+// overwritten by the rename are dropped.
+func (lm *lockManager) rename(oldName, newName string) {
+	oldName, newName = lockName(oldName), lockName(newName)
+	if oldName == newName {
+		return
+	}
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	changed := lm._clear(newName)
+	oldPrefix := oldName + "/"
+	moved := make(map[string][]Lock)
+	for lockedName, locks := range lm.locks {
+		switch {
+		case lockedName == oldName:
+			moved[newName] = locks
+		case strings.HasPrefix(lockedName, oldPrefix):
+			moved[path.Join(newName, lockedName[len(oldPrefix):])] = locks
+		default:
+			continue
+		}
+		delete(lm.locks, lockedName)
+	}
+	for lockedName, locks := range moved {
+		lm.locks[lockedName] = locks
+		changed = true
+	}
+	if changed {
+		lm._changed()
+	}
+}
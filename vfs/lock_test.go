@@ -0,0 +1,177 @@
+package vfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func wholeFile(owner string, lockType LockType) Lock {
+	return Lock{Owner: owner, Type: lockType, Start: 0, End: LockEOF}
+}
+
+func TestLockTypeString(t *testing.T) {
+	assert.Equal(t, "shared", LockShared.String())
+	assert.Equal(t, "exclusive", LockExclusive.String())
+	assert.Equal(t, "LockType(7)", LockType(7).String())
+}
+
+func TestLockName(t *testing.T) {
+	assert.Equal(t, "", lockName(""))
+	assert.Equal(t, "", lockName("/"))
+	assert.Equal(t, "dir/file", lockName("/dir//file/"))
+	assert.Equal(t, "file", lockName("dir/../file"))
+}
+
+func TestLockInvalid(t *testing.T) {
+	_, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+
+	assert.Equal(t, EINVAL, vfs.TryLockFile("file", Lock{Type: LockShared, End: LockEOF}))
+	assert.Equal(t, EINVAL, vfs.TryLockFile("file", Lock{Owner: "a", Start: 10, End: 9}))
+	assert.Equal(t, EINVAL, vfs.TryLockFile("file", Lock{Owner: "a", Start: -1, End: 9}))
+	assert.Equal(t, EINVAL, vfs.TryLockFile("file", Lock{Owner: "a", Type: 7, End: 9}))
+	assert.Equal(t, EINVAL, vfs.LockFile(context.Background(), "file", Lock{End: 9}))
+}
+
+func TestLockSharedExclusive(t *testing.T) {
+	_, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+
+	// Many shared locks
+	require.NoError(t, vfs.TryLockFile("file", wholeFile("a", LockShared)))
+	require.NoError(t, vfs.TryLockFile("/file", wholeFile("b", LockShared)))
+	assert.Len(t, vfs.Locks("file"), 2)
+
+	// Exclusive conflicts with shared
+	assert.Equal(t, EAGAIN, vfs.TryLockFile("file", wholeFile("c", LockExclusive)))
+	conflict := vfs.TestLock("file", wholeFile("c", LockExclusive))
+	require.NotNil(t, conflict)
+	assert.Equal(t, LockShared, conflict.Type)
+
+	// Other files aren't affected
+	require.NoError(t, vfs.TryLockFile("file2", wholeFile("c", LockExclusive)))
+	assert.Equal(t, EAGAIN, vfs.TryLockFile("file2", wholeFile("a", LockShared)))
+
+	// Can't upgrade while another shared lock is held
+	assert.Equal(t, EAGAIN, vfs.TryLockFile("file", wholeFile("a", LockExclusive)))
+	assert.True(t, vfs.UnlockFile("file", "b", 0, LockEOF))
+	assert.False(t, vfs.UnlockFile("file", "b", 0, LockEOF))
+
+	// Now can upgrade and the lock is replaced
+	require.NoError(t, vfs.TryLockFile("file", wholeFile("a", LockExclusive)))
+	assert.Equal(t, []Lock{wholeFile("a", LockExclusive)}, vfs.Locks("file"))
+	assert.Nil(t, vfs.TestLock("file", wholeFile("a", LockShared)))
+	assert.NotNil(t, vfs.TestLock("file", wholeFile("b", LockShared)))
+
+	assert.True(t, vfs.UnlockFile("file", "a", 0, LockEOF))
+	assert.Empty(t, vfs.Locks("file"))
+}
+
+func TestLockRanges(t *testing.T) {
+	_, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+
+	require.NoError(t, vfs.TryLockFile("file", Lock{Owner: "a", Type: LockExclusive, Start: 0, End: 99}))
+	require.NoError(t, vfs.TryLockFile("file", Lock{Owner: "b", Type: LockExclusive, Start: 100, End: 199}))
+	assert.Equal(t, EAGAIN, vfs.TryLockFile("file", Lock{Owner: "b", Type: LockShared, Start: 99, End: 100}))
+
+	// Unlocking the middle splits the lock
+	assert.True(t, vfs.UnlockFile("file", "a", 10, 19))
+	assert.Equal(t, []Lock{
+		{Owner: "a", Type: LockExclusive, Start: 0, End: 9},
+		{Owner: "a", Type: LockExclusive, Start: 20, End: 99},
+		{Owner: "b", Type: LockExclusive, Start: 100, End: 199},
+	}, vfs.Locks("file"))
+	require.NoError(t, vfs.TryLockFile("file", Lock{Owner: "c", Type: LockShared, Start: 10, End: 19}))
+	assert.Equal(t, EAGAIN, vfs.TryLockFile("file", Lock{Owner: "c", Type: LockShared, Start: 10, End: 20}))
+}
+
+func TestLockExpires(t *testing.T) {
+	_, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+
+	lock := wholeFile("a", LockExclusive)
+	lock.Expires = time.Now().Add(-time.Second)
+	require.NoError(t, vfs.TryLockFile("file", lock))
+	assert.Empty(t, vfs.Locks("file"))
+
+	// LockFile waits for the lock to expire
+	lock.Expires = time.Now().Add(50 * time.Millisecond)
+	require.NoError(t, vfs.TryLockFile("file", lock))
+	start := time.Now()
+	require.NoError(t, vfs.LockFile(context.Background(), "file", wholeFile("b", LockExclusive)))
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+	assert.Equal(t, []Lock{wholeFile("b", LockExclusive)}, vfs.Locks("file"))
+}
+
+func TestLockFileWait(t *testing.T) {
+	_, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+
+	require.NoError(t, vfs.TryLockFile("file", wholeFile("a", LockExclusive)))
+
+	// Times out
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, vfs.LockFile(ctx, "file", wholeFile("b", LockShared)))
+
+	// Woken up by the unlock
+	done := make(chan error)
+	go func() {
+		done <- vfs.LockFile(context.Background(), "file", wholeFile("b", LockShared))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("LockFile returned early: %v", err)
+	default:
+	}
+	vfs.UnlockFile("file", "a", 0, LockEOF)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("LockFile didn't return")
+	}
+	assert.Equal(t, []Lock{wholeFile("b", LockShared)}, vfs.Locks("file"))
+}
+
+func TestLockRenameRemove(t *testing.T) {
+	r, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+	r.WriteObject(context.Background(), "file1", "file1 contents", t1)
+	r.WriteObject(context.Background(), "file2", "file2 contents", t1)
+	r.WriteObject(context.Background(), "dir/file3", "file3 contents", t1)
+
+	require.NoError(t, vfs.TryLockFile("file1", wholeFile("a", LockExclusive)))
+	require.NoError(t, vfs.TryLockFile("file2", wholeFile("b", LockExclusive)))
+	require.NoError(t, vfs.TryLockFile("dir/file3", wholeFile("c", LockShared)))
+
+	// Renaming over file2 moves the lock from file1 and drops
+	// the lock on the file overwritten
+	require.NoError(t, vfs.Rename("file1", "file2"))
+	assert.Empty(t, vfs.Locks("file1"))
+	assert.Equal(t, []Lock{wholeFile("a", LockExclusive)}, vfs.Locks("file2"))
+
+	// Renaming a directory moves the locks on its contents
+	if r.Fremote.Features().DirMove != nil || r.Fremote.Features().Move != nil {
+		require.NoError(t, vfs.Rename("dir", "dir2"))
+		assert.Empty(t, vfs.Locks("dir/file3"))
+		assert.Equal(t, []Lock{wholeFile("c", LockShared)}, vfs.Locks("dir2/file3"))
+	}
+
+	// Locks are released by owner wherever they have moved to
+	assert.True(t, vfs.UnlockOwner("c"))
+	assert.False(t, vfs.UnlockOwner("c"))
+	assert.Empty(t, vfs.Locks("dir/file3"))
+	assert.Empty(t, vfs.Locks("dir2/file3"))
+
+	// Removing the file drops its locks
+	require.NoError(t, vfs.Remove("file2"))
+	assert.Empty(t, vfs.Locks("file2"))
+	require.NoError(t, vfs.TryLockFile("file2", wholeFile("d", LockExclusive)))
+}
@@ -0,0 +1,59 @@
+package vfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// errNoOverlay is returned if the VFS isn't using an overlay
+var errNoOverlay = errors.New("the VFS isn't using an overlay - need --vfs-overlay")
+
+// OverlayStatus returns the files changed in the overlay and the
+// paths deleted from the remote.
+func (vfs *VFS) OverlayStatus(ctx context.Context) (changed, deleted []string, err error) {
+	if vfs.overlay == nil {
+		return nil, nil, errNoOverlay
+	}
+	return vfs.overlay.Status(ctx)
+}
+
+// checkOverlayIdle returns an error if files are being written to the
+// overlay
+//
+// It is called by the overlay with changes blocked so nothing can
+// start writing before the commit or discard.
+func (vfs *VFS) checkOverlayIdle() error {
+	writers := vfs.root.countActiveWriters()
+	cacheInUse := 0
+	if vfs.cache != nil {
+		cacheInUse = vfs.cache.TotalInUse()
+	}
+	if writers != 0 || cacheInUse != 0 {
+		return fmt.Errorf("overlay busy with %d writers active and %d cache items in use - try again later", writers, cacheInUse)
+	}
+	return nil
+}
+
+// OverlayCommit writes the changes in the overlay to the remote and
+// empties the overlay.
+//
+// It fails if any files are open or waiting to be written back.
+func (vfs *VFS) OverlayCommit(ctx context.Context) error {
+	if vfs.overlay == nil {
+		return errNoOverlay
+	}
+	defer vfs.FlushDirCache()
+	return vfs.overlay.Commit(ctx, vfs.checkOverlayIdle)
+}
+
+// OverlayDiscard throws away the changes in the overlay.
+//
+// It fails if any files are open or waiting to be written back.
+func (vfs *VFS) OverlayDiscard(ctx context.Context) error {
+	if vfs.overlay == nil {
+		return errNoOverlay
+	}
+	defer vfs.FlushDirCache()
+	return vfs.overlay.Discard(ctx, vfs.checkOverlayIdle)
+}
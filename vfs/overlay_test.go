@@ -0,0 +1,89 @@
+package vfs
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVFSOverlay(t *testing.T) {
+	// Keep the overlay out of the real cache directory
+	oldCacheDir := config.GetCacheDir()
+	require.NoError(t, config.SetCacheDir(t.TempDir()))
+	defer func() {
+		require.NoError(t, config.SetCacheDir(oldCacheDir))
+	}()
+
+	opt := vfscommon.DefaultOpt
+	opt.CacheMode = vfscommon.CacheModeWrites
+	opt.WriteBack = 0
+	opt.Overlay = true
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+	ctx := context.Background()
+
+	file1 := r.WriteObject(ctx, "file1", "file1 contents", t1)
+	file2 := r.WriteObject(ctx, "file2", "file2 contents", t1)
+	file3 := fstest.NewItem("file3", "file3 contents", t1)
+
+	// Make changes through the VFS
+	require.NoError(t, vfs.Remove("file1"))
+	require.NoError(t, vfs.Rename("file2", "renamed"))
+	fd, err := vfs.OpenFile("file3", os.O_WRONLY|os.O_CREATE, 0600)
+	require.NoError(t, err)
+	_, err = fd.WriteString("file3 contents")
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	require.NoError(t, vfs.Chtimes("file3", t1, t1))
+	vfs.WaitForWriters(waitForWritersDelay)
+
+	// The remote is unchanged
+	r.CheckRemoteItems(t, file1, file2)
+
+	changed, deleted, err := vfs.OverlayStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file3", "renamed"}, changed)
+	assert.Equal(t, []string{"file1", "file2"}, deleted)
+
+	// Commit is refused while a file is open for write
+	fd, err = vfs.OpenFile("file4", os.O_WRONLY|os.O_CREATE, 0600)
+	require.NoError(t, err)
+	assert.Error(t, vfs.OverlayCommit(ctx))
+	require.NoError(t, fd.Close())
+	vfs.WaitForWriters(waitForWritersDelay)
+	require.NoError(t, vfs.Remove("file4"))
+
+	require.NoError(t, vfs.OverlayCommit(ctx))
+	file2.Path = "renamed"
+	r.CheckRemoteItems(t, file2, file3)
+	changed, deleted, err = vfs.OverlayStatus(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+	assert.Empty(t, deleted)
+
+	// Discard puts the VFS back to the remote
+	require.NoError(t, vfs.Remove("file3"))
+	_, err = vfs.Stat("file3")
+	assert.Equal(t, ENOENT, err)
+	require.NoError(t, vfs.OverlayDiscard(ctx))
+	_, err = vfs.Stat("file3")
+	assert.NoError(t, err)
+	r.CheckRemoteItems(t, file2, file3)
+}
+
+func TestVFSOverlayNotEnabled(t *testing.T) {
+	_, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, _, err := vfs.OverlayStatus(ctx)
+	assert.Equal(t, errNoOverlay, err)
+	assert.Equal(t, errNoOverlay, vfs.OverlayCommit(ctx))
+	assert.Equal(t, errNoOverlay, vfs.OverlayDiscard(ctx))
+}
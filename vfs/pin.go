@@ -0,0 +1,94 @@
+package vfs
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs/vfscommon"
+)
+
+// PinXattr is the name of the extended attribute which pins a file
+// when set to "1" and unpins it when removed or set to "0".
+const PinXattr = "user.rclone.pinned"
+
+// errPinNeedsCache is returned if the cache mode can't pin files
+var errPinNeedsCache = errors.New("pinning files needs --vfs-cache-mode full")
+
+// Pin marks the file or all the files in the directory tree at name
+// to be downloaded completely into the VFS cache and never evicted.
+//
+// The files are downloaded in the background. It returns the number
+// of files pinned.
+func (vfs *VFS) Pin(name string) (files int, err error) {
+	return vfs.setPinned(name, true)
+}
+
+// Unpin removes the pin from the file or all the files in the
+// directory tree at name so they can be evicted from the cache in the
+// normal way.
+//
+// It returns the number of files unpinned.
+func (vfs *VFS) Unpin(name string) (files int, err error) {
+	return vfs.setPinned(name, false)
+}
+
+// IsPinned returns whether the file at name is pinned. Directories
+// are never pinned themselves.
+func (vfs *VFS) IsPinned(name string) (bool, error) {
+	node, err := vfs.Stat(name)
+	if err != nil {
+		return false, err
+	}
+	file, ok := node.(*File)
+	if !ok || vfs.cache == nil {
+		return false, nil
+	}
+	return vfs.cache.IsPinned(file.cachePath()), nil
+}
+
+// setPinned pins or unpins the tree at name
+func (vfs *VFS) setPinned(name string, pinned bool) (files int, err error) {
+	if vfs.cache == nil || vfs.Opt.CacheMode < vfscommon.CacheModeFull {
+		return 0, errPinNeedsCache
+	}
+	node, err := vfs.Stat(name)
+	if err != nil {
+		return 0, err
+	}
+	err = vfs.setPinnedNode(node, pinned, &files)
+	return files, err
+}
+
+// setPinnedNode pins or unpins node and anything below it, counting
+// the files in files.
+//
+// It carries on after errors, returning the last one.
+func (vfs *VFS) setPinnedNode(node Node, pinned bool, files *int) (err error) {
+	switch x := node.(type) {
+	case *File:
+		if pinned {
+			err = vfs.cache.Pin(x.cachePath(), x.getObject())
+		} else {
+			err = vfs.cache.Unpin(x.cachePath())
+		}
+		if err != nil {
+			fs.Errorf(x, "Failed to set pinned=%v: %v", pinned, err)
+			return err
+		}
+		*files++
+	case *Dir:
+		nodes, err := x.ReadDirAll()
+		if err != nil {
+			return fmt.Errorf("failed to read directory to pin: %w", err)
+		}
+		var lastErr error
+		for _, child := range nodes {
+			if err := vfs.setPinnedNode(child, pinned, files); err != nil {
+				lastErr = err
+			}
+		}
+		return lastErr
+	}
+	return nil
+}
@@ -57,6 +57,14 @@ func init() {
 		Path:  "vfs/refresh",
 		Fn:    rcRefresh,
 		Title: "Refresh the directory cache.",
+		Params: []rc.Param{
+			{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"},
+			{Name: "dir", Type: rc.TypeString, Help: "directory to refresh - any parameter starting with dir may be used"},
+			{Name: "recursive", Type: rc.TypeString, Help: `set to "true" to refresh the whole tree`},
+		},
+		Returns: []rc.Param{
+			{Name: "result", Type: rc.TypeObject, Required: true, Help: "the result for each directory refreshed"},
+		},
 		Help: `
 This reads the directories for the specified paths and freshens the
 directory cache.
@@ -172,6 +180,14 @@ func init() {
 		Path:  "vfs/forget",
 		Fn:    rcForget,
 		Title: "Forget files or directories in the directory cache.",
+		Params: []rc.Param{
+			{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"},
+			{Name: "file", Type: rc.TypeString, Help: "file to forget - any parameter starting with file may be used"},
+			{Name: "dir", Type: rc.TypeString, Help: "directory to forget - any parameter starting with dir may be used"},
+		},
+		Returns: []rc.Param{
+			{Name: "forgotten", Type: rc.TypeArray, Items: rc.TypeString, Required: true, Help: "the paths forgotten"},
+		},
 		Help: `
 This forgets the paths in the directory cache causing them to be
 re-read from the remote when needed.
@@ -290,6 +306,17 @@ func init() {
 		Path:  "vfs/poll-interval",
 		Fn:    rcPollInterval,
 		Title: "Get the status or update the value of the poll-interval option.",
+		Params: []rc.Param{
+			{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"},
+			{Name: "interval", Type: rc.TypeString, Help: `the new poll interval e.g. "5m" or "0" to disable`},
+			{Name: "timeout", Type: rc.TypeString, Help: "how long to wait for the new value to apply"},
+		},
+		Returns: []rc.Param{
+			{Name: "enabled", Type: rc.TypeBoolean, Required: true, Help: "whether polling is enabled"},
+			{Name: "supported", Type: rc.TypeBoolean, Required: true, Help: "whether the remote supports polling"},
+			{Name: "interval", Type: rc.TypeObject, Required: true, Help: "the poll interval as raw, seconds and string"},
+			{Name: "timeout", Type: rc.TypeBoolean, Help: "set if the timeout was reached"},
+		},
 		Help: `
 Without any parameter given this returns the current status of the
 poll-interval setting.
@@ -362,6 +389,9 @@ func init() {
 	rc.Add(rc.Call{
 		Path:  "vfs/list",
 		Title: "List active VFSes.",
+		Returns: []rc.Param{
+			{Name: "vfses", Type: rc.TypeArray, Items: rc.TypeString, Required: true, Help: "the names of the active VFSes"},
+		},
 		Help: `
 This lists the active VFSes.
 
@@ -392,8 +422,16 @@ func rcList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 
 func init() {
 	rc.Add(rc.Call{
-		Path:  "vfs/stats",
-		Title: "Stats for a VFS.",
+		Path:   "vfs/stats",
+		Title:  "Stats for a VFS.",
+		Params: []rc.Param{{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"}},
+		Returns: []rc.Param{
+			{Name: "fs", Type: rc.TypeString, Required: true, Help: "name of the VFS"},
+			{Name: "inUse", Type: rc.TypeInteger, Required: true, Help: "number of users of the VFS"},
+			{Name: "metadataCache", Type: rc.TypeObject, Required: true, Help: "counts of the dirs and files in the directory cache"},
+			{Name: "diskCache", Type: rc.TypeObject, Help: "stats for the disk cache if in use"},
+			{Name: "opt", Type: rc.TypeObject, Required: true, Help: "the options of the VFS"},
+		},
 		Help: `
 This returns stats for the selected VFS.
 
@@ -401,12 +439,31 @@ This returns stats for the selected VFS.
         // Status of the disk cache - only present if --vfs-cache-mode > off
         "diskCache": {
             "bytesUsed": 0,
+            // files changed on the remote before they were written
+            // back, by the --vfs-write-back-conflict policy applied -
+            // not counted with the overwrite policy
+            "conflictsKeptBoth": 0,
+            "conflictsRefused": 0,
+            // set if --vfs-cache-encrypt is in use
+            "encrypted": false,
             "erroredFiles": 0,
+            "evictions": 0,
             "files": 0,
             "hashType": 1,
+            "hits": 0,
+            "misses": 0,
             "outOfSpace": false,
             "path": "/home/user/.cache/rclone/vfs/local/mnt/a",
             "pathMeta": "/home/user/.cache/rclone/vfsMeta/local/mnt/a",
+            // bytes in the cache for pinned files, number of pinned
+            // files and how many are still downloading
+            "pinnedBytes": 0,
+            "pinnedFiles": 0,
+            "pinnedPending": 0,
+            // files whose upload was refused by
+            // --vfs-write-back-conflict refuse which are waiting to
+            // be written to again
+            "refusedFiles": 0,
             "uploadsInProgress": 0,
             "uploadsQueued": 0
         },
@@ -437,3 +494,322 @@ func rcStats(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	}
 	return vfs.Stats(), nil
 }
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "vfs/pin",
+		Fn:    rcPin,
+		Title: "Pin files in the VFS cache.",
+		Params: []rc.Param{
+			{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"},
+			{Name: "path", Type: rc.TypeString, Required: true, Help: "file or directory to pin - any parameter starting with path may be used"},
+		},
+		Returns: []rc.Param{
+			{Name: "result", Type: rc.TypeObject, Required: true, Help: "the result for each path"},
+			{Name: "files", Type: rc.TypeInteger, Required: true, Help: "the number of files pinned"},
+		},
+		Help: `
+This marks files to be downloaded completely into the VFS cache in the
+background and never evicted from it, so they are available offline.
+Pinning a directory pins all the files in it and its subdirectories
+at the time of the call.
+
+This needs --vfs-cache-mode full. Pins are kept in the cache metadata
+so they survive a restart.
+
+Pass the files or directories in as path=path. Any parameter key
+starting with path will be pinned, e.g.
+
+    rclone rc vfs/pin path=music/favourite path2=docs/report.pdf
+
+Files can also be pinned on a mount by setting the
+` + "`" + PinXattr + "`" + ` extended attribute to "1".
+
+The number and size of the pinned files is shown in vfs/stats.
+` + getVFSHelp,
+	})
+	rc.Add(rc.Call{
+		Path:  "vfs/unpin",
+		Fn:    rcUnpin,
+		Title: "Unpin files in the VFS cache.",
+		Params: []rc.Param{
+			{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"},
+			{Name: "path", Type: rc.TypeString, Required: true, Help: "file or directory to unpin - any parameter starting with path may be used"},
+		},
+		Returns: []rc.Param{
+			{Name: "result", Type: rc.TypeObject, Required: true, Help: "the result for each path"},
+			{Name: "files", Type: rc.TypeInteger, Required: true, Help: "the number of files unpinned"},
+		},
+		Help: `
+This removes the pin from files pinned with vfs/pin, so they can be
+evicted from the VFS cache in the normal way. Unpinning a directory
+unpins all the files in it and its subdirectories.
+
+Pass the files or directories in as path=path. Any parameter key
+starting with path will be unpinned, e.g.
+
+    rclone rc vfs/unpin path=music/favourite
+` + getVFSHelp,
+	})
+}
+
+func rcPin(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	return rcSetPinned(in, true)
+}
+
+func rcUnpin(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	return rcSetPinned(in, false)
+}
+
+// rcSetPinned pins or unpins the paths in in for the rc
+func rcSetPinned(in rc.Params, pin bool) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(in) == 0 {
+		return nil, errors.New("need at least one path parameter")
+	}
+	result := map[string]string{}
+	total := 0
+	for k, v := range in {
+		path, ok := v.(string)
+		if !ok {
+			return out, fmt.Errorf("value must be string %q=%v", k, v)
+		}
+		if !strings.HasPrefix(k, "path") {
+			return out, fmt.Errorf("unknown key %q", k)
+		}
+		path = strings.Trim(path, "/")
+		var files int
+		if pin {
+			files, err = vfs.Pin(path)
+		} else {
+			files, err = vfs.Unpin(path)
+		}
+		total += files
+		if err != nil {
+			result[path] = err.Error()
+		} else {
+			result[path] = "OK"
+		}
+	}
+	out = rc.Params{
+		"result": result,
+		"files":  total,
+	}
+	return out, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:   "vfs/overlay-status",
+		Fn:     rcOverlayStatus,
+		Title:  "Show the changes in the VFS overlay.",
+		Params: []rc.Param{{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"}},
+		Returns: []rc.Param{
+			{Name: "changed", Type: rc.TypeArray, Items: rc.TypeString, Required: true, Help: "files written, created or renamed in the overlay"},
+			{Name: "deleted", Type: rc.TypeArray, Items: rc.TypeString, Required: true, Help: "files and directories deleted from the remote"},
+		},
+		Help: `
+This shows the changes kept in the overlay of a VFS using
+--vfs-overlay which haven't been committed to the remote.
+
+    rclone rc vfs/overlay-status
+
+    {
+        "changed": [
+            "dir/new-file.txt"
+        ],
+        "deleted": [
+            "old-dir"
+        ]
+    }
+` + getVFSHelp,
+	})
+	rc.Add(rc.Call{
+		Path:   "vfs/overlay-commit",
+		Fn:     rcOverlayCommit,
+		Title:  "Write the changes in the VFS overlay to the remote.",
+		Params: []rc.Param{{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"}},
+		Help: `
+This applies the changes kept in the overlay of a VFS using
+--vfs-overlay to the remote. Deleted files and directories are deleted
+from the remote, then the changed files are uploaded. The overlay is
+empty afterwards.
+
+This fails if any files are open for write or waiting to be written
+back to the overlay, in which case try again later.
+` + getVFSHelp,
+	})
+	rc.Add(rc.Call{
+		Path:   "vfs/overlay-discard",
+		Fn:     rcOverlayDiscard,
+		Title:  "Throw away the changes in the VFS overlay.",
+		Params: []rc.Param{{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"}},
+		Help: `
+This throws away the changes kept in the overlay of a VFS using
+--vfs-overlay so the VFS shows the remote as it is.
+
+This fails if any files are open for write or waiting to be written
+back to the overlay, in which case try again later.
+` + getVFSHelp,
+	})
+}
+
+func rcOverlayStatus(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	changed, deleted, err := vfs.OverlayStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Return empty lists rather than null
+	if changed == nil {
+		changed = []string{}
+	}
+	if deleted == nil {
+		deleted = []string{}
+	}
+	return rc.Params{
+		"changed": changed,
+		"deleted": deleted,
+	}, nil
+}
+
+func rcOverlayCommit(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	return nil, vfs.OverlayCommit(ctx)
+}
+
+func rcOverlayDiscard(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	return nil, vfs.OverlayDiscard(ctx)
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:   "vfs/trash-list",
+		Fn:     rcTrashList,
+		Title:  "List the files in the VFS trash.",
+		Params: []rc.Param{{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"}},
+		Returns: []rc.Param{
+			{Name: "trash", Type: rc.TypeArray, Items: rc.TypeObject, Required: true, Help: "the files in the trash, oldest first"},
+		},
+		Help: `
+This lists the files deleted from a VFS using --vfs-trash, oldest
+first.
+
+    rclone rc vfs/trash-list
+
+    {
+        "trash": [
+            {
+                "path": "2023-04-05T060708.123456789Z/dir/file.txt",
+                "original": "dir/file.txt",
+                "deleted": "2023-04-05T06:07:08.123456789Z",
+                "size": 1234
+            }
+        ]
+    }
+
+The path can be passed to vfs/trash-restore to restore the file.
+` + getVFSHelp,
+	})
+	rc.Add(rc.Call{
+		Path:  "vfs/trash-restore",
+		Fn:    rcTrashRestore,
+		Title: "Restore a file from the VFS trash.",
+		Params: []rc.Param{
+			{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"},
+			{Name: "path", Type: rc.TypeString, Required: true, Help: "the path of the file in the trash as returned by vfs/trash-list"},
+		},
+		Help: `
+This moves a file in the trash of a VFS using --vfs-trash back to
+where it was deleted from, e.g.
+
+    rclone rc vfs/trash-restore path=2023-04-05T060708.123456789Z/dir/file.txt
+
+It fails if a file exists at the original path.
+` + getVFSHelp,
+	})
+	rc.Add(rc.Call{
+		Path:  "vfs/trash-empty",
+		Fn:    rcTrashEmpty,
+		Title: "Empty the VFS trash.",
+		Params: []rc.Param{
+			{Name: "fs", Type: rc.TypeString, Help: "the VFS to use - needed if more than one is active"},
+			{Name: "maxAge", Type: rc.TypeString, Help: "only delete files which were deleted longer ago than this, e.g. 30d - default is all files"},
+		},
+		Returns: []rc.Param{
+			{Name: "removed", Type: rc.TypeArray, Items: rc.TypeString, Required: true, Help: "the directories removed from the trash"},
+		},
+		Help: `
+This permanently deletes the files in the trash of a VFS using
+--vfs-trash. If maxAge is set only files deleted longer ago than it
+are deleted, so this can be run regularly to expire old files, e.g.
+
+    rclone rc vfs/trash-empty maxAge=30d
+` + getVFSHelp,
+	})
+}
+
+func rcTrashList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := vfs.TrashList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Return an empty list rather than null
+	if entries == nil {
+		entries = []TrashEntry{}
+	}
+	return rc.Params{
+		"trash": entries,
+	}, nil
+}
+
+func rcTrashRestore(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	trashPath, err := in.GetString("path")
+	if err != nil {
+		return nil, err
+	}
+	return nil, vfs.TrashRestore(ctx, trashPath)
+}
+
+func rcTrashEmpty(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	maxAge, err := in.GetDuration("maxAge")
+	if rc.NotErrParamNotFound(err) {
+		return nil, err
+	}
+	removed, err := vfs.TrashEmpty(ctx, maxAge)
+	if err != nil {
+		return nil, err
+	}
+	// Return an empty list rather than null
+	if removed == nil {
+		removed = []string{}
+	}
+	return rc.Params{
+		"removed": removed,
+	}, nil
+}
@@ -2,7 +2,9 @@ package vfs
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/rc"
@@ -131,3 +133,68 @@ func TestRcStats(t *testing.T) {
 	assert.Equal(t, 1, out["metadataCache"].(rc.Params)["dirs"])
 	assert.Equal(t, vfs.Opt, out["opt"].(vfscommon.Options))
 }
+
+func TestRcStatsHidesKey(t *testing.T) {
+	if *fstest.RemoteName != "" {
+		t.Skip("Skipping test on non local remote")
+	}
+	opt := vfscommon.DefaultOpt
+	opt.CacheEncryptKey = "potato-passphrase"
+	_, _, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+	call := rc.Calls.Get("vfs/stats")
+	require.NotNil(t, call)
+	out, err := call.Fn(context.Background(), nil)
+	require.NoError(t, err)
+	data, err := json.Marshal(out)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "potato-passphrase")
+	assert.Contains(t, string(data), `"CacheEncryptKey":"*****"`)
+}
+
+func TestRcPin(t *testing.T) {
+	// Pinning needs the cache
+	r, _, cleanup, call := rcNewRun(t, "vfs/pin")
+	out, err := call.Fn(context.Background(), rc.Params{"path": "file"})
+	require.NoError(t, err)
+	assert.Equal(t, errPinNeedsCache.Error(), out["result"].(map[string]string)["file"])
+	cleanup()
+
+	opt := vfscommon.DefaultOpt
+	opt.CacheMode = vfscommon.CacheModeFull
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+	r.WriteObject(context.Background(), "dir/file1", "file1 contents", t1)
+	r.WriteObject(context.Background(), "dir/sub/file2", "file2 contents", t2)
+
+	out, err = call.Fn(context.Background(), rc.Params{"path": "dir", "path2": "notfound"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, out["files"])
+	assert.Equal(t, "OK", out["result"].(map[string]string)["dir"])
+	assert.NotEqual(t, "OK", out["result"].(map[string]string)["notfound"])
+	for _, name := range []string{"dir/file1", "dir/sub/file2"} {
+		pinned, err := vfs.IsPinned(name)
+		require.NoError(t, err)
+		assert.True(t, pinned, name)
+	}
+	assert.Equal(t, 2, vfs.Stats()["diskCache"].(rc.Params)["pinnedFiles"])
+
+	// Wait for the pinned files to download so they don't outlive the test
+	require.Eventually(t, func() bool {
+		return vfs.Stats()["diskCache"].(rc.Params)["pinnedPending"] == 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	unpin := rc.Calls.Get("vfs/unpin")
+	out, err = unpin.Fn(context.Background(), rc.Params{"path": "dir/sub"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, out["files"])
+	pinned, err := vfs.IsPinned("dir/sub/file2")
+	require.NoError(t, err)
+	assert.False(t, pinned)
+	pinned, err = vfs.IsPinned("dir/file1")
+	require.NoError(t, err)
+	assert.True(t, pinned)
+
+	_, err = unpin.Fn(context.Background(), rc.Params{})
+	assert.Error(t, err)
+}
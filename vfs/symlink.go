@@ -0,0 +1,130 @@
+package vfs
+
+// With --vfs-links symlinks are stored on the remote as regular
+// files named with fs.LinkSuffix which hold the target of the link,
+// the same way as the local backend does with --links. These are
+// shown in the VFS as symlinks without the suffix.
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// maxLinkSize is the largest symlink target which will be read
+const maxLinkSize = 64 * 1024
+
+// trimLinkSuffix returns the name of the object with leaf as shown
+// in the VFS and whether it is a symlink.
+func (vfs *VFS) trimLinkSuffix(leaf string) (name string, isLink bool) {
+	if !vfs.Opt.Links || leaf == fs.LinkSuffix || !strings.HasSuffix(leaf, fs.LinkSuffix) {
+		return leaf, false
+	}
+	return leaf[:len(leaf)-len(fs.LinkSuffix)], true
+}
+
+// cachePath returns the name of the file in the VFS cache. This is
+// the name of the object on the remote so it has fs.LinkSuffix if the
+// file is a symlink.
+func (f *File) cachePath() string {
+	if f.isLink {
+		return f.Path() + fs.LinkSuffix
+	}
+	return f.Path()
+}
+
+// IsSymlink returns true if the file is a symlink
+func (f *File) IsSymlink() bool {
+	return f.isLink
+}
+
+// Readlink returns the target of the symlink
+//
+// It returns EINVAL if the file isn't a symlink.
+func (f *File) Readlink() (target string, err error) {
+	if !f.isLink {
+		return "", EINVAL
+	}
+	o, err := f.waitForValidObject()
+	if err != nil {
+		return "", err
+	}
+	in, err := o.Open(context.TODO())
+	if err != nil {
+		return "", err
+	}
+	defer fs.CheckClose(in, &err)
+	buf, err := io.ReadAll(io.LimitReader(in, maxLinkSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(buf) > maxLinkSize {
+		return "", EINVAL
+	}
+	return string(buf), nil
+}
+
+// Symlink creates a symlink called name in the directory pointing to
+// target.
+//
+// It returns ENOSYS unless --vfs-links is in use.
+func (d *Dir) Symlink(target, name string) (*File, error) {
+	if !d.vfs.Opt.Links {
+		return nil, ENOSYS
+	}
+	if d.vfs.Opt.ReadOnly {
+		return nil, EROFS
+	}
+	if target == "" || len(target) > maxLinkSize {
+		return nil, EINVAL
+	}
+	_, err := d.stat(name)
+	switch err {
+	case ENOENT:
+		// not found, carry on
+	case nil:
+		return nil, EEXIST
+	default:
+		fs.Errorf(d, "Dir.Symlink failed to read directory: %v", err)
+		return nil, err
+	}
+	remote := path.Join(d.Path(), name) + fs.LinkSuffix
+	in := io.NopCloser(strings.NewReader(target))
+	o, err := operations.RcatSize(context.TODO(), d.f, remote, in, int64(len(target)), time.Now(), nil)
+	if err != nil {
+		fs.Errorf(d, "Dir.Symlink failed to create symlink: %v", err)
+		return nil, err
+	}
+	file := newFile(d, d.Path(), o, name)
+	file.isLink = true
+	d.addObject(file)
+	return file, nil
+}
+
+// Symlink creates newname as a symlink pointing to target.
+func (vfs *VFS) Symlink(target, newname string) error {
+	dir, leaf, err := vfs.StatParent(newname)
+	if err != nil {
+		return err
+	}
+	_, err = dir.Symlink(target, leaf)
+	return err
+}
+
+// Readlink returns the target of the symlink name.
+func (vfs *VFS) Readlink(name string) (string, error) {
+	node, err := vfs.Stat(name)
+	if err != nil {
+		return "", err
+	}
+	file, ok := node.(*File)
+	if !ok {
+		return "", EINVAL
+	}
+	return file.Readlink()
+}
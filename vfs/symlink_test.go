@@ -0,0 +1,193 @@
+package vfs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVFSLinks(t *testing.T) (r *fstest.Run, vfs *VFS, cleanup func()) {
+	opt := vfscommon.DefaultOpt
+	opt.Links = true
+	return newTestVFSOpt(t, &opt)
+}
+
+func TestTrimLinkSuffix(t *testing.T) {
+	_, vfs, cleanup := newTestVFSLinks(t)
+	defer cleanup()
+
+	for _, test := range []struct {
+		in     string
+		want   string
+		isLink bool
+	}{
+		{"file", "file", false},
+		{"file" + fs.LinkSuffix, "file", true},
+		{fs.LinkSuffix, fs.LinkSuffix, false},
+		{"file" + fs.LinkSuffix + ".txt", "file" + fs.LinkSuffix + ".txt", false},
+	} {
+		got, isLink := vfs.trimLinkSuffix(test.in)
+		assert.Equal(t, test.want, got, test.in)
+		assert.Equal(t, test.isLink, isLink, test.in)
+	}
+
+	vfs.Opt.Links = false
+	got, isLink := vfs.trimLinkSuffix("file" + fs.LinkSuffix)
+	assert.Equal(t, "file"+fs.LinkSuffix, got)
+	assert.False(t, isLink)
+}
+
+func TestSymlinkRead(t *testing.T) {
+	r, vfs, cleanup := newTestVFSLinks(t)
+	defer cleanup()
+	file1 := r.WriteObject(context.Background(), "dir/link"+fs.LinkSuffix, "../file", t1)
+	r.CheckRemoteItems(t, file1)
+
+	node, err := vfs.Stat("dir/link")
+	require.NoError(t, err)
+	assert.True(t, node.IsFile())
+	assert.Equal(t, os.ModeSymlink|0777, node.Mode())
+	assert.Equal(t, "link", node.Name())
+	assert.Equal(t, "dir/link", node.Path())
+	assert.True(t, node.(*File).IsSymlink())
+
+	target, err := vfs.Readlink("dir/link")
+	require.NoError(t, err)
+	assert.Equal(t, "../file", target)
+
+	// Symlinks can't be opened
+	_, err = vfs.OpenFile("dir/link", os.O_RDONLY, 0)
+	assert.Equal(t, EINVAL, err)
+
+	// Directories and files aren't links
+	_, err = vfs.Readlink("dir")
+	assert.Equal(t, EINVAL, err)
+	_, err = vfs.Stat("dir/link" + fs.LinkSuffix)
+	assert.Equal(t, ENOENT, err)
+}
+
+func TestSymlinkDisabled(t *testing.T) {
+	r, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+	r.WriteObject(context.Background(), "link"+fs.LinkSuffix, "file", t1)
+
+	node, err := vfs.Stat("link" + fs.LinkSuffix)
+	require.NoError(t, err)
+	assert.False(t, node.(*File).IsSymlink())
+	assert.Equal(t, os.FileMode(0), node.Mode()&os.ModeSymlink)
+	_, err = vfs.Readlink("link" + fs.LinkSuffix)
+	assert.Equal(t, EINVAL, err)
+
+	assert.Equal(t, ENOSYS, vfs.Symlink("file", "link2"))
+}
+
+func TestSymlinkCreate(t *testing.T) {
+	r, vfs, cleanup := newTestVFSLinks(t)
+	defer cleanup()
+	file1 := r.WriteObject(context.Background(), "file", "file contents", t1)
+
+	require.NoError(t, vfs.Symlink("file", "link"))
+	assert.Equal(t, EEXIST, vfs.Symlink("file", "link"))
+	assert.Equal(t, EEXIST, vfs.Symlink("file", "file"))
+	assert.Equal(t, EINVAL, vfs.Symlink("", "link2"))
+
+	// The link is stored as a file holding the target
+	o, err := r.Fremote.NewObject(context.Background(), "link"+fs.LinkSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("file")), o.Size())
+	target, err := vfs.Readlink("link")
+	require.NoError(t, err)
+	assert.Equal(t, "file", target)
+
+	// It is still a link when the directory is read again
+	vfs.FlushDirCache()
+	node, err := vfs.Stat("link")
+	require.NoError(t, err)
+	assert.True(t, node.(*File).IsSymlink())
+
+	// Renaming keeps the suffix
+	require.NoError(t, vfs.Rename("link", "link2"))
+	file2 := fstest.NewItem("link2"+fs.LinkSuffix, "file", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2}, nil, fs.ModTimeNotSupported)
+
+	// Removing removes the file
+	require.NoError(t, vfs.Remove("link2"))
+	r.CheckRemoteItems(t, file1)
+}
+
+func TestSymlinkReadOnly(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.Links = true
+	opt.ReadOnly = true
+	_, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	assert.Equal(t, EROFS, vfs.Symlink("file", "link"))
+}
+
+func TestSymlinkClash(t *testing.T) {
+	r, vfs, cleanup := newTestVFSLinks(t)
+	defer cleanup()
+	r.WriteObject(context.Background(), "file", "file contents", t1)
+	r.WriteObject(context.Background(), "file"+fs.LinkSuffix, "target", t1)
+	r.WriteObject(context.Background(), "dir/file", "file contents", t1)
+	r.WriteObject(context.Background(), "dir"+fs.LinkSuffix, "target", t1)
+
+	checkEntries := func() {
+		node, err := vfs.Stat("file")
+		require.NoError(t, err)
+		assert.False(t, node.(*File).IsSymlink())
+		node, err = vfs.Stat("dir")
+		require.NoError(t, err)
+		assert.True(t, node.IsDir())
+		nodes, err := vfs.ReadDir("")
+		require.NoError(t, err)
+		assert.Len(t, nodes, 2)
+	}
+	checkEntries()
+
+	// The file or directory is shown whatever order they are listed in
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	entries, err := r.Fremote.List(context.Background(), "")
+	require.NoError(t, err)
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	root.mu.Lock()
+	err = root._readDirFromEntries(entries, nil, time.Now())
+	root.mu.Unlock()
+	require.NoError(t, err)
+	checkEntries()
+}
+
+func TestSymlinkCacheName(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.Links = true
+	opt.CacheMode = vfscommon.CacheModeFull
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+	r.WriteObject(context.Background(), "dir/link"+fs.LinkSuffix, "../file", t1)
+
+	// The link is pinned in the cache under its name on the remote
+	files, err := vfs.Pin("dir/link")
+	require.NoError(t, err)
+	assert.Equal(t, 1, files)
+	assert.True(t, vfs.cache.IsPinned("dir/link"+fs.LinkSuffix))
+	assert.False(t, vfs.cache.IsPinned("dir/link"))
+	pinned, err := vfs.IsPinned("dir/link")
+	require.NoError(t, err)
+	assert.True(t, pinned)
+
+	// and removed from the cache with it
+	require.True(t, vfs.cache.Exists("dir/link"+fs.LinkSuffix))
+	require.NoError(t, vfs.Remove("dir/link"))
+	assert.False(t, vfs.cache.Exists("dir/link"+fs.LinkSuffix))
+}
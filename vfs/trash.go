@@ -0,0 +1,259 @@
+package vfs
+
+// With --vfs-trash files removed through the VFS are moved into a
+// directory on the remote instead of being deleted. Each deletion
+// goes into a sub directory named after the time of the deletion
+// which holds the file at its original path, eg
+//
+//	.trash/2023-04-05T060708.123456789Z/dir/file.txt
+//
+// The trash directory isn't shown in the VFS.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/walk"
+	"github.com/rclone/rclone/vfs/vfscommon"
+)
+
+// trashTimeFormat is the format of the directory names in the trash
+const trashTimeFormat = "2006-01-02T150405.000000000Z"
+
+// errNoTrash is returned if the VFS isn't using a trash
+var errNoTrash = errors.New("the VFS isn't using a trash - need --vfs-trash")
+
+// lastTrashTime is the time of the previous deletion - this is used
+// to give each deletion a unique time
+var (
+	trashTimeMu   sync.Mutex
+	lastTrashTime time.Time
+)
+
+// newTrashTime returns the time for a deletion which is always
+// later than the previous one
+func newTrashTime() time.Time {
+	trashTimeMu.Lock()
+	defer trashTimeMu.Unlock()
+	now := time.Now().UTC()
+	if !now.After(lastTrashTime) {
+		now = lastTrashTime.Add(time.Nanosecond)
+	}
+	lastTrashTime = now
+	return now
+}
+
+// isTrash returns true if remote is the trash directory
+func (vfs *VFS) isTrash(remote string) bool {
+	return vfs.Opt.Trash != "" && remote == vfs.Opt.Trash
+}
+
+// trashObject moves o into the trash instead of deleting it using a
+// server side move if possible.
+func (vfs *VFS) trashObject(ctx context.Context, o fs.Object) error {
+	remote := path.Join(vfs.Opt.Trash, newTrashTime().Format(trashTimeFormat), o.Remote())
+	_, err := operations.Move(ctx, vfs.f, nil, remote, o)
+	if err != nil {
+		return fmt.Errorf("failed to move to trash: %w", err)
+	}
+	fs.Debugf(o, "Moved to trash as %q", remote)
+	return nil
+}
+
+// TrashEntry describes a file in the trash
+type TrashEntry struct {
+	Path     string    `json:"path"`     // path of the file in the trash directory
+	Original string    `json:"original"` // path the file was deleted from
+	Deleted  time.Time `json:"deleted"`  // when the file was deleted
+	Size     int64     `json:"size"`     // size of the file
+}
+
+// parseTrashPath splits the path of a file in the trash directory
+// into the time it was deleted and its original path
+func parseTrashPath(trashPath string) (deleted time.Time, original string, err error) {
+	i := strings.IndexRune(trashPath, '/')
+	if i < 0 {
+		return deleted, "", fmt.Errorf("%q isn't a file in the trash", trashPath)
+	}
+	deleted, err = time.Parse(trashTimeFormat, trashPath[:i])
+	if err != nil {
+		return deleted, "", fmt.Errorf("%q isn't a file in the trash: %w", trashPath, err)
+	}
+	return deleted, trashPath[i+1:], nil
+}
+
+// TrashList returns the files in the trash, oldest first.
+func (vfs *VFS) TrashList(ctx context.Context) (entries []TrashEntry, err error) {
+	if vfs.Opt.Trash == "" {
+		return nil, errNoTrash
+	}
+	err = walk.ListR(ctx, vfs.f, vfs.Opt.Trash, true, -1, walk.ListObjects, func(dirEntries fs.DirEntries) error {
+		dirEntries.ForObject(func(o fs.Object) {
+			trashPath := strings.TrimPrefix(o.Remote(), vfs.Opt.Trash+"/")
+			deleted, original, err := parseTrashPath(trashPath)
+			if err != nil {
+				fs.Debugf(o, "Ignoring in trash: %v", err)
+				return
+			}
+			entries = append(entries, TrashEntry{
+				Path:     trashPath,
+				Original: original,
+				Deleted:  deleted,
+				Size:     o.Size(),
+			})
+		})
+		return nil
+	})
+	if errors.Is(err, fs.ErrorDirNotFound) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, nil
+}
+
+// mkdirAll returns the directory dir, making it and any parents which
+// don't exist
+func (vfs *VFS) mkdirAll(dir string) (d *Dir, err error) {
+	d = vfs.root
+	for _, name := range strings.Split(dir, "/") {
+		if name == "" {
+			continue
+		}
+		d, err = d.Mkdir(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// reserveFile adds a file for the object remote to the directory
+// before the object exists, failing with EEXIST if there is anything
+// called remote in the directory or on the remote. Until the file is
+// given its object anything else trying to create remote sees it.
+func (d *Dir) reserveFile(ctx context.Context, remote string) (*File, error) {
+	name, isLink := d.vfs.trimLinkSuffix(path.Base(remote))
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d._readDir(); err != nil {
+		return nil, err
+	}
+	if _, found := d.items[name]; found {
+		return nil, EEXIST
+	}
+	if _, err := d.f.NewObject(ctx, remote); err == nil {
+		return nil, EEXIST
+	}
+	file := newFile(d, d.path, nil, name)
+	file.isLink = isLink
+	d.items[name] = file
+	if d.virtual == nil {
+		d.virtual = make(map[string]vState)
+	}
+	d.virtual[name] = vAddFile
+	return file, nil
+}
+
+// TrashRestore moves the file trashPath in the trash back to where it
+// was deleted from.
+//
+// It fails with EEXIST if a file exists there. The name is reserved in
+// the VFS while the file is moved so nothing else can be created there
+// in the meantime.
+func (vfs *VFS) TrashRestore(ctx context.Context, trashPath string) error {
+	if vfs.Opt.Trash == "" {
+		return errNoTrash
+	}
+	trashPath = strings.Trim(path.Clean("/"+trashPath), "/")
+	_, original, err := parseTrashPath(trashPath)
+	if err != nil {
+		return err
+	}
+	if vfs.Opt.ReadOnly {
+		return EROFS
+	}
+	// Fail early if the file exists - reserveFile checks again
+	if _, err := vfs.Stat(original); err == nil {
+		return EEXIST
+	}
+	o, err := vfs.f.NewObject(ctx, path.Join(vfs.Opt.Trash, trashPath))
+	if err != nil {
+		return err
+	}
+	d, err := vfs.mkdirAll(vfscommon.FindParent(original))
+	if err != nil {
+		return err
+	}
+	file, err := d.reserveFile(ctx, original)
+	if err != nil {
+		return err
+	}
+	newObj, err := operations.Move(ctx, vfs.f, nil, original, o)
+	if err == nil && newObj == nil {
+		err = errors.New("nil object returned")
+	}
+	if err != nil {
+		d.delObject(file.Name())
+		return fmt.Errorf("failed to restore from trash: %w", err)
+	}
+	file.setObject(newObj)
+	// Tidy up the empty directories left in the trash
+	deletionDir := path.Join(vfs.Opt.Trash, trashPath[:strings.IndexRune(trashPath, '/')])
+	if err := operations.Rmdirs(ctx, vfs.f, deletionDir, false); err != nil {
+		fs.Debugf(deletionDir, "Failed to tidy trash: %v", err)
+	}
+	return nil
+}
+
+// TrashEmpty permanently deletes the files in the trash which were
+// deleted more than maxAge ago, or all of them if maxAge is 0. It
+// returns the names of the deletion directories removed.
+func (vfs *VFS) TrashEmpty(ctx context.Context, maxAge time.Duration) (removed []string, err error) {
+	if vfs.Opt.Trash == "" {
+		return nil, errNoTrash
+	}
+	if vfs.Opt.ReadOnly {
+		return nil, EROFS
+	}
+	entries, err := vfs.f.List(ctx, vfs.Opt.Trash)
+	if errors.Is(err, fs.ErrorDirNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-maxAge)
+	for _, entry := range entries {
+		dir, ok := entry.(fs.Directory)
+		if !ok {
+			continue
+		}
+		name := path.Base(dir.Remote())
+		deleted, err := time.Parse(trashTimeFormat, name)
+		if err != nil {
+			fs.Debugf(dir, "Ignoring in trash: %v", err)
+			continue
+		}
+		if maxAge > 0 && deleted.After(cutoff) {
+			continue
+		}
+		err = operations.Purge(ctx, vfs.f, dir.Remote())
+		if err != nil {
+			return removed, fmt.Errorf("failed to empty trash: %w", err)
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
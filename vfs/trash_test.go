@@ -0,0 +1,171 @@
+package vfs
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTrashTime(t *testing.T) {
+	first := newTrashTime()
+	second := newTrashTime()
+	assert.True(t, second.After(first))
+	assert.Equal(t, time.UTC, first.Location())
+}
+
+func TestParseTrashPath(t *testing.T) {
+	deleted, original, err := parseTrashPath("2023-04-05T060708.123456789Z/dir/file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "dir/file.txt", original)
+	assert.Equal(t, time.Date(2023, 4, 5, 6, 7, 8, 123456789, time.UTC), deleted)
+
+	_, _, err = parseTrashPath("2023-04-05T060708.123456789Z")
+	assert.Error(t, err)
+	_, _, err = parseTrashPath("potato/file.txt")
+	assert.Error(t, err)
+}
+
+func newTestTrashVFS(t *testing.T) (r *fstest.Run, vfs *VFS, cleanup func()) {
+	opt := vfscommon.DefaultOpt
+	opt.Trash = "/.trash/"
+	return newTestVFSOpt(t, &opt)
+}
+
+func TestTrash(t *testing.T) {
+	r, vfs, cleanup := newTestTrashVFS(t)
+	defer cleanup()
+	ctx := context.Background()
+	assert.Equal(t, ".trash", vfs.Opt.Trash)
+
+	file1 := r.WriteObject(ctx, "file1", "file1 contents", t1)
+	file2 := r.WriteObject(ctx, "dir/file2", "file2 contents", t1)
+	r.CheckRemoteItems(t, file1, file2)
+
+	require.NoError(t, vfs.Remove("file1"))
+	dir, err := vfs.Stat("dir")
+	require.NoError(t, err)
+	require.NoError(t, dir.RemoveAll())
+
+	// The files are in the trash
+	entries, err := vfs.TrashList(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "file1", entries[0].Original)
+	assert.Equal(t, "dir/file2", entries[1].Original)
+	assert.Equal(t, int64(len("file1 contents")), entries[0].Size)
+	assert.WithinDuration(t, time.Now(), entries[0].Deleted, time.Minute)
+	file1.Path = path.Join(".trash", entries[0].Path)
+	file2.Path = path.Join(".trash", entries[1].Path)
+	r.CheckRemoteItems(t, file1, file2)
+
+	// The trash isn't shown in the VFS
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	nodes, err := root.ReadDirAll()
+	require.NoError(t, err)
+	assert.Empty(t, nodes)
+
+	// Restore dir/file2
+	require.NoError(t, vfs.TrashRestore(ctx, entries[1].Path))
+	_, err = vfs.Stat("dir/file2")
+	require.NoError(t, err)
+	file2.Path = "dir/file2"
+	r.CheckRemoteItems(t, file1, file2)
+	assert.Equal(t, EEXIST, vfs.TrashRestore(ctx, entries[1].Path))
+	assert.Error(t, vfs.TrashRestore(ctx, "potato/file1"))
+
+	// Emptying with a max age leaves the recent files
+	removed, err := vfs.TrashEmpty(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+	r.CheckRemoteItems(t, file1, file2)
+
+	removed, err = vfs.TrashEmpty(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path.Dir(entries[0].Path)}, removed)
+	r.CheckRemoteItems(t, file2)
+	entries, err = vfs.TrashList(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestTrashNotEnabled(t *testing.T) {
+	r, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	r.WriteObject(ctx, "file1", "file1 contents", t1)
+	require.NoError(t, vfs.Remove("file1"))
+	r.CheckRemoteItems(t)
+
+	_, err := vfs.TrashList(ctx)
+	assert.Equal(t, errNoTrash, err)
+	assert.Equal(t, errNoTrash, vfs.TrashRestore(ctx, "2023-04-05T060708.123456789Z/file1"))
+	_, err = vfs.TrashEmpty(ctx, 0)
+	assert.Equal(t, errNoTrash, err)
+}
+
+func TestTrashEmptyNoTrash(t *testing.T) {
+	_, vfs, cleanup := newTestTrashVFS(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	entries, err := vfs.TrashList(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	removed, err := vfs.TrashEmpty(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+	_, err = vfs.Stat(".trash")
+	assert.Equal(t, ENOENT, err)
+}
+
+func TestTrashRoot(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.Trash = "/"
+	_, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	// Deleting files permanently is refused rather than using no trash
+	assert.Equal(t, "", vfs.Opt.Trash)
+	assert.True(t, vfs.Opt.ReadOnly)
+}
+
+func TestTrashRestoreExists(t *testing.T) {
+	r, vfs, cleanup := newTestTrashVFS(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	trashed := r.WriteObject(ctx, "dir/file1", "file1 contents", t1)
+	require.NoError(t, vfs.Remove("dir/file1"))
+	entries, err := vfs.TrashList(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+
+	// A file put on the remote since the directory was read isn't
+	// overwritten
+	file1 := r.WriteObject(ctx, "dir/file1", "new contents", t1)
+	assert.Equal(t, EEXIST, vfs.TrashRestore(ctx, entries[0].Path))
+	trashed.Path = path.Join(".trash", entries[0].Path)
+	r.CheckRemoteItems(t, file1, trashed)
+
+	// The name is reserved in the VFS while the file is restored
+	// so creating it finds the restored file
+	require.NoError(t, r.Fremote.Features().Purge(ctx, "dir"))
+	vfs.FlushDirCache()
+	d, err := vfs.mkdirAll("dir")
+	require.NoError(t, err)
+	file, err := d.reserveFile(ctx, "dir/file2")
+	require.NoError(t, err)
+	_, err = d.reserveFile(ctx, "dir/file2")
+	assert.Equal(t, EEXIST, err)
+	created, err := d.Create("file2", 0)
+	require.NoError(t, err)
+	assert.Equal(t, file, created)
+}
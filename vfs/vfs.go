@@ -39,6 +39,7 @@ import (
 	"github.com/rclone/rclone/fs/walk"
 	"github.com/rclone/rclone/vfs/vfscache"
 	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/rclone/rclone/vfs/vfsoverlay"
 )
 
 // Node represents either a directory (*Dir) or a file (*File)
@@ -162,12 +163,15 @@ type VFS struct {
 	root        *Dir
 	Opt         vfscommon.Options
 	cache       *vfscache.Cache
+	dirStore    *dirStore // persistent directory cache - may be nil
 	cancelCache context.CancelFunc
 	usageMu     sync.Mutex
 	usageTime   time.Time
 	usage       *fs.Usage
 	pollChan    chan time.Duration
-	inUse       int32 // count of number of opens accessed with atomic
+	locks       *lockManager   // advisory file locks
+	overlay     *vfsoverlay.Fs // copy on write overlay - may be nil
+	inUse       int32          // count of number of opens accessed with atomic
 }
 
 // Keep track of active VFS keyed on fs.ConfigString(f)
@@ -182,6 +186,7 @@ func New(f fs.Fs, opt *vfscommon.Options) *VFS {
 	fsDir := fs.NewDir("", time.Now())
 	vfs := &VFS{
 		f:     f,
+		locks: newLockManager(),
 		inUse: int32(1),
 	}
 
@@ -192,6 +197,9 @@ func New(f fs.Fs, opt *vfscommon.Options) *VFS {
 		vfs.Opt = vfscommon.DefaultOpt
 	}
 
+	// The trash can't be the root as everything would be in it
+	trashIsRoot := vfs.Opt.Trash != "" && strings.Trim(path.Clean("/"+vfs.Opt.Trash), "/") == ""
+
 	// Fill out anything else
 	vfs.Opt.Init()
 
@@ -209,8 +217,36 @@ func New(f fs.Fs, opt *vfscommon.Options) *VFS {
 	// Put the VFS into the active cache
 	active[configName] = append(active[configName], vfs)
 
+	// Don't permanently delete files if the trash can't be used
+	if trashIsRoot {
+		fs.Errorf(f, "--vfs-trash can't be the root of the remote - making read only")
+		vfs.Opt.ReadOnly = true
+	}
+
+	// Put the changes into an overlay if required
+	if vfs.Opt.Overlay {
+		overlay, err := vfsoverlay.New(context.TODO(), f)
+		if err != nil {
+			fs.Errorf(f, "Failed to create vfs overlay - making read only: %v", err)
+			vfs.Opt.ReadOnly = true
+		} else {
+			vfs.overlay = overlay
+			vfs.f = overlay
+		}
+	}
+
+	// Open the persistent directory cache if required
+	if vfs.Opt.DirCachePersist {
+		store, err := newDirStore(context.TODO(), vfs.f, vfs.Opt.DirCacheTime)
+		if err != nil {
+			fs.Errorf(f, "Failed to open persistent directory cache - disabling: %v", err)
+		} else {
+			vfs.dirStore = store
+		}
+	}
+
 	// Create root directory
-	vfs.root = newDir(vfs, f, nil, fsDir)
+	vfs.root = newDir(vfs, vfs.f, nil, fsDir)
 
 	// Start polling function
 	features := vfs.f.Features()
@@ -237,10 +273,19 @@ func New(f fs.Fs, opt *vfscommon.Options) *VFS {
 	return vfs
 }
 
+// remote returns the Fs the VFS was made with which is underneath
+// the overlay if there is one
+func (vfs *VFS) remote() fs.Fs {
+	if vfs.overlay != nil {
+		return vfs.overlay.Lower()
+	}
+	return vfs.f
+}
+
 // Stats returns info about the VFS
 func (vfs *VFS) Stats() (out rc.Params) {
 	out = make(rc.Params)
-	out["fs"] = fs.ConfigString(vfs.f)
+	out["fs"] = fs.ConfigString(vfs.remote())
 	out["opt"] = vfs.Opt
 	out["inUse"] = atomic.LoadInt32(&vfs.inUse)
 
@@ -277,7 +322,8 @@ func activeCacheEntries() (vfs *VFS, count int) {
 	return vfs, count
 }
 
-// Fs returns the Fs passed into the New call
+// Fs returns the Fs passed into the New call or the overlay on top of
+// it if --vfs-overlay is in use
 func (vfs *VFS) Fs() fs.Fs {
 	return vfs.f
 }
@@ -318,7 +364,7 @@ func (vfs *VFS) Shutdown() {
 
 	// Remove from active cache
 	activeMu.Lock()
-	configName := fs.ConfigString(vfs.f)
+	configName := fs.ConfigString(vfs.remote())
 	activeVFSes := active[configName]
 	for i, activeVFS := range activeVFSes {
 		if activeVFS == vfs {
@@ -330,6 +376,7 @@ func (vfs *VFS) Shutdown() {
 	activeMu.Unlock()
 
 	vfs.shutdownCache()
+	vfs.dirStore.stop()
 }
 
 // CleanUp deletes the contents of the on disk cache
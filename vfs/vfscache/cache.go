@@ -9,9 +9,9 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
-	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	sysdnotify "github.com/iguanesolutions/go-systemd/v5/notify"
@@ -48,19 +48,35 @@ type Cache struct {
 	metaRoot   string               // root of the cache metadata directory
 	hashType   hash.Type            // hash to use locally and remotely
 	hashOption *fs.HashesOption     // corresponding OpenOption
+	cipher     *cacheCipher         // encrypts the cache files if set
 	writeback  *writeback.WriteBack // holds Items for writeback
 	avFn       AddVirtualFn         // if set, can be called to add dir entries
 
-	mu            sync.Mutex       // protects the following variables
-	cond          sync.Cond        // cond lock for synchronous cache cleaning
-	item          map[string]*Item // files/directories in the cache
-	errItems      map[string]error // items in error state
-	used          int64            // total size of files in the cache
-	outOfSpace    bool             // out of space
-	cleanerKicked bool             // some thread kicked the cleaner upon out of space
-	kickerMu      sync.Mutex       // mutex for cleanerKicked
-	kick          chan struct{}    // channel for kicking clear to start
+	mu            sync.Mutex          // protects the following variables
+	cond          sync.Cond           // cond lock for synchronous cache cleaning
+	item          map[string]*Item    // files/directories in the cache
+	errItems      map[string]error    // items in error state
+	used          int64               // total size of files in the cache
+	outOfSpace    bool                // out of space
+	cleanerKicked bool                // some thread kicked the cleaner upon out of space
+	kickerMu      sync.Mutex          // mutex for cleanerKicked
+	kick          chan struct{}       // channel for kicking clear to start
+	prefetching   map[string]struct{} // pinned files being downloaded
 
+	ctx            context.Context // cancelled when the cache is finished with
+	prefetchTokens chan struct{}   // limits the number of pinned files downloading
+
+	dirQuotas   []dirQuota // limits on the space used by directories
+	arcTarget   int64      // ARC target for the space used by items used once
+	ghostsOnce  ghostList  // ARC items used once which were removed recently
+	ghostsOften ghostList  // ARC items used more often which were removed recently
+
+	// metrics - use sync/atomic to access
+	hits      int64 // read calls which found their data in the cache
+	misses    int64 // read calls which needed to fetch data from the remote
+	evictions int64 // items removed or reset to keep the cache within limits
+
+	undecryptable int64 // items removed when loading as they couldn't be decrypted - use sync/atomic
 }
 
 // AddVirtualFn if registered by the WithAddVirtual method, can be
@@ -112,6 +128,30 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 	}
 	hashType, hashOption := operations.CommonHash(ctx, fdata, fremote)
 
+	dirQuotas, err := parseDirQuotas(opt.CacheDirMaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the cipher if the cache is to be encrypted
+	var cc *cacheCipher
+	if opt.CacheEncrypt {
+		var salt []byte
+		if opt.CacheEncryptKey == "" {
+			fs.Infof(nil, "vfs cache: encrypting with a key for this session only - cached files from previous sessions will be removed")
+		} else {
+			saltOSPath := filepath.Join(parentOSPath, "vfsSalt", strings.TrimRight(relativeDirOSPath, `/\`)+".salt")
+			salt, err = loadSalt(saltOSPath)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cc, err = newCacheCipher(string(opt.CacheEncryptKey), salt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create the cache object
 	c := &Cache{
 		fremote:    fremote,
@@ -124,8 +164,14 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 		errItems:   make(map[string]error),
 		hashType:   hashType,
 		hashOption: hashOption,
+		cipher:     cc,
 		writeback:  writeback.New(ctx, opt),
 		avFn:       avFn,
+
+		prefetching:    make(map[string]struct{}),
+		ctx:            ctx,
+		prefetchTokens: make(chan struct{}, fs.GetConfig(ctx).Transfers),
+		dirQuotas:      dirQuotas,
 	}
 
 	// load in the cache and metadata off disk
@@ -133,6 +179,9 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 	if err != nil {
 		return nil, fmt.Errorf("failed to load cache: %w", err)
 	}
+	if n := atomic.LoadInt64(&c.undecryptable); n > 0 && opt.CacheEncryptKey == "" {
+		fs.Errorf(nil, "vfs cache: removed %d files cached by a previous session which can't be decrypted with this session's random key - any changes to them which weren't uploaded are lost. Set --vfs-cache-encrypt-key to keep the cache across restarts", n)
+	}
 
 	// Remove any empty directories
 	c.purgeEmptyDirs("", true)
@@ -143,6 +192,14 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 
 	go c.cleaner(ctx)
 
+	// Collect metrics from the cache until it is finished with
+	addActive(c)
+	go func() {
+		<-ctx.Done()
+		removeActive(c)
+		c.warnDirtyLost()
+	}()
+
 	return c, nil
 }
 
@@ -153,10 +210,12 @@ func (c *Cache) Stats() (out rc.Params) {
 	out["path"] = c.root
 	out["pathMeta"] = c.metaRoot
 	out["hashType"] = c.hashType
+	out["encrypted"] = c.cipher != nil
 
 	uploadsInProgress, uploadsQueued := c.writeback.Stats()
 	out["uploadsInProgress"] = uploadsInProgress
 	out["uploadsQueued"] = uploadsQueued
+	out["conflictsKeptBoth"], out["conflictsRefused"] = c.writeback.ConflictStats()
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -165,6 +224,11 @@ func (c *Cache) Stats() (out rc.Params) {
 	out["erroredFiles"] = len(c.errItems)
 	out["bytesUsed"] = c.used
 	out["outOfSpace"] = c.outOfSpace
+	out["hits"] = atomic.LoadInt64(&c.hits)
+	out["misses"] = atomic.LoadInt64(&c.misses)
+	out["evictions"] = atomic.LoadInt64(&c.evictions)
+	out["pinnedFiles"], out["pinnedBytes"], out["pinnedPending"] = c._pinStats()
+	out["refusedFiles"] = c._refusedFiles()
 
 	return out
 }
@@ -272,6 +336,7 @@ func (c *Cache) _get(name string) (item *Item, found bool) {
 	if !found {
 		item = newItem(c, name)
 		c.item[name] = item
+		c._arcNewItem(item)
 	}
 	return item, found
 }
@@ -568,19 +633,23 @@ func (c *Cache) KickCleaner() {
 }
 
 // removeNotInUse removes items not in use with a possible maxAge cutoff
+// returning the space freed
 // called with cache mutex locked and up-to-date c.used (as we update it directly here)
-func (c *Cache) removeNotInUse(item *Item, maxAge time.Duration, emptyOnly bool) {
+func (c *Cache) removeNotInUse(item *Item, maxAge time.Duration, emptyOnly bool) (spaceFreed int64) {
+	accesses := item.getAccesses()
 	removed, spaceFreed := item.RemoveNotInUse(maxAge, emptyOnly)
 	// The item space might be freed even if we get an error after the cache file is removed
 	// The item will not be removed or reset the cache data is dirty (DataDirty)
 	c.used -= spaceFreed
 	if removed {
+		c._evicted(item.name, accesses, spaceFreed)
 		fs.Infof(nil, "vfs cache RemoveNotInUse (maxAge=%d, emptyOnly=%v): item %s was removed, freed %d bytes", maxAge, emptyOnly, item.GetName(), spaceFreed)
 		// Remove the entry
 		delete(c.item, item.name)
 	} else {
 		fs.Debugf(nil, "vfs cache RemoveNotInUse (maxAge=%d, emptyOnly=%v): item %s not removed, freed %d bytes", maxAge, emptyOnly, item.GetName(), spaceFreed)
 	}
+	return spaceFreed
 }
 
 // Retry failed resets during purgeClean()
@@ -624,13 +693,14 @@ func (c *Cache) purgeClean(quota int64) {
 		}
 	}
 
-	sort.Sort(items)
+	c._evictOrder(items)
 
 	// Reset items until the quota is OK
 	for _, item := range items {
 		if c.used < quota {
 			break
 		}
+		accesses := item.getAccesses()
 		resetResult, spaceFreed, err := item.Reset()
 		// The item space might be freed even if we get an error after the cache file is removed
 		// The item will not be removed or reset if the cache data is dirty (DataDirty)
@@ -638,6 +708,10 @@ func (c *Cache) purgeClean(quota int64) {
 		fs.Infof(nil, "vfs cache purgeClean item.Reset %s: %s, freed %d bytes", item.GetName(), resetResult.String(), spaceFreed)
 		if resetResult == RemovedNotInUse {
 			delete(c.item, item.name)
+			c._evicted(item.name, accesses, spaceFreed)
+		}
+		if resetResult == ResetComplete {
+			atomic.AddInt64(&c.evictions, 1)
 		}
 		if err != nil {
 			fs.Errorf(nil, "vfs cache purgeClean item.Reset %s reset failed, err = %v, freed %d bytes", item.GetName(), err, spaceFreed)
@@ -715,7 +789,7 @@ func (c *Cache) purgeOverQuota(quota int64) {
 		}
 	}
 
-	sort.Sort(items)
+	c._evictOrder(items)
 
 	// Remove items until the quota is OK
 	for _, item := range items {
@@ -742,9 +816,14 @@ func (c *Cache) clean(kicked bool) {
 	// Remove any files that are over age
 	c.purgeOld(c.opt.CacheMaxAge)
 
+	// Remove files not in use from any directories over their quota
+	for _, dq := range c.dirQuotas {
+		c.purgeDirOverQuota(dq.dir, dq.quota)
+	}
+
 	// If have a maximum cache size...
 	if int64(c.opt.CacheMaxSize) > 0 {
-		// Remove files not in use until cache size is below quota starting from the oldest first
+		// Remove files not in use until cache size is below quota in the evict policy order
 		c.purgeOverQuota(int64(c.opt.CacheMaxSize))
 
 		// Remove cache files that are not dirty if we are still above the max cache size
@@ -790,6 +869,7 @@ func (c *Cache) cleaner(ctx context.Context) {
 	}
 	// Start cleaning the cache immediately
 	c.clean(false)
+	c.prefetchPinned()
 	// Then every interval specified
 	timer := time.NewTicker(c.opt.CachePollInterval)
 	defer timer.Stop()
@@ -799,6 +879,7 @@ func (c *Cache) cleaner(ctx context.Context) {
 			c.clean(true) // kicked is true
 		case <-timer.C:
 			c.clean(false) // timer driven cache poll, kicked is false
+			c.prefetchPinned()
 		case <-ctx.Done():
 			fs.Debugf(nil, "vfs cache: cleaner exiting")
 			return
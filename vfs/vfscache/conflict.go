@@ -0,0 +1,83 @@
+package vfscache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/vfs/vfscache/writeback"
+	"github.com/rclone/rclone/vfs/vfscommon"
+)
+
+// conflictName returns the name the remote file name is moved to if
+// it conflicts with the file being written back
+func conflictName(name string, now time.Time) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + ".conflict-" + now.Format("2006-01-02-150405") + ext
+}
+
+// checkConflict checks whether the remote file name has changed since
+// the cache file was opened, when its fingerprint was fingerprint (or
+// "" if it didn't exist), and applies the --vfs-write-back-conflict
+// policy if it has.
+//
+// It returns the remote object to overwrite, which may be nil, or an
+// error wrapping writeback.ErrConflict if the upload should not go
+// ahead. o is the object known when the file was opened which is
+// returned without checking the remote if the policy is overwrite.
+//
+// call with the lock not held
+func (item *Item) checkConflict(ctx context.Context, name, fingerprint string, o fs.Object) (dst fs.Object, err error) {
+	if item.c.opt.WriteBackConflict == vfscommon.ConflictOverwrite {
+		return o, nil
+	}
+	dst, err = item.c.fremote.NewObject(ctx, name)
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		// If the remote has been deleted then just upload it again
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("vfs cache: failed to check remote for conflicts: %w", err)
+	}
+	remoteFingerprint := fs.Fingerprint(ctx, dst, item.c.opt.FastFingerprint)
+	if remoteFingerprint == fingerprint {
+		return dst, nil
+	}
+	fs.Debugf(name, "vfs cache: remote fingerprint %q != fingerprint when opened %q", remoteFingerprint, fingerprint)
+	switch item.c.writeback.Conflict(name) {
+	case vfscommon.ConflictKeepBoth:
+		newName := conflictName(name, time.Now())
+		_, err = operations.Move(ctx, item.c.fremote, nil, newName, dst)
+		if err != nil {
+			return nil, fmt.Errorf("vfs cache: failed to move conflicting remote file to %q: %w", newName, err)
+		}
+		fs.Logf(name, "vfs cache: moved conflicting remote file to %q", newName)
+		// Show the moved file in the directory listings
+		if err := item.c.AddVirtual(newName, dst.Size(), false); err != nil {
+			fs.Debugf(newName, "vfs cache: failed to add virtual dir entry: %v", err)
+		}
+		return nil, nil
+	case vfscommon.ConflictRefuse:
+		return nil, fmt.Errorf("%w: %q changed on the remote since it was opened - not uploading, local changes kept in the cache", writeback.ErrConflict, name)
+	}
+	return dst, nil
+}
+
+// _refusedFiles returns the number of items whose upload was refused
+// because of a conflict
+//
+// call with c.mu held
+func (c *Cache) _refusedFiles() (files int) {
+	for _, item := range c.item {
+		item.mu.Lock()
+		if item.info.Refused {
+			files++
+		}
+		item.mu.Unlock()
+	}
+	return files
+}
@@ -0,0 +1,137 @@
+package vfscache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/vfs/vfscache/writeback"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConflictName(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	assert.Equal(t, "file.conflict-2021-03-04-050607.txt", conflictName("file.txt", now))
+	assert.Equal(t, "dir/file.conflict-2021-03-04-050607", conflictName("dir/file", now))
+	assert.Equal(t, "dir.d/file.tar.conflict-2021-03-04-050607.gz", conflictName("dir.d/file.tar.gz", now))
+}
+
+// newConflictTest makes a cache with the conflict policy given, opens
+// "existing" and modifies it, then changes "existing" on the remote
+// before the file is closed
+func newConflictTest(t *testing.T, policy vfscommon.ConflictPolicy) (r *fstest.Run, c *Cache, item *Item, contents string, cleanup func()) {
+	opt := vfscommon.DefaultOpt
+	opt.CachePollInterval = 0
+	opt.WriteBack = 0
+	opt.WriteBackConflict = policy
+	r, c, cleanup = newTestCacheOpt(t, opt)
+
+	contents, obj, item := newFile(t, r, c, "existing")
+	require.NoError(t, item.Open(obj))
+	// Read it all so the close doesn't need to download any of it
+	_, err := item.ReadAt(make([]byte, len(contents)), 0)
+	require.NoError(t, err)
+	_, err = item.WriteAt([]byte("THEENDMYFRIEND"), 95)
+	require.NoError(t, err)
+
+	// Change the file on the remote
+	r.WriteObject(context.Background(), "existing", "changed", time.Now().Add(time.Minute))
+
+	return r, c, item, contents[:95] + "THEENDMYFRIEND", cleanup
+}
+
+func TestConflictNone(t *testing.T) {
+	r, c, cleanup := newTestCache(t)
+	defer cleanup()
+
+	contents, obj, item := newFile(t, r, c, "existing")
+	require.NoError(t, item.Open(obj))
+	_, err := item.WriteAt([]byte("THEENDMYFRIEND"), 95)
+	require.NoError(t, err)
+	require.NoError(t, item.Close(nil))
+	checkObject(t, r, "existing", contents[:95]+"THEENDMYFRIEND")
+
+	out := c.Stats()
+	assert.Equal(t, int64(0), out["conflictsKeptBoth"])
+	assert.Equal(t, int64(0), out["conflictsRefused"])
+}
+
+func TestConflictOverwrite(t *testing.T) {
+	r, c, item, contents, cleanup := newConflictTest(t, vfscommon.ConflictOverwrite)
+	defer cleanup()
+
+	require.NoError(t, item.Close(nil))
+	checkObject(t, r, "existing", contents)
+
+	// The remote isn't checked for conflicts when overwriting
+	out := c.Stats()
+	assert.Equal(t, int64(0), out["conflictsKeptBoth"])
+	assert.Equal(t, int64(0), out["conflictsRefused"])
+}
+
+func TestConflictKeepBoth(t *testing.T) {
+	r, c, item, contents, cleanup := newConflictTest(t, vfscommon.ConflictKeepBoth)
+	defer cleanup()
+
+	require.NoError(t, item.Close(nil))
+	checkObject(t, r, "existing", contents)
+	assert.Equal(t, int64(1), c.Stats()["conflictsKeptBoth"])
+
+	// Find the remote file which was moved out of the way
+	entries, err := r.Fremote.List(context.Background(), "")
+	require.NoError(t, err)
+	var conflict string
+	entries.ForObject(func(o fs.Object) {
+		if strings.HasPrefix(o.Remote(), "existing.conflict-") {
+			conflict = o.Remote()
+		}
+	})
+	require.NotEqual(t, "", conflict)
+	checkObject(t, r, conflict, "changed")
+	assert.Equal(t, []avInfo{{Remote: conflict, Size: 7, IsDir: false}}, avInfos)
+}
+
+func TestConflictRefuse(t *testing.T) {
+	r, c, item, _, cleanup := newConflictTest(t, vfscommon.ConflictRefuse)
+	defer cleanup()
+
+	err := item.Close(nil)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, writeback.ErrConflict))
+	checkObject(t, r, "existing", "changed")
+	assert.Equal(t, int64(1), c.Stats()["conflictsRefused"])
+
+	// The local changes are kept in the cache
+	assert.True(t, item.IsDirty())
+	assert.Equal(t, 1, c.Stats()["refusedFiles"])
+
+	// Opening and closing the file again doesn't retry the upload
+	require.NoError(t, item.Open(nil))
+	require.NoError(t, item.Close(nil))
+	assert.Equal(t, int64(1), c.Stats()["conflictsRefused"])
+
+	// Nor does reloading it when the cache starts
+	c.mu.Lock()
+	delete(c.item, item.name)
+	c.mu.Unlock()
+	item2, _ := c._get("existing")
+	require.NoError(t, item2.reload(context.Background()))
+	assert.True(t, item2.IsDirty())
+	assert.Equal(t, int64(1), c.Stats()["conflictsRefused"])
+	assert.Equal(t, 1, c.Stats()["refusedFiles"])
+	checkObject(t, r, "existing", "changed")
+
+	// Writing to the file again retries the upload
+	require.NoError(t, item2.Open(nil))
+	_, err = item2.WriteAt([]byte("AGAIN"), 0)
+	require.NoError(t, err)
+	err = item2.Close(nil)
+	assert.True(t, errors.Is(err, writeback.ErrConflict))
+	assert.Equal(t, int64(2), c.Stats()["conflictsRefused"])
+}
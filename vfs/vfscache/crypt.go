@@ -0,0 +1,650 @@
+package vfscache
+
+// This implements encryption of the cache files and their metadata
+// at rest.
+//
+// The data files are split into blocks of plaintext which are
+// encrypted individually with XChaCha20-Poly1305 so they can be read
+// and written at any offset. Each block is stored as a random nonce
+// followed by the sealed data and the authentication tag. The file
+// starts with a header containing a random file ID which is
+// authenticated along with the block number so blocks can't be moved
+// around within or between files.
+//
+// The cache files are sparse, so a block whose nonce is all zeros
+// hasn't been written and reads as zeros. The file ID and which blocks
+// have been written are kept in the (encrypted) metadata, so a block
+// which was written can't be replaced by a hole and the data file
+// can't be replaced by another one.
+//
+// The metadata files are sealed in one piece with the name of the
+// item as additional data so they can't be swapped between items.
+//
+// The key is derived from the passphrase with scrypt using a random
+// salt which is stored with the cache.
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/ranges"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	cryptMagic      = "RCLVFSC\x00"                              // start of an encrypted cache file
+	cryptIDSize     = 24                                         // size of the random file ID
+	cryptHeaderSize = len(cryptMagic) + cryptIDSize              // size of the file header
+	cryptBlockSize  = 64 * 1024                                  // size of a block of plaintext
+	cryptNonceSize  = chacha20poly1305.NonceSizeX                // size of the nonce before each block
+	cryptOverhead   = cryptNonceSize + chacha20poly1305.Overhead // bytes added to each block
+	cryptDiskBlock  = cryptBlockSize + cryptOverhead             // size of a full block on disk
+	cryptMetaAD     = "rclone vfs cache metadata\x00"            // additional data for the metadata, followed by the item name
+	cryptKeySize    = chacha20poly1305.KeySize                   // size of the key
+	cryptSaltSize   = 16                                         // size of the salt for the key
+)
+
+var errCryptAuth = errors.New("vfs cache: encrypted cache file failed authentication - wrong key or corrupted")
+
+// cacheCipher encrypts and decrypts the cache files
+type cacheCipher struct {
+	aead cipher.AEAD
+}
+
+// loadSalt reads the salt for deriving the key from the passphrase
+// from osPath, making a new random one if there isn't one.
+func loadSalt(osPath string) ([]byte, error) {
+	salt, err := os.ReadFile(osPath)
+	if err == nil && len(salt) == cryptSaltSize {
+		return salt, nil
+	} else if err == nil {
+		fs.Errorf(nil, "vfs cache: ignoring corrupted salt %q - any encrypted files in the cache will be removed", osPath)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("vfs cache: failed to read salt: %w", err)
+	}
+	salt = make([]byte, cryptSaltSize)
+	if _, err = io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("vfs cache: failed to make salt: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(osPath), 0700); err != nil {
+		return nil, fmt.Errorf("vfs cache: failed to make salt directory: %w", err)
+	}
+	if err = os.WriteFile(osPath, salt, 0600); err != nil {
+		return nil, fmt.Errorf("vfs cache: failed to write salt: %w", err)
+	}
+	return salt, nil
+}
+
+// newCacheCipher makes a cacheCipher from the passphrase and salt. If
+// the passphrase is empty then a random key is used which lasts until
+// rclone exits and salt isn't used.
+func newCacheCipher(passphrase string, salt []byte) (*cacheCipher, error) {
+	key := make([]byte, cryptKeySize)
+	if passphrase == "" {
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, fmt.Errorf("vfs cache: failed to make encryption key: %w", err)
+		}
+	} else {
+		var err error
+		key, err = scrypt.Key([]byte(passphrase), salt, 16384, 8, 1, cryptKeySize)
+		if err != nil {
+			return nil, fmt.Errorf("vfs cache: failed to make encryption key: %w", err)
+		}
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("vfs cache: failed to make cipher: %w", err)
+	}
+	return &cacheCipher{aead: aead}, nil
+}
+
+// seal encrypts plaintext with a random nonce returning nonce+ciphertext
+func (cc *cacheCipher) seal(plaintext, ad []byte) ([]byte, error) {
+	out := make([]byte, cryptNonceSize, cryptNonceSize+len(plaintext)+chacha20poly1305.Overhead)
+	if _, err := io.ReadFull(rand.Reader, out); err != nil {
+		return nil, fmt.Errorf("vfs cache: failed to make nonce: %w", err)
+	}
+	return cc.aead.Seal(out, out, plaintext, ad), nil
+}
+
+// open decrypts nonce+ciphertext made by seal
+func (cc *cacheCipher) open(in, ad []byte) ([]byte, error) {
+	if len(in) < cryptOverhead {
+		return nil, errCryptAuth
+	}
+	plaintext, err := cc.aead.Open(nil, in[:cryptNonceSize], in[cryptNonceSize:], ad)
+	if err != nil {
+		return nil, errCryptAuth
+	}
+	return plaintext, nil
+}
+
+// plainSize returns the size of the plaintext of an encrypted file
+// of size cipherSize
+func plainSize(cipherSize int64) int64 {
+	cipherSize -= int64(cryptHeaderSize)
+	if cipherSize <= 0 {
+		return 0
+	}
+	size := (cipherSize / cryptDiskBlock) * cryptBlockSize
+	if rem := cipherSize % cryptDiskBlock; rem > cryptOverhead {
+		size += rem - cryptOverhead
+	}
+	return size
+}
+
+// cipherSize returns the size of the encrypted file holding size
+// bytes of plaintext
+func cipherSize(size int64) int64 {
+	if size <= 0 {
+		return 0
+	}
+	out := int64(cryptHeaderSize) + (size/cryptBlockSize)*cryptDiskBlock
+	if rem := size % cryptBlockSize; rem > 0 {
+		out += rem + cryptOverhead
+	}
+	return out
+}
+
+// cacheFile is the interface to the open cache file
+//
+// It is satisfied by *os.File and *cryptFile
+type cacheFile interface {
+	io.ReaderAt
+	io.WriterAt
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// Check interfaces
+var (
+	_ cacheFile = (*os.File)(nil)
+	_ cacheFile = (*cryptFile)(nil)
+)
+
+// cryptState is what is known about an encrypted cache file. It is
+// kept in the sealed metadata of the item so the file can be checked
+// against it.
+type cryptState struct {
+	mu     sync.Mutex
+	id     []byte        // file ID from the header or nil if not known
+	blocks ranges.Ranges // blocks which have been written, in units of blocks
+}
+
+// newCryptState makes a cryptState from the saved id and blocks
+func newCryptState(id []byte, blocks ranges.Ranges) *cryptState {
+	return &cryptState{id: id, blocks: blocks}
+}
+
+// get returns copies of the id and blocks for saving
+func (s *cryptState) get() (id []byte, blocks ranges.Ranges) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]byte(nil), s.id...), append(ranges.Ranges(nil), s.blocks...)
+}
+
+// reset the state for a new file with id which is nil if the file is
+// empty
+func (s *cryptState) reset(id []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.id = append([]byte(nil), id...)
+	s.blocks = nil
+}
+
+// checkID checks id read from the header of the file is the one
+// expected. If none is known yet, for example if rclone stopped
+// before the metadata was saved, then id is used from now on.
+func (s *cryptState) checkID(id []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.id) == 0 {
+		s.id = append([]byte(nil), id...)
+		return nil
+	}
+	if !bytes.Equal(s.id, id) {
+		return errCryptAuth
+	}
+	return nil
+}
+
+// setWritten marks block i as written
+func (s *cryptState) setWritten(i int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks.Insert(ranges.Range{Pos: i, Size: 1})
+}
+
+// written returns true if block i has been written
+func (s *cryptState) written(i int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blocks.Present(ranges.Range{Pos: i, Size: 1})
+}
+
+// truncate forgets the blocks from block n onwards
+func (s *cryptState) truncate(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks = s.blocks.Intersection(ranges.Range{Pos: 0, Size: n})
+}
+
+// cryptFile reads and writes plaintext to an encrypted cache file
+type cryptFile struct {
+	mu    sync.Mutex // serialise access as blocks are read, modified and written
+	cc    *cacheCipher
+	fd    *os.File
+	state *cryptState // shared with the other cryptFiles for the item
+	id    []byte      // file ID from the header or nil if not read yet
+}
+
+// wrapFile returns fd as a cacheFile, encrypting it with state if
+// required
+func (c *Cache) wrapFile(fd *os.File, state *cryptState) cacheFile {
+	if c.cipher == nil {
+		return fd
+	}
+	return &cryptFile{cc: c.cipher, fd: fd, state: state}
+}
+
+// statFile returns the info for the cache file at osPath with the
+// size of the plaintext
+func (c *Cache) statFile(osPath string) (os.FileInfo, error) {
+	fi, err := os.Stat(osPath)
+	if err != nil || c.cipher == nil {
+		return fi, err
+	}
+	return cryptFileInfo{FileInfo: fi}, nil
+}
+
+// cryptFileInfo returns the size of the plaintext
+type cryptFileInfo struct {
+	os.FileInfo
+}
+
+// Size returns the size of the plaintext
+func (fi cryptFileInfo) Size() int64 {
+	return plainSize(fi.FileInfo.Size())
+}
+
+// _size returns the size of the plaintext
+//
+// Call with the lock held
+func (f *cryptFile) _size() (int64, error) {
+	fi, err := f.fd.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return plainSize(fi.Size()), nil
+}
+
+// _header reads the file ID from the header, writing a new header if
+// the file is empty and create is set. It returns false if the file
+// is empty.
+//
+// Call with the lock held
+func (f *cryptFile) _header(create bool) (ok bool, err error) {
+	if f.id != nil {
+		return true, nil
+	}
+	header := make([]byte, cryptHeaderSize)
+	n, err := f.fd.ReadAt(header, 0)
+	if err == io.EOF && n == 0 {
+		if !create {
+			return false, nil
+		}
+		copy(header, cryptMagic)
+		if _, err = io.ReadFull(rand.Reader, header[len(cryptMagic):]); err != nil {
+			return false, fmt.Errorf("vfs cache: failed to make file ID: %w", err)
+		}
+		if _, err = f.fd.WriteAt(header, 0); err != nil {
+			return false, err
+		}
+		f.state.reset(header[len(cryptMagic):])
+	} else if err != nil {
+		if err == io.EOF {
+			return false, errCryptAuth
+		}
+		return false, err
+	} else if string(header[:len(cryptMagic)]) != cryptMagic {
+		return false, errCryptAuth
+	} else if err = f.state.checkID(header[len(cryptMagic):]); err != nil {
+		return false, err
+	}
+	f.id = header[len(cryptMagic):]
+	return true, nil
+}
+
+// additional data for block i
+func (f *cryptFile) ad(i int64) []byte {
+	ad := make([]byte, cryptIDSize+8)
+	copy(ad, f.id)
+	binary.BigEndian.PutUint64(ad[cryptIDSize:], uint64(i))
+	return ad
+}
+
+// _readBlock reads and decrypts block i which should contain size
+// bytes of plaintext
+//
+// Call with the lock held and the header read
+func (f *cryptFile) _readBlock(i int64, size int) ([]byte, error) {
+	buf := make([]byte, size+cryptOverhead)
+	_, err := f.fd.ReadAt(buf, int64(cryptHeaderSize)+i*cryptDiskBlock)
+	if err != nil {
+		if err == io.EOF {
+			return nil, errCryptAuth
+		}
+		return nil, err
+	}
+	// A block which hasn't been written is a hole and reads as zeros,
+	// but a block which has been written must not look like a hole
+	if bytes.Count(buf[:cryptNonceSize], []byte{0}) == cryptNonceSize {
+		if f.state.written(i) {
+			return nil, errCryptAuth
+		}
+		return make([]byte, size), nil
+	}
+	return f.cc.open(buf, f.ad(i))
+}
+
+// _writeBlock encrypts and writes block i
+//
+// Call with the lock held and the header read
+func (f *cryptFile) _writeBlock(i int64, plaintext []byte) error {
+	buf, err := f.cc.seal(plaintext, f.ad(i))
+	if err != nil {
+		return err
+	}
+	_, err = f.fd.WriteAt(buf, int64(cryptHeaderSize)+i*cryptDiskBlock)
+	if err != nil {
+		return err
+	}
+	f.state.setWritten(i)
+	return nil
+}
+
+// blockLen returns the length of the plaintext in block i of a file
+// of size bytes
+func blockLen(i, size int64) int {
+	n := size - i*cryptBlockSize
+	if n > cryptBlockSize {
+		n = cryptBlockSize
+	} else if n < 0 {
+		n = 0
+	}
+	return int(n)
+}
+
+// ReadAt reads len(p) bytes of plaintext at off
+func (f *cryptFile) ReadAt(p []byte, off int64) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if off < 0 {
+		return 0, errors.New("vfs cache: negative offset")
+	}
+	size, err := f._size()
+	if err != nil {
+		return 0, err
+	}
+	if off >= size {
+		return 0, io.EOF
+	}
+	if _, err = f._header(false); err != nil {
+		return 0, err
+	}
+	for len(p) > 0 && off < size {
+		i := off / cryptBlockSize
+		block, err := f._readBlock(i, blockLen(i, size))
+		if err != nil {
+			return n, err
+		}
+		nn := copy(p, block[off-i*cryptBlockSize:])
+		p = p[nn:]
+		off += int64(nn)
+		n += nn
+	}
+	if len(p) > 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt writes len(p) bytes of plaintext at off
+func (f *cryptFile) WriteAt(p []byte, off int64) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if off < 0 {
+		return 0, errors.New("vfs cache: negative offset")
+	}
+	size, err := f._size()
+	if err != nil {
+		return 0, err
+	}
+	// Writing off the end of the file so fill the gap with zeros
+	if off > size {
+		if err = f._truncate(size, off); err != nil {
+			return 0, err
+		}
+		size = off
+	}
+	if _, err = f._header(true); err != nil {
+		return 0, err
+	}
+	for len(p) > 0 {
+		i := off / cryptBlockSize
+		start := int(off - i*cryptBlockSize)
+		nn := len(p)
+		if nn > cryptBlockSize-start {
+			nn = cryptBlockSize - start
+		}
+		// Read the existing block unless it is being overwritten
+		oldLen := blockLen(i, size)
+		var block []byte
+		if oldLen > 0 && (start > 0 || nn < oldLen) {
+			block, err = f._readBlock(i, oldLen)
+			if err != nil {
+				return n, err
+			}
+		}
+		if end := start + nn; end > len(block) {
+			block = append(block, make([]byte, end-len(block))...)
+		}
+		copy(block[start:], p[:nn])
+		if err = f._writeBlock(i, block); err != nil {
+			return n, err
+		}
+		p = p[nn:]
+		off += int64(nn)
+		n += nn
+	}
+	return n, nil
+}
+
+// Truncate changes the size of the plaintext to size
+func (f *cryptFile) Truncate(size int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	oldSize, err := f._size()
+	if err != nil {
+		return err
+	}
+	return f._truncate(oldSize, size)
+}
+
+// _truncate changes the size of the plaintext from oldSize to size
+//
+// Call with the lock held
+func (f *cryptFile) _truncate(oldSize, size int64) error {
+	if size == oldSize {
+		return nil
+	}
+	if size <= 0 {
+		f.id = nil
+		f.state.reset(nil)
+		return f.fd.Truncate(0)
+	}
+	if _, err := f._header(true); err != nil {
+		return err
+	}
+	// Re-encrypt the block which changes length. If the file is
+	// growing the new parts of the file are holes which read as
+	// zeros.
+	i := oldSize / cryptBlockSize
+	if size < oldSize {
+		i = size / cryptBlockSize
+	}
+	oldLen, newLen := blockLen(i, oldSize), blockLen(i, size)
+	if oldLen > 0 && newLen > 0 && oldLen != newLen {
+		block, err := f._readBlock(i, oldLen)
+		if err != nil {
+			return err
+		}
+		if newLen > oldLen {
+			block = append(block, make([]byte, newLen-oldLen)...)
+		}
+		if err = f._writeBlock(i, block[:newLen]); err != nil {
+			return err
+		}
+	}
+	f.state.truncate((size + cryptBlockSize - 1) / cryptBlockSize)
+	return f.fd.Truncate(cipherSize(size))
+}
+
+// Stat returns the file info with the size of the plaintext
+func (f *cryptFile) Stat() (os.FileInfo, error) {
+	fi, err := f.fd.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return cryptFileInfo{FileInfo: fi}, nil
+}
+
+// Sync commits the file to stable storage
+func (f *cryptFile) Sync() error {
+	return f.fd.Sync()
+}
+
+// Close the file
+func (f *cryptFile) Close() error {
+	return f.fd.Close()
+}
+
+// marshalMeta encrypts the metadata of the item called name if
+// required
+func (c *Cache) marshalMeta(data []byte, name string) ([]byte, error) {
+	if c.cipher == nil {
+		return data, nil
+	}
+	return c.cipher.seal(data, []byte(cryptMetaAD+name))
+}
+
+// unmarshalMeta decrypts the metadata of the item called name if
+// required
+func (c *Cache) unmarshalMeta(data []byte, name string) ([]byte, error) {
+	if c.cipher == nil {
+		return data, nil
+	}
+	return c.cipher.open(data, []byte(cryptMetaAD+name))
+}
+
+// warnDirtyLost logs an error for each item with changes which
+// haven't been uploaded if the cache is encrypted with a random key
+// for this session, as the next session won't be able to read them.
+func (c *Cache) warnDirtyLost() {
+	if c.cipher == nil || c.opt.CacheEncryptKey != "" {
+		return
+	}
+	c.mu.Lock()
+	items := make([]*Item, 0, len(c.item))
+	for _, item := range c.item {
+		items = append(items, item)
+	}
+	c.mu.Unlock()
+	for _, item := range items {
+		if item.IsDirty() {
+			fs.Errorf(item.GetName(), "vfs cache: changes which haven't been uploaded are lost as the cache is encrypted with a random key for this session")
+		}
+	}
+}
+
+// cryptObject is the cache file as an fs.Object which reads the
+// plaintext so it can be uploaded with operations.Copy
+type cryptObject struct {
+	fs.Object
+	c      *Cache
+	osPath string
+	state  *cryptState
+	size   int64
+}
+
+// newCryptObject wraps the cache file o at osPath with state
+func (c *Cache) newCryptObject(o fs.Object, osPath string, state *cryptState) (*cryptObject, error) {
+	fi, err := c.statFile(osPath)
+	if err != nil {
+		return nil, err
+	}
+	return &cryptObject{Object: o, c: c, osPath: osPath, state: state, size: fi.Size()}, nil
+}
+
+// Size returns the size of the plaintext
+func (o *cryptObject) Size() int64 {
+	return o.size
+}
+
+// Open the plaintext for reading
+func (o *cryptObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	var offset, limit int64 = 0, -1
+	for _, option := range options {
+		switch x := option.(type) {
+		case *fs.SeekOption:
+			offset = x.Offset
+		case *fs.RangeOption:
+			offset, limit = x.Decode(o.size)
+		}
+	}
+	if limit < 0 || offset+limit > o.size {
+		limit = o.size - offset
+	}
+	fd, err := os.Open(o.osPath)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.NewSectionReader(o.c.wrapFile(fd, o.state), offset, limit),
+		Closer: fd,
+	}, nil
+}
+
+// Hash returns the hash of the plaintext
+func (o *cryptObject) Hash(ctx context.Context, ht hash.Type) (sum string, err error) {
+	hasher, err := hash.NewMultiHasherTypes(hash.NewHashSet(ht))
+	if err != nil {
+		return "", err
+	}
+	in, err := o.Open(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer fs.CheckClose(in, &err)
+	if _, err = io.Copy(hasher, in); err != nil {
+		return "", err
+	}
+	return hasher.Sums()[ht], nil
+}
@@ -0,0 +1,294 @@
+package vfscache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptSizes(t *testing.T) {
+	for _, size := range []int64{0, 1, 100, cryptBlockSize - 1, cryptBlockSize, cryptBlockSize + 1, 3*cryptBlockSize + 17} {
+		assert.Equal(t, size, plainSize(cipherSize(size)), size)
+	}
+	assert.Equal(t, int64(0), cipherSize(0))
+	assert.Equal(t, int64(cryptHeaderSize+cryptOverhead+1), cipherSize(1))
+}
+
+// newTestCryptFile makes a cryptFile in a temporary file
+func newTestCryptFile(t *testing.T, passphrase string, osPath string) *cryptFile {
+	return newTestCryptFileState(t, passphrase, osPath, newCryptState(nil, nil))
+}
+
+// newTestCryptFileState makes a cryptFile in a temporary file with state
+func newTestCryptFileState(t *testing.T, passphrase string, osPath string, state *cryptState) *cryptFile {
+	cc, err := newCacheCipher(passphrase, []byte("0123456789abcdef"))
+	require.NoError(t, err)
+	fd, err := os.OpenFile(osPath, os.O_RDWR|os.O_CREATE, 0600)
+	require.NoError(t, err)
+	f := &cryptFile{cc: cc, fd: fd, state: state}
+	t.Cleanup(func() { _ = f.Close() })
+	return f
+}
+
+func TestCryptFile(t *testing.T) {
+	osPath := filepath.Join(t.TempDir(), "file")
+	f := newTestCryptFile(t, "potato", osPath)
+	var want []byte
+
+	// check the file reads as want
+	check := func() {
+		fi, err := f.Stat()
+		require.NoError(t, err)
+		require.Equal(t, int64(len(want)), fi.Size())
+		got := make([]byte, len(want))
+		n, err := f.ReadAt(got, 0)
+		if err != io.EOF {
+			require.NoError(t, err)
+		}
+		require.Equal(t, len(want), n)
+		require.True(t, bytes.Equal(want, got))
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		switch r.Intn(4) {
+		case 0:
+			size := int64(r.Intn(4 * cryptBlockSize))
+			require.NoError(t, f.Truncate(size))
+			if size < int64(len(want)) {
+				want = want[:size]
+			} else {
+				want = append(want, make([]byte, size-int64(len(want)))...)
+			}
+		default:
+			off := int64(r.Intn(4 * cryptBlockSize))
+			b := make([]byte, r.Intn(2*cryptBlockSize))
+			_, _ = r.Read(b)
+			n, err := f.WriteAt(b, off)
+			require.NoError(t, err)
+			require.Equal(t, len(b), n)
+			if end := off + int64(len(b)); end > int64(len(want)) {
+				want = append(want, make([]byte, end-int64(len(want)))...)
+			}
+			copy(want[off:], b)
+		}
+		check()
+	}
+
+	// Read past the end
+	n, err := f.ReadAt(make([]byte, 10), int64(len(want)))
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 0, n)
+
+	// Reading with the wrong key fails
+	f2 := newTestCryptFile(t, "wrong", osPath)
+	_, err = f2.ReadAt(make([]byte, 10), 0)
+	assert.Equal(t, errCryptAuth, err)
+}
+
+func TestCryptFileHoles(t *testing.T) {
+	osPath := filepath.Join(t.TempDir(), "file")
+	f := newTestCryptFile(t, "potato", osPath)
+
+	// Write a block a long way past the end
+	off := int64(10 * cryptBlockSize)
+	_, err := f.WriteAt([]byte("hello"), off)
+	require.NoError(t, err)
+
+	fi, err := os.Stat(osPath)
+	require.NoError(t, err)
+	assert.Equal(t, cipherSize(off+5), fi.Size())
+
+	// The holes read as zeros
+	buf := make([]byte, 2*cryptBlockSize)
+	n, err := f.ReadAt(buf, cryptBlockSize)
+	require.NoError(t, err)
+	assert.Equal(t, len(buf), n)
+	assert.Equal(t, make([]byte, len(buf)), buf)
+
+	buf = make([]byte, 5)
+	_, err = f.ReadAt(buf, off)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+
+	// The plaintext isn't in the file
+	data, err := os.ReadFile(osPath)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(data, []byte("hello")))
+}
+
+func TestCryptFileTampered(t *testing.T) {
+	dir := t.TempDir()
+	osPath := filepath.Join(dir, "file")
+	state := newCryptState(nil, nil)
+	f := newTestCryptFileState(t, "potato", osPath, state)
+	_, err := f.WriteAt(bytes.Repeat([]byte("A"), 3*cryptBlockSize), 0)
+	require.NoError(t, err)
+	id, blocks := state.get()
+	assert.Equal(t, cryptIDSize, len(id))
+	assert.Equal(t, int64(3), blocks.Size())
+
+	// A written block replaced by a hole fails
+	_, err = f.fd.WriteAt(make([]byte, cryptDiskBlock), int64(cryptHeaderSize)+cryptDiskBlock)
+	require.NoError(t, err)
+	buf := make([]byte, 10)
+	_, err = f.ReadAt(buf, 0)
+	require.NoError(t, err)
+	_, err = f.ReadAt(buf, cryptBlockSize)
+	assert.Equal(t, errCryptAuth, err)
+
+	// Truncating forgets the blocks
+	require.NoError(t, f.Truncate(2*cryptBlockSize))
+	_, blocks = state.get()
+	assert.Equal(t, int64(2), blocks.Size())
+	require.NoError(t, f.Truncate(0))
+	id, blocks = state.get()
+	assert.Equal(t, 0, len(id))
+	assert.Equal(t, int64(0), blocks.Size())
+
+	// A data file from another item fails
+	otherPath := filepath.Join(dir, "other")
+	other := newTestCryptFile(t, "potato", otherPath)
+	_, err = other.WriteAt([]byte("hello"), 0)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte("hello"), 0)
+	require.NoError(t, err)
+	f2 := newTestCryptFileState(t, "potato", otherPath, state)
+	_, err = f2.ReadAt(buf[:5], 0)
+	assert.Equal(t, errCryptAuth, err)
+}
+
+func TestCryptSalt(t *testing.T) {
+	dir := t.TempDir()
+	osPath := filepath.Join(dir, "dir", "remote.salt")
+	salt, err := loadSalt(osPath)
+	require.NoError(t, err)
+	assert.Equal(t, cryptSaltSize, len(salt))
+
+	// The same salt is read back
+	salt2, err := loadSalt(osPath)
+	require.NoError(t, err)
+	assert.Equal(t, salt, salt2)
+
+	// Each cache has its own
+	salt3, err := loadSalt(filepath.Join(dir, "other.salt"))
+	require.NoError(t, err)
+	assert.NotEqual(t, salt, salt3)
+
+	// A corrupted salt is replaced
+	require.NoError(t, os.WriteFile(osPath, []byte("short"), 0600))
+	salt4, err := loadSalt(osPath)
+	require.NoError(t, err)
+	assert.Equal(t, cryptSaltSize, len(salt4))
+	assert.NotEqual(t, salt, salt4)
+}
+
+func TestCacheEncrypted(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.CachePollInterval = 0
+	opt.WriteBack = 0
+	opt.CacheEncrypt = true
+	opt.CacheEncryptKey = "potato"
+	r, c, cleanup := newTestCacheOpt(t, opt)
+	defer cleanup()
+
+	contents, obj, item := newFile(t, r, c, "existing")
+
+	// Read the file through the cache
+	require.NoError(t, item.Open(obj))
+	buf := make([]byte, 10)
+	_, err := item.ReadAt(buf, 0)
+	require.NoError(t, err)
+	assert.Equal(t, contents[:10], string(buf))
+
+	// Modify it and upload it
+	_, err = item.WriteAt([]byte("THEENDMYFRIEND"), 95)
+	require.NoError(t, err)
+	require.NoError(t, item.Close(nil))
+	checkObject(t, r, "existing", contents[:95]+"THEENDMYFRIEND")
+
+	// Check the size is correct
+	size, err := item.GetSize()
+	require.NoError(t, err)
+	assert.Equal(t, int64(109), size)
+
+	// The data and metadata in the cache are encrypted
+	data, err := os.ReadFile(c.toOSPath("existing"))
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(string(data), "THEENDMYFRIEND"))
+	meta, err := os.ReadFile(c.toOSPathMeta("existing"))
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(string(meta), "Fingerprint"))
+
+	// A cache with the same key can read the item
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c2, err := New(ctx, r.Fremote, &opt, nil)
+	require.NoError(t, err)
+	item2, _ := c2.get("existing")
+	assert.Equal(t, int64(109), item2.getDiskSize())
+
+	// ...and after it has been renamed
+	require.NoError(t, c2.Rename("existing", "renamed", nil))
+	c2, err = New(ctx, r.Fremote, &opt, nil)
+	require.NoError(t, err)
+	item2, _ = c2.get("renamed")
+	assert.Equal(t, int64(109), item2.getDiskSize())
+	require.NoError(t, c2.Rename("renamed", "existing", nil))
+
+	// Metadata moved to another item is removed
+	_, _, other := newFile(t, r, c2, "other")
+	require.NoError(t, other.Open(nil))
+	_, err = other.WriteAt([]byte("other"), 0)
+	require.NoError(t, err)
+	require.NoError(t, other.Close(nil))
+	meta, err = os.ReadFile(c2.toOSPathMeta("existing"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(c2.toOSPathMeta("other"), meta, 0600))
+	c2, err = New(ctx, r.Fremote, &opt, nil)
+	require.NoError(t, err)
+	item2, _ = c2.get("other")
+	assert.Equal(t, int64(0), item2.getDiskSize())
+	item2, _ = c2.get("existing")
+	assert.Equal(t, int64(109), item2.getDiskSize())
+
+	// A cache with a different key removes the item
+	opt.CacheEncryptKey = "wrong"
+	c3, err := New(ctx, r.Fremote, &opt, nil)
+	require.NoError(t, err)
+	item3, _ := c3.get("existing")
+	assert.Equal(t, int64(0), item3.getDiskSize())
+	assertPathNotExist(t, c.toOSPath("existing"))
+}
+
+func TestCacheEncryptedRandomKey(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.CachePollInterval = 0
+	opt.WriteBack = 0
+	opt.CacheEncrypt = true
+	r, c, cleanup := newTestCacheOpt(t, opt)
+	defer cleanup()
+
+	_, obj, item := newFile(t, r, c, "existing")
+	require.NoError(t, item.Open(obj))
+	_, err := item.ReadAt(make([]byte, 10), 0)
+	require.NoError(t, err)
+	require.NoError(t, item.Close(nil))
+
+	// A new session can't read the files of the old one and counts them
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c2, err := New(ctx, r.Fremote, &opt, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), c2.undecryptable)
+	assertPathNotExist(t, c.toOSPath("existing"))
+}
@@ -0,0 +1,275 @@
+package vfscache
+
+// The eviction policies choose the order in which files which aren't
+// in use are removed when the cache, or a directory in it, is over
+// quota.
+//
+// The policies other than LRU use the number of times each file has
+// been opened which is stored in the item metadata. LFU removes the
+// least often used files first. 2Q removes files which have only been
+// used once before files which have been used more often, so a scan
+// through lots of files removes the files it read rather than the
+// working set. ARC does the same but adapts how much of the cache is
+// given to files used once by remembering the names of files it
+// removed recently and seeing which sort get used again.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs/vfscommon"
+)
+
+// maxGhosts is the maximum number of names in each ARC ghost list
+const maxGhosts = 1024
+
+// evictInfo is a snapshot of an item for the eviction policies
+type evictInfo struct {
+	item     *Item
+	aTime    time.Time
+	accesses int64
+	size     int64
+}
+
+// ghostList remembers the names and sizes of recently removed items
+// oldest first
+type ghostList struct {
+	size  map[string]int64
+	order []string
+}
+
+// add name to the list, forgetting the oldest if it is full
+func (g *ghostList) add(name string, size int64) {
+	if g.size == nil {
+		g.size = make(map[string]int64)
+	}
+	if _, found := g.size[name]; !found {
+		g.order = append(g.order, name)
+	}
+	g.size[name] = size
+	for len(g.order) > maxGhosts {
+		delete(g.size, g.order[0])
+		g.order = g.order[1:]
+	}
+}
+
+// remove name from the list returning its size and whether it was found
+func (g *ghostList) remove(name string) (size int64, found bool) {
+	size, found = g.size[name]
+	if !found {
+		return 0, false
+	}
+	delete(g.size, name)
+	for i, orderName := range g.order {
+		if orderName == name {
+			g.order = append(g.order[:i], g.order[i+1:]...)
+			break
+		}
+	}
+	return size, true
+}
+
+// dirQuota limits the space used by the items in dir
+type dirQuota struct {
+	dir   string
+	quota int64
+}
+
+// parseDirQuotas parses the --vfs-cache-dir-max-size value
+func parseDirQuotas(in string) (quotas []dirQuota, err error) {
+	var list fs.CommaSepList
+	if in != "" {
+		err = list.Set(in)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --vfs-cache-dir-max-size %q: %w", in, err)
+		}
+	}
+	for _, s := range list {
+		i := strings.LastIndex(s, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid --vfs-cache-dir-max-size %q: must be dir=size", s)
+		}
+		var size fs.SizeSuffix
+		err = size.Set(s[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid --vfs-cache-dir-max-size %q: %w", s, err)
+		}
+		quotas = append(quotas, dirQuota{dir: clean(s[:i]), quota: int64(size)})
+	}
+	return quotas, nil
+}
+
+// inDir returns true if name is dir or inside it
+func inDir(name, dir string) bool {
+	return dir == "" || name == dir || strings.HasPrefix(name, dir+"/")
+}
+
+// getAccesses returns the number of times the item has been opened
+func (item *Item) getAccesses() int64 {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	return item.info.Accesses
+}
+
+// _evictOrder sorts items into the order they should be removed from
+// the cache according to --vfs-cache-evict-policy
+//
+// call with c.mu held
+func (c *Cache) _evictOrder(items Items) {
+	policy := c.opt.CacheEvictPolicy
+	if policy == vfscommon.EvictPolicyLRU {
+		sort.Sort(items)
+		return
+	}
+	infos := make([]evictInfo, len(items))
+	for i, item := range items {
+		item.mu.Lock()
+		infos[i] = evictInfo{
+			item:     item,
+			aTime:    item.info.ATime,
+			accesses: item.info.Accesses,
+			size:     item.info.Rs.Size(),
+		}
+		item.mu.Unlock()
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		a, b := infos[i], infos[j]
+		switch policy {
+		case vfscommon.EvictPolicyLFU:
+			if a.accesses != b.accesses {
+				return a.accesses < b.accesses
+			}
+		case vfscommon.EvictPolicy2Q, vfscommon.EvictPolicyARC:
+			if aOnce, bOnce := a.accesses <= 1, b.accesses <= 1; aOnce != bOnce {
+				return aOnce
+			}
+		}
+		return a.aTime.Before(b.aTime)
+	})
+	if policy == vfscommon.EvictPolicyARC {
+		infos = c._arcOrder(infos)
+	}
+	for i := range infos {
+		items[i] = infos[i].item
+	}
+}
+
+// _arcOrder takes infos with the items used once first and
+// interleaves them with the items used more often so the items used
+// once are removed first only while they use more than the target
+// space.
+//
+// call with c.mu held
+func (c *Cache) _arcOrder(infos []evictInfo) []evictInfo {
+	var (
+		once     = infos
+		often    []evictInfo
+		onceSize int64
+	)
+	for i, info := range infos {
+		if info.accesses > 1 {
+			once, often = infos[:i], infos[i:]
+			break
+		}
+		onceSize += info.size
+	}
+	out := make([]evictInfo, 0, len(infos))
+	for len(once) > 0 || len(often) > 0 {
+		if len(once) > 0 && (onceSize > c.arcTarget || len(often) == 0) {
+			out = append(out, once[0])
+			onceSize -= once[0].size
+			once = once[1:]
+		} else {
+			out = append(out, often[0])
+			often = often[1:]
+		}
+	}
+	return out
+}
+
+// _evicted records that name, which had been opened accesses times
+// and used size bytes, has been removed from the cache
+//
+// call with c.mu held
+func (c *Cache) _evicted(name string, accesses, size int64) {
+	atomic.AddInt64(&c.evictions, 1)
+	if c.opt.CacheEvictPolicy != vfscommon.EvictPolicyARC {
+		return
+	}
+	if accesses <= 1 {
+		c.ghostsOnce.add(name, size)
+	} else {
+		c.ghostsOften.add(name, size)
+	}
+}
+
+// _arcNewItem adapts the ARC target if the new item was removed from
+// the cache recently.
+//
+// If it was used once then the target space for items used once is
+// increased and if it was used more often it is decreased. Either way
+// it counts as used more than once from now on.
+//
+// call with c.mu held
+func (c *Cache) _arcNewItem(item *Item) {
+	if c.opt.CacheEvictPolicy != vfscommon.EvictPolicyARC {
+		return
+	}
+	if size, found := c.ghostsOnce.remove(item.name); found {
+		c.arcTarget += size
+		if quota := int64(c.opt.CacheMaxSize); quota > 0 && c.arcTarget > quota {
+			c.arcTarget = quota
+		}
+	} else if size, found := c.ghostsOften.remove(item.name); found {
+		c.arcTarget -= size
+		if c.arcTarget < 0 {
+			c.arcTarget = 0
+		}
+	} else {
+		return
+	}
+	item.mu.Lock()
+	if item.info.Accesses < 1 {
+		item.info.Accesses = 1
+	}
+	item.mu.Unlock()
+}
+
+// purgeDirOverQuota removes files not in use from dir, in the order
+// given by the evict policy, until the space they use is below quota
+func (c *Cache) purgeDirOverQuota(dir string, quota int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		items Items
+		used  int64
+	)
+	for name, item := range c.item {
+		if !inDir(name, dir) {
+			continue
+		}
+		used += item.getDiskSize()
+		if !item.inUse() {
+			items = append(items, item)
+		}
+	}
+	if used < quota {
+		return
+	}
+	fs.Debugf(nil, "vfs cache: directory %q is over quota: used %v, quota %v", dir, fs.SizeSuffix(used), fs.SizeSuffix(quota))
+
+	c._evictOrder(items)
+
+	// Remove items until the quota is OK
+	for _, item := range items {
+		if used < quota {
+			break
+		}
+		used -= c.removeNotInUse(item, 0, false)
+	}
+}
@@ -0,0 +1,157 @@
+package vfscache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/lib/ranges"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDirQuotas(t *testing.T) {
+	quotas, err := parseDirQuotas("")
+	require.NoError(t, err)
+	assert.Nil(t, quotas)
+
+	quotas, err = parseDirQuotas("downloads=10k,/a/b/=1M")
+	require.NoError(t, err)
+	assert.Equal(t, []dirQuota{
+		{dir: "downloads", quota: 10 * 1024},
+		{dir: "a/b", quota: 1024 * 1024},
+	}, quotas)
+
+	_, err = parseDirQuotas("downloads")
+	assert.Error(t, err)
+	_, err = parseDirQuotas("downloads=potato")
+	assert.Error(t, err)
+}
+
+func TestInDir(t *testing.T) {
+	assert.True(t, inDir("a", ""))
+	assert.True(t, inDir("a", "a"))
+	assert.True(t, inDir("a/b", "a"))
+	assert.False(t, inDir("ab", "a"))
+	assert.False(t, inDir("b/a", "a"))
+}
+
+func TestEvictOrder(t *testing.T) {
+	_, c, cleanup := newTestCache(t)
+	defer cleanup()
+
+	now := time.Now()
+	var items Items
+	for i, x := range []struct {
+		name     string
+		accesses int64
+	}{
+		{"a", 5},
+		{"b", 1},
+		{"c", 2},
+		{"d", 1},
+	} {
+		item := c.Item(x.name)
+		item.info.ATime = now.Add(time.Duration(i) * time.Second)
+		item.info.Accesses = x.accesses
+		item.info.Rs = ranges.Ranges{{Pos: 0, Size: 10}}
+		items = append(items, item)
+	}
+
+	order := func(policy vfscommon.EvictPolicy) (out []string) {
+		c.opt.CacheEvictPolicy = policy
+		sorted := append(Items(nil), items...)
+		c.mu.Lock()
+		c._evictOrder(sorted)
+		c.mu.Unlock()
+		for _, item := range sorted {
+			out = append(out, item.name)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, order(vfscommon.EvictPolicyLRU))
+	assert.Equal(t, []string{"b", "d", "c", "a"}, order(vfscommon.EvictPolicyLFU))
+	assert.Equal(t, []string{"b", "d", "a", "c"}, order(vfscommon.EvictPolicy2Q))
+	assert.Equal(t, []string{"b", "d", "a", "c"}, order(vfscommon.EvictPolicyARC))
+
+	// With a target the ARC keeps some of the items used once
+	c.arcTarget = 15
+	assert.Equal(t, []string{"b", "a", "c", "d"}, order(vfscommon.EvictPolicyARC))
+}
+
+func TestEvictARCGhosts(t *testing.T) {
+	_, c, cleanup := newTestCache(t)
+	defer cleanup()
+	c.opt.CacheEvictPolicy = vfscommon.EvictPolicyARC
+
+	c.mu.Lock()
+	c._evicted("once", 1, 100)
+	c._evicted("often", 3, 40)
+	c.mu.Unlock()
+
+	// Using an item removed after being used once increases the target
+	item := c.Item("once")
+	assert.Equal(t, int64(100), c.arcTarget)
+	assert.Equal(t, int64(1), item.getAccesses())
+
+	// Using an item removed after being used often decreases it
+	item = c.Item("often")
+	assert.Equal(t, int64(60), c.arcTarget)
+	assert.Equal(t, int64(1), item.getAccesses())
+
+	// The ghosts are forgotten once used
+	c.Item("other")
+	assert.Equal(t, int64(60), c.arcTarget)
+	_, found := c.ghostsOnce.remove("once")
+	assert.False(t, found)
+
+	// The ghost lists are limited in size
+	var g ghostList
+	for i := 0; i < maxGhosts+10; i++ {
+		g.add(string(rune('a'+i)), 1)
+	}
+	assert.Equal(t, maxGhosts, len(g.order))
+	assert.Equal(t, maxGhosts, len(g.size))
+}
+
+func TestCachePurgeDirOverQuota(t *testing.T) {
+	r, c, cleanup := newTestCache(t)
+	defer cleanup()
+
+	// Make some test files
+	for _, name := range []string{"noisy/a", "noisy/sub/b", "noisyother/c", "quiet/d"} {
+		item := c.Item(name)
+		itemWrite(t, item, "hello")
+		require.NoError(t, item.Close(nil))
+	}
+
+	// make noisy/a older
+	c.Item("noisy/a").info.ATime = time.Now().Add(-time.Hour)
+
+	c.purgeDirOverQuota("noisy", 6)
+	assert.Equal(t, []string{
+		`name="noisy/sub/b" opens=0 size=5`,
+		`name="noisyother/c" opens=0 size=5`,
+		`name="quiet/d" opens=0 size=5`,
+	}, itemAsString(c))
+
+	// The directory is under quota now
+	c.purgeDirOverQuota("noisy", 6)
+	assert.Equal(t, 3, len(itemAsString(c)))
+
+	// Files in use aren't removed
+	item := c.Item("quiet/d")
+	obj, err := r.Fremote.NewObject(context.Background(), "quiet/d")
+	require.NoError(t, err)
+	require.NoError(t, item.Open(obj))
+	c.purgeDirOverQuota("quiet", 1)
+	assert.Equal(t, 3, len(itemAsString(c)))
+	require.NoError(t, item.Close(nil))
+	c.purgeDirOverQuota("quiet", 1)
+	assert.Equal(t, []string{
+		`name="noisy/sub/b" opens=0 size=5`,
+		`name="noisyother/c" opens=0 size=5`,
+	}, itemAsString(c))
+}
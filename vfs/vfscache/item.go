@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rclone/rclone/fs"
@@ -62,12 +63,13 @@ type Item struct {
 	opens           int                      // number of times file is open
 	downloaders     *downloaders.Downloaders // a record of the downloaders in action - may be nil
 	o               fs.Object                // object we are caching - may be nil
-	fd              *os.File                 // handle we are using to read and write to the file
+	fd              cacheFile                // handle we are using to read and write to the file
 	info            Info                     // info about the file to persist to backing store
 	writeBackID     writeback.Handle         // id of any writebacks in progress
 	pendingAccesses int                      // number of threads - cache reset not allowed if not zero
 	modified        bool                     // set if the file has been modified since the last Open
 	beingReset      bool                     // cache cleaner is resetting the cache file, access not allowed
+	crypt           *cryptState              // state of the encrypted cache file - nil if not encrypting
 }
 
 // Info is persisted to backing store
@@ -78,6 +80,12 @@ type Info struct {
 	Rs          ranges.Ranges // which parts of the file are present
 	Fingerprint string        // fingerprint of remote object
 	Dirty       bool          // set if the backing file has been modified
+	Pinned      bool          // set if the file should be kept in the cache
+	Accesses    int64         // number of times the file has been opened
+	Metadata    fs.Metadata   // if set, metadata to replace the remote metadata on upload
+	Refused     bool          // set if the upload was refused by --vfs-write-back-conflict refuse
+	CryptID     []byte        // if encrypting, the ID of the cache file
+	CryptBlocks ranges.Ranges // if encrypting, which blocks of the cache file have been written
 }
 
 // Items are a slice of *Item ordered by ATime
@@ -94,11 +102,12 @@ const (
 	RemovedNotInUse                         // Item not used. Remove instead of reset
 	ResetFailed                             // Reset failed with an error
 	ResetComplete                           // Reset completed successfully
+	SkippedPinned                           // Pinned item is never reset
 )
 
 func (rr ResetResult) String() string {
 	return [...]string{"Dirty item skipped", "In-access item skipped", "Empty item skipped",
-		"Not-in-use item removed", "Item reset failed", "Item reset completed"}[rr]
+		"Not-in-use item removed", "Item reset failed", "Item reset completed", "Pinned item skipped"}[rr]
 }
 
 func (v Items) Len() int      { return len(v) }
@@ -118,8 +127,10 @@ func (v Items) Less(i, j int) bool {
 }
 
 // clean the item after its cache file has been deleted
+//
+// The pin is kept as it belongs to the name rather than the data.
 func (info *Info) clean() {
-	*info = Info{}
+	*info = Info{Pinned: info.Pinned}
 	info.ModTime = time.Now()
 	info.ATime = info.ModTime
 }
@@ -141,7 +152,7 @@ func newItem(c *Cache, name string) (item *Item) {
 	item.cond = sync.Cond{L: &item.mu}
 	// check the cache file exists
 	osPath := c.toOSPath(name)
-	fi, statErr := os.Stat(osPath)
+	fi, statErr := c.statFile(osPath)
 	if statErr != nil {
 		if os.IsNotExist(statErr) {
 			item._removeMeta("cache file doesn't exist")
@@ -155,8 +166,14 @@ func newItem(c *Cache, name string) (item *Item) {
 	if !exists {
 		item._removeFile("metadata doesn't exist")
 	} else if err != nil {
+		if errors.Is(err, errCryptAuth) {
+			atomic.AddInt64(&c.undecryptable, 1)
+		}
 		item.remove(fmt.Sprintf("failed to load metadata: %v", err))
 	}
+	if c.cipher != nil {
+		item.crypt = newCryptState(item.info.CryptID, item.info.CryptBlocks)
+	}
 
 	// Get size estimate (which is best we can do until Open() called)
 	if statErr == nil {
@@ -202,8 +219,14 @@ func (item *Item) load() (exists bool, err error) {
 		return true, fmt.Errorf("vfs cache item: failed to read metadata: %w", err)
 	}
 	defer fs.CheckClose(in, &err)
-	decoder := json.NewDecoder(in)
-	err = decoder.Decode(&item.info)
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return true, fmt.Errorf("vfs cache item: failed to read metadata: %w", err)
+	}
+	data, err = item.c.unmarshalMeta(data, item.name)
+	if err == nil {
+		err = json.Unmarshal(data, &item.info)
+	}
 	if err != nil {
 		return true, fmt.Errorf("vfs cache item: corrupt metadata: %w", err)
 	}
@@ -214,17 +237,26 @@ func (item *Item) load() (exists bool, err error) {
 //
 // call with the lock held
 func (item *Item) _save() (err error) {
+	if item.crypt != nil {
+		item.info.CryptID, item.info.CryptBlocks = item.crypt.get()
+	}
+	data, err := json.MarshalIndent(item.info, "", "\t")
+	if err != nil {
+		return fmt.Errorf("vfs cache item: failed to encode metadata: %w", err)
+	}
+	data, err = item.c.marshalMeta(append(data, '\n'), item.name)
+	if err != nil {
+		return fmt.Errorf("vfs cache item: failed to encrypt metadata: %w", err)
+	}
 	osPathMeta := item.c.toOSPathMeta(item.name) // No locking in Cache
 	out, err := os.Create(osPathMeta)
 	if err != nil {
 		return fmt.Errorf("vfs cache item: failed to write metadata: %w", err)
 	}
 	defer fs.CheckClose(out, &err)
-	encoder := json.NewEncoder(out)
-	encoder.SetIndent("", "\t")
-	err = encoder.Encode(item.info)
+	_, err = out.Write(data)
 	if err != nil {
-		return fmt.Errorf("vfs cache item: failed to encode metadata: %w", err)
+		return fmt.Errorf("vfs cache item: failed to write metadata: %w", err)
 	}
 	return nil
 }
@@ -245,12 +277,18 @@ func (item *Item) _truncate(size int64) (err error) {
 	if fd == nil {
 		// If the metadata says we have some blocks cached then the
 		// file should exist, so open without O_CREATE
+		//
+		// Encrypted files need reading to change their size
 		oFlags := os.O_WRONLY
+		if item.c.cipher != nil {
+			oFlags = os.O_RDWR
+		}
 		if item.info.Rs.Size() == 0 {
 			oFlags |= os.O_CREATE
 		}
 		osPath := item.c.toOSPath(item.name) // No locking in Cache
-		fd, err = file.OpenFile(osPath, oFlags, 0600)
+		var osFd *os.File
+		osFd, err = file.OpenFile(osPath, oFlags, 0600)
 		if err != nil && os.IsNotExist(err) {
 			// If the metadata has info but the file doesn't
 			// not exist then it has been externally removed
@@ -258,18 +296,19 @@ func (item *Item) _truncate(size int64) (err error) {
 			item.info.Rs = nil      // show we have no blocks cached
 			item.info.Dirty = false // file can't be dirty if it doesn't exist
 			item._removeMeta("cache file externally deleted")
-			fd, err = file.OpenFile(osPath, os.O_CREATE|os.O_WRONLY, 0600)
+			osFd, err = file.OpenFile(osPath, oFlags|os.O_CREATE, 0600)
 		}
 		if err != nil {
 			return fmt.Errorf("vfs cache: truncate: failed to open cache file: %w", err)
 		}
 
-		defer fs.CheckClose(fd, &err)
+		defer fs.CheckClose(osFd, &err)
 
-		err = file.SetSparse(fd)
+		err = file.SetSparse(osFd)
 		if err != nil {
 			fs.Errorf(item.name, "vfs cache: truncate: failed to set as a sparse file: %v", err)
 		}
+		fd = item.c.wrapFile(osFd, item.crypt)
 	}
 
 	// Check to see what the current size is, and don't truncate
@@ -378,7 +417,7 @@ func (item *Item) _stat() (fi os.FileInfo, err error) {
 		return item.fd.Stat()
 	}
 	osPath := item.c.toOSPath(item.name) // No locking in Cache
-	return os.Stat(osPath)
+	return item.c.statFile(osPath)
 }
 
 // _getSize gets the current size of the item and updates item.info.Size
@@ -484,7 +523,7 @@ func (item *Item) _createFile(osPath string) (err error) {
 	if err != nil {
 		fs.Errorf(item.name, "vfs cache: failed to set as a sparse file: %v", err)
 	}
-	item.fd = fd
+	item.fd = item.c.wrapFile(fd, item.crypt)
 
 	err = item._save()
 	if err != nil {
@@ -526,6 +565,7 @@ func (item *Item) open(o fs.Object) (err error) {
 	defer item.mu.Unlock()
 
 	item.info.ATime = time.Now()
+	item.info.Accesses++
 
 	osPath, err := item.c.createItemDir(item.name) // No locking in Cache
 	if err != nil {
@@ -593,16 +633,52 @@ func (item *Item) _store(ctx context.Context, storeFn StoreFn) (err error) {
 		return fmt.Errorf("vfs cache: failed to find cache file: %w", err)
 	}
 
+	// Upload the plaintext if the cache file is encrypted
+	if cacheObj != nil && item.c.cipher != nil {
+		cacheObj, err = item.c.newCryptObject(cacheObj, item.c.toOSPath(item.name), item.crypt) // No locking in Cache
+		if err != nil {
+			return fmt.Errorf("vfs cache: failed to read cache file: %w", err)
+		}
+	}
+
 	// Object has disappeared if cacheObj == nil
+	metadataChanged := false
 	if cacheObj != nil {
-		o, name := item.o, item.name
+		name, fingerprint, metadata, o := item.name, item.info.Fingerprint, item.info.Metadata, item.o
 		item.mu.Unlock()
-		o, err := operations.Copy(ctx, item.c.fremote, o, name, cacheObj)
+		// Check the remote hasn't changed since the file was opened
+		o, err := item.checkConflict(ctx, name, fingerprint, o)
+		if err == nil {
+			var src fs.Object = cacheObj
+			if metadata != nil {
+				// Upload the metadata set through the VFS too
+				src = &metadataObject{Object: cacheObj, metadata: metadata}
+				var ci *fs.ConfigInfo
+				ctx, ci = fs.AddConfig(ctx)
+				ci.Metadata = true
+			}
+			o, err = operations.Copy(ctx, item.c.fremote, o, name, src)
+		}
 		item.mu.Lock()
+		if errors.Is(err, writeback.ErrConflict) {
+			// Remember the refusal so the upload isn't retried
+			// until the file is written to again
+			item.info.Refused = true
+			if saveErr := item._save(); saveErr != nil {
+				fs.Errorf(item.name, "vfs cache: failed to write metadata file: %v", saveErr)
+			}
+			return err
+		}
 		if err != nil {
 			return fmt.Errorf("vfs cache: failed to transfer file from cache to remote: %w", err)
 		}
 		item.o = o
+		// Keep any metadata set while uploading for the next upload
+		metadataChanged = !metadataEqual(item.info.Metadata, metadata)
+		if !metadataChanged {
+			item.info.Metadata = nil
+		}
+		item.info.Refused = false
 		item._updateFingerprint()
 	}
 
@@ -618,8 +694,9 @@ func (item *Item) _store(ctx context.Context, storeFn StoreFn) (err error) {
 		item.mu.Lock()
 	}
 
-	// Show item is clean and is elegible for cache removal
-	item.info.Dirty = false
+	// Show item is clean and is elegible for cache removal, unless
+	// metadata was set while uploading
+	item.info.Dirty = metadataChanged
 	err = item._save()
 	if err != nil {
 		fs.Errorf(item.name, "vfs cache: failed to write metadata file: %v", err)
@@ -720,8 +797,11 @@ func (item *Item) Close(storeFn StoreFn) (err error) {
 		}
 	}
 
-	// upload the file to backing store if changed
-	if item.info.Dirty {
+	// upload the file to backing store if changed, unless the
+	// upload was refused and it hasn't been written to since
+	if item.info.Dirty && item.info.Refused && !item.modified {
+		fs.Debugf(item.name, "vfs cache: not uploading as upload was refused and file hasn't been written to since")
+	} else if item.info.Dirty {
 		fs.Infof(item.name, "vfs cache: queuing for upload in %v", item.c.opt.WriteBack)
 		if syncWriteBack {
 			// do synchronous writeback
@@ -752,22 +832,27 @@ func (item *Item) Close(storeFn StoreFn) (err error) {
 // metaDirty will be false.
 func (item *Item) reload(ctx context.Context) error {
 	item.mu.Lock()
-	dirty := item.info.Dirty
+	dirty, refused := item.info.Dirty, item.info.Refused
 	item.mu.Unlock()
 	if !dirty {
 		return nil
 	}
-	// see if the object still exists
-	obj, _ := item.c.fremote.NewObject(ctx, item.name)
-	// open the file with the object (or nil)
-	err := item.Open(obj)
-	if err != nil {
-		return err
-	}
-	// close the file to execute the writeback if needed
-	err = item.Close(nil)
-	if err != nil {
-		return err
+	if refused {
+		// Retrying won't help so leave the changes in the cache
+		fs.Errorf(item.name, "vfs cache: upload was refused as the file changed on the remote - local changes kept in the cache until the file is written to again")
+	} else {
+		// see if the object still exists
+		obj, _ := item.c.fremote.NewObject(ctx, item.name)
+		// open the file with the object (or nil)
+		err := item.Open(obj)
+		if err != nil {
+			return err
+		}
+		// close the file to execute the writeback if needed
+		err = item.Close(nil)
+		if err != nil {
+			return err
+		}
 	}
 	// put the file into the directory listings
 	size, err := item._getSize()
@@ -907,7 +992,7 @@ func (item *Item) RemoveNotInUse(maxAge time.Duration, emptyOnly bool) (removed
 	spaceFreed = 0
 	removed = false
 
-	if item.opens != 0 || item.info.Dirty {
+	if item.opens != 0 || item.info.Dirty || item.info.Pinned {
 		return
 	}
 
@@ -942,6 +1027,11 @@ func (item *Item) Reset() (rr ResetResult, spaceFreed int64, err error) {
 	item.mu.Lock()
 	defer item.mu.Unlock()
 
+	// Pinned items are never evicted
+	if item.info.Pinned {
+		return SkippedPinned, 0, nil
+	}
+
 	// The item is not being used now.  Just remove it instead of resetting it.
 	if item.opens == 0 && !item.info.Dirty {
 		spaceFreed = item.info.Rs.Size()
@@ -1141,6 +1231,7 @@ func (item *Item) _ensure(offset, size int64) (err error) {
 	item.mu.Unlock()
 	defer item.mu.Lock()
 	if present {
+		atomic.AddInt64(&item.c.hits, 1)
 		// This is a file we are writing so no downloaders needed
 		if item.downloaders == nil {
 			return nil
@@ -1148,6 +1239,7 @@ func (item *Item) _ensure(offset, size int64) (err error) {
 		// Otherwise start the downloader for the future if required
 		return item.downloaders.EnsureDownloader(r)
 	}
+	atomic.AddInt64(&item.c.misses, 1)
 	if item.downloaders == nil {
 		// Downloaders can be nil here if the file has been
 		// renamed, so need to make some more downloaders
@@ -1426,6 +1518,16 @@ func (item *Item) rename(name string, newName string, newObj fs.Object) (err err
 		err = err2
 	}
 
+	// Encrypted metadata is sealed with the name so reseal it
+	if err2 == nil && item.c.cipher != nil {
+		if _, statErr := os.Stat(item.c.toOSPathMeta(newName)); statErr == nil {
+			err2 = item._save()
+			if err2 != nil {
+				err = err2
+			}
+		}
+	}
+
 	item.mu.Unlock()
 
 	// close downloader and cancel writebacks with mutex unlocked
@@ -0,0 +1,145 @@
+package vfscache
+
+// Metadata set through the VFS, for example as extended attributes on
+// a mount, is stored in the item metadata and the item is marked
+// dirty, so the file is uploaded again with its new metadata by the
+// write back. Setting the metadata doesn't open the file so it doesn't
+// wait for the file to be downloaded and uploaded.
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs/vfscache/writeback"
+)
+
+// metadataObject is the cache file as an fs.Object with the metadata
+// to set on the remote object
+type metadataObject struct {
+	fs.Object
+	metadata fs.Metadata
+}
+
+// Metadata returns the metadata for the object
+func (o *metadataObject) Metadata(ctx context.Context) (fs.Metadata, error) {
+	return o.metadata, nil
+}
+
+// Check interfaces
+var _ fs.Metadataer = (*metadataObject)(nil)
+
+// getMetadata returns a copy of the metadata waiting to be set on the
+// remote object or nil if there is none
+func (item *Item) getMetadata() (metadata fs.Metadata) {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	if item.info.Metadata == nil {
+		return nil
+	}
+	metadata = make(fs.Metadata, len(item.info.Metadata))
+	metadata.Merge(item.info.Metadata)
+	return metadata
+}
+
+// setMetadata sets the metadata to replace the metadata of the remote
+// object when the item is next uploaded and marks the item dirty.
+//
+// It returns true and the write back ID to use if the item isn't
+// open, so the upload needs queueing, otherwise the upload is done
+// when the item is closed.
+func (item *Item) setMetadata(metadata fs.Metadata) (id writeback.Handle, queue bool, err error) {
+	item.preAccess()
+	defer item.postAccess()
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	item.info.Metadata = make(fs.Metadata, len(metadata))
+	item.info.Metadata.Merge(metadata)
+	// Changing the metadata doesn't change the modification time
+	modTime := item.info.ModTime
+	item._dirty()
+	item.info.ModTime = modTime
+	if item.opens == 0 {
+		item.c.writeback.SetID(&item.writeBackID)
+		id, queue = item.writeBackID, true
+	}
+	return id, queue, item._save()
+}
+
+// storeMetadata is called by the write back to upload the item with
+// its new metadata. It opens the item so any parts of the file which
+// aren't in the cache are downloaded first.
+//
+// o is the remote object for the item if known
+func (item *Item) storeMetadata(ctx context.Context, o fs.Object, storeFn StoreFn) (err error) {
+	err = item.Open(o)
+	if err != nil {
+		return fmt.Errorf("vfs cache: failed to open item to set metadata: %w", err)
+	}
+	item.mu.Lock()
+	if item.info.Dirty {
+		err = item._ensure(0, item.info.Size)
+		if err != nil {
+			err = fmt.Errorf("vfs cache: failed to download missing parts of cache file: %w", err)
+		} else {
+			err = item._store(ctx, storeFn)
+		}
+	}
+	item.mu.Unlock()
+	// If the store failed the item is still dirty so closing it
+	// queues it for upload again
+	closeErr := item.Close(storeFn)
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// Metadata returns the metadata waiting to be set on the remote
+// object name or nil if there is none.
+func (c *Cache) Metadata(name string) fs.Metadata {
+	name = clean(name)
+	c.mu.Lock()
+	item := c.item[name]
+	c.mu.Unlock()
+	if item == nil {
+		return nil
+	}
+	return item.getMetadata()
+}
+
+// SetMetadata queues metadata to replace the metadata of the remote
+// object name. The file is uploaded again with the new metadata by
+// the write back after --vfs-write-back, or when it is closed if it
+// is open.
+//
+// o is the remote object for name if known and storeFn is called
+// with the new object once it has been uploaded.
+func (c *Cache) SetMetadata(name string, o fs.Object, metadata fs.Metadata, storeFn StoreFn) error {
+	name = clean(name)
+	item := c.Item(name)
+	id, queue, err := item.setMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("vfs cache: failed to set metadata: %w", err)
+	}
+	if queue {
+		// Cancel any upload in progress as it has the old metadata
+		c.writeback.Add(id, name, true, func(ctx context.Context) error {
+			return item.storeMetadata(ctx, o, storeFn)
+		})
+	}
+	return nil
+}
+
+// metadataEqual returns true if a and b contain the same metadata
+func metadataEqual(a, b fs.Metadata) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
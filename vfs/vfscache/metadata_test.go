@@ -0,0 +1,83 @@
+package vfscache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheSetMetadata(t *testing.T) {
+	r, c, cleanup := newItemTestCache(t)
+	defer cleanup()
+	if !r.Fremote.Features().UserMetadata {
+		t.Skip("remote doesn't support user metadata")
+	}
+
+	contents, obj, _ := newFile(t, r, c, "existing")
+	assert.Nil(t, c.Metadata("existing"))
+
+	var stored fs.Object
+	storeFn := func(o fs.Object) {
+		stored = o
+	}
+	require.NoError(t, c.SetMetadata("existing", obj, fs.Metadata{"potato": "chips"}, storeFn))
+
+	// The upload is left to the write back
+	assert.Equal(t, fs.Metadata{"potato": "chips"}, c.Metadata("existing"))
+	waitForUploads(t, c)
+
+	// The file was uploaded with the metadata
+	require.NotNil(t, stored)
+	assert.Nil(t, c.Metadata("existing"))
+	assert.False(t, c.Item("existing").IsDirty())
+	checkObject(t, r, "existing", contents)
+	checkMetadata(t, r, "existing", "potato", "chips")
+
+	// If the file is open the upload is done when it is closed
+	item := c.Item("existing")
+	require.NoError(t, item.Open(stored))
+	require.NoError(t, c.SetMetadata("existing", stored, fs.Metadata{"potato": "crisps"}, storeFn))
+	_, uploadsQueued := c.writeback.Stats()
+	assert.Equal(t, 0, uploadsQueued)
+	checkMetadata(t, r, "existing", "potato", "chips")
+	require.NoError(t, item.Close(storeFn))
+	waitForUploads(t, c)
+	assert.Nil(t, c.Metadata("existing"))
+	checkObject(t, r, "existing", contents)
+	checkMetadata(t, r, "existing", "potato", "crisps")
+}
+
+func TestMetadataEqual(t *testing.T) {
+	assert.True(t, metadataEqual(nil, nil))
+	assert.True(t, metadataEqual(nil, fs.Metadata{}))
+	assert.True(t, metadataEqual(fs.Metadata{"a": "1"}, fs.Metadata{"a": "1"}))
+	assert.False(t, metadataEqual(fs.Metadata{"a": "1"}, nil))
+	assert.False(t, metadataEqual(fs.Metadata{"a": "1"}, fs.Metadata{"a": "2"}))
+	assert.False(t, metadataEqual(fs.Metadata{"a": "1"}, fs.Metadata{"b": "1"}))
+}
+
+// waitForUploads waits for the write back to be idle
+func waitForUploads(t *testing.T, c *Cache) {
+	for i := 0; i < 100; i++ {
+		uploadsInProgress, uploadsQueued := c.writeback.Stats()
+		if uploadsInProgress == 0 && uploadsQueued == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for uploads")
+}
+
+// checkMetadata checks the remote object has metadata key set to value
+func checkMetadata(t *testing.T, r *fstest.Run, remote, key, value string) {
+	o, err := r.Fremote.NewObject(context.Background(), remote)
+	require.NoError(t, err)
+	metadata, err := fs.GetMetadata(context.Background(), o)
+	require.NoError(t, err)
+	assert.Equal(t, value, metadata[key])
+}
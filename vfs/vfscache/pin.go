@@ -0,0 +1,227 @@
+package vfscache
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/ranges"
+)
+
+// Pinned files are downloaded completely in the background and are
+// never removed from the cache by the cleaner, whatever their age or
+// the size of the cache. The pin is stored in the item metadata so it
+// survives a restart.
+
+// isPinned returns true if the item is pinned
+func (item *Item) isPinned() bool {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	return item.info.Pinned
+}
+
+// setPinned sets whether the item is pinned and saves the metadata
+func (item *Item) setPinned(pinned bool) error {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	if item.info.Pinned == pinned {
+		return nil
+	}
+	item.info.Pinned = pinned
+	return item._save()
+}
+
+// prefetch downloads any parts of the file not in the cache using
+// the downloaders, returning when it is complete.
+//
+// The item must be open.
+func (item *Item) prefetch() error {
+	item.preAccess()
+	defer item.postAccess()
+	item.mu.Lock()
+	r := ranges.Range{Pos: 0, Size: item.info.Size}
+	present := item.info.Rs.Present(r)
+	dls := item.downloaders
+	item.mu.Unlock()
+	if present || r.Size <= 0 {
+		return nil
+	}
+	if dls == nil {
+		return errors.New("vfs cache: no downloaders to prefetch with")
+	}
+	return dls.Download(r)
+}
+
+// Pin marks the file name as pinned and starts downloading it in the
+// background if it isn't completely in the cache.
+//
+// o is the remote object for name.
+func (c *Cache) Pin(name string, o fs.Object) error {
+	if o == nil {
+		return errors.New("can't pin a file which is being written")
+	}
+	name = clean(name)
+	// Stop the cleaner starting a download until we are done
+	if !c.reservePrefetch(name) {
+		// already pinned and downloading
+		return nil
+	}
+	item := c.Item(name)
+	// Opening the item makes sure the cache file exists so the
+	// metadata isn't thrown away on a restart
+	err := item.Open(o)
+	if err == nil {
+		err = item.setPinned(true)
+		closeErr := item.Close(nil)
+		if err != nil {
+			err = fmt.Errorf("vfs cache: failed to pin: %w", err)
+		} else {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		c.releasePrefetch(name)
+		return err
+	}
+	fs.Infof(name, "vfs cache: pinned")
+	go c.runPrefetch(name, o)
+	return nil
+}
+
+// Unpin removes the pin from the file name so it can be removed from
+// the cache in the normal way. It is not an error if name isn't
+// pinned.
+func (c *Cache) Unpin(name string) error {
+	name = clean(name)
+	c.mu.Lock()
+	item := c.item[name]
+	c.mu.Unlock()
+	if item == nil {
+		return nil
+	}
+	if err := item.setPinned(false); err != nil {
+		return fmt.Errorf("vfs cache: failed to unpin: %w", err)
+	}
+	fs.Infof(name, "vfs cache: unpinned")
+	return nil
+}
+
+// IsPinned returns true if the file name is pinned
+func (c *Cache) IsPinned(name string) bool {
+	name = clean(name)
+	c.mu.Lock()
+	item := c.item[name]
+	c.mu.Unlock()
+	return item != nil && item.isPinned()
+}
+
+// prefetchPinned starts downloading any pinned files which aren't
+// completely in the cache.
+//
+// This picks up pinned files from before a restart and files which
+// were reset because they changed on the remote.
+func (c *Cache) prefetchPinned() {
+	var names []string
+	c.mu.Lock()
+	for name, item := range c.item {
+		if _, found := c.prefetching[name]; found {
+			continue
+		}
+		// Leave files in use until they are finished with
+		if item.isPinned() && !item.present() && !item.inUse() {
+			names = append(names, name)
+		}
+	}
+	c.mu.Unlock()
+	for _, name := range names {
+		if c.reservePrefetch(name) {
+			go c.runPrefetch(name, nil)
+		}
+	}
+}
+
+// reservePrefetch marks name as being downloaded returning false if
+// it is already
+func (c *Cache) reservePrefetch(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, found := c.prefetching[name]; found {
+		return false
+	}
+	c.prefetching[name] = struct{}{}
+	return true
+}
+
+// releasePrefetch marks name as no longer being downloaded
+func (c *Cache) releasePrefetch(name string) {
+	c.mu.Lock()
+	delete(c.prefetching, name)
+	c.mu.Unlock()
+}
+
+// runPrefetch downloads name, which must have been reserved with
+// reservePrefetch, and releases it when done. If o is nil then the
+// object is found on the remote.
+func (c *Cache) runPrefetch(name string, o fs.Object) {
+	defer c.releasePrefetch(name)
+	err := c.prefetch(name, o)
+	if err != nil {
+		fs.Errorf(name, "vfs cache: failed to download pinned file: %v", err)
+	}
+}
+
+// prefetch downloads name into the cache, limiting the number of
+// simultaneous downloads to --transfers.
+func (c *Cache) prefetch(name string, o fs.Object) (err error) {
+	select {
+	case c.prefetchTokens <- struct{}{}:
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	}
+	defer func() { <-c.prefetchTokens }()
+	if o == nil {
+		o, err = c.fremote.NewObject(c.ctx, name)
+		if err != nil {
+			return err
+		}
+	}
+	item := c.Item(name)
+	if !item.isPinned() {
+		return nil
+	}
+	err = item.Open(o)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := item.Close(nil)
+		if err == nil {
+			err = closeErr
+		}
+	}()
+	fs.Debugf(name, "vfs cache: downloading pinned file")
+	err = item.prefetch()
+	if err == nil {
+		fs.Infof(name, "vfs cache: pinned file downloaded")
+	}
+	return err
+}
+
+// pinStats returns the number of pinned files, how many bytes of
+// them are in the cache and how many are waiting to be downloaded.
+//
+// call with c.mu held
+func (c *Cache) _pinStats() (files int, bytes int64, pending int) {
+	for _, item := range c.item {
+		item.mu.Lock()
+		if item.info.Pinned {
+			files++
+			bytes += item.info.Rs.Size()
+			if !item._present() {
+				pending++
+			}
+		}
+		item.mu.Unlock()
+	}
+	return files, bytes, pending
+}
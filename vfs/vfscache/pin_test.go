@@ -0,0 +1,90 @@
+package vfscache
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForPinned waits for all the pinned files to be downloaded
+func waitForPinned(t *testing.T, c *Cache) {
+	for i := 0; i < 1000; i++ {
+		c.mu.Lock()
+		_, _, pending := c._pinStats()
+		prefetching := len(c.prefetching)
+		c.mu.Unlock()
+		if pending == 0 && prefetching == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for pinned files to download")
+}
+
+func TestCachePin(t *testing.T) {
+	r, c, cleanup := newTestCache(t)
+	defer cleanup()
+
+	contents, obj, _ := newFileLength(t, r, c, "sub/pinned", 10000)
+	require.NoError(t, c.Pin("sub/pinned", obj))
+	waitForPinned(t, c)
+	assert.True(t, c.IsPinned("sub/pinned"))
+	assert.False(t, c.IsPinned("sub/notpinned"))
+
+	// Check the file was downloaded completely
+	data, err := os.ReadFile(c.toOSPath("sub/pinned"))
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(data))
+
+	out := c.Stats()
+	assert.Equal(t, 1, out["pinnedFiles"])
+	assert.Equal(t, int64(10000), out["pinnedBytes"])
+	assert.Equal(t, 0, out["pinnedPending"])
+
+	// Pinned files survive the cleaner whatever the limits
+	c.purgeOld(-10 * time.Second)
+	c.purgeOverQuota(1)
+	c.purgeClean(1)
+	assert.Equal(t, []string{
+		`name="sub/pinned" opens=0 size=10000 space=10000`,
+	}, itemSpaceAsString(c))
+
+	// The pin is stored in the metadata
+	assert.True(t, newItem(c, "sub/pinned").isPinned())
+
+	// Once unpinned they can be removed
+	require.NoError(t, c.Unpin("sub/pinned"))
+	assert.False(t, c.IsPinned("sub/pinned"))
+	require.NoError(t, c.Unpin("sub/notpinned"))
+	c.purgeOld(-10 * time.Second)
+	assert.Equal(t, []string(nil), itemSpaceAsString(c))
+}
+
+func TestCachePrefetchPinned(t *testing.T) {
+	r, c, cleanup := newTestCache(t)
+	defer cleanup()
+
+	// Make a pinned item with no data, as it would be if the
+	// download was interrupted by a restart
+	contents, obj, item := newFileLength(t, r, c, "pinned", 1000)
+	require.NoError(t, item.Open(obj))
+	require.NoError(t, item.setPinned(true))
+	require.NoError(t, item.Close(nil))
+	out := c.Stats()
+	assert.Equal(t, 1, out["pinnedFiles"])
+	assert.Equal(t, int64(0), out["pinnedBytes"])
+	assert.Equal(t, 1, out["pinnedPending"])
+
+	c.prefetchPinned()
+	waitForPinned(t, c)
+
+	data, err := os.ReadFile(c.toOSPath("pinned"))
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(data))
+	out = c.Stats()
+	assert.Equal(t, int64(1000), out["pinnedBytes"])
+	assert.Equal(t, 0, out["pinnedPending"])
+}
@@ -0,0 +1,136 @@
+package vfscache
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rclone/rclone/fs"
+)
+
+// active holds the Caches in use so their metrics can be collected
+//
+// The counters of Caches which have been finished with are added to
+// removed so the counters reported never go down.
+var active = struct {
+	mu      sync.Mutex
+	caches  map[*Cache]struct{}
+	removed map[string]*cacheMetrics
+}{
+	caches:  make(map[*Cache]struct{}),
+	removed: make(map[string]*cacheMetrics),
+}
+
+// addActive adds c to the active caches
+func addActive(c *Cache) {
+	active.mu.Lock()
+	active.caches[c] = struct{}{}
+	active.mu.Unlock()
+}
+
+// removeActive removes c from the active caches, keeping its counters
+func removeActive(c *Cache) {
+	active.mu.Lock()
+	defer active.mu.Unlock()
+	if _, found := active.caches[c]; !found {
+		return
+	}
+	delete(active.caches, c)
+	name := fs.ConfigString(c.fremote)
+	m := active.removed[name]
+	if m == nil {
+		m = &cacheMetrics{}
+		active.removed[name] = m
+	}
+	m.addCounters(c)
+}
+
+// Collector is a Prometheus collector for the VFS caches in use
+type Collector struct {
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	evictions *prometheus.Desc
+	bytesUsed *prometheus.Desc
+	files     *prometheus.Desc
+}
+
+// NewCollector makes a new Collector
+func NewCollector(namespace string) *Collector {
+	labels := []string{"remote"}
+	return &Collector{
+		hits: prometheus.NewDesc(namespace+"_vfs_cache_hits_total",
+			"Number of reads from the VFS cache which found the data already cached, counted per read call",
+			labels, nil,
+		),
+		misses: prometheus.NewDesc(namespace+"_vfs_cache_misses_total",
+			"Number of reads from the VFS cache which needed to fetch data from the remote, counted per read call",
+			labels, nil,
+		),
+		evictions: prometheus.NewDesc(namespace+"_vfs_cache_evictions_total",
+			"Number of files removed or emptied from the VFS cache to keep it within its limits",
+			labels, nil,
+		),
+		bytesUsed: prometheus.NewDesc(namespace+"_vfs_cache_bytes",
+			"Bytes used by the VFS cache on disk",
+			labels, nil,
+		),
+		files: prometheus.NewDesc(namespace+"_vfs_cache_files",
+			"Number of files in the VFS cache",
+			labels, nil,
+		),
+	}
+}
+
+// Describe is part of the Collector interface: https://godoc.org/github.com/prometheus/client_golang/prometheus#Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.bytesUsed
+	ch <- c.files
+}
+
+// cacheMetrics holds the metrics for the caches of one remote
+type cacheMetrics struct {
+	hits, misses, evictions, bytesUsed, files int64
+}
+
+// addCounters adds the counters from cache to m
+func (m *cacheMetrics) addCounters(cache *Cache) {
+	m.hits += atomic.LoadInt64(&cache.hits)
+	m.misses += atomic.LoadInt64(&cache.misses)
+	m.evictions += atomic.LoadInt64(&cache.evictions)
+}
+
+// Collect is part of the Collector interface: https://godoc.org/github.com/prometheus/client_golang/prometheus#Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	// Several VFS may be caching the same remote so add them up
+	metrics := map[string]*cacheMetrics{}
+	active.mu.Lock()
+	for name, removed := range active.removed {
+		m := *removed
+		metrics[name] = &m
+	}
+	for cache := range active.caches {
+		name := fs.ConfigString(cache.fremote)
+		m := metrics[name]
+		if m == nil {
+			m = &cacheMetrics{}
+			metrics[name] = m
+		}
+		m.addCounters(cache)
+		cache.mu.Lock()
+		m.bytesUsed += cache.used
+		m.files += int64(len(cache.item))
+		cache.mu.Unlock()
+	}
+	active.mu.Unlock()
+
+	for name, m := range metrics {
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(m.hits), name)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(m.misses), name)
+		ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(m.evictions), name)
+		ch <- prometheus.MustNewConstMetric(c.bytesUsed, prometheus.GaugeValue, float64(m.bytesUsed), name)
+		ch <- prometheus.MustNewConstMetric(c.files, prometheus.GaugeValue, float64(m.files), name)
+	}
+}
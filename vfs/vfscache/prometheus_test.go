@@ -0,0 +1,75 @@
+package vfscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheMetrics(t *testing.T) {
+	r, c, cleanup := newTestCache(t)
+	defer cleanup()
+
+	// Collect the metrics for this remote
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(NewCollector("test")))
+	gather := func() map[string]float64 {
+		families, err := registry.Gather()
+		require.NoError(t, err)
+		got := map[string]float64{}
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				if metric.GetLabel()[0].GetValue() != fs.ConfigString(r.Fremote) {
+					continue
+				}
+				if counter := metric.GetCounter(); counter != nil {
+					got[family.GetName()] = counter.GetValue()
+				} else {
+					got[family.GetName()] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+		return got
+	}
+
+	// Other tests may have finished with caches of the same remote
+	before := gather()
+
+	// First read fetches from the remote, second is from the cache
+	contents, obj, item := newFile(t, r, c, "potato")
+	require.NoError(t, item.Open(obj))
+	buf := make([]byte, len(contents))
+	for i := 0; i < 2; i++ {
+		_, err := item.ReadAt(buf, 0)
+		require.NoError(t, err)
+		assert.Equal(t, contents, string(buf))
+	}
+	require.NoError(t, item.Close(nil))
+	assert.Equal(t, int64(len(contents)), c.updateUsed())
+	c.purgeOld(-10 * time.Second)
+
+	out := c.Stats()
+	assert.Equal(t, int64(1), out["hits"])
+	assert.Equal(t, int64(1), out["misses"])
+	assert.Equal(t, int64(1), out["evictions"])
+
+	// Check the collector reports the cache
+	want := map[string]float64{
+		"test_vfs_cache_hits_total":      before["test_vfs_cache_hits_total"] + 1,
+		"test_vfs_cache_misses_total":    before["test_vfs_cache_misses_total"] + 1,
+		"test_vfs_cache_evictions_total": before["test_vfs_cache_evictions_total"] + 1,
+		"test_vfs_cache_bytes":           0,
+		"test_vfs_cache_files":           0,
+	}
+	assert.Equal(t, want, gather())
+
+	// The counters are kept when the cache is finished with
+	removeActive(c)
+	assert.Equal(t, want, gather())
+	removeActive(c)
+	assert.Equal(t, want, gather())
+}
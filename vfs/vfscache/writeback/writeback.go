@@ -18,6 +18,11 @@ const (
 	maxUploadDelay = 5 * time.Minute // max delay between upload attempts
 )
 
+// ErrConflict should be wrapped in the error returned by the PutFn
+// if the upload was refused because the remote file has changed. The
+// upload won't be retried.
+var ErrConflict = errors.New("vfs cache: write back conflict")
+
 // PutFn is the interface that item provides to store the data
 type PutFn func(context.Context) error
 
@@ -36,7 +41,9 @@ type WriteBack struct {
 	uploads int                       // number of uploads in progress
 
 	// read and written with atomic
-	id Handle // id of the last writeBackItem created
+	id                Handle // id of the last writeBackItem created
+	conflictsKeptBoth int64  // conflicts where the remote was kept under a new name
+	conflictsRefused  int64  // conflicts where the upload was refused
 }
 
 // New make a new WriteBack
@@ -359,7 +366,11 @@ func (wb *WriteBack) upload(ctx context.Context, wbItem *writeBackItem) {
 	wbItem.uploading = false
 	wb.uploads--
 
-	if err != nil {
+	if errors.Is(err, ErrConflict) {
+		// Retrying won't help so give up until the file is written again
+		fs.Errorf(wbItem.name, "vfs cache: upload refused, won't retry until the file is written to again: %v", err)
+		wb._delItem(wbItem)
+	} else if err != nil {
 		// FIXME should this have a max number of transfer attempts?
 		wbItem.delay *= 2
 		if wbItem.delay > maxUploadDelay {
@@ -457,6 +468,30 @@ func (wb *WriteBack) processItems(ctx context.Context) {
 	}
 }
 
+// Conflict is called when the remote file name has changed since
+// the file being written back was opened. It counts the conflict and
+// returns the --vfs-write-back-conflict policy to apply.
+//
+// Conflicts aren't looked for with the overwrite policy so they
+// aren't counted.
+func (wb *WriteBack) Conflict(name string) (policy vfscommon.ConflictPolicy) {
+	policy = wb.opt.WriteBackConflict
+	switch policy {
+	case vfscommon.ConflictKeepBoth:
+		atomic.AddInt64(&wb.conflictsKeptBoth, 1)
+	case vfscommon.ConflictRefuse:
+		atomic.AddInt64(&wb.conflictsRefused, 1)
+	}
+	fs.Logf(name, "vfs cache: file changed on the remote since it was opened - applying conflict policy %q", policy)
+	return policy
+}
+
+// ConflictStats returns the number of conflicts found when writing
+// back files split by the policy applied
+func (wb *WriteBack) ConflictStats() (keptBoth, refused int64) {
+	return atomic.LoadInt64(&wb.conflictsKeptBoth), atomic.LoadInt64(&wb.conflictsRefused)
+}
+
 // Stats return the number of uploads in progress and queued
 func (wb *WriteBack) Stats() (uploadsInProgress, uploadsQueued int) {
 	wb.mu.Lock()
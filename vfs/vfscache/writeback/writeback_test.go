@@ -347,6 +347,40 @@ func TestWriteBackAddFailRetry(t *testing.T) {
 	checkNotInLookup(t, wb, wbItem)
 }
 
+// Now test the upload failing with a conflict and not being retried
+func TestWriteBackAddFailConflict(t *testing.T) {
+	wb, cancel := newTestWriteBack(t)
+	defer cancel()
+
+	pi := newPutItem(t)
+
+	id := wb.Add(0, "one", true, pi.put)
+	wbItem := wb.lookup[id]
+
+	<-pi.started
+	checkNotOnHeap(t, wb, wbItem)
+	checkInLookup(t, wb, wbItem)
+
+	pi.finish(fmt.Errorf("upload refused: %w", ErrConflict))
+	waitUntilNoTransfers(t, wb)
+	checkNotOnHeap(t, wb, wbItem)
+	checkNotInLookup(t, wb, wbItem)
+}
+
+func TestWriteBackConflict(t *testing.T) {
+	wb, cancel := newTestWriteBack(t)
+	defer cancel()
+
+	for _, policy := range []vfscommon.ConflictPolicy{vfscommon.ConflictOverwrite, vfscommon.ConflictKeepBoth, vfscommon.ConflictKeepBoth, vfscommon.ConflictRefuse} {
+		wb.opt.WriteBackConflict = policy
+		assert.Equal(t, policy, wb.Conflict("one"))
+	}
+
+	keptBoth, refused := wb.ConflictStats()
+	assert.Equal(t, int64(2), keptBoth)
+	assert.Equal(t, int64(1), refused)
+}
+
 // Now test the upload being cancelled by another upload being added
 func TestWriteBackAddUpdate(t *testing.T) {
 	wb, cancel := newTestWriteBack(t)
@@ -0,0 +1,59 @@
+package vfscommon
+
+import (
+	"fmt"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// ConflictPolicy controls what happens when a file being written
+// back has been changed on the remote since it was opened
+type ConflictPolicy byte
+
+// ConflictPolicy options
+const (
+	ConflictOverwrite ConflictPolicy = iota // overwrite the remote file
+	ConflictKeepBoth                        // rename the remote file with a conflict suffix then upload
+	ConflictRefuse                          // don't upload and log an error
+)
+
+var conflictPolicyToString = []string{
+	ConflictOverwrite: "overwrite",
+	ConflictKeepBoth:  "keep-both",
+	ConflictRefuse:    "refuse",
+}
+
+// String turns a ConflictPolicy into a string
+func (p ConflictPolicy) String() string {
+	if p >= ConflictPolicy(len(conflictPolicyToString)) {
+		return fmt.Sprintf("ConflictPolicy(%d)", p)
+	}
+	return conflictPolicyToString[p]
+}
+
+// Set a ConflictPolicy
+func (p *ConflictPolicy) Set(s string) error {
+	for n, name := range conflictPolicyToString {
+		if s != "" && name == s {
+			*p = ConflictPolicy(n)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown write back conflict policy %q", s)
+}
+
+// Type of the value
+func (p *ConflictPolicy) Type() string {
+	return "ConflictPolicy"
+}
+
+// UnmarshalJSON makes sure the value can be parsed as a string or integer in JSON
+func (p *ConflictPolicy) UnmarshalJSON(in []byte) error {
+	return fs.UnmarshalJSONFlag(in, p, func(i int64) error {
+		if i < 0 || i >= int64(len(conflictPolicyToString)) {
+			return fmt.Errorf("unknown write back conflict policy %d", i)
+		}
+		*p = ConflictPolicy(i)
+		return nil
+	})
+}
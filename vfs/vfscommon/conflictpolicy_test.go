@@ -0,0 +1,54 @@
+package vfscommon
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+// Check ConflictPolicy it satisfies the pflag interface
+var _ pflag.Value = (*ConflictPolicy)(nil)
+
+// Check ConflictPolicy it satisfies the json.Unmarshaller interface
+var _ json.Unmarshaler = (*ConflictPolicy)(nil)
+
+func TestConflictPolicyString(t *testing.T) {
+	assert.Equal(t, "overwrite", ConflictOverwrite.String())
+	assert.Equal(t, "keep-both", ConflictKeepBoth.String())
+	assert.Equal(t, "ConflictPolicy(17)", ConflictPolicy(17).String())
+}
+
+func TestConflictPolicySet(t *testing.T) {
+	var p ConflictPolicy
+
+	err := p.Set("refuse")
+	assert.NoError(t, err)
+	assert.Equal(t, ConflictRefuse, p)
+
+	err = p.Set("potato")
+	assert.Error(t, err)
+
+	err = p.Set("")
+	assert.Error(t, err)
+}
+
+func TestConflictPolicyUnmarshalJSON(t *testing.T) {
+	var p ConflictPolicy
+
+	err := json.Unmarshal([]byte(`"keep-both"`), &p)
+	assert.NoError(t, err)
+	assert.Equal(t, ConflictKeepBoth, p)
+
+	err = json.Unmarshal([]byte(`"potato"`), &p)
+	assert.Error(t, err)
+
+	err = json.Unmarshal([]byte(strconv.Itoa(int(ConflictRefuse))), &p)
+	assert.NoError(t, err)
+	assert.Equal(t, ConflictRefuse, p)
+
+	err = json.Unmarshal([]byte("99"), &p)
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package vfscommon
+
+import (
+	"fmt"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// EvictPolicy controls which files are removed from the cache first
+// when it is over quota
+type EvictPolicy byte
+
+// EvictPolicy options
+const (
+	EvictPolicyLRU EvictPolicy = iota // least recently used first
+	EvictPolicyLFU                    // least frequently used first
+	EvictPolicyARC                    // adaptive replacement cache
+	EvictPolicy2Q                     // files used once before files used more often
+)
+
+var evictPolicyToString = []string{
+	EvictPolicyLRU: "lru",
+	EvictPolicyLFU: "lfu",
+	EvictPolicyARC: "arc",
+	EvictPolicy2Q:  "2q",
+}
+
+// String turns an EvictPolicy into a string
+func (p EvictPolicy) String() string {
+	if p >= EvictPolicy(len(evictPolicyToString)) {
+		return fmt.Sprintf("EvictPolicy(%d)", p)
+	}
+	return evictPolicyToString[p]
+}
+
+// Set an EvictPolicy
+func (p *EvictPolicy) Set(s string) error {
+	for n, name := range evictPolicyToString {
+		if s != "" && name == s {
+			*p = EvictPolicy(n)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown cache evict policy %q", s)
+}
+
+// Type of the value
+func (p *EvictPolicy) Type() string {
+	return "EvictPolicy"
+}
+
+// UnmarshalJSON makes sure the value can be parsed as a string or integer in JSON
+func (p *EvictPolicy) UnmarshalJSON(in []byte) error {
+	return fs.UnmarshalJSONFlag(in, p, func(i int64) error {
+		if i < 0 || i >= int64(len(evictPolicyToString)) {
+			return fmt.Errorf("unknown cache evict policy %d", i)
+		}
+		*p = EvictPolicy(i)
+		return nil
+	})
+}
@@ -0,0 +1,54 @@
+package vfscommon
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+// Check EvictPolicy it satisfies the pflag interface
+var _ pflag.Value = (*EvictPolicy)(nil)
+
+// Check EvictPolicy it satisfies the json.Unmarshaller interface
+var _ json.Unmarshaler = (*EvictPolicy)(nil)
+
+func TestEvictPolicyString(t *testing.T) {
+	assert.Equal(t, "lru", EvictPolicyLRU.String())
+	assert.Equal(t, "2q", EvictPolicy2Q.String())
+	assert.Equal(t, "EvictPolicy(17)", EvictPolicy(17).String())
+}
+
+func TestEvictPolicySet(t *testing.T) {
+	var p EvictPolicy
+
+	err := p.Set("arc")
+	assert.NoError(t, err)
+	assert.Equal(t, EvictPolicyARC, p)
+
+	err = p.Set("potato")
+	assert.Error(t, err)
+
+	err = p.Set("")
+	assert.Error(t, err)
+}
+
+func TestEvictPolicyUnmarshalJSON(t *testing.T) {
+	var p EvictPolicy
+
+	err := json.Unmarshal([]byte(`"lfu"`), &p)
+	assert.NoError(t, err)
+	assert.Equal(t, EvictPolicyLFU, p)
+
+	err = json.Unmarshal([]byte(`"potato"`), &p)
+	assert.Error(t, err)
+
+	err = json.Unmarshal([]byte(strconv.Itoa(int(EvictPolicy2Q))), &p)
+	assert.NoError(t, err)
+	assert.Equal(t, EvictPolicy2Q, p)
+
+	err = json.Unmarshal([]byte("99"), &p)
+	assert.Error(t, err)
+}
@@ -2,7 +2,9 @@ package vfscommon
 
 import (
 	"os"
+	"path"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/rclone/rclone/fs"
@@ -13,8 +15,11 @@ type Options struct {
 	NoSeek             bool          // don't allow seeking if set
 	NoChecksum         bool          // don't check checksums if set
 	ReadOnly           bool          // if set VFS is read only
+	Overlay            bool          // if set write changes to a local overlay instead of the remote
+	Trash              string        // if set move deleted files to this directory on the remote
 	NoModTime          bool          // don't read mod times for files
 	DirCacheTime       time.Duration // how long to consider directory listing cache valid
+	DirCachePersist    bool          // if set keep the directory cache on disk
 	PollInterval       time.Duration
 	Umask              int
 	UID                uint32
@@ -26,14 +31,20 @@ type Options struct {
 	CacheMode          CacheMode
 	CacheMaxAge        time.Duration
 	CacheMaxSize       fs.SizeSuffix
+	CacheEvictPolicy   EvictPolicy // which files to remove first when the cache is over quota
+	CacheDirMaxSize    string      // max size of directories in the cache as dir=size,dir=size
 	CachePollInterval  time.Duration
+	CacheEncrypt       bool   // if set encrypt the cache files at rest
+	CacheEncryptKey    Secret // passphrase for the cache encryption - random if empty
 	CaseInsensitive    bool
-	WriteWait          time.Duration // time to wait for in-sequence write
-	ReadWait           time.Duration // time to wait for in-sequence read
-	WriteBack          time.Duration // time to wait before writing back dirty files
-	ReadAhead          fs.SizeSuffix // bytes to read ahead in cache mode "full"
-	UsedIsSize         bool          // if true, use the `rclone size` algorithm for Used size
-	FastFingerprint    bool          // if set use fast fingerprints
+	Links              bool           // if set translate symlinks to/from .rclonelink files
+	WriteWait          time.Duration  // time to wait for in-sequence write
+	ReadWait           time.Duration  // time to wait for in-sequence read
+	WriteBack          time.Duration  // time to wait before writing back dirty files
+	WriteBackConflict  ConflictPolicy // what to do if the remote changed before writing back
+	ReadAhead          fs.SizeSuffix  // bytes to read ahead in cache mode "full"
+	UsedIsSize         bool           // if true, use the `rclone size` algorithm for Used size
+	FastFingerprint    bool           // if set use fast fingerprints
 	DiskSpaceTotalSize fs.SizeSuffix
 }
 
@@ -51,6 +62,7 @@ var DefaultOpt = Options{
 	DirPerms:           os.FileMode(0777),
 	FilePerms:          os.FileMode(0666),
 	CacheMode:          CacheModeOff,
+	CacheEvictPolicy:   EvictPolicyLRU,
 	CacheMaxAge:        3600 * time.Second,
 	CachePollInterval:  60 * time.Second,
 	ChunkSize:          128 * fs.Mebi,
@@ -60,6 +72,7 @@ var DefaultOpt = Options{
 	WriteWait:          1000 * time.Millisecond,
 	ReadWait:           20 * time.Millisecond,
 	WriteBack:          5 * time.Second,
+	WriteBackConflict:  ConflictOverwrite,
 	ReadAhead:          0 * fs.Mebi,
 	UsedIsSize:         false,
 	DiskSpaceTotalSize: -1,
@@ -74,4 +87,8 @@ func (opt *Options) Init() {
 	// Make sure directories are returned as directories
 	opt.DirPerms |= os.ModeDir
 
+	// Make the trash directory relative to the root
+	if opt.Trash != "" {
+		opt.Trash = strings.Trim(path.Clean("/"+opt.Trash), "/")
+	}
 }
@@ -0,0 +1,50 @@
+package vfscommon
+
+import (
+	"encoding/json"
+)
+
+// secretHidden is shown instead of a Secret which is set
+const secretHidden = "*****"
+
+// Secret is a string, such as a passphrase, which is hidden when the
+// options are shown, for example by vfs/stats or options/get
+type Secret string
+
+// String returns the Secret hidden if it is set
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return secretHidden
+}
+
+// Set a Secret
+func (s *Secret) Set(in string) error {
+	*s = Secret(in)
+	return nil
+}
+
+// Type of the value
+func (s *Secret) Type() string {
+	return "string"
+}
+
+// MarshalJSON hides the Secret if it is set
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON reads the Secret as a string, leaving it unchanged
+// if it is the hidden value so options can be read, changed and set
+// again.
+func (s *Secret) UnmarshalJSON(in []byte) error {
+	var value string
+	if err := json.Unmarshal(in, &value); err != nil {
+		return err
+	}
+	if value != secretHidden {
+		*s = Secret(value)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package vfscommon
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Check Secret it satisfies the pflag interface
+var _ pflag.Value = (*Secret)(nil)
+
+// Check Secret it satisfies the json.Unmarshaller interface
+var _ json.Unmarshaler = (*Secret)(nil)
+
+func TestSecretString(t *testing.T) {
+	assert.Equal(t, "", Secret("").String())
+	assert.Equal(t, "*****", Secret("potato").String())
+	opt := Options{CacheEncryptKey: "potato"}
+	assert.NotContains(t, fmt.Sprintf("%v %+v", opt, opt), "potato")
+}
+
+func TestSecretSet(t *testing.T) {
+	var s Secret
+	require.NoError(t, s.Set("potato"))
+	assert.Equal(t, Secret("potato"), s)
+}
+
+func TestSecretJSON(t *testing.T) {
+	opt := Options{CacheEncryptKey: "potato"}
+	data, err := json.Marshal(opt)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "potato")
+	assert.Contains(t, string(data), `"CacheEncryptKey":"*****"`)
+
+	// Reading back the hidden value leaves it unchanged
+	require.NoError(t, json.Unmarshal(data, &opt))
+	assert.Equal(t, Secret("potato"), opt.CacheEncryptKey)
+
+	var s Secret
+	require.NoError(t, json.Unmarshal([]byte(`"sausage"`), &s))
+	assert.Equal(t, Secret("sausage"), s)
+	assert.Error(t, json.Unmarshal([]byte(`17`), &s))
+}
@@ -2,6 +2,7 @@
 package vfsflags
 
 import (
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/rc"
 	"github.com/rclone/rclone/vfs/vfscommon"
@@ -22,20 +23,29 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.BoolVarP(flagSet, &Opt.NoChecksum, "no-checksum", "", Opt.NoChecksum, "Don't compare checksums on up/download")
 	flags.BoolVarP(flagSet, &Opt.NoSeek, "no-seek", "", Opt.NoSeek, "Don't allow seeking in files")
 	flags.DurationVarP(flagSet, &Opt.DirCacheTime, "dir-cache-time", "", Opt.DirCacheTime, "Time to cache directory entries for")
+	flags.BoolVarP(flagSet, &Opt.DirCachePersist, "vfs-dir-cache-persist", "", Opt.DirCachePersist, "Keep the directory cache on disk so it survives a restart")
 	flags.DurationVarP(flagSet, &Opt.PollInterval, "poll-interval", "", Opt.PollInterval, "Time to wait between polling for changes, must be smaller than dir-cache-time and only on supported remotes (set 0 to disable)")
 	flags.BoolVarP(flagSet, &Opt.ReadOnly, "read-only", "", Opt.ReadOnly, "Only allow read-only access")
 	flags.FVarP(flagSet, &Opt.CacheMode, "vfs-cache-mode", "", "Cache mode off|minimal|writes|full")
 	flags.DurationVarP(flagSet, &Opt.CachePollInterval, "vfs-cache-poll-interval", "", Opt.CachePollInterval, "Interval to poll the cache for stale objects")
 	flags.DurationVarP(flagSet, &Opt.CacheMaxAge, "vfs-cache-max-age", "", Opt.CacheMaxAge, "Max age of objects in the cache")
 	flags.FVarP(flagSet, &Opt.CacheMaxSize, "vfs-cache-max-size", "", "Max total size of objects in the cache")
+	flags.FVarP(flagSet, &Opt.CacheEvictPolicy, "vfs-cache-evict-policy", "", "Which files to remove first when the cache is over quota lru|lfu|arc|2q")
+	flags.StringVarP(flagSet, &Opt.CacheDirMaxSize, "vfs-cache-dir-max-size", "", Opt.CacheDirMaxSize, "Max total size of objects in directories in the cache as dir=size, e.g. downloads=10G,tmp=1G")
+	flags.BoolVarP(flagSet, &Opt.CacheEncrypt, "vfs-cache-encrypt", "", Opt.CacheEncrypt, "Encrypt the files in the VFS cache")
+	flags.FVarP(flagSet, &Opt.CacheEncryptKey, "vfs-cache-encrypt-key", "", "Passphrase to encrypt the VFS cache with (default is a random key for each run)")
 	flags.FVarP(flagSet, &Opt.ChunkSize, "vfs-read-chunk-size", "", "Read the source objects in chunks")
 	flags.FVarP(flagSet, &Opt.ChunkSizeLimit, "vfs-read-chunk-size-limit", "", "If greater than --vfs-read-chunk-size, double the chunk size after each chunk read, until the limit is reached ('off' is unlimited)")
 	flags.FVarP(flagSet, DirPerms, "dir-perms", "", "Directory permissions")
 	flags.FVarP(flagSet, FilePerms, "file-perms", "", "File permissions")
 	flags.BoolVarP(flagSet, &Opt.CaseInsensitive, "vfs-case-insensitive", "", Opt.CaseInsensitive, "If a file name not found, find a case insensitive match")
+	flags.BoolVarP(flagSet, &Opt.Overlay, "vfs-overlay", "", Opt.Overlay, "Keep changes in a local overlay instead of writing them to the remote")
+	flags.StringVarP(flagSet, &Opt.Trash, "vfs-trash", "", Opt.Trash, "Move deleted files to this directory on the remote instead of deleting them")
+	flags.BoolVarP(flagSet, &Opt.Links, "vfs-links", "", Opt.Links, "Translate symlinks to/from regular files with a '"+fs.LinkSuffix+"' extension")
 	flags.DurationVarP(flagSet, &Opt.WriteWait, "vfs-write-wait", "", Opt.WriteWait, "Time to wait for in-sequence write before giving error")
 	flags.DurationVarP(flagSet, &Opt.ReadWait, "vfs-read-wait", "", Opt.ReadWait, "Time to wait for in-sequence read before seeking")
 	flags.DurationVarP(flagSet, &Opt.WriteBack, "vfs-write-back", "", Opt.WriteBack, "Time to writeback files after last use when using cache")
+	flags.FVarP(flagSet, &Opt.WriteBackConflict, "vfs-write-back-conflict", "", "What to do if the remote file changed before writeback overwrite|keep-both|refuse")
 	flags.FVarP(flagSet, &Opt.ReadAhead, "vfs-read-ahead", "", "Extra read ahead over --buffer-size when using cache-mode full")
 	flags.BoolVarP(flagSet, &Opt.UsedIsSize, "vfs-used-is-size", "", Opt.UsedIsSize, "Use the `rclone size` algorithm for Used size")
 	flags.BoolVarP(flagSet, &Opt.FastFingerprint, "vfs-fast-fingerprint", "", Opt.FastFingerprint, "Use fast (less accurate) fingerprints for change detection")
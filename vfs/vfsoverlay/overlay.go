@@ -0,0 +1,597 @@
+// Package vfsoverlay implements a copy on write overlay for the VFS
+//
+// The remote is the lower layer which is never changed. Files which
+// are written, created or renamed are stored in an upper layer in a
+// local directory next to the VFS cache, and files and directories
+// which are deleted from the lower layer are recorded as whiteouts
+// which hide them. The changes can be committed to the remote or
+// discarded later.
+//
+// The upper layer is a complete local Fs rather than the VFS cache as
+// the cache holds partial copies of remote files which it evicts and
+// uploads, whereas the upper layer must hold whole files which are
+// only ever written to the remote by Commit.
+package vfsoverlay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/walk"
+	"github.com/rclone/rclone/lib/encoder"
+	"github.com/rclone/rclone/lib/file"
+)
+
+// Fs shows the lower Fs with the changes stored in the upper Fs on
+// top of it
+type Fs struct {
+	lower        fs.Fs        // the remote - never changed until Commit
+	upper        fs.Fs        // local directory holding the changed files
+	features     *fs.Features // optional features
+	whiteoutPath string       // file the whiteouts are stored in
+
+	// changeMu is held for reading while the overlay is being
+	// changed and for writing while it is committed or discarded
+	changeMu sync.RWMutex
+
+	mu        sync.Mutex          // protects the following
+	whiteouts map[string]struct{} // paths hidden in the lower layer
+}
+
+// cacheDir returns the OS path and the standard path of the directory
+// called kind for the lower Fs in the cache directory
+func cacheDir(kind string, lower fs.Fs) (osPath, standardPath string) {
+	parentOSPath := config.GetCacheDir()
+	relativeDirPath := lower.Root()
+	if runtime.GOOS == "windows" {
+		relativeDirPath = strings.TrimPrefix(relativeDirPath, `//?/`)
+	}
+	relativeDirPath = lower.Name() + "/" + relativeDirPath
+	relativeDirOSPath := filepath.FromSlash(encoder.OS.FromStandardPath(relativeDirPath))
+	osPath = file.UNCPath(filepath.Join(parentOSPath, kind, relativeDirOSPath))
+	standardPath = encoder.OS.ToStandardPath(filepath.ToSlash(parentOSPath)) + "/" + kind + "/" + relativeDirPath
+	return osPath, standardPath
+}
+
+// New makes an overlay on top of lower
+//
+// Any changes made by a previous overlay on the same remote which
+// weren't committed or discarded are kept.
+func New(ctx context.Context, lower fs.Fs) (*Fs, error) {
+	upperOSPath, upperPath := cacheDir("vfsOverlay", lower)
+	metaOSPath, _ := cacheDir("vfsOverlayMeta", lower)
+	for _, dir := range []string{upperOSPath, metaOSPath} {
+		if err := file.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to make overlay directory: %w", err)
+		}
+	}
+	fs.Debugf(nil, "vfs overlay: upper layer is %q", upperOSPath)
+	upper, err := cache.Get(ctx, upperPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overlay backend: %w", err)
+	}
+	f := &Fs{
+		lower:        lower,
+		upper:        upper,
+		whiteoutPath: filepath.Join(metaOSPath, "whiteouts.json"),
+		whiteouts:    make(map[string]struct{}),
+	}
+	if err := f.loadWhiteouts(); err != nil {
+		return nil, err
+	}
+	f.features = (&fs.Features{
+		CaseInsensitive:         lower.Features().CaseInsensitive,
+		CanHaveEmptyDirectories: true,
+	}).Fill(ctx, f)
+	// Only pass on change notifications if the remote has them
+	if lower.Features().ChangeNotify == nil {
+		f.features.ChangeNotify = nil
+	}
+	return f, nil
+}
+
+// loadWhiteouts reads the whiteouts from disk
+func (f *Fs) loadWhiteouts() error {
+	data, err := os.ReadFile(f.whiteoutPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read overlay whiteouts: %w", err)
+	}
+	var whiteouts []string
+	if err := json.Unmarshal(data, &whiteouts); err != nil {
+		return fmt.Errorf("failed to decode overlay whiteouts: %w", err)
+	}
+	for _, name := range whiteouts {
+		f.whiteouts[name] = struct{}{}
+	}
+	return nil
+}
+
+// _saveWhiteouts writes the whiteouts to disk
+//
+// call with mu held
+func (f *Fs) _saveWhiteouts() error {
+	data, err := json.Marshal(f._whiteoutList())
+	if err != nil {
+		return fmt.Errorf("failed to encode overlay whiteouts: %w", err)
+	}
+	err = os.WriteFile(f.whiteoutPath, data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write overlay whiteouts: %w", err)
+	}
+	return nil
+}
+
+// _whiteoutList returns the whiteouts sorted
+//
+// call with mu held
+func (f *Fs) _whiteoutList() []string {
+	whiteouts := make([]string, 0, len(f.whiteouts))
+	for name := range f.whiteouts {
+		whiteouts = append(whiteouts, name)
+	}
+	sort.Strings(whiteouts)
+	return whiteouts
+}
+
+// hidden returns true if remote or any of its parents has been
+// deleted from the lower layer
+func (f *Fs) hidden(remote string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for remote != "" {
+		if _, found := f.whiteouts[remote]; found {
+			return true
+		}
+		remote = path.Dir(remote)
+		if remote == "." || remote == "/" {
+			remote = ""
+		}
+	}
+	return false
+}
+
+// whiteout hides remote in the lower layer if it is there
+func (f *Fs) whiteout(ctx context.Context, remote string, isDir bool) error {
+	if f.hidden(remote) {
+		return nil
+	}
+	if isDir {
+		if _, err := f.lower.List(ctx, remote); err != nil {
+			return nil
+		}
+	} else if _, err := f.lower.NewObject(ctx, remote); err != nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.whiteouts[remote] = struct{}{}
+	return f._saveWhiteouts()
+}
+
+// Name of the remote (as passed into NewFs)
+//
+// This is different to the name of the lower Fs so the VFS cache of
+// the overlay is kept apart from the cache of the remote.
+func (f *Fs) Name() string {
+	return f.lower.Name() + "{overlay}"
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.lower.Root()
+}
+
+// String returns a description of the FS
+func (f *Fs) String() string {
+	return fmt.Sprintf("overlay of %v", f.lower)
+}
+
+// Precision of the ModTimes in this Fs
+func (f *Fs) Precision() time.Duration {
+	return f.lower.Precision()
+}
+
+// Hashes returns the supported hash types of the filesystem
+func (f *Fs) Hashes() hash.Set {
+	return f.lower.Hashes()
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// Lower returns the Fs of the lower layer
+func (f *Fs) Lower() fs.Fs {
+	return f.lower
+}
+
+// List the objects and directories in dir into entries. Entries in
+// the upper layer replace those in the lower layer.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	upperEntries, upperErr := f.upper.List(ctx, dir)
+	if upperErr != nil && upperErr != fs.ErrorDirNotFound {
+		return nil, upperErr
+	}
+	var lowerEntries fs.DirEntries
+	lowerErr := fs.ErrorDirNotFound
+	if !f.hidden(dir) {
+		lowerEntries, lowerErr = f.lower.List(ctx, dir)
+		if lowerErr != nil && lowerErr != fs.ErrorDirNotFound {
+			return nil, lowerErr
+		}
+	}
+	if upperErr != nil && lowerErr != nil {
+		return nil, fs.ErrorDirNotFound
+	}
+	seen := make(map[string]struct{}, len(upperEntries))
+	for _, entry := range upperEntries {
+		seen[entry.Remote()] = struct{}{}
+		if o, ok := entry.(fs.Object); ok {
+			entry = f.newObject(o, true)
+		}
+		entries = append(entries, entry)
+	}
+	for _, entry := range lowerEntries {
+		if _, found := seen[entry.Remote()]; found || f.hidden(entry.Remote()) {
+			continue
+		}
+		if o, ok := entry.(fs.Object); ok {
+			entry = f.newObject(o, false)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// NewObject finds the Object at remote, looking in the upper layer
+// first.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	o, err := f.upper.NewObject(ctx, remote)
+	if err == nil {
+		return f.newObject(o, true), nil
+	} else if err != fs.ErrorObjectNotFound {
+		return nil, err
+	}
+	if f.hidden(remote) {
+		return nil, fs.ErrorObjectNotFound
+	}
+	o, err = f.lower.NewObject(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	return f.newObject(o, false), nil
+}
+
+// Put the object into the upper layer
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	f.changeMu.RLock()
+	defer f.changeMu.RUnlock()
+	o, err := f.upper.Put(ctx, in, src, options...)
+	if err != nil {
+		return nil, err
+	}
+	return f.newObject(o, true), nil
+}
+
+// PutStream uploads to the upper layer with an indeterminate size
+func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	f.changeMu.RLock()
+	defer f.changeMu.RUnlock()
+	o, err := f.upper.Features().PutStream(ctx, in, src, options...)
+	if err != nil {
+		return nil, err
+	}
+	return f.newObject(o, true), nil
+}
+
+// Mkdir makes the directory in the upper layer
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	f.changeMu.RLock()
+	defer f.changeMu.RUnlock()
+	return f.upper.Mkdir(ctx, dir)
+}
+
+// Rmdir removes the directory from the upper layer and hides it in
+// the lower layer
+//
+// Returns an error if it isn't empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	f.changeMu.RLock()
+	defer f.changeMu.RUnlock()
+	entries, err := f.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 0 {
+		return fs.ErrorDirectoryNotEmpty
+	}
+	if _, err := f.upper.List(ctx, dir); err == nil {
+		if err := f.upper.Rmdir(ctx, dir); err != nil {
+			return err
+		}
+	}
+	return f.whiteout(ctx, dir, true)
+}
+
+// Move src to remote by moving it within the upper layer or copying it
+// there from the lower layer, hiding the original.
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok || srcObj.f != f {
+		return nil, fs.ErrorCantMove
+	}
+	f.changeMu.RLock()
+	defer f.changeMu.RUnlock()
+	var (
+		o   fs.Object
+		err error
+	)
+	if srcObj.upper {
+		o, err = f.upper.Features().Move(ctx, srcObj.Object, remote)
+	} else {
+		o, err = operations.Copy(ctx, f.upper, nil, remote, srcObj.Object)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := f.whiteout(ctx, srcObj.Remote(), false); err != nil {
+		return nil, err
+	}
+	return f.newObject(o, true), nil
+}
+
+// DirMove moves src, srcRemote to this remote at dstRemote
+//
+// Only directories which are entirely in the upper layer can be moved.
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	srcFs, ok := src.(*Fs)
+	if !ok || srcFs != f {
+		return fs.ErrorCantDirMove
+	}
+	f.changeMu.RLock()
+	defer f.changeMu.RUnlock()
+	if !f.hidden(srcRemote) {
+		if _, err := f.lower.List(ctx, srcRemote); err == nil {
+			fs.Debugf(f, "Can't move directory %q which is on the remote", srcRemote)
+			return fs.ErrorCantDirMove
+		}
+	}
+	return f.upper.Features().DirMove(ctx, f.upper, srcRemote, dstRemote)
+}
+
+// ChangeNotify calls the passed function with a path that has had
+// changes on the lower layer.
+func (f *Fs) ChangeNotify(ctx context.Context, notifyFunc func(string, fs.EntryType), pollIntervalChan <-chan time.Duration) {
+	f.lower.Features().ChangeNotify(ctx, notifyFunc, pollIntervalChan)
+}
+
+// Status returns the files changed in the upper layer and the
+// paths deleted from the lower layer.
+func (f *Fs) Status(ctx context.Context) (changed, deleted []string, err error) {
+	err = walk.ListR(ctx, f.upper, "", true, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		entries.ForObject(func(o fs.Object) {
+			changed = append(changed, o.Remote())
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(changed)
+	f.mu.Lock()
+	deleted = f._whiteoutList()
+	f.mu.Unlock()
+	return changed, deleted, nil
+}
+
+// Commit applies the changes in the overlay to the lower layer then
+// empties the overlay.
+//
+// Changes to the overlay wait until Commit has finished. If check is
+// not nil it is called once they are blocked and Commit fails with its
+// error if it returns one.
+func (f *Fs) Commit(ctx context.Context, check func() error) error {
+	f.changeMu.Lock()
+	defer f.changeMu.Unlock()
+	if check != nil {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	whiteouts := f._whiteoutList()
+	f.mu.Unlock()
+
+	// Remove what was deleted first as files and directories may
+	// have been replaced with new ones
+	for _, remote := range whiteouts {
+		if o, err := f.lower.NewObject(ctx, remote); err == nil {
+			if _, err := f.upper.NewObject(ctx, remote); err == nil {
+				// will be overwritten
+				continue
+			}
+			if err := operations.DeleteFile(ctx, o); err != nil {
+				return fmt.Errorf("overlay commit: failed to delete %q: %w", remote, err)
+			}
+		} else if _, err := f.lower.List(ctx, remote); err == nil {
+			if err := operations.Purge(ctx, f.lower, remote); err != nil {
+				return fmt.Errorf("overlay commit: failed to delete directory %q: %w", remote, err)
+			}
+		}
+	}
+
+	// Then copy the upper layer to the lower layer
+	err := walk.ListR(ctx, f.upper, "", true, -1, walk.ListAll, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			switch x := entry.(type) {
+			case fs.Object:
+				dst, _ := f.lower.NewObject(ctx, x.Remote())
+				if _, err := operations.Copy(ctx, f.lower, dst, x.Remote(), x); err != nil {
+					return fmt.Errorf("overlay commit: failed to copy %q: %w", x.Remote(), err)
+				}
+			case fs.Directory:
+				if err := operations.Mkdir(ctx, f.lower, x.Remote()); err != nil {
+					return fmt.Errorf("overlay commit: failed to make directory %q: %w", x.Remote(), err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return f.discard(ctx)
+}
+
+// Discard throws away the changes in the overlay
+//
+// Changes to the overlay wait until Discard has finished. If check is
+// not nil it is called once they are blocked and Discard fails with
+// its error if it returns one.
+func (f *Fs) Discard(ctx context.Context, check func() error) error {
+	f.changeMu.Lock()
+	defer f.changeMu.Unlock()
+	if check != nil {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return f.discard(ctx)
+}
+
+// discard throws away the changes in the overlay
+//
+// call with changeMu held for writing
+func (f *Fs) discard(ctx context.Context) error {
+	entries, err := f.upper.List(ctx, "")
+	if err != nil && err != fs.ErrorDirNotFound {
+		return err
+	}
+	for _, entry := range entries {
+		switch x := entry.(type) {
+		case fs.Object:
+			err = x.Remove(ctx)
+		case fs.Directory:
+			err = f.upper.Features().Purge(ctx, x.Remote())
+		}
+		if err != nil {
+			return fmt.Errorf("overlay discard: failed to remove %q: %w", entry.Remote(), err)
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.whiteouts = make(map[string]struct{})
+	return f._saveWhiteouts()
+}
+
+// Object is an object in the overlay
+type Object struct {
+	fs.Object
+	f     *Fs
+	upper bool // set if the object is in the upper layer
+}
+
+// newObject wraps o from the upper or lower layer
+func (f *Fs) newObject(o fs.Object, upper bool) *Object {
+	return &Object{
+		Object: o,
+		f:      f,
+		upper:  upper,
+	}
+}
+
+// Fs returns the parent Fs
+func (o *Object) Fs() fs.Info {
+	return o.f
+}
+
+// UnWrap returns the Object from the layer it is in
+func (o *Object) UnWrap() fs.Object {
+	return o.Object
+}
+
+// copyUp copies the object into the upper layer if it isn't there
+// already
+func (o *Object) copyUp(ctx context.Context) error {
+	if o.upper {
+		return nil
+	}
+	newObj, err := operations.Copy(ctx, o.f.upper, nil, o.Remote(), o.Object)
+	if err != nil {
+		return err
+	}
+	o.Object = newObj
+	o.upper = true
+	return nil
+}
+
+// SetModTime sets the modification time of the object, copying it to
+// the upper layer first if necessary.
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	o.f.changeMu.RLock()
+	defer o.f.changeMu.RUnlock()
+	if err := o.copyUp(ctx); err != nil {
+		return err
+	}
+	return o.Object.SetModTime(ctx, modTime)
+}
+
+// Update the object with the contents of the io.Reader, writing it
+// to the upper layer.
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	o.f.changeMu.RLock()
+	defer o.f.changeMu.RUnlock()
+	if o.upper {
+		return o.Object.Update(ctx, in, src, options...)
+	}
+	newObj, err := o.f.upper.Put(ctx, in, src, options...)
+	if err != nil {
+		return err
+	}
+	o.Object = newObj
+	o.upper = true
+	return nil
+}
+
+// Remove the object from the upper layer and hide it in the lower
+// layer.
+func (o *Object) Remove(ctx context.Context) error {
+	o.f.changeMu.RLock()
+	defer o.f.changeMu.RUnlock()
+	if o.upper {
+		if err := o.Object.Remove(ctx); err != nil {
+			return err
+		}
+	}
+	return o.f.whiteout(ctx, o.Remote(), false)
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs              = (*Fs)(nil)
+	_ fs.PutStreamer     = (*Fs)(nil)
+	_ fs.Mover           = (*Fs)(nil)
+	_ fs.DirMover        = (*Fs)(nil)
+	_ fs.ChangeNotifier  = (*Fs)(nil)
+	_ fs.Object          = (*Object)(nil)
+	_ fs.ObjectUnWrapper = (*Object)(nil)
+)
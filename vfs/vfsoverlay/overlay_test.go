@@ -0,0 +1,273 @@
+package vfsoverlay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local" // import the local backend
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain drives the tests
+func TestMain(m *testing.M) {
+	fstest.TestMain(m)
+}
+
+var t1 = fstest.Time("2001-02-03T04:05:06.499999999Z")
+
+// newTestOverlay makes an overlay on top of the test remote
+func newTestOverlay(t *testing.T) (r *fstest.Run, f *Fs, cleanup func()) {
+	r = fstest.NewRun(t)
+	f, err := New(context.Background(), r.Fremote)
+	require.NoError(t, err)
+	cleanup = func() {
+		for _, kind := range []string{"vfsOverlay", "vfsOverlayMeta"} {
+			osPath, _ := cacheDir(kind, r.Fremote)
+			assert.NoError(t, os.RemoveAll(osPath))
+		}
+		r.Finalise()
+	}
+	return r, f, cleanup
+}
+
+// put writes contents to remote in f
+func put(t *testing.T, f fs.Fs, remote, contents string) fs.Object {
+	src := object.NewStaticObjectInfo(remote, t1, int64(len(contents)), true, nil, nil)
+	o, err := f.Put(context.Background(), bytes.NewBufferString(contents), src)
+	require.NoError(t, err)
+	return o
+}
+
+// read returns the contents of remote in f
+func read(t *testing.T, f fs.Fs, remote string) string {
+	ctx := context.Background()
+	o, err := f.NewObject(ctx, remote)
+	require.NoError(t, err)
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, in.Close())
+	}()
+	data, err := io.ReadAll(in)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// makeChanges makes some changes in the overlay of the remote
+// containing file1, file2, dir/file3 and empty/
+func makeChanges(t *testing.T, r *fstest.Run, f *Fs) {
+	ctx := context.Background()
+
+	// Overwrite file1
+	o, err := f.NewObject(ctx, "file1")
+	require.NoError(t, err)
+	src := object.NewStaticObjectInfo("file1", t1, 7, true, nil, nil)
+	require.NoError(t, o.Update(ctx, bytes.NewBufferString("changed"), src))
+
+	// Rename file2
+	o, err = f.NewObject(ctx, "file2")
+	require.NoError(t, err)
+	_, err = f.Move(ctx, o, "renamed")
+	require.NoError(t, err)
+
+	// Delete dir/file3 and the directories
+	o, err = f.NewObject(ctx, "dir/file3")
+	require.NoError(t, err)
+	require.NoError(t, o.Remove(ctx))
+	require.NoError(t, f.Rmdir(ctx, "dir"))
+	require.NoError(t, f.Rmdir(ctx, "empty"))
+
+	// Make a new file
+	put(t, f, "new/file4", "new file")
+}
+
+func TestOverlay(t *testing.T) {
+	r, f, cleanup := newTestOverlay(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	file1 := r.WriteObject(ctx, "file1", "file1 contents", t1)
+	file2 := r.WriteObject(ctx, "file2", "file2 contents", t1)
+	file3 := r.WriteObject(ctx, "dir/file3", "file3 contents", t1)
+	require.NoError(t, r.Fremote.Mkdir(ctx, "empty"))
+
+	// The overlay shows the remote
+	fstest.CheckListingWithPrecision(t, f, []fstest.Item{file1, file2, file3}, []string{"dir", "empty"}, fs.ModTimeNotSupported)
+
+	makeChanges(t, r, f)
+
+	// The overlay shows the changes
+	assert.Equal(t, "changed", read(t, f, "file1"))
+	assert.Equal(t, "file2 contents", read(t, f, "renamed"))
+	assert.Equal(t, "new file", read(t, f, "new/file4"))
+	_, err := f.NewObject(ctx, "file2")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	_, err = f.List(ctx, "dir")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	assert.ElementsMatch(t, []string{"file1", "renamed", "new"}, names)
+
+	// The remote is unchanged
+	r.CheckRemoteListing(t, []fstest.Item{file1, file2, file3}, []string{"dir", "empty"})
+	assert.Equal(t, "file1 contents", read(t, r.Fremote, "file1"))
+
+	changed, deleted, err := f.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file1", "new/file4", "renamed"}, changed)
+	assert.Equal(t, []string{"dir", "dir/file3", "empty", "file2"}, deleted)
+}
+
+func TestOverlayPersists(t *testing.T) {
+	r, f, cleanup := newTestOverlay(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	r.WriteObject(ctx, "file1", "file1 contents", t1)
+	r.WriteObject(ctx, "file2", "file2 contents", t1)
+	r.WriteObject(ctx, "dir/file3", "file3 contents", t1)
+	require.NoError(t, r.Fremote.Mkdir(ctx, "empty"))
+	makeChanges(t, r, f)
+
+	// A new overlay on the same remote sees the changes
+	f2, err := New(ctx, r.Fremote)
+	require.NoError(t, err)
+	changed, deleted, err := f2.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file1", "new/file4", "renamed"}, changed)
+	assert.Equal(t, []string{"dir", "dir/file3", "empty", "file2"}, deleted)
+	_, err = f2.NewObject(ctx, "file2")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+}
+
+func TestOverlayCommit(t *testing.T) {
+	r, f, cleanup := newTestOverlay(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	r.WriteObject(ctx, "file1", "file1 contents", t1)
+	r.WriteObject(ctx, "file2", "file2 contents", t1)
+	r.WriteObject(ctx, "dir/file3", "file3 contents", t1)
+	require.NoError(t, r.Fremote.Mkdir(ctx, "empty"))
+	makeChanges(t, r, f)
+
+	require.NoError(t, f.Commit(ctx, nil))
+
+	// The remote has the changes
+	entries, err := r.Fremote.List(ctx, "")
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	assert.ElementsMatch(t, []string{"file1", "renamed", "new"}, names)
+	assert.Equal(t, "changed", read(t, r.Fremote, "file1"))
+	assert.Equal(t, "file2 contents", read(t, r.Fremote, "renamed"))
+	assert.Equal(t, "new file", read(t, r.Fremote, "new/file4"))
+
+	// The overlay is empty
+	changed, deleted, err := f.Status(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+	assert.Empty(t, deleted)
+	assert.Equal(t, "changed", read(t, f, "file1"))
+}
+
+func TestOverlayDiscard(t *testing.T) {
+	r, f, cleanup := newTestOverlay(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	file1 := r.WriteObject(ctx, "file1", "file1 contents", t1)
+	file2 := r.WriteObject(ctx, "file2", "file2 contents", t1)
+	file3 := r.WriteObject(ctx, "dir/file3", "file3 contents", t1)
+	require.NoError(t, r.Fremote.Mkdir(ctx, "empty"))
+	makeChanges(t, r, f)
+
+	require.NoError(t, f.Discard(ctx, nil))
+
+	// The overlay shows the remote again
+	fstest.CheckListingWithPrecision(t, f, []fstest.Item{file1, file2, file3}, []string{"dir", "empty"}, fs.ModTimeNotSupported)
+	assert.Equal(t, "file1 contents", read(t, f, "file1"))
+	r.CheckRemoteListing(t, []fstest.Item{file1, file2, file3}, []string{"dir", "empty"})
+}
+
+func TestOverlayCommitBlocksChanges(t *testing.T) {
+	r, f, cleanup := newTestOverlay(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// A failed check stops the commit and discard
+	put(t, f, "file1", "file1 contents")
+	errBusy := errors.New("busy")
+	assert.Equal(t, errBusy, f.Commit(ctx, func() error { return errBusy }))
+	assert.Equal(t, errBusy, f.Discard(ctx, func() error { return errBusy }))
+	r.CheckRemoteItems(t)
+
+	// Changes made while committing wait until it has finished
+	var done chan struct{}
+	require.NoError(t, f.Commit(ctx, func() error {
+		done = make(chan struct{})
+		go func() {
+			put(t, f, "file2", "file2 contents")
+			close(done)
+		}()
+		select {
+		case <-done:
+			t.Error("change made during commit")
+		case <-time.After(100 * time.Millisecond):
+		}
+		return nil
+	}))
+	<-done
+
+	// So file2 is left in the overlay
+	assert.Equal(t, "file1 contents", read(t, r.Fremote, "file1"))
+	changed, _, err := f.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file2"}, changed)
+}
+
+func TestOverlaySetModTime(t *testing.T) {
+	r, f, cleanup := newTestOverlay(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	file1 := r.WriteObject(ctx, "file1", "file1 contents", t1)
+	t2 := t1.Add(time.Hour)
+
+	o, err := f.NewObject(ctx, "file1")
+	require.NoError(t, err)
+	require.NoError(t, o.SetModTime(ctx, t2))
+
+	o, err = f.NewObject(ctx, "file1")
+	require.NoError(t, err)
+	fstest.AssertTimeEqualWithPrecision(t, "file1", t2, o.ModTime(ctx), f.Precision())
+	r.CheckRemoteItems(t, file1)
+}
+
+func TestOverlayDirMove(t *testing.T) {
+	r, f, cleanup := newTestOverlay(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	r.WriteObject(ctx, "dir/file1", "file1 contents", t1)
+	put(t, f, "newdir/file2", "file2 contents")
+
+	assert.Equal(t, fs.ErrorCantDirMove, f.DirMove(ctx, f, "dir", "dir2"))
+	require.NoError(t, f.DirMove(ctx, f, "newdir", "newdir2"))
+	assert.Equal(t, "file2 contents", read(t, f, "newdir2/file2"))
+}
@@ -0,0 +1,255 @@
+package vfs
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/vfs/vfscommon"
+)
+
+// Xattrer is an optional interface for Nodes which have extended
+// attributes. *File and *Dir implement it.
+type Xattrer interface {
+	Getxattr(name string) ([]byte, error)
+	Listxattr() ([]string, error)
+	Setxattr(name string, value []byte) error
+	Removexattr(name string) error
+}
+
+// Check interfaces
+var (
+	_ Xattrer = (*File)(nil)
+	_ Xattrer = (*Dir)(nil)
+)
+
+// Extended attributes on files are the metadata of the remote
+// object. Only the user namespace may be used by unprivileged users
+// so the metadata key "key", whether it is user metadata or system
+// metadata from the backend's MetadataInfo, is the extended
+// attribute "user.key".
+//
+// Setting or removing an extended attribute changes the metadata in
+// the VFS cache and marks the file dirty so it is uploaded again
+// with its new metadata by the write back.
+//
+// PinXattr isn't metadata - it pins files in the VFS cache.
+
+// xattrPrefix is the namespace the metadata keys appear in
+const xattrPrefix = "user."
+
+// xattrKey returns the metadata key for the extended attribute name
+// or "" if it isn't one
+func xattrKey(name string) string {
+	if name == PinXattr || !strings.HasPrefix(name, xattrPrefix) {
+		return ""
+	}
+	return name[len(xattrPrefix):]
+}
+
+// canSetMetadata checks whether the metadata key can be set on the
+// objects in f
+func canSetMetadata(f fs.Fs, key string) error {
+	features := f.Features()
+	if info := operations.GetFsInfo(f).MetadataInfo; info != nil {
+		if help, found := info.System[key]; found {
+			if help.ReadOnly {
+				return EPERM
+			}
+			if !features.WriteMetadata {
+				return ENOTSUP
+			}
+			return nil
+		}
+	}
+	if !features.UserMetadata {
+		return ENOTSUP
+	}
+	return nil
+}
+
+// setPinnedXattr sets PinXattr on node to value
+func (vfs *VFS) setPinnedXattr(node Node, value []byte) (err error) {
+	if vfs.Opt.CacheMode < vfscommon.CacheModeFull {
+		return ENOTSUP
+	}
+	switch string(value) {
+	case "1":
+		_, err = vfs.Pin(node.Path())
+	case "0":
+		_, err = vfs.Unpin(node.Path())
+	default:
+		return EINVAL
+	}
+	return err
+}
+
+// removePinnedXattr removes PinXattr from node
+func (vfs *VFS) removePinnedXattr(node Node) error {
+	if vfs.Opt.CacheMode < vfscommon.CacheModeFull {
+		return ENOTSUP
+	}
+	_, err := vfs.Unpin(node.Path())
+	return err
+}
+
+// isPinned returns true if the file is pinned in the VFS cache
+func (f *File) isPinned() bool {
+	cache := f.VFS().cache
+	return cache != nil && cache.IsPinned(f.cachePath())
+}
+
+// metadata returns the metadata of the file including any changes
+// which haven't been uploaded yet
+func (f *File) metadata() (fs.Metadata, error) {
+	if cache := f.VFS().cache; cache != nil {
+		if metadata := cache.Metadata(f.cachePath()); metadata != nil {
+			return metadata, nil
+		}
+	}
+	o := f.getObject()
+	if o == nil {
+		// file is being written
+		return nil, nil
+	}
+	return fs.GetMetadata(context.TODO(), o)
+}
+
+// updateMetadata calls fn to change the metadata for key then queues
+// the new metadata to be uploaded
+func (f *File) updateMetadata(key string, fn func(metadata fs.Metadata) error) error {
+	vfs := f.VFS()
+	if vfs.Opt.ReadOnly {
+		return EROFS
+	}
+	if vfs.cache == nil || f.isLink {
+		return ENOTSUP
+	}
+	if err := canSetMetadata(vfs.f, key); err != nil {
+		return err
+	}
+	metadata, err := f.metadata()
+	if err != nil {
+		return err
+	}
+	newMetadata := make(fs.Metadata, len(metadata)+1)
+	newMetadata.Merge(metadata)
+	if err := fn(newMetadata); err != nil {
+		return err
+	}
+	return vfs.cache.SetMetadata(f.Path(), f.getObject(), newMetadata, f.setObject)
+}
+
+// Getxattr returns the value of the extended attribute name
+//
+// If there is no attribute by that name, returns ENOATTR.
+func (f *File) Getxattr(name string) ([]byte, error) {
+	if name == PinXattr {
+		if !f.isPinned() {
+			return nil, ENOATTR
+		}
+		return []byte("1"), nil
+	}
+	key := xattrKey(name)
+	if key == "" {
+		return nil, ENOATTR
+	}
+	metadata, err := f.metadata()
+	if err != nil {
+		return nil, err
+	}
+	value, found := metadata[key]
+	if !found {
+		return nil, ENOATTR
+	}
+	return []byte(value), nil
+}
+
+// Listxattr returns the names of the extended attributes of the file
+func (f *File) Listxattr() (names []string, err error) {
+	metadata, err := f.metadata()
+	if err != nil {
+		return nil, err
+	}
+	for key := range metadata {
+		names = append(names, xattrPrefix+key)
+	}
+	sort.Strings(names)
+	if f.isPinned() {
+		names = append(names, PinXattr)
+	}
+	return names, nil
+}
+
+// Setxattr sets the extended attribute name to value
+func (f *File) Setxattr(name string, value []byte) error {
+	if name == PinXattr {
+		return f.VFS().setPinnedXattr(f, value)
+	}
+	key := xattrKey(name)
+	if key == "" {
+		return ENOTSUP
+	}
+	return f.updateMetadata(key, func(metadata fs.Metadata) error {
+		metadata[key] = string(value)
+		return nil
+	})
+}
+
+// Removexattr removes the extended attribute name
+//
+// If there is no attribute by that name, returns ENOATTR.
+func (f *File) Removexattr(name string) error {
+	if name == PinXattr {
+		return f.VFS().removePinnedXattr(f)
+	}
+	key := xattrKey(name)
+	if key == "" {
+		return ENOATTR
+	}
+	return f.updateMetadata(key, func(metadata fs.Metadata) error {
+		if _, found := metadata[key]; !found {
+			return ENOATTR
+		}
+		delete(metadata, key)
+		return nil
+	})
+}
+
+// Getxattr returns the value of the extended attribute name
+//
+// Directories have no metadata and are never pinned themselves so
+// this always returns ENOATTR.
+func (d *Dir) Getxattr(name string) ([]byte, error) {
+	return nil, ENOATTR
+}
+
+// Listxattr returns the names of the extended attributes of the
+// directory
+func (d *Dir) Listxattr() ([]string, error) {
+	return nil, nil
+}
+
+// Setxattr sets the extended attribute name to value
+//
+// Only PinXattr can be set, which pins or unpins all the files in the
+// directory tree.
+func (d *Dir) Setxattr(name string, value []byte) error {
+	if name == PinXattr {
+		return d.vfs.setPinnedXattr(d, value)
+	}
+	return ENOTSUP
+}
+
+// Removexattr removes the extended attribute name
+//
+// Only PinXattr can be removed, which unpins all the files in the
+// directory tree.
+func (d *Dir) Removexattr(name string) error {
+	if name == PinXattr {
+		return d.vfs.removePinnedXattr(d)
+	}
+	return ENOATTR
+}
@@ -0,0 +1,138 @@
+package vfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinXattr(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.CacheMode = vfscommon.CacheModeFull
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+	r.WriteObject(context.Background(), "dir/file1", "file1 contents", t1)
+
+	node, err := vfs.Stat("dir/file1")
+	require.NoError(t, err)
+	file := node.(*File)
+	_, err = file.Getxattr(PinXattr)
+	assert.Equal(t, ENOATTR, err)
+	assert.Equal(t, EINVAL, file.Setxattr(PinXattr, []byte("potato")))
+
+	// Pinning the directory pins the file
+	node, err = vfs.Stat("dir")
+	require.NoError(t, err)
+	dir := node.(*Dir)
+	require.NoError(t, dir.Setxattr(PinXattr, []byte("1")))
+	value, err := file.Getxattr(PinXattr)
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(value))
+	names, err := file.Listxattr()
+	require.NoError(t, err)
+	assert.Contains(t, names, PinXattr)
+
+	// Wait for the pinned file to download so it doesn't outlive the test
+	require.Eventually(t, func() bool {
+		return vfs.Stats()["diskCache"].(rc.Params)["pinnedPending"] == 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, file.Removexattr(PinXattr))
+	_, err = file.Getxattr(PinXattr)
+	assert.Equal(t, ENOATTR, err)
+	_, err = dir.Getxattr(PinXattr)
+	assert.Equal(t, ENOATTR, err)
+}
+
+func TestPinXattrNeedsCache(t *testing.T) {
+	r, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+	r.WriteObject(context.Background(), "file1", "file1 contents", t1)
+
+	node, err := vfs.Stat("file1")
+	require.NoError(t, err)
+	assert.Equal(t, ENOTSUP, node.(Xattrer).Setxattr(PinXattr, []byte("1")))
+}
+
+func TestXattrKey(t *testing.T) {
+	assert.Equal(t, "potato", xattrKey("user.potato"))
+	assert.Equal(t, "", xattrKey("security.selinux"))
+	assert.Equal(t, "", xattrKey(PinXattr))
+}
+
+func TestFileXattr(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.CacheMode = vfscommon.CacheModeWrites
+	opt.WriteBack = 0
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+	if !r.Fremote.Features().UserMetadata {
+		t.Skip("remote doesn't support user metadata")
+	}
+	r.WriteObject(context.Background(), "file1", "file1 contents", t1)
+
+	node, err := vfs.Stat("file1")
+	require.NoError(t, err)
+	file := node.(*File)
+
+	_, err = file.Getxattr("user.potato")
+	assert.Equal(t, ENOATTR, err)
+	_, err = file.Getxattr("security.selinux")
+	assert.Equal(t, ENOATTR, err)
+	assert.Equal(t, ENOTSUP, file.Setxattr("security.selinux", []byte("x")))
+	assert.Equal(t, ENOATTR, file.Removexattr("user.potato"))
+
+	require.NoError(t, file.Setxattr("user.potato", []byte("chips")))
+	value, err := file.Getxattr("user.potato")
+	require.NoError(t, err)
+	assert.Equal(t, "chips", string(value))
+	names, err := file.Listxattr()
+	require.NoError(t, err)
+	assert.Contains(t, names, "user.potato")
+
+	// Check the metadata was uploaded and the contents unchanged
+	vfs.WaitForWriters(waitForWritersDelay)
+	o, err := r.Fremote.NewObject(context.Background(), "file1")
+	require.NoError(t, err)
+	metadata, err := fs.GetMetadata(context.Background(), o)
+	require.NoError(t, err)
+	assert.Equal(t, "chips", metadata["potato"])
+	assert.Equal(t, int64(len("file1 contents")), o.Size())
+
+	// Whether removed metadata is removed from the remote object
+	// depends on the backend so just check it can be removed
+	require.NoError(t, file.Removexattr("user.potato"))
+	vfs.WaitForWriters(waitForWritersDelay)
+}
+
+func TestFileXattrNeedsCache(t *testing.T) {
+	r, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+	r.WriteObject(context.Background(), "file1", "file1 contents", t1)
+
+	node, err := vfs.Stat("file1")
+	require.NoError(t, err)
+	assert.Equal(t, ENOTSUP, node.(Xattrer).Setxattr("user.potato", []byte("chips")))
+}
+
+func TestDirXattr(t *testing.T) {
+	_, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+
+	root, err := vfs.Root()
+	require.NoError(t, err)
+	_, err = root.Getxattr("user.potato")
+	assert.Equal(t, ENOATTR, err)
+	names, err := root.Listxattr()
+	require.NoError(t, err)
+	assert.Empty(t, names)
+	assert.Equal(t, ENOTSUP, root.Setxattr("user.potato", []byte("chips")))
+	assert.Equal(t, ENOTSUP, root.Setxattr(PinXattr, []byte("1")))
+	assert.Equal(t, ENOATTR, root.Removexattr("user.potato"))
+}